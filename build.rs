@@ -0,0 +1,53 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Fingerprint the sources and dependency versions as `ANALYZER_BUILD_ID`,
+//! which names the directory `DiskCache` keeps its entries in: results
+//! cached by a differently built analyzer are never read back.
+
+use std::path::{Path, PathBuf};
+
+use sha2::{Digest, Sha256};
+
+/// Inputs besides `src` that change what the analyzer extracts
+const MANIFESTS: &[&str] = &["Cargo.toml", "Cargo.lock"];
+
+fn main() {
+    let root = PathBuf::from(std::env::var("CARGO_MANIFEST_DIR").unwrap());
+    let mut files = vec![];
+    collect_files(&root.join("src"), &mut files);
+    files.sort();
+    files.extend(MANIFESTS.iter().map(|name| root.join(name)));
+
+    let mut hasher = Sha256::new();
+    for file in &files {
+        // A missing Cargo.lock hashes as empty
+        let content = std::fs::read(file).unwrap_or_default();
+        let name = file.strip_prefix(&root).unwrap_or(file);
+        hasher.update(name.to_string_lossy().as_bytes());
+        hasher.update([0]);
+        hasher.update(content.len().to_le_bytes());
+        hasher.update(&content);
+    }
+    let id = format!("{:x}", hasher.finalize());
+    println!("cargo:rustc-env=ANALYZER_BUILD_ID={}", &id[..16]);
+
+    println!("cargo:rerun-if-changed=src");
+    for name in MANIFESTS {
+        println!("cargo:rerun-if-changed={}", name);
+    }
+}
+
+fn collect_files(dir: &Path, files: &mut Vec<PathBuf>) {
+    let Ok(entries) = std::fs::read_dir(dir) else {
+        return;
+    };
+    for entry in entries.flatten() {
+        let path = entry.path();
+        if path.is_dir() {
+            collect_files(&path, files);
+        } else {
+            files.push(path);
+        }
+    }
+}
@@ -0,0 +1,311 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use super::types::{AnalysisResult, FieldInfo, FunctionInfo, ParamInfo};
+
+/// Entry points count as exported for the unused check but are not part of a
+/// package's API
+const ENTRY_POINTS: &[&str] = &["main", "init"];
+
+/// An exported type together with its exported fields and methods
+#[derive(Debug, Clone)]
+pub struct ApiType {
+    pub name: String,
+    pub path: PathBuf,
+    pub line: usize,
+    /// Exported fields in declaration order
+    pub fields: Vec<FieldInfo>,
+    /// Exported methods sorted by name
+    pub methods: Vec<FunctionInfo>,
+}
+
+/// An exported free function
+#[derive(Debug, Clone)]
+pub struct ApiFunction {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+}
+
+/// Exported identifiers of the analyzed files, grouped by type
+#[derive(Debug, Clone, Default)]
+pub struct ApiSurface {
+    /// Exported types sorted by package directory, then name
+    pub types: Vec<ApiType>,
+    /// Exported free functions sorted by package directory, then name
+    pub functions: Vec<ApiFunction>,
+}
+
+/// Collect the exported API of the analyzed files, each directory being one
+/// package.
+///
+/// Methods are attached to the type their receiver names in the same
+/// package; methods of unexported types are left out along with everything
+/// else unexported.
+pub fn exported_api(results: &[(PathBuf, AnalysisResult)]) -> ApiSurface {
+    let mut types: BTreeMap<(&Path, &str), ApiType> = BTreeMap::new();
+
+    for (path, result) in results {
+        for class in result.classes.iter().filter(|c| c.exported) {
+            let key = (package_dir(path), class.name.as_str());
+            let entry = types.entry(key).or_insert_with(|| ApiType {
+                name: class.name.clone(),
+                path: path.clone(),
+                line: class.line,
+                fields: vec![],
+                methods: vec![],
+            });
+            // A type may be captured more than once (a Rust struct and its impl)
+            if entry.fields.is_empty() {
+                entry
+                    .fields
+                    .extend(class.fields.iter().filter(|f| f.exported).cloned());
+            }
+        }
+    }
+
+    let mut functions = Vec::new();
+
+    for (path, result) in results {
+        for func in result.functions.iter().filter(|f| f.exported) {
+            match &func.receiver {
+                Some(receiver) => {
+                    let key = (package_dir(path), receiver_type_name(receiver));
+                    if let Some(api_type) = types.get_mut(&key) {
+                        api_type.methods.push(func.clone());
+                    }
+                }
+                None if ENTRY_POINTS.contains(&func.name.as_str()) => {}
+                None => functions.push(ApiFunction {
+                    path: path.clone(),
+                    function: func.clone(),
+                }),
+            }
+        }
+    }
+
+    let mut types: Vec<ApiType> = types.into_values().collect();
+    for api_type in &mut types {
+        api_type.methods.sort_by(|a, b| a.name.cmp(&b.name));
+    }
+    functions.sort_by(|a, b| {
+        package_dir(&a.path)
+            .cmp(package_dir(&b.path))
+            .then_with(|| a.function.name.cmp(&b.function.name))
+    });
+
+    ApiSurface { types, functions }
+}
+
+fn package_dir(path: &Path) -> &Path {
+    path.parent().unwrap_or(path)
+}
+
+/// Bare type name of a receiver, e.g. `Greeter` for `*Greeter` or `List` for `List[T]`
+pub(crate) fn receiver_type_name(receiver: &str) -> &str {
+    let name = receiver.trim_start_matches(['*', '&']);
+    name.split(['[', '<']).next().unwrap_or(name).trim()
+}
+
+/// Render a function as `name(params) returns`
+fn format_signature(func: &FunctionInfo) -> String {
+    let join = |params: &[ParamInfo]| {
+        params
+            .iter()
+            .map(ToString::to_string)
+            .collect::<Vec<_>>()
+            .join(", ")
+    };
+    let returns = match func.returns.as_slice() {
+        [] => String::new(),
+        [single] if single.name.is_none() => format!(" {}", single),
+        returns => format!(" ({})", join(returns)),
+    };
+    format!("{}({}){}", func.name, join(&func.params), returns)
+}
+
+/// Format the API surface as an `API:` listing without line numbers so two
+/// versions can be diffed directly. When the API spans several packages,
+/// each one's types and functions follow a `package dir` line naming its
+/// directory relative to `base`.
+pub fn format_api_surface(base: &Path, api: &ApiSurface) -> String {
+    let mut output = String::from("API:\n");
+
+    let packages: BTreeSet<&Path> = api
+        .types
+        .iter()
+        .map(|api_type| package_dir(&api_type.path))
+        .chain(api.functions.iter().map(|entry| package_dir(&entry.path)))
+        .collect();
+
+    for &package in &packages {
+        if packages.len() > 1 {
+            let relative = package.strip_prefix(base).unwrap_or(package);
+            let name = if relative.as_os_str().is_empty() {
+                Path::new(".")
+            } else {
+                relative
+            };
+            output.push_str(&format!("package {}\n", name.display()));
+        }
+
+        for api_type in api
+            .types
+            .iter()
+            .filter(|api_type| package_dir(&api_type.path) == package)
+        {
+            output.push_str(&format!("type {}\n", api_type.name));
+            for field in &api_type.fields {
+                match &field.type_name {
+                    Some(type_name) => {
+                        output.push_str(&format!("  field {} {}\n", field.name, type_name))
+                    }
+                    None => output.push_str(&format!("  field {}\n", field.name)),
+                }
+            }
+            for method in &api_type.methods {
+                output.push_str(&format!("  method {}\n", format_signature(method)));
+            }
+        }
+
+        for entry in api
+            .functions
+            .iter()
+            .filter(|entry| package_dir(&entry.path) == package)
+        {
+            output.push_str(&format!("func {}\n", format_signature(&entry.function)));
+        }
+    }
+
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::ClassInfo;
+
+    fn function(name: &str, receiver: Option<&str>, exported: bool) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            receiver: receiver.map(|r| r.to_string()),
+            exported,
+            ..Default::default()
+        }
+    }
+
+    fn field(name: &str, type_name: &str, exported: bool) -> FieldInfo {
+        FieldInfo {
+            name: name.into(),
+            type_name: Some(type_name.into()),
+            exported,
+            ..Default::default()
+        }
+    }
+
+    fn sample() -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(24);
+        result.classes = vec![ClassInfo {
+            name: "Greeter".into(),
+            line: 5,
+            methods: vec![],
+            fields: vec![field("Name", "string", true), field("id", "int", false)],
+            exported: true,
+            interface: None,
+        }];
+        result.functions = vec![
+            FunctionInfo {
+                returns: vec![ParamInfo::unnamed("string")],
+                ..function("Greet", Some("*Greeter"), true)
+            },
+            function("reset", Some("*Greeter"), false),
+            function("helper", None, false),
+            function("main", None, true),
+            function("New", None, true),
+        ];
+        vec![(PathBuf::from("/p/sample.go"), result)]
+    }
+
+    #[test]
+    fn groups_exported_members_by_type() {
+        let api = exported_api(&sample());
+        assert_eq!(api.types.len(), 1);
+        let greeter = &api.types[0];
+        assert_eq!(greeter.name, "Greeter");
+        let fields: Vec<&str> = greeter.fields.iter().map(|f| f.name.as_str()).collect();
+        assert_eq!(fields, vec!["Name"]);
+        let methods: Vec<&str> = greeter.methods.iter().map(|m| m.name.as_str()).collect();
+        assert_eq!(methods, vec!["Greet"]);
+    }
+
+    #[test]
+    fn excludes_unexported_functions_and_entry_points() {
+        let api = exported_api(&sample());
+        let names: Vec<&str> = api
+            .functions
+            .iter()
+            .map(|f| f.function.name.as_str())
+            .collect();
+        assert_eq!(names, vec!["New"]);
+    }
+
+    #[test]
+    fn drops_methods_of_unexported_types() {
+        let mut results = sample();
+        results[0].1.classes[0].exported = false;
+        let api = exported_api(&results);
+        assert!(api.types.is_empty());
+    }
+
+    #[test]
+    fn receiver_type_name_strips_pointers_and_generics() {
+        assert_eq!(receiver_type_name("*Greeter"), "Greeter");
+        assert_eq!(receiver_type_name("List[T]"), "List");
+        assert_eq!(receiver_type_name("Wrapper<T>"), "Wrapper");
+    }
+
+    #[test]
+    fn format_lists_types_then_functions() {
+        let out = format_api_surface(Path::new("/p"), &exported_api(&sample()));
+        assert_eq!(
+            out,
+            "API:\ntype Greeter\n  field Name string\n  method Greet() string\nfunc New()\n"
+        );
+    }
+
+    #[test]
+    fn same_named_types_of_two_packages_stay_apart() {
+        let mut root = sample();
+        let mut store = sample();
+        store[0].0 = PathBuf::from("/p/store/store.go");
+        store[0].1.classes[0].fields = vec![field("Path", "string", true)];
+        store[0].1.functions = vec![function("Close", Some("Greeter"), true)];
+        root.append(&mut store);
+
+        let api = exported_api(&root);
+        let types: Vec<(&str, Vec<&str>, Vec<&str>)> = api
+            .types
+            .iter()
+            .map(|t| {
+                (
+                    t.path.to_str().unwrap(),
+                    t.fields.iter().map(|f| f.name.as_str()).collect(),
+                    t.methods.iter().map(|m| m.name.as_str()).collect(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            types,
+            vec![
+                ("/p/sample.go", vec!["Name"], vec!["Greet"]),
+                ("/p/store/store.go", vec!["Path"], vec!["Close"]),
+            ]
+        );
+        assert_eq!(
+            format_api_surface(Path::new("/p"), &api),
+            "API:\npackage .\ntype Greeter\n  field Name string\n  method Greet() string\nfunc New()\npackage store\ntype Greeter\n  field Path string\n  method Close()\n"
+        );
+    }
+}
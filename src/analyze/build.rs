@@ -0,0 +1,429 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Go build constraints, deciding which files a build for a given platform
+//! and set of tags would compile.
+//!
+//! Both `//go:build` expressions and legacy `// +build` lines are
+//! understood, as are `_GOOS`, `_GOARCH` and `_GOOS_GOARCH` file name
+//! suffixes. Release tags such as `go1.21` are always satisfied, and `cgo`
+//! only when it is among the tags.
+
+use std::path::{Path, PathBuf};
+
+/// Operating systems known to the Go toolchain
+const KNOWN_GOOS: &[&str] = &[
+    "aix",
+    "android",
+    "darwin",
+    "dragonfly",
+    "freebsd",
+    "hurd",
+    "illumos",
+    "ios",
+    "js",
+    "linux",
+    "nacl",
+    "netbsd",
+    "openbsd",
+    "plan9",
+    "solaris",
+    "wasip1",
+    "windows",
+    "zos",
+];
+
+/// Operating systems satisfying the `unix` tag
+const UNIX_GOOS: &[&str] = &[
+    "aix",
+    "android",
+    "darwin",
+    "dragonfly",
+    "freebsd",
+    "hurd",
+    "illumos",
+    "ios",
+    "linux",
+    "netbsd",
+    "openbsd",
+    "solaris",
+];
+
+/// Architectures known to the Go toolchain
+const KNOWN_GOARCH: &[&str] = &[
+    "386",
+    "amd64",
+    "amd64p32",
+    "arm",
+    "armbe",
+    "arm64",
+    "arm64be",
+    "loong64",
+    "mips",
+    "mipsle",
+    "mips64",
+    "mips64le",
+    "mips64p32",
+    "mips64p32le",
+    "ppc",
+    "ppc64",
+    "ppc64le",
+    "riscv",
+    "riscv64",
+    "s390",
+    "s390x",
+    "sparc",
+    "sparc64",
+    "wasm",
+];
+
+/// Target platform and tags to evaluate build constraints against
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct BuildContext {
+    pub goos: String,
+    pub goarch: String,
+    /// Extra tags, as given to `go build -tags`
+    pub tags: Vec<String>,
+}
+
+impl Default for BuildContext {
+    fn default() -> Self {
+        Self::host()
+    }
+}
+
+impl BuildContext {
+    /// The platform the analyzer runs on, without extra tags
+    pub fn host() -> Self {
+        let goos = match std::env::consts::OS {
+            "macos" => "darwin",
+            os => os,
+        };
+        let goarch = match std::env::consts::ARCH {
+            "x86" => "386",
+            "x86_64" => "amd64",
+            "aarch64" => "arm64",
+            "powerpc64" => "ppc64",
+            "riscv64gc" => "riscv64",
+            arch => arch,
+        };
+        Self {
+            goos: goos.to_string(),
+            goarch: goarch.to_string(),
+            tags: vec![],
+        }
+    }
+
+    /// Whether a single tag is satisfied
+    pub fn matches_tag(&self, tag: &str) -> bool {
+        tag == self.goos
+            || tag == self.goarch
+            || self.tags.iter().any(|t| t == tag)
+            || (tag == "unix" && UNIX_GOOS.contains(&self.goos.as_str()))
+            // GOOS values that imply another one, as in the toolchain
+            || (tag == "linux" && self.goos == "android")
+            || (tag == "darwin" && self.goos == "ios")
+            || (tag == "solaris" && self.goos == "illumos")
+            || is_release_tag(tag)
+    }
+
+    /// Why a Go file would not be compiled in this context, or `None` when it
+    /// would be. Files of other languages are never excluded. Constraints
+    /// that cannot be parsed do not exclude the file.
+    pub fn exclusion_reason(&self, path: &Path, source: &str) -> Option<String> {
+        if path.extension().and_then(|e| e.to_str()) != Some("go") {
+            return None;
+        }
+        if let Some(reason) = self.file_name_exclusion(path) {
+            return Some(reason);
+        }
+
+        let (go_build, plus_build) = header_constraints(source);
+        match go_build {
+            Some(line) => {
+                let expr = line.trim_start_matches("//go:build").trim();
+                match eval_expression(expr, self) {
+                    Some(false) => Some(line),
+                    _ => None,
+                }
+            }
+            None => plus_build
+                .into_iter()
+                .find(|line| !self.matches_plus_build(line)),
+        }
+    }
+
+    /// `_GOOS`, `_GOARCH` and `_GOOS_GOARCH` suffixes, ignoring `_test`
+    fn file_name_exclusion(&self, path: &Path) -> Option<String> {
+        let stem = path.file_stem()?.to_str()?;
+        let stem = stem.strip_suffix("_test").unwrap_or(stem);
+        // The part before the first underscore is never a constraint, so
+        // `linux.go` is compiled everywhere
+        let parts: Vec<&str> = stem.split('_').skip(1).collect();
+
+        let required: Vec<&str> = match parts.as_slice() {
+            [.., os, arch] if KNOWN_GOOS.contains(os) && KNOWN_GOARCH.contains(arch) => {
+                vec![os, arch]
+            }
+            [.., last] if KNOWN_GOOS.contains(last) || KNOWN_GOARCH.contains(last) => vec![last],
+            _ => return None,
+        };
+        if required.iter().all(|tag| self.matches_tag(tag)) {
+            None
+        } else {
+            Some(format!("file name requires {}", required.join("/")))
+        }
+    }
+
+    /// A `// +build` line: space-separated options of which one must hold,
+    /// each a comma-separated list of terms that must all hold
+    fn matches_plus_build(&self, line: &str) -> bool {
+        line.trim_start_matches("//")
+            .trim()
+            .trim_start_matches("+build")
+            .split_whitespace()
+            .any(|option| {
+                option.split(',').all(|term| match term.strip_prefix('!') {
+                    Some(tag) => !self.matches_tag(tag),
+                    None => self.matches_tag(term),
+                })
+            })
+    }
+}
+
+/// A Go file left out of the analysis by build constraints
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SkippedFile {
+    pub path: PathBuf,
+    /// The constraint that failed, e.g. `//go:build windows`
+    pub reason: String,
+}
+
+/// `go1.N` tags, satisfied by every toolchain the analyzer cares about
+fn is_release_tag(tag: &str) -> bool {
+    tag.strip_prefix("go1.")
+        .is_some_and(|minor| !minor.is_empty() && minor.chars().all(|c| c.is_ascii_digit()))
+}
+
+/// The `//go:build` line and the `// +build` lines of a file's header: the
+/// comments and blank lines before the package clause
+fn header_constraints(source: &str) -> (Option<String>, Vec<String>) {
+    let mut go_build = None;
+    let mut plus_build = Vec::new();
+    let mut in_block_comment = false;
+
+    for line in source.lines() {
+        let line = line.trim();
+        if in_block_comment {
+            in_block_comment = !line.contains("*/");
+            continue;
+        }
+        if line.is_empty() {
+            continue;
+        }
+        if line.starts_with("/*") {
+            in_block_comment = !line.contains("*/");
+            continue;
+        }
+        if !line.starts_with("//") {
+            break;
+        }
+        if line.starts_with("//go:build") && go_build.is_none() {
+            go_build = Some(line.to_string());
+        } else if line
+            .trim_start_matches("//")
+            .trim_start()
+            .starts_with("+build")
+        {
+            plus_build.push(line.to_string());
+        }
+    }
+
+    (go_build, plus_build)
+}
+
+/// Evaluate a `//go:build` expression of tags, `!`, `&&`, `||` and
+/// parentheses; `None` when it is malformed
+fn eval_expression(expr: &str, context: &BuildContext) -> Option<bool> {
+    let tokens = tokenize(expr)?;
+    let mut parser = ExpressionParser {
+        tokens: &tokens,
+        pos: 0,
+        context,
+    };
+    let value = parser.or()?;
+    (parser.pos == tokens.len()).then_some(value)
+}
+
+fn tokenize(expr: &str) -> Option<Vec<&str>> {
+    let mut tokens = Vec::new();
+    let mut rest = expr.trim_start();
+    while !rest.is_empty() {
+        let len = if rest.starts_with("&&") || rest.starts_with("||") {
+            2
+        } else if rest.starts_with(['!', '(', ')']) {
+            1
+        } else {
+            let len = rest
+                .find(|c: char| !(c.is_alphanumeric() || c == '_' || c == '.'))
+                .unwrap_or(rest.len());
+            if len == 0 {
+                return None;
+            }
+            len
+        };
+        tokens.push(&rest[..len]);
+        rest = rest[len..].trim_start();
+    }
+    Some(tokens)
+}
+
+struct ExpressionParser<'a> {
+    tokens: &'a [&'a str],
+    pos: usize,
+    context: &'a BuildContext,
+}
+
+impl<'a> ExpressionParser<'a> {
+    fn peek(&self) -> Option<&'a str> {
+        self.tokens.get(self.pos).copied()
+    }
+
+    fn or(&mut self) -> Option<bool> {
+        let mut value = self.and()?;
+        while self.peek() == Some("||") {
+            self.pos += 1;
+            // Evaluated unconditionally so malformed operands are caught
+            value = self.and()? || value;
+        }
+        Some(value)
+    }
+
+    fn and(&mut self) -> Option<bool> {
+        let mut value = self.not()?;
+        while self.peek() == Some("&&") {
+            self.pos += 1;
+            value = self.not()? && value;
+        }
+        Some(value)
+    }
+
+    fn not(&mut self) -> Option<bool> {
+        let token = self.peek()?;
+        self.pos += 1;
+        match token {
+            "!" => self.not().map(|value| !value),
+            "(" => {
+                let value = self.or()?;
+                if self.peek() != Some(")") {
+                    return None;
+                }
+                self.pos += 1;
+                Some(value)
+            }
+            "&&" | "||" | ")" => None,
+            tag => Some(self.context.matches_tag(tag)),
+        }
+    }
+}
+
+/// Format skipped files as a `SKIPPED (build constraints):` section with
+/// paths relative to `base`
+pub fn format_skipped_files(base: &Path, skipped: &[SkippedFile]) -> String {
+    if skipped.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nSKIPPED (build constraints):\n");
+    for entry in skipped {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!("  {} ({})\n", path.display(), entry.reason));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn context(goos: &str, goarch: &str, tags: &[&str]) -> BuildContext {
+        BuildContext {
+            goos: goos.into(),
+            goarch: goarch.into(),
+            tags: tags.iter().map(|t| t.to_string()).collect(),
+        }
+    }
+
+    fn excluded(context: &BuildContext, file: &str, source: &str) -> Option<String> {
+        context.exclusion_reason(Path::new(file), source)
+    }
+
+    #[test]
+    fn file_name_suffixes_select_platform_files() {
+        let linux = context("linux", "amd64", &[]);
+        assert_eq!(excluded(&linux, "/p/fs_linux.go", ""), None);
+        assert_eq!(excluded(&linux, "/p/fs_linux_amd64_test.go", ""), None);
+        assert_eq!(
+            excluded(&linux, "/p/fs_windows.go", ""),
+            Some("file name requires windows".into())
+        );
+        assert_eq!(
+            excluded(&linux, "/p/fs_linux_arm64.go", ""),
+            Some("file name requires linux/arm64".into())
+        );
+        assert_eq!(excluded(&linux, "/p/windows.go", ""), None);
+        assert_eq!(excluded(&linux, "/p/fs_windows.py", ""), None);
+    }
+
+    #[test]
+    fn go_build_expressions_are_evaluated() {
+        let linux = context("linux", "amd64", &["integration"]);
+        let source = |expr: &str| format!("// Copyright\n\n//go:build {expr}\n\npackage fs\n");
+        assert_eq!(excluded(&linux, "/p/a.go", &source("linux && !cgo")), None);
+        assert_eq!(excluded(&linux, "/p/a.go", &source("unix && go1.21")), None);
+        assert_eq!(
+            excluded(&linux, "/p/a.go", &source("(darwin || windows) && amd64")),
+            Some("//go:build (darwin || windows) && amd64".into())
+        );
+        assert_eq!(excluded(&linux, "/p/a.go", &source("integration")), None);
+        assert!(
+            excluded(
+                &context("linux", "amd64", &[]),
+                "/p/a.go",
+                &source("ignore")
+            )
+            .is_some()
+        );
+        // Malformed constraints leave the file in
+        assert_eq!(excluded(&linux, "/p/a.go", &source("linux &&")), None);
+    }
+
+    #[test]
+    fn legacy_plus_build_lines_are_anded() {
+        let linux = context("linux", "arm64", &[]);
+        let source = "// +build linux darwin\n// +build arm64,!cgo\n\npackage fs\n";
+        assert_eq!(excluded(&linux, "/p/a.go", source), None);
+        assert_eq!(
+            excluded(&context("windows", "arm64", &[]), "/p/a.go", source),
+            Some("// +build linux darwin".into())
+        );
+        // A constraint after the package clause is just a comment
+        assert_eq!(
+            excluded(&linux, "/p/a.go", "package fs\n\n//go:build windows\n"),
+            None
+        );
+    }
+
+    #[test]
+    fn format_lists_path_and_reason() {
+        let skipped = vec![SkippedFile {
+            path: PathBuf::from("/p/fs_windows.go"),
+            reason: "file name requires windows".into(),
+        }];
+        assert_eq!(
+            format_skipped_files(Path::new("/p"), &skipped),
+            "\nSKIPPED (build constraints):\n  fs_windows.go (file name requires windows)\n"
+        );
+        assert!(format_skipped_files(Path::new("/p"), &[]).is_empty());
+    }
+}
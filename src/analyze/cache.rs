@@ -3,8 +3,10 @@
 // SPDX-License-Identifier: Apache-2.0
 
 use lru::LruCache;
+use sha2::{Digest, Sha256};
 use std::num::NonZeroUsize;
 use std::path::{Path, PathBuf};
+use std::sync::atomic::{AtomicUsize, Ordering};
 use std::sync::{Arc, Mutex};
 use std::time::SystemTime;
 
@@ -81,6 +83,93 @@ impl Default for AnalysisCache {
     }
 }
 
+/// Distinguishes temporary files written concurrently for the same key
+static TEMP_FILE_COUNTER: AtomicUsize = AtomicUsize::new(0);
+
+/// On-disk cache of analysis results keyed by a hash of the file contents,
+/// so unchanged files are not re-parsed across runs.
+///
+/// Entries live in a subdirectory named after the analyzer version and a
+/// fingerprint of the sources it was built from (see `build.rs`), so a
+/// build that extracts results differently never reads entries written by
+/// another. The cache is best effort: unreadable entries are misses and
+/// failed writes are ignored.
+#[derive(Clone, Debug)]
+pub struct DiskCache {
+    dir: PathBuf,
+}
+
+impl DiskCache {
+    pub fn new(root: impl Into<PathBuf>) -> Self {
+        let build = format!(
+            "v{}-{}",
+            env!("CARGO_PKG_VERSION"),
+            env!("ANALYZER_BUILD_ID")
+        );
+        Self::in_subdir(root, &build)
+    }
+
+    /// Cache keeping its entries in `root/subdir`
+    fn in_subdir(root: impl Into<PathBuf>, subdir: &str) -> Self {
+        Self {
+            dir: root.into().join(subdir),
+        }
+    }
+
+    /// SHA-256 of the file contents together with everything else that
+    /// affects the extracted result
+    pub fn key(
+        content: &str,
+        language: &str,
+        mode: &AnalysisMode,
+        ast_recursion_limit: Option<usize>,
+    ) -> String {
+        let mut hasher = Sha256::new();
+        hasher.update(content.as_bytes());
+        hasher.update([0]);
+        hasher.update(language.as_bytes());
+        hasher.update([0]);
+        hasher.update(mode.as_str().as_bytes());
+        hasher.update([0]);
+        if let Some(limit) = ast_recursion_limit {
+            hasher.update(limit.to_le_bytes());
+        }
+        format!("{:x}", hasher.finalize())
+    }
+
+    fn entry_path(&self, key: &str) -> PathBuf {
+        self.dir.join(format!("{}.json", key))
+    }
+
+    pub fn get(&self, key: &str) -> Option<AnalysisResult> {
+        let data = std::fs::read(self.entry_path(key)).ok()?;
+        serde_json::from_slice(&data).ok()
+    }
+
+    /// Store `result` under `key`, writing to a temporary file first so
+    /// concurrent readers never see a partial entry
+    pub fn put(&self, key: &str, result: &AnalysisResult) {
+        let Ok(data) = serde_json::to_vec(result) else {
+            return;
+        };
+        if std::fs::create_dir_all(&self.dir).is_err() {
+            return;
+        }
+
+        let temp = self.dir.join(format!(
+            "{}.{}.{}.tmp",
+            key,
+            std::process::id(),
+            TEMP_FILE_COUNTER.fetch_add(1, Ordering::Relaxed)
+        ));
+        if std::fs::write(&temp, data).is_err()
+            || std::fs::rename(&temp, self.entry_path(key)).is_err()
+        {
+            let _ = std::fs::remove_file(&temp);
+        }
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -174,4 +263,70 @@ mod tests {
                 .is_some()
         );
     }
+
+    #[test]
+    fn disk_cache_put_and_get() {
+        let dir = tempfile::tempdir().unwrap();
+        let cache = DiskCache::new(dir.path());
+        let key = DiskCache::key("fn main() {}", "rust", &AnalysisMode::Semantic, None);
+
+        assert!(cache.get(&key).is_none());
+        cache.put(&key, &sample_result());
+        let result = cache.get(&key);
+        assert!(result.is_some());
+        assert_eq!(result.unwrap().line_count, 10);
+    }
+
+    #[test]
+    fn disk_cache_key_depends_on_content_and_mode() {
+        let key = DiskCache::key("a", "go", &AnalysisMode::Semantic, None);
+        assert_eq!(key.len(), 64);
+        assert_eq!(
+            key,
+            DiskCache::key("a", "go", &AnalysisMode::Semantic, None)
+        );
+        assert_ne!(
+            key,
+            DiskCache::key("b", "go", &AnalysisMode::Semantic, None)
+        );
+        assert_ne!(
+            key,
+            DiskCache::key("a", "go", &AnalysisMode::Structure, None)
+        );
+        assert_ne!(
+            key,
+            DiskCache::key("a", "go", &AnalysisMode::Semantic, Some(5))
+        );
+    }
+
+    #[test]
+    fn disk_cache_entries_are_scoped_to_build() {
+        let dir = tempfile::tempdir().unwrap();
+        let cache = DiskCache::new(dir.path());
+        assert!(cache.dir.starts_with(dir.path()));
+        let name = cache.dir.file_name().unwrap().to_string_lossy();
+        assert!(name.contains(env!("CARGO_PKG_VERSION")));
+        assert!(name.ends_with(env!("ANALYZER_BUILD_ID")));
+    }
+
+    #[test]
+    fn disk_cache_misses_entries_of_another_build() {
+        let dir = tempfile::tempdir().unwrap();
+        let key = DiskCache::key("a", "go", &AnalysisMode::Semantic, None);
+        let old = DiskCache::in_subdir(dir.path(), "v0.1.1-20");
+        old.put(&key, &sample_result());
+        assert!(old.get(&key).is_some());
+
+        assert!(DiskCache::new(dir.path()).get(&key).is_none());
+    }
+
+    #[test]
+    fn disk_cache_ignores_corrupt_entries() {
+        let dir = tempfile::tempdir().unwrap();
+        let cache = DiskCache::new(dir.path());
+        let key = DiskCache::key("a", "go", &AnalysisMode::Semantic, None);
+        std::fs::create_dir_all(&cache.dir).unwrap();
+        std::fs::write(cache.dir.join(format!("{}.json", key)), "not json").unwrap();
+        assert!(cache.get(&key).is_none());
+    }
 }
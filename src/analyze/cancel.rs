@@ -0,0 +1,66 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::sync::Arc;
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::time::{Duration, Instant};
+
+/// Shared flag that stops an analysis run, set by calling [`cancel`] from
+/// any thread or by reaching a deadline. Clones share the flag.
+///
+/// Cancellation is checked before each file is analyzed: files already
+/// being parsed finish, the rest are left out.
+///
+/// [`cancel`]: CancelToken::cancel
+#[derive(Debug, Clone, Default)]
+pub struct CancelToken {
+    cancelled: Arc<AtomicBool>,
+    deadline: Option<Instant>,
+}
+
+impl CancelToken {
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// A token that cancels itself once `timeout` has passed from now
+    pub fn with_timeout(timeout: Duration) -> Self {
+        Self {
+            deadline: Instant::now().checked_add(timeout),
+            ..Self::default()
+        }
+    }
+
+    /// Cancel every run holding this token or a clone of it
+    pub fn cancel(&self) {
+        self.cancelled.store(true, Ordering::Relaxed);
+    }
+
+    /// Whether `cancel` was called or the deadline has passed
+    pub fn is_cancelled(&self) -> bool {
+        self.cancelled.load(Ordering::Relaxed)
+            || self
+                .deadline
+                .is_some_and(|deadline| Instant::now() >= deadline)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn clones_share_cancellation() {
+        let token = CancelToken::new();
+        let clone = token.clone();
+        assert!(!clone.is_cancelled());
+        token.cancel();
+        assert!(clone.is_cancelled());
+    }
+
+    #[test]
+    fn deadline_cancels_the_token() {
+        assert!(CancelToken::with_timeout(Duration::ZERO).is_cancelled());
+        assert!(!CancelToken::with_timeout(Duration::from_secs(3600)).is_cancelled());
+    }
+}
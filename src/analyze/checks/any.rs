@@ -0,0 +1,247 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use crate::analyze::types::{AnalysisResult, EmptyInterface, EmptyInterfaceUsage};
+
+/// Every usage category, in the order summaries list them
+const USAGES: &[EmptyInterfaceUsage] = &[
+    EmptyInterfaceUsage::Parameter,
+    EmptyInterfaceUsage::Result,
+    EmptyInterfaceUsage::Field,
+    EmptyInterfaceUsage::Element,
+    EmptyInterfaceUsage::Assertion,
+    EmptyInterfaceUsage::Other,
+];
+
+/// An empty interface type in a Go file
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct EmptyInterfaceType {
+    pub path: PathBuf,
+    pub empty_interface: EmptyInterface,
+}
+
+/// Collect the `interface{}` and `any` types found while parsing, ordered
+/// by path and position
+pub fn find_empty_interfaces(results: &[(PathBuf, AnalysisResult)]) -> Vec<EmptyInterfaceType> {
+    let mut found: Vec<EmptyInterfaceType> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .empty_interfaces
+                .iter()
+                .map(move |empty_interface| EmptyInterfaceType {
+                    path: path.clone(),
+                    empty_interface: empty_interface.clone(),
+                })
+        })
+        .collect();
+
+    found.sort_by(|a, b| {
+        a.path.cmp(&b.path).then_with(|| {
+            (a.empty_interface.line, a.empty_interface.column)
+                .cmp(&(b.empty_interface.line, b.empty_interface.column))
+        })
+    });
+    found
+}
+
+/// Number of empty interface types per usage, in summary order, leaving out
+/// usages without any
+pub fn count_by_usage(found: &[EmptyInterfaceType]) -> Vec<(EmptyInterfaceUsage, usize)> {
+    USAGES
+        .iter()
+        .map(|&usage| {
+            let count = found
+                .iter()
+                .filter(|entry| entry.empty_interface.usage == usage)
+                .count();
+            (usage, count)
+        })
+        .filter(|&(_, count)| count > 0)
+        .collect()
+}
+
+/// Format empty interface types as an `EMPTY INTERFACES:` section headed by
+/// their total and count per usage, with paths relative to `base`
+pub fn format_empty_interfaces(base: &Path, found: &[EmptyInterfaceType]) -> String {
+    if found.is_empty() {
+        return String::new();
+    }
+
+    let counts: Vec<String> = count_by_usage(found)
+        .iter()
+        .map(|(usage, count)| format!("{} {}", count, usage.as_str()))
+        .collect();
+    let mut output = format!(
+        "\nEMPTY INTERFACES: {} ({})\n",
+        found.len(),
+        counts.join(", ")
+    );
+    for entry in found {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        let empty_interface = &entry.empty_interface;
+        output.push_str(&format!(
+            "  {}:{}:{} {}",
+            path.display(),
+            empty_interface.line,
+            empty_interface.column,
+            empty_interface.usage.as_str()
+        ));
+        if !empty_interface.declaration.is_empty() {
+            output.push_str(&format!(" in {}", empty_interface.declaration));
+        }
+        output.push('\n');
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+
+    fn empty_interfaces(code: &str) -> Vec<(String, usize, usize, String)> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        result
+            .empty_interfaces
+            .iter()
+            .map(|e| {
+                (
+                    e.usage.as_str().to_string(),
+                    e.line,
+                    e.column,
+                    e.declaration.clone(),
+                )
+            })
+            .collect()
+    }
+
+    fn found(
+        usage: &str,
+        line: usize,
+        column: usize,
+        declaration: &str,
+    ) -> (String, usize, usize, String) {
+        (usage.to_string(), line, column, declaration.to_string())
+    }
+
+    #[test]
+    fn empty_interfaces_are_labelled_by_usage() {
+        let code = r#"package codec
+
+type Message struct {
+	Body    any
+	Headers map[string]interface{}
+}
+
+func Decode(data []byte, v any) (interface{}, error) {
+	if s, ok := v.(any); ok {
+		return s, nil
+	}
+	return nil, nil
+}
+
+func Log(format string, args ...any) {}
+
+var Default interface{} = nil
+"#;
+        assert_eq!(
+            empty_interfaces(code),
+            vec![
+                found("field", 4, 10, "Message"),
+                found("element", 5, 21, "Message"),
+                found("parameter", 8, 28, "Decode"),
+                found("result", 8, 34, "Decode"),
+                found("assertion", 9, 17, "Decode"),
+                found("parameter", 15, 33, "Log"),
+                found("other", 17, 13, "Default"),
+            ]
+        );
+    }
+
+    #[test]
+    fn constraints_and_non_empty_interfaces_are_skipped() {
+        let code = r#"package set
+
+type Set[T any] map[T]struct{}
+
+type Stringer interface {
+	String() string
+}
+
+func Keys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	return keys
+}
+"#;
+        assert!(
+            empty_interfaces(code).is_empty(),
+            "{:?}",
+            empty_interfaces(code)
+        );
+    }
+
+    #[test]
+    fn type_switches_and_interface_methods_are_labelled() {
+        let code = r#"package store
+
+type Getter interface {
+	Get(key string) any
+}
+
+func kind(v Value) string {
+	switch v.(type) {
+	case interface{}:
+		return "any"
+	}
+	var items []any
+	_ = items
+	return ""
+}
+"#;
+        assert_eq!(
+            empty_interfaces(code),
+            vec![
+                found("result", 4, 18, "Getter"),
+                found("assertion", 9, 7, "kind"),
+                found("element", 12, 14, "kind"),
+            ]
+        );
+    }
+
+    #[test]
+    fn format_totals_usages() {
+        let mut result = AnalysisResult::empty(20);
+        let empty_interface = |usage, line, declaration: &str| EmptyInterface {
+            usage,
+            declaration: declaration.into(),
+            line,
+            column: 5,
+        };
+        result.empty_interfaces = vec![
+            empty_interface(EmptyInterfaceUsage::Parameter, 8, "Decode"),
+            empty_interface(EmptyInterfaceUsage::Field, 4, "Message"),
+            empty_interface(EmptyInterfaceUsage::Parameter, 12, "Encode"),
+            empty_interface(EmptyInterfaceUsage::Other, 15, ""),
+        ];
+        let found = find_empty_interfaces(&[(PathBuf::from("/p/codec.go"), result)]);
+        assert_eq!(
+            count_by_usage(&found),
+            vec![
+                (EmptyInterfaceUsage::Parameter, 2),
+                (EmptyInterfaceUsage::Field, 1),
+                (EmptyInterfaceUsage::Other, 1),
+            ]
+        );
+        assert_eq!(
+            format_empty_interfaces(Path::new("/p"), &found),
+            "\nEMPTY INTERFACES: 4 (2 parameter, 1 field, 1 other)\n  codec.go:4:5 field in Message\n  codec.go:8:5 parameter in Decode\n  codec.go:12:5 parameter in Encode\n  codec.go:15:5 other\n"
+        );
+        assert!(format_empty_interfaces(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,287 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_SLICE_APPENDS;
+use crate::analyze::types::{AnalysisResult, SliceAppend};
+
+/// A slice grown by `append` inside a loop without preallocated capacity
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SliceAppendInLoop {
+    pub path: PathBuf,
+    /// Function containing the call
+    pub function: String,
+    pub append: SliceAppend,
+}
+
+impl SliceAppendInLoop {
+    /// `ids in collect grows in the loop at line 6; preallocate with
+    /// make([]int, 0, len(items))`, with `[]T` and `n` standing in for a type
+    /// or capacity that is not known
+    pub fn describe(&self) -> String {
+        let append = &self.append;
+        let grows = if append.zero_capacity {
+            "appends to a zero-capacity slice"
+        } else {
+            "grows"
+        };
+        let slice_type = if append.slice_type.is_empty() {
+            "[]T"
+        } else {
+            &append.slice_type
+        };
+        let capacity = if append.capacity.is_empty() {
+            "n"
+        } else {
+            &append.capacity
+        };
+        format!(
+            "{} in {} {} in the loop at line {}; preallocate with make({}, 0, {})",
+            append.variable, self.function, grows, append.loop_line, slice_type, capacity
+        )
+    }
+}
+
+/// Collect the `append` calls in loops found while parsing, ordered by path
+/// and position. Each one may copy the slice built so far to a larger array,
+/// so a slice made with the capacity the loop needs is faster on hot paths.
+/// Functions under an `analyzer:ignore slice-appends` comment are skipped.
+pub fn find_slice_appends(results: &[(PathBuf, AnalysisResult)]) -> Vec<SliceAppendInLoop> {
+    let mut appends: Vec<SliceAppendInLoop> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.is_ignored(CHECK_SLICE_APPENDS))
+                .flat_map(move |f| {
+                    f.slice_appends.iter().map(move |append| SliceAppendInLoop {
+                        path: path.clone(),
+                        function: f.name.clone(),
+                        append: append.clone(),
+                    })
+                })
+        })
+        .collect();
+
+    appends.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| (a.append.line, a.append.column).cmp(&(b.append.line, b.append.column)))
+    });
+    appends
+}
+
+/// Format appends as a `SLICE APPENDS IN LOOPS:` section with paths
+/// relative to `base`
+pub fn format_slice_appends(base: &Path, appends: &[SliceAppendInLoop]) -> String {
+    if appends.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nSLICE APPENDS IN LOOPS:\n");
+    for entry in appends {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{}:{} {}\n",
+            path.display(),
+            entry.append.line,
+            entry.append.column,
+            entry.describe()
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::FunctionInfo;
+
+    type Found = (String, usize, usize, usize, String, String, bool);
+
+    fn appends(code: &str) -> Vec<Found> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        result
+            .functions
+            .iter()
+            .flat_map(|f| &f.slice_appends)
+            .map(|a| {
+                (
+                    a.variable.clone(),
+                    a.line,
+                    a.column,
+                    a.loop_line,
+                    a.slice_type.clone(),
+                    a.capacity.clone(),
+                    a.zero_capacity,
+                )
+            })
+            .collect()
+    }
+
+    fn found(
+        variable: &str,
+        (line, column): (usize, usize),
+        loop_line: usize,
+        slice_type: &str,
+        capacity: &str,
+        zero_capacity: bool,
+    ) -> Found {
+        (
+            variable.to_string(),
+            line,
+            column,
+            loop_line,
+            slice_type.to_string(),
+            capacity.to_string(),
+            zero_capacity,
+        )
+    }
+
+    #[test]
+    fn appends_to_slices_without_capacity_are_found() {
+        let code = r#"package list
+
+func collect(items []Item, ch chan int) []int {
+	var ids []int
+	names := []string{}
+	sizes := make([]int, 0)
+	for _, item := range items {
+		ids = append(ids, item.ID)
+		names = append(names, item.Name)
+	}
+	for i := range 10 {
+		sizes = append(sizes, i)
+	}
+	for v := range ch {
+		ids = append(ids, v)
+	}
+	return ids
+}
+"#;
+        assert_eq!(
+            appends(code),
+            vec![
+                found("ids", (8, 9), 7, "[]int", "len(items)", false),
+                found("names", (9, 11), 7, "[]string", "len(items)", false),
+                found("sizes", (12, 11), 11, "[]int", "10", false),
+                found("ids", (15, 9), 14, "[]int", "", false),
+            ]
+        );
+    }
+
+    #[test]
+    fn preallocated_and_loop_local_slices_are_not_found() {
+        let code = r#"package list
+
+func build(items []Item, out []int) []int {
+	ids := make([]int, 0, len(items))
+	var names []string
+	names = make([]string, 0, len(items))
+	sized := make([]int, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+		names = append(names, item.Name)
+		sized = append(sized, 1)
+		out = append(out, item.ID)
+		var row []int
+		row = append(row, item.ID)
+		go func() {
+			var local []int
+			local = append(local, 1)
+		}()
+	}
+	var once []int
+	once = append(once, 1)
+	return ids
+}
+"#;
+        assert!(appends(code).is_empty(), "{:?}", appends(code));
+    }
+
+    #[test]
+    fn zero_capacity_slices_are_found() {
+        let code = r#"package list
+
+var cache []int
+
+func reset(rows [][]int) {
+	tmp := rows[0][:0:0]
+	for _, row := range rows {
+		tmp = append(tmp, row...)
+		cache = append(cache[:0:0], row...)
+	}
+	for i := 0; i < len(rows); i++ {
+		tmp = append(tmp, i)
+	}
+}
+"#;
+        assert_eq!(
+            appends(code),
+            vec![
+                found("tmp", (8, 9), 7, "", "len(rows)", false),
+                found("cache", (9, 11), 7, "", "len(rows)", true),
+                found("tmp", (12, 9), 11, "", "", false),
+            ]
+        );
+    }
+
+    #[test]
+    fn format_suggests_a_capacity() {
+        let mut result = AnalysisResult::empty(20);
+        result.functions = vec![
+            FunctionInfo {
+                name: "collect".into(),
+                line: 3,
+                end_line: 12,
+                slice_appends: vec![
+                    SliceAppend {
+                        variable: "ids".into(),
+                        line: 8,
+                        column: 9,
+                        loop_line: 7,
+                        slice_type: "[]int".into(),
+                        capacity: "len(items)".into(),
+                        zero_capacity: false,
+                    },
+                    SliceAppend {
+                        variable: "tmp".into(),
+                        line: 11,
+                        column: 9,
+                        loop_line: 10,
+                        slice_type: String::new(),
+                        capacity: String::new(),
+                        zero_capacity: true,
+                    },
+                ],
+                ..Default::default()
+            },
+            FunctionInfo {
+                name: "quiet".into(),
+                line: 14,
+                end_line: 18,
+                ignored_checks: vec!["slice-appends".into()],
+                slice_appends: vec![SliceAppend {
+                    variable: "rows".into(),
+                    line: 16,
+                    column: 10,
+                    loop_line: 15,
+                    ..Default::default()
+                }],
+                ..Default::default()
+            },
+        ];
+        let found = find_slice_appends(&[(PathBuf::from("/p/list.go"), result)]);
+        assert_eq!(
+            format_slice_appends(Path::new("/p"), &found),
+            "\nSLICE APPENDS IN LOOPS:\n  list.go:8:9 ids in collect grows in the loop at line 7; preallocate with make([]int, 0, len(items))\n  list.go:11:9 tmp in collect appends to a zero-capacity slice in the loop at line 10; preallocate with make([]T, 0, n)\n"
+        );
+        assert!(format_slice_appends(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,328 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! The built-in checks as [`Check`]s, run by the same [`run_checks`] as
+//! custom ones. Each reports a [`Finding`] per result and keeps the typed
+//! results in the [`AnalysisOutput`], where the report sections of every
+//! format take them from.
+//!
+//! [`run_checks`]: super::custom::run_checks
+
+use std::path::PathBuf;
+use std::sync::Arc;
+
+use super::custom::{Check, CheckContext};
+use super::*;
+use crate::analyze::types::AnalysisResult;
+use crate::analyze::{AnalysisOutput, AnalyzeOptions, metrics};
+
+type Files = [(PathBuf, AnalysisResult)];
+
+/// A built-in check reporting under a single rule of [`RULES`]
+struct Builtin {
+    rule_id: &'static str,
+    run: Box<dyn Fn(&mut CheckContext) + Send + Sync>,
+}
+
+impl Check for Builtin {
+    fn name(&self) -> &'static str {
+        self.rule_id
+    }
+
+    fn description(&self) -> &'static str {
+        rule_description(self.rule_id)
+    }
+
+    fn run(&self, ctx: &mut CheckContext) {
+        (self.run)(ctx)
+    }
+}
+
+/// Check reporting each result of `find`, then handing the results kept
+/// under a diff to the field of the output that `field` picks
+fn builtin<T: 'static>(
+    rule_id: &'static str,
+    find: impl Fn(&Files) -> Vec<T> + Send + Sync + 'static,
+    field: fn(&mut AnalysisOutput) -> &mut Vec<T>,
+) -> Arc<dyn Check>
+where
+    for<'b> Finding: From<&'b T>,
+{
+    Arc::new(Builtin {
+        rule_id,
+        run: Box::new(move |ctx| {
+            let mut found = find(ctx.files());
+            found.retain(|entry| ctx.report_finding(Finding::from(entry)));
+            *field(ctx.analysis()) = found;
+        }),
+    })
+}
+
+/// The built-in checks `options` enables, in the order their findings are
+/// reported
+pub(crate) fn builtin_checks(options: &AnalyzeOptions) -> Vec<Arc<dyn Check>> {
+    let mut checks = vec![];
+    if let Some(max) = options.max_complexity {
+        checks.push(builtin(
+            RULE_COMPLEXITY,
+            move |files| metrics::complexity_violations(files, max),
+            |analysis| &mut analysis.complexity_violations,
+        ));
+    }
+    if let Some(max) = options.max_function_loc {
+        checks.push(builtin(
+            RULE_FUNCTION_LENGTH,
+            move |files| metrics::length_violations(files, max),
+            |analysis| &mut analysis.length_violations,
+        ));
+    }
+    if let Some(max) = options.max_params {
+        checks.push(builtin(
+            RULE_TOO_MANY_PARAMS,
+            move |files| metrics::param_count_violations(files, max),
+            |analysis| &mut analysis.param_violations,
+        ));
+    }
+    if let Some(max) = options.max_nesting {
+        checks.push(builtin(
+            RULE_NESTING_DEPTH,
+            move |files| metrics::nesting_violations(files, max),
+            |analysis| &mut analysis.nesting_violations,
+        ));
+    }
+    if options.find_unused {
+        checks.push(builtin(
+            RULE_UNUSED_FUNCTION,
+            unused::find_unused_functions,
+            |analysis| &mut analysis.unused_functions,
+        ));
+    }
+    if options.find_unused_receivers {
+        checks.push(builtin(
+            RULE_UNUSED_RECEIVER,
+            receiver::find_unused_receivers,
+            |analysis| &mut analysis.unused_receivers,
+        ));
+    }
+    if options.find_duplicate_tags {
+        checks.push(builtin(
+            RULE_DUPLICATE_JSON_TAG,
+            tags::find_duplicate_json_tags,
+            |analysis| &mut analysis.duplicate_tags,
+        ));
+    }
+    if options.find_shadowed {
+        let skip_common = options.shadow_skip_common;
+        checks.push(builtin(
+            RULE_SHADOWED_VARIABLE,
+            move |files| shadow::find_shadowed_variables(files, skip_common),
+            |analysis| &mut analysis.shadowed,
+        ));
+    }
+    if options.find_naked_returns {
+        let max_loc = options.naked_return_max_loc;
+        checks.push(builtin(
+            RULE_NAKED_RETURN,
+            move |files| naked::find_naked_returns(files, max_loc),
+            |analysis| &mut analysis.naked_returns,
+        ));
+    }
+    if options.find_todos {
+        let markers = options.todo_markers.clone();
+        checks.push(builtin(
+            RULE_TODO_COMMENT,
+            move |files| todo::find_todo_comments(files, &markers),
+            |analysis| &mut analysis.todos,
+        ));
+    }
+    if options.find_clones {
+        let min_statements = options.clone_min_statements;
+        // One group is reported at each of its locations, and kept whole
+        // while a diff keeps any of them
+        checks.push(Arc::new(Builtin {
+            rule_id: RULE_DUPLICATE_CODE,
+            run: Box::new(move |ctx| {
+                let mut groups = clones::find_clones(ctx.files(), min_statements);
+                groups.retain(|group| {
+                    clone_findings(group)
+                        .into_iter()
+                        .fold(false, |kept, finding| ctx.report_finding(finding) || kept)
+                });
+                ctx.analysis().clones = groups;
+            }),
+        }));
+    }
+    if options.find_ignored_errors {
+        let skip_deferred = options.ignored_errors_skip_deferred;
+        checks.push(builtin(
+            RULE_IGNORED_ERROR,
+            move |files| errors::find_ignored_errors(files, skip_deferred),
+            |analysis| &mut analysis.ignored_errors,
+        ));
+    }
+    if options.find_magic_numbers {
+        let allowed = options.magic_numbers_allowed.clone();
+        let ignore_indices = options.magic_numbers_ignore_indices;
+        checks.push(builtin(
+            RULE_MAGIC_NUMBER,
+            move |files| magic::find_magic_numbers(files, &allowed, ignore_indices),
+            |analysis| &mut analysis.magic_numbers,
+        ));
+    }
+    if options.find_panics {
+        let include_fatal = options.panics_include_fatal;
+        let include_acceptable = options.panics_include_acceptable;
+        checks.push(builtin(
+            RULE_PANIC,
+            move |files| panics::find_panics(files, include_fatal, include_acceptable),
+            |analysis| &mut analysis.panics,
+        ));
+    }
+    if options.find_mixed_receivers {
+        checks.push(builtin(
+            RULE_MIXED_RECEIVERS,
+            receiver_kinds::find_mixed_receivers,
+            |analysis| &mut analysis.mixed_receivers,
+        ));
+    }
+    if options.find_unused_fields {
+        let include_exported = options.unused_fields_include_exported;
+        checks.push(builtin(
+            RULE_UNUSED_FIELD,
+            move |files| fields::find_unused_fields(files, include_exported),
+            |analysis| &mut analysis.unused_fields,
+        ));
+    }
+    if options.find_string_concats {
+        checks.push(builtin(
+            RULE_STRING_CONCAT,
+            concat::find_string_concats,
+            |analysis| &mut analysis.string_concats,
+        ));
+    }
+    if options.find_unwrapped_errors {
+        checks.push(builtin(
+            RULE_UNWRAPPED_ERROR,
+            wrapping::find_unwrapped_errors,
+            |analysis| &mut analysis.unwrapped_errors,
+        ));
+    }
+    if options.find_empty_interfaces {
+        checks.push(builtin(
+            RULE_EMPTY_INTERFACE,
+            any::find_empty_interfaces,
+            |analysis| &mut analysis.empty_interfaces,
+        ));
+    }
+    if options.find_missing_docs {
+        let any_text = options.missing_docs_any_text;
+        checks.push(builtin(
+            RULE_MISSING_DOC,
+            move |files| docs::find_missing_docs(files, any_text),
+            |analysis| &mut analysis.missing_docs,
+        ));
+    }
+    if options.find_unreachable {
+        checks.push(builtin(
+            RULE_UNREACHABLE_CODE,
+            unreachable::find_unreachable_code,
+            |analysis| &mut analysis.unreachable_code,
+        ));
+    }
+    if options.find_slice_appends {
+        checks.push(builtin(
+            RULE_SLICE_APPEND,
+            append::find_slice_appends,
+            |analysis| &mut analysis.slice_appends,
+        ));
+    }
+    checks
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::checks::custom::{ParsedFiles, run_checks};
+    use crate::analyze::types::FunctionInfo;
+
+    #[test]
+    fn every_builtin_check_has_a_rule_of_its_own() {
+        let options = AnalyzeOptions {
+            max_complexity: Some(1),
+            max_function_loc: Some(1),
+            max_params: Some(1),
+            max_nesting: Some(1),
+            find_unused: true,
+            find_unused_receivers: true,
+            find_duplicate_tags: true,
+            find_shadowed: true,
+            find_naked_returns: true,
+            find_todos: true,
+            find_clones: true,
+            find_ignored_errors: true,
+            find_magic_numbers: true,
+            find_panics: true,
+            find_mixed_receivers: true,
+            find_unused_fields: true,
+            find_string_concats: true,
+            find_unwrapped_errors: true,
+            find_empty_interfaces: true,
+            find_missing_docs: true,
+            find_unreachable: true,
+            find_slice_appends: true,
+            ..AnalyzeOptions::default()
+        };
+        let names: Vec<&str> = builtin_checks(&options)
+            .iter()
+            .map(|check| check.name())
+            .collect();
+        let rules: Vec<&str> = RULES.iter().map(|(rule, _)| *rule).collect();
+        assert_eq!(names, rules);
+        assert!(builtin_checks(&AnalyzeOptions::default()).is_empty());
+    }
+
+    #[test]
+    fn results_are_kept_and_reported() {
+        let mut result = AnalysisResult::empty(20);
+        result.functions = vec![
+            FunctionInfo {
+                name: "branchy".into(),
+                line: 3,
+                end_line: 12,
+                complexity: 6,
+                ..Default::default()
+            },
+            FunctionInfo {
+                name: "flat".into(),
+                line: 14,
+                end_line: 15,
+                complexity: 1,
+                ..Default::default()
+            },
+        ];
+        let files = vec![(PathBuf::from("/p/a.go"), result)];
+        let options = AnalyzeOptions {
+            max_complexity: Some(5),
+            ..AnalyzeOptions::default()
+        };
+
+        let mut analysis = AnalysisOutput::default();
+        let findings = run_checks(
+            &builtin_checks(&options),
+            &files,
+            &ParsedFiles::new(),
+            None,
+            &mut analysis,
+        );
+        let names: Vec<&str> = analysis
+            .complexity_violations
+            .iter()
+            .map(|violation| violation.function.name.as_str())
+            .collect();
+        assert_eq!(names, vec!["branchy"]);
+        assert_eq!(
+            findings,
+            vec![Finding::from(&analysis.complexity_violations[0])]
+        );
+    }
+}
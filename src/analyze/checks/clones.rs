@@ -0,0 +1,411 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Duplicated statement sequences, found by comparing statement fingerprints
+//! over sliding windows.
+
+use std::collections::{HashMap, HashSet};
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_CLONES;
+use crate::analyze::languages::LanguageInfo;
+use crate::analyze::types::{AnalysisResult, FunctionInfo, StatementHash};
+
+/// Shortest run of statements reported as duplicated
+pub const DEFAULT_CLONE_MIN_STATEMENTS: usize = 5;
+
+/// Placeholder every identifier is hashed as, so renamed copies match
+const IDENTIFIER_TOKEN: &[u8] = b"$id";
+
+const FNV_OFFSET_BASIS: u64 = 0xcbf2_9ce4_8422_2325;
+const FNV_PRIME: u64 = 0x0100_0000_01b3;
+
+/// One copy of a duplicated statement sequence
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct CloneLocation {
+    pub path: PathBuf,
+    /// Function containing the copy
+    pub function: String,
+    /// 1-based line of the first statement
+    pub start_line: usize,
+    /// 1-based last line of the last statement
+    pub end_line: usize,
+}
+
+/// A statement sequence that appears in more than one place
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct CloneGroup {
+    /// Number of statements in each copy
+    pub statements: usize,
+    /// Copies ordered by path and line
+    pub locations: Vec<CloneLocation>,
+}
+
+/// Fingerprint the statements of every block in a declaration, in source
+/// order. Identifiers are hashed as one placeholder so renamed copies match;
+/// literals keep their text, keywords and operators their kind, and comments
+/// are skipped.
+pub fn statement_blocks(
+    decl: &tree_sitter::Node,
+    source: &str,
+    info: &LanguageInfo,
+) -> Vec<Vec<StatementHash>> {
+    let mut blocks = Vec::new();
+    let mut stack = vec![*decl];
+
+    while let Some(node) = stack.pop() {
+        if node.is_named() && info.statement_block_kinds.contains(&node.kind()) {
+            let statements: Vec<StatementHash> = (0..node.child_count() as u32)
+                .filter_map(|i| node.child(i))
+                .filter(|child| child.is_named() && !child.kind().contains("comment"))
+                .map(|statement| StatementHash {
+                    hash: statement_hash(&statement, source),
+                    line: statement.start_position().row + 1,
+                    end_line: statement.end_position().row + 1,
+                })
+                .collect();
+            if !statements.is_empty() {
+                blocks.push(statements);
+            }
+        }
+        stack.extend(
+            (0..node.child_count() as u32)
+                .rev()
+                .filter_map(|i| node.child(i)),
+        );
+    }
+
+    blocks
+}
+
+/// FNV-1a hash of the normalized tokens of a statement; stable across runs
+/// so fingerprints can be cached on disk
+fn statement_hash(statement: &tree_sitter::Node, source: &str) -> u64 {
+    let mut hash = FNV_OFFSET_BASIS;
+    let mut feed = |bytes: &[u8]| {
+        // A separator keeps `ab` `c` and `a` `bc` apart
+        for &byte in bytes.iter().chain(&[0xff]) {
+            hash ^= u64::from(byte);
+            hash = hash.wrapping_mul(FNV_PRIME);
+        }
+    };
+
+    let mut stack = vec![*statement];
+    while let Some(node) = stack.pop() {
+        let kind = node.kind();
+        if kind.contains("comment") {
+            continue;
+        }
+        if kind.ends_with("identifier") || kind == "constant" {
+            feed(IDENTIFIER_TOKEN);
+            continue;
+        }
+        // String literals are compared whole: some grammars keep their
+        // content out of the child nodes
+        if kind.contains("string") || node.child_count() == 0 {
+            feed(kind.as_bytes());
+            if node.is_named() {
+                feed(source.get(node.byte_range()).unwrap_or_default().as_bytes());
+            }
+            continue;
+        }
+        stack.extend(
+            (0..node.child_count() as u32)
+                .rev()
+                .filter_map(|i| node.child(i)),
+        );
+    }
+
+    hash
+}
+
+/// A block of statements and the function it belongs to
+struct Block<'a> {
+    path: &'a PathBuf,
+    function: &'a FunctionInfo,
+    statements: &'a [StatementHash],
+}
+
+/// Find statement sequences of at least `min_statements` statements that
+/// appear more than once, within a function or across functions. Each group
+/// covers the longest run its copies share, so a run shared by two functions
+/// is reported alongside a shorter one shared by three; copies never overlap. Functions
+/// under an `analyzer:ignore clones` comment are skipped.
+pub fn find_clones(
+    results: &[(PathBuf, AnalysisResult)],
+    min_statements: usize,
+) -> Vec<CloneGroup> {
+    let min_statements = min_statements.max(1);
+    let blocks: Vec<Block> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.is_ignored(CHECK_CLONES))
+                .flat_map(move |function| {
+                    function
+                        .statement_blocks
+                        .iter()
+                        .filter(move |statements| statements.len() >= min_statements)
+                        .map(move |statements| Block {
+                            path,
+                            function,
+                            statements,
+                        })
+                })
+        })
+        .collect();
+
+    // Window fingerprints to the (block, first statement) of each occurrence
+    let mut windows: HashMap<Vec<u64>, Vec<(usize, usize)>> = HashMap::new();
+    for (index, block) in blocks.iter().enumerate() {
+        for start in 0..=block.statements.len() - min_statements {
+            let key = block.statements[start..start + min_statements]
+                .iter()
+                .map(|statement| statement.hash)
+                .collect();
+            windows.entry(key).or_default().push((index, start));
+        }
+    }
+
+    let hash_at = |(index, position): (usize, usize)| {
+        blocks[index]
+            .statements
+            .get(position)
+            .map(|statement| statement.hash)
+    };
+
+    // Windows inside a longer shared run all grow into the same group
+    let mut seen: HashSet<(Vec<(usize, usize)>, usize)> = HashSet::new();
+    let mut groups = Vec::new();
+    for occurrences in windows.into_values() {
+        let mut occurrences = without_overlaps(occurrences, min_statements);
+        if occurrences.len() < 2 {
+            continue;
+        }
+
+        let mut length = min_statements;
+        let grows = |occurrences: &[(usize, usize)], length: usize| {
+            without_overlaps(occurrences.to_vec(), length + 1).len() == occurrences.len()
+        };
+        while occurrences.iter().all(|&(_, start)| start > 0)
+            && all_equal(
+                occurrences
+                    .iter()
+                    .map(|&(index, start)| hash_at((index, start - 1))),
+            )
+            && grows(&occurrences, length)
+        {
+            for occurrence in &mut occurrences {
+                occurrence.1 -= 1;
+            }
+            length += 1;
+        }
+        while all_equal(
+            occurrences
+                .iter()
+                .map(|&(index, start)| hash_at((index, start + length))),
+        ) && grows(&occurrences, length)
+        {
+            length += 1;
+        }
+        if !seen.insert((occurrences.clone(), length)) {
+            continue;
+        }
+
+        let mut locations: Vec<CloneLocation> = occurrences
+            .iter()
+            .map(|&(index, start)| {
+                let block = &blocks[index];
+                CloneLocation {
+                    path: block.path.clone(),
+                    function: block.function.name.clone(),
+                    start_line: block.statements[start].line,
+                    end_line: block.statements[start + length - 1].end_line,
+                }
+            })
+            .collect();
+        locations.sort_by(|a, b| {
+            a.path
+                .cmp(&b.path)
+                .then_with(|| a.start_line.cmp(&b.start_line))
+        });
+        groups.push(CloneGroup {
+            statements: length,
+            locations,
+        });
+    }
+
+    groups.sort_by(|a, b| {
+        let first = |group: &CloneGroup| {
+            (
+                group.locations[0].path.clone(),
+                group.locations[0].start_line,
+            )
+        };
+        first(a)
+            .cmp(&first(b))
+            .then_with(|| b.statements.cmp(&a.statements))
+    });
+    groups
+}
+
+/// Whether every hash is present and the same
+fn all_equal(mut hashes: impl Iterator<Item = Option<u64>>) -> bool {
+    let first = hashes.next().flatten();
+    first.is_some() && hashes.all(|hash| hash == first)
+}
+
+/// Drop occurrences that overlap an earlier one in the same block when each
+/// spans `length` statements
+fn without_overlaps(mut occurrences: Vec<(usize, usize)>, length: usize) -> Vec<(usize, usize)> {
+    occurrences.sort_unstable();
+    let mut kept: Vec<(usize, usize)> = Vec::with_capacity(occurrences.len());
+    for occurrence in occurrences {
+        match kept.last() {
+            Some(&(index, start)) if index == occurrence.0 && occurrence.1 < start + length => {}
+            _ => kept.push(occurrence),
+        }
+    }
+    kept
+}
+
+/// Format duplicate groups as a `DUPLICATES:` section with paths relative to `base`
+pub fn format_clones(base: &Path, groups: &[CloneGroup]) -> String {
+    if groups.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nDUPLICATES:\n");
+    for group in groups {
+        output.push_str(&format!(
+            "  {} statements in {} places:\n",
+            group.statements,
+            group.locations.len()
+        ));
+        for location in &group.locations {
+            let path = location.path.strip_prefix(base).unwrap_or(&location.path);
+            output.push_str(&format!(
+                "    {}:{}-{} {}\n",
+                path.display(),
+                location.start_line,
+                location.end_line,
+                location.function
+            ));
+        }
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+
+    fn function(name: &str, line: usize, hashes: &[u64]) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            statement_blocks: vec![
+                hashes
+                    .iter()
+                    .enumerate()
+                    .map(|(i, &hash)| StatementHash {
+                        hash,
+                        line: line + 1 + i,
+                        end_line: line + 1 + i,
+                    })
+                    .collect(),
+            ],
+            ..Default::default()
+        }
+    }
+
+    fn results(functions: Vec<FunctionInfo>) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(100);
+        result.functions = functions;
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    #[test]
+    fn shared_runs_are_reported_once_at_full_length() {
+        let results = results(vec![
+            function("parse", 1, &[9, 1, 2, 3, 4, 5, 8]),
+            function("parseAll", 20, &[7, 1, 2, 3, 4, 5, 6]),
+            function("short", 40, &[1, 2]),
+        ]);
+        let groups = find_clones(&results, 3);
+        assert_eq!(groups.len(), 1, "{groups:?}");
+        assert_eq!(groups[0].statements, 5);
+        let spans: Vec<(&str, usize, usize)> = groups[0]
+            .locations
+            .iter()
+            .map(|l| (l.function.as_str(), l.start_line, l.end_line))
+            .collect();
+        assert_eq!(spans, vec![("parse", 3, 7), ("parseAll", 22, 26)]);
+    }
+
+    #[test]
+    fn short_and_ignored_sequences_are_not_reported() {
+        let mut ignored = function("generated", 20, &[1, 2, 3]);
+        ignored.ignored_checks = vec!["clones".into()];
+        let results = results(vec![function("parse", 1, &[1, 2, 3]), ignored]);
+        assert!(find_clones(&results, 3).is_empty());
+
+        let results = self::results(vec![
+            function("parse", 1, &[1, 2, 3]),
+            function("other", 20, &[1, 2, 3]),
+        ]);
+        assert!(find_clones(&results, 4).is_empty());
+    }
+
+    #[test]
+    fn longer_run_shared_by_fewer_copies_is_its_own_group() {
+        let results = results(vec![
+            function("sum", 1, &[1, 2, 3, 4, 5]),
+            function("double", 20, &[1, 2, 3, 4, 5]),
+            function("scale", 40, &[1, 2, 3, 4, 6]),
+        ]);
+        let groups: Vec<(usize, usize)> = find_clones(&results, 4)
+            .iter()
+            .map(|g| (g.statements, g.locations.len()))
+            .collect();
+        assert_eq!(groups, vec![(5, 2), (4, 3)]);
+    }
+
+    #[test]
+    fn repeated_statements_do_not_match_themselves() {
+        let results = results(vec![function("fill", 1, &[1, 1, 1])]);
+        assert!(find_clones(&results, 2).is_empty());
+    }
+
+    #[test]
+    fn format_lists_each_copy() {
+        let results = results(vec![
+            function("parse", 1, &[1, 2, 3]),
+            function("parseAll", 20, &[1, 2, 3]),
+        ]);
+        assert_eq!(
+            format_clones(Path::new("/p"), &find_clones(&results, 3)),
+            "\nDUPLICATES:\n  3 statements in 2 places:\n    a.go:2-4 parse\n    a.go:21-23 parseAll\n"
+        );
+        assert!(format_clones(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn renamed_copies_match_but_changed_literals_do_not() {
+        let code = "package main\n\nfunc a(xs []int) int {\n\ttotal := 0\n\tfor _, x := range xs {\n\t\ttotal += x\n\t}\n\treturn total * 2\n}\n\nfunc b(items []int) int {\n\tsum := 0\n\tfor _, item := range items {\n\t\tsum += item\n\t}\n\treturn sum * 2\n}\n\nfunc c(xs []int) int {\n\ttotal := 0\n\tfor _, x := range xs {\n\t\ttotal -= x\n\t}\n\treturn total * 3\n}\n";
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+        let groups = find_clones(&[(PathBuf::from("/p/a.go"), result)], 3);
+        assert_eq!(groups.len(), 1, "{groups:?}");
+        let names: Vec<&str> = groups[0]
+            .locations
+            .iter()
+            .map(|l| l.function.as_str())
+            .collect();
+        assert_eq!(names, vec!["a", "b"]);
+    }
+}
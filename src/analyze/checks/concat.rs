@@ -0,0 +1,198 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_STRING_CONCAT;
+use crate::analyze::types::{AnalysisResult, StringConcat};
+
+/// A string built up by concatenation inside a loop
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct StringConcatInLoop {
+    pub path: PathBuf,
+    /// Function containing the assignment
+    pub function: String,
+    pub concat: StringConcat,
+}
+
+/// Collect the string concatenations in loops found while parsing, ordered
+/// by path and position. Each one copies the string built so far, so a
+/// `strings.Builder` is faster for strings grown over many iterations.
+/// Functions under an `analyzer:ignore string-concat` comment are skipped.
+pub fn find_string_concats(results: &[(PathBuf, AnalysisResult)]) -> Vec<StringConcatInLoop> {
+    let mut concats: Vec<StringConcatInLoop> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.is_ignored(CHECK_STRING_CONCAT))
+                .flat_map(move |f| {
+                    f.string_concats
+                        .iter()
+                        .map(move |concat| StringConcatInLoop {
+                            path: path.clone(),
+                            function: f.name.clone(),
+                            concat: concat.clone(),
+                        })
+                })
+        })
+        .collect();
+
+    concats.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| (a.concat.line, a.concat.column).cmp(&(b.concat.line, b.concat.column)))
+    });
+    concats
+}
+
+/// Format concatenations as a `STRING CONCATENATION IN LOOPS:` section with
+/// paths relative to `base`
+pub fn format_string_concats(base: &Path, concats: &[StringConcatInLoop]) -> String {
+    if concats.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nSTRING CONCATENATION IN LOOPS:\n");
+    for entry in concats {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{}:{} {} in {}; use strings.Builder\n",
+            path.display(),
+            entry.concat.line,
+            entry.concat.column,
+            entry.concat.variable,
+            entry.function
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::FunctionInfo;
+
+    fn concats(code: &str) -> Vec<(String, usize, usize)> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        result
+            .functions
+            .iter()
+            .flat_map(|f| &f.string_concats)
+            .map(|c| (c.variable.clone(), c.line, c.column))
+            .collect()
+    }
+
+    #[test]
+    fn concatenation_in_loops_is_found() {
+        let code = r#"package text
+
+func join(words []string) (out string) {
+	s := ""
+	var t string
+	for _, w := range words {
+		s += w
+		t = t + ", " + w
+		out = w + out
+	}
+	return s + t
+}
+"#;
+        assert_eq!(
+            concats(code),
+            vec![
+                ("s".to_string(), 7, 3),
+                ("t".to_string(), 8, 3),
+                ("out".to_string(), 9, 3)
+            ]
+        );
+    }
+
+    #[test]
+    fn non_strings_and_strings_declared_in_the_loop_are_not_found() {
+        let code = r#"package text
+
+func count(words []string, pad string) int {
+	n := 0
+	label := "total"
+	label += ":"
+	for _, w := range words {
+		n += len(w)
+		line := "- "
+		line += w
+		x := n
+		x = x + 1
+		go func() {
+			msg := ""
+			msg += pad
+		}()
+	}
+	return n
+}
+"#;
+        assert!(concats(code).is_empty(), "{:?}", concats(code));
+    }
+
+    #[test]
+    fn values_and_captures_decide_unknown_types() {
+        let code = r#"package text
+
+func render(items []Item, pad string) {
+	var b = names()
+	for i := range items {
+		b += fmt.Sprintf("%d", i)
+		func() {
+			for range items {
+				pad += "."
+			}
+		}()
+	}
+}
+"#;
+        assert_eq!(
+            concats(code),
+            vec![("b".to_string(), 6, 3), ("pad".to_string(), 9, 5)]
+        );
+    }
+
+    #[test]
+    fn format_suggests_a_builder() {
+        let mut result = AnalysisResult::empty(20);
+        result.functions = vec![
+            FunctionInfo {
+                name: "join".into(),
+                line: 3,
+                end_line: 12,
+                string_concats: vec![StringConcat {
+                    variable: "s".into(),
+                    line: 7,
+                    column: 3,
+                }],
+                ..Default::default()
+            },
+            FunctionInfo {
+                name: "quiet".into(),
+                line: 14,
+                end_line: 18,
+                ignored_checks: vec!["string-concat".into()],
+                string_concats: vec![StringConcat {
+                    variable: "t".into(),
+                    line: 16,
+                    column: 3,
+                }],
+                ..Default::default()
+            },
+        ];
+        let found = find_string_concats(&[(PathBuf::from("/p/text.go"), result)]);
+        assert_eq!(
+            format_string_concats(Path::new("/p"), &found),
+            "\nSTRING CONCATENATION IN LOOPS:\n  text.go:7:3 s in join; use strings.Builder\n"
+        );
+        assert!(format_string_concats(Path::new("/p"), &[]).is_empty());
+    }
+}
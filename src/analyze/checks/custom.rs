@@ -0,0 +1,358 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Checks run over the analyzed files, built-in and supplied by programs
+//! embedding the analyzer alike.
+//!
+//! A [`Check`] sees the same per-file results every check works from, the
+//! source and syntax tree each file was analyzed from, and reports
+//! [`Finding`]s under its own rule ID. The built-in checks run first, then
+//! the custom ones in registration order, each with a fresh
+//! [`CheckContext`]: a check never sees the findings of another. With a
+//! diff, checks still see every file and function in full, and a finding is
+//! only kept when its lines overlap a changed line.
+
+use std::collections::{HashMap, HashSet};
+use std::fmt;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+use super::{Finding, RULES, ignore};
+use crate::analyze::AnalysisOutput;
+use crate::analyze::diff::ChangedLines;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// A check registered with [`AnalyzeOptions::register_check`]
+///
+/// [`AnalyzeOptions::register_check`]: crate::AnalyzeOptions::register_check
+pub trait Check: Send + Sync {
+    /// Rule ID of the findings, e.g. `no-sleep`; also the name an
+    /// `analyzer:ignore` comment uses to suppress the check
+    fn name(&self) -> &'static str;
+
+    /// One-line description, listed with the rule in SARIF output
+    fn description(&self) -> &'static str;
+
+    /// Inspect the analyzed files and report problems through `ctx`
+    fn run(&self, ctx: &mut CheckContext);
+}
+
+impl fmt::Debug for dyn Check {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_tuple("Check").field(&self.name()).finish()
+    }
+}
+
+/// Source and syntax tree of one analyzed file
+pub struct ParsedFile {
+    /// Language identifier, e.g. `go`
+    pub language: &'static str,
+    pub source: String,
+    pub tree: tree_sitter::Tree,
+}
+
+/// Parsed files of a run by path, kept from the analysis so checks never
+/// read or parse a file again
+pub(crate) type ParsedFiles = HashMap<PathBuf, ParsedFile>;
+
+/// What a running [`Check`] can see, and where it reports
+pub struct CheckContext<'a> {
+    rule_id: &'static str,
+    files: &'a [(PathBuf, AnalysisResult)],
+    parsed: &'a ParsedFiles,
+    changes: Option<&'a ChangedLines>,
+    analysis: &'a mut AnalysisOutput,
+    findings: Vec<Finding>,
+}
+
+impl<'a> CheckContext<'a> {
+    /// Every analyzed file with its functions, classes, imports and
+    /// comments, as the built-in checks see them
+    pub fn files(&self) -> &'a [(PathBuf, AnalysisResult)] {
+        self.files
+    }
+
+    /// Source and syntax tree `path` was analyzed from, or `None` for a file
+    /// that was not analyzed or has no parser for its language
+    pub fn parsed(&self, path: &Path) -> Option<&'a ParsedFile> {
+        self.parsed.get(path)
+    }
+
+    /// Report a problem on lines `start_line..=end_line` of `path`; with a
+    /// diff, dropped unless one of the lines changed
+    pub fn report(
+        &mut self,
+        path: &Path,
+        start_line: usize,
+        end_line: usize,
+        message: impl Into<String>,
+    ) {
+        self.report_finding(Finding {
+            rule_id: self.rule_id,
+            message: message.into(),
+            path: path.to_path_buf(),
+            start_line,
+            end_line: end_line.max(start_line),
+        });
+    }
+
+    /// Report a problem spanning `function`, unless an `analyzer:ignore`
+    /// comment above it names this check
+    pub fn report_function(
+        &mut self,
+        path: &Path,
+        function: &FunctionInfo,
+        message: impl Into<String>,
+    ) {
+        if !function.is_ignored(self.rule_id) {
+            self.report(path, function.line, function.end_line, message);
+        }
+    }
+
+    /// Report a finding, of a built-in check when it carries a rule ID of
+    /// its own. Every finding passes through here, where a diff drops those
+    /// with no changed line; returns whether it was kept.
+    pub(crate) fn report_finding(&mut self, finding: Finding) -> bool {
+        let changed = self.changes.is_none_or(|changes| {
+            changes.overlaps(&finding.path, finding.start_line, finding.end_line)
+        });
+        if changed {
+            self.findings.push(finding);
+        }
+        changed
+    }
+
+    /// Output a built-in check keeps its typed results in, for the report
+    /// sections of each format
+    pub(crate) fn analysis(&mut self) -> &mut AnalysisOutput {
+        self.analysis
+    }
+}
+
+/// Run `checks` in order over the analyzed files, keeping only findings on
+/// `changes` when given; findings are grouped by check, then ordered by path
+/// and line
+pub(crate) fn run_checks(
+    checks: &[Arc<dyn Check>],
+    files: &[(PathBuf, AnalysisResult)],
+    parsed: &ParsedFiles,
+    changes: Option<&ChangedLines>,
+    analysis: &mut AnalysisOutput,
+) -> Vec<Finding> {
+    let mut findings = Vec::new();
+    for check in checks {
+        let mut ctx = CheckContext {
+            rule_id: check.name(),
+            files,
+            parsed,
+            changes,
+            analysis: &mut *analysis,
+            findings: vec![],
+        };
+        check.run(&mut ctx);
+        ctx.findings.sort_by(|a, b| {
+            a.path
+                .cmp(&b.path)
+                .then_with(|| a.start_line.cmp(&b.start_line))
+        });
+        findings.extend(ctx.findings);
+    }
+    findings
+}
+
+/// Make sure no two of `checks` share a name and none takes the rule ID or
+/// ignore name of a built-in check, so findings and `analyzer:ignore`
+/// comments always tell checks apart
+pub(crate) fn validate_checks(checks: &[Arc<dyn Check>]) -> Result<(), String> {
+    let mut names = HashSet::new();
+    for check in checks {
+        let name = check.name();
+        if RULES.iter().any(|(rule, _)| *rule == name) || ignore::CHECKS.contains(&name) {
+            return Err(format!(
+                "Check name '{}' is taken by a built-in check",
+                name
+            ));
+        }
+        if !names.insert(name) {
+            return Err(format!(
+                "Check name '{}' is registered more than once",
+                name
+            ));
+        }
+    }
+    Ok(())
+}
+
+/// Format custom findings as a `CHECKS:` section with paths relative to `base`
+pub fn format_findings(base: &Path, findings: &[Finding]) -> String {
+    if findings.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nCHECKS:\n");
+    for finding in findings {
+        let path = finding.path.strip_prefix(base).unwrap_or(&finding.path);
+        output.push_str(&format!(
+            "  {}:{} {}: {}\n",
+            path.display(),
+            finding.start_line,
+            finding.rule_id,
+            finding.message
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Reports every function longer than one line, and the first file
+    struct LongFunctions;
+
+    impl Check for LongFunctions {
+        fn name(&self) -> &'static str {
+            "multi-line"
+        }
+
+        fn description(&self) -> &'static str {
+            "Function spans several lines"
+        }
+
+        fn run(&self, ctx: &mut CheckContext) {
+            for (path, result) in ctx.files() {
+                for function in &result.functions {
+                    if function.end_line > function.line {
+                        ctx.report_function(path, function, format!("{} is long", function.name));
+                    }
+                }
+            }
+            if let Some((path, _)) = ctx.files().first() {
+                ctx.report(path, 1, 1, "first file");
+            }
+        }
+    }
+
+    fn results() -> Vec<(PathBuf, AnalysisResult)> {
+        let function = |name: &str, line, end_line| FunctionInfo {
+            name: name.into(),
+            line,
+            end_line,
+            ..Default::default()
+        };
+        let mut ignored = function("generated", 20, 30);
+        ignored.ignored_checks = vec!["multi-line".into()];
+        let mut result = AnalysisResult::empty(40);
+        result.functions = vec![function("b", 10, 12), function("a", 3, 3), ignored];
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    fn run(checks: &[Arc<dyn Check>]) -> Vec<Finding> {
+        run_checks(
+            checks,
+            &results(),
+            &ParsedFiles::new(),
+            None,
+            &mut AnalysisOutput::default(),
+        )
+    }
+
+    #[test]
+    fn findings_carry_the_check_name_in_line_order() {
+        let findings = run(&[Arc::new(LongFunctions)]);
+        let lines: Vec<(&str, usize, usize, &str)> = findings
+            .iter()
+            .map(|f| (f.rule_id, f.start_line, f.end_line, f.message.as_str()))
+            .collect();
+        assert_eq!(
+            lines,
+            vec![
+                ("multi-line", 1, 1, "first file"),
+                ("multi-line", 10, 12, "b is long"),
+            ]
+        );
+    }
+
+    #[test]
+    fn findings_off_the_changed_lines_are_dropped() {
+        let mut changes = ChangedLines::new();
+        changes.add("/p/a.go", 12, 12);
+        let findings = run_checks(
+            &[Arc::new(LongFunctions)],
+            &results(),
+            &ParsedFiles::new(),
+            Some(&changes),
+            &mut AnalysisOutput::default(),
+        );
+        let lines: Vec<(usize, usize)> = findings
+            .iter()
+            .map(|f| (f.start_line, f.end_line))
+            .collect();
+        assert_eq!(lines, vec![(10, 12)]);
+    }
+
+    #[test]
+    fn format_lists_rule_and_message() {
+        let findings = run(&[Arc::new(LongFunctions)]);
+        assert_eq!(
+            format_findings(Path::new("/p"), &findings),
+            "\nCHECKS:\n  a.go:1 multi-line: first file\n  a.go:10 multi-line: b is long\n"
+        );
+        assert!(format_findings(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn files_not_analyzed_have_no_tree() {
+        let parsed = ParsedFiles::new();
+        let mut analysis = AnalysisOutput::default();
+        let ctx = CheckContext {
+            rule_id: "multi-line",
+            files: &[],
+            parsed: &parsed,
+            changes: None,
+            analysis: &mut analysis,
+            findings: vec![],
+        };
+        assert!(ctx.parsed(Path::new("/p/a.go")).is_none());
+    }
+
+    /// A check going by whatever name it is given
+    struct Named(&'static str);
+
+    impl Check for Named {
+        fn name(&self) -> &'static str {
+            self.0
+        }
+
+        fn description(&self) -> &'static str {
+            ""
+        }
+
+        fn run(&self, _: &mut CheckContext) {}
+    }
+
+    #[test]
+    fn check_names_must_be_unique() {
+        let validate = |names: &[&'static str]| {
+            let checks: Vec<Arc<dyn Check>> = names
+                .iter()
+                .map(|name| Arc::new(Named(name)) as Arc<dyn Check>)
+                .collect();
+            validate_checks(&checks)
+        };
+        assert_eq!(validate(&["no-sleep", "no-println"]), Ok(()));
+        assert_eq!(
+            validate(&["no-sleep", "no-sleep"]),
+            Err("Check name 'no-sleep' is registered more than once".to_string())
+        );
+        for taken in ["unused-function", "unused", "all"] {
+            assert_eq!(
+                validate(&[taken]),
+                Err(format!(
+                    "Check name '{}' is taken by a built-in check",
+                    taken
+                ))
+            );
+        }
+    }
+}
@@ -0,0 +1,439 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_MISSING_DOCS;
+use crate::analyze::api::receiver_type_name;
+use crate::analyze::types::{AnalysisResult, CommentInfo};
+use crate::lang;
+
+/// Kind of declaration a doc comment belongs to
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum DocTarget {
+    Package,
+    Type,
+    Function,
+    Method,
+}
+
+impl DocTarget {
+    pub fn as_str(&self) -> &str {
+        match self {
+            DocTarget::Package => "package",
+            DocTarget::Type => "type",
+            DocTarget::Function => "function",
+            DocTarget::Method => "method",
+        }
+    }
+}
+
+/// An exported Go declaration, or a package, without a proper doc comment
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct MissingDoc {
+    pub path: PathBuf,
+    pub target: DocTarget,
+    /// Declared name; `Type.Method` for methods
+    pub name: String,
+    /// 1-based line of the declaration or `package` clause
+    pub line: usize,
+    /// First line of a comment that does not start with the expected
+    /// words; `None` when there is no comment at all
+    pub comment: Option<String>,
+}
+
+impl MissingDoc {
+    /// Words the doc comment should start with, e.g. `Greet` or `Package greet`
+    pub fn expected_start(&self) -> String {
+        match self.target {
+            DocTarget::Package => format!("Package {}", self.name),
+            DocTarget::Method => self
+                .name
+                .rsplit('.')
+                .next()
+                .unwrap_or(&self.name)
+                .to_string(),
+            DocTarget::Type | DocTarget::Function => self.name.clone(),
+        }
+    }
+
+    /// `type Greeter has no doc comment`, or what it should start with
+    pub fn describe(&self) -> String {
+        match self.target {
+            DocTarget::Package if self.comment.is_none() => {
+                format!("package {} has no package comment", self.name)
+            }
+            _ if self.comment.is_none() => {
+                format!("{} {} has no doc comment", self.target.as_str(), self.name)
+            }
+            _ => format!(
+                "{} {} doc comment should start with \"{}\"",
+                self.target.as_str(),
+                self.name,
+                self.expected_start()
+            ),
+        }
+    }
+}
+
+/// Find exported Go functions, methods and types without a doc comment, and
+/// packages without a package comment, ordered by path and line.
+///
+/// A doc comment is the run of comments ending on the line above the
+/// declaration; directives such as `//go:generate` or `//analyzer:ignore`
+/// in it do not count. Following Go convention it must start with the
+/// declared name, which for types may follow `A`, `An` or `The`, and a
+/// package comment with `Package name`. With `any_text`, any comment will
+/// do. Methods of unexported types and `_test.go` files are skipped, as are
+/// functions under an `analyzer:ignore missing-docs` comment.
+///
+/// A package, the directory of its files, needs one package comment in any
+/// of them; each one present is checked for its form, except in `main`
+/// packages, whose comment describes the command instead.
+pub fn find_missing_docs(results: &[(PathBuf, AnalysisResult)], any_text: bool) -> Vec<MissingDoc> {
+    let go_files = results.iter().filter(|(path, _)| {
+        lang::get_language_identifier(path) == "go"
+            && !path
+                .file_name()
+                .is_some_and(|name| name.to_string_lossy().ends_with("_test.go"))
+    });
+
+    let mut missing = Vec::new();
+    // Package directory to an entry per file, holding its whole package comment
+    let mut packages: BTreeMap<&Path, Vec<MissingDoc>> = BTreeMap::new();
+
+    for (path, result) in go_files {
+        let mut check = |target: DocTarget, name: String, line: usize| {
+            let entry = MissingDoc {
+                path: path.clone(),
+                target,
+                name,
+                line,
+                comment: doc_comment(&result.comments, line),
+            };
+            let expected = entry.expected_start();
+            let proper = entry.comment.as_deref().is_some_and(|comment| {
+                any_text || starts_with_name(comment, &expected, target == DocTarget::Type)
+            });
+            if !proper {
+                missing.push(MissingDoc {
+                    comment: entry.comment.as_deref().map(first_line),
+                    ..entry
+                });
+            }
+        };
+
+        for class in result.classes.iter().filter(|class| class.exported) {
+            check(DocTarget::Type, class.name.clone(), class.line);
+        }
+        for function in result
+            .functions
+            .iter()
+            .filter(|f| f.exported && !f.is_ignored(CHECK_MISSING_DOCS))
+        {
+            match function.receiver.as_deref().map(receiver_type_name) {
+                None => check(DocTarget::Function, function.name.clone(), function.line),
+                Some(type_name) if type_name.starts_with(char::is_uppercase) => check(
+                    DocTarget::Method,
+                    format!("{}.{}", type_name, function.name),
+                    function.line,
+                ),
+                Some(_) => {}
+            }
+        }
+
+        if let Some(package) = &result.package {
+            packages
+                .entry(path.parent().unwrap_or(Path::new("")))
+                .or_default()
+                .push(MissingDoc {
+                    path: path.clone(),
+                    target: DocTarget::Package,
+                    name: package.name.clone(),
+                    line: package.line,
+                    comment: doc_comment(&result.comments, package.line),
+                });
+        }
+    }
+
+    for mut files in packages.into_values() {
+        files.sort_by(|a, b| a.path.cmp(&b.path));
+        if files.iter().all(|file| file.comment.is_none()) {
+            missing.extend(files.into_iter().take(1));
+            continue;
+        }
+        for file in files {
+            let Some(comment) = &file.comment else {
+                continue;
+            };
+            if any_text
+                || file.name == "main"
+                || starts_with_name(comment, &file.expected_start(), false)
+            {
+                continue;
+            }
+            missing.push(MissingDoc {
+                comment: Some(first_line(comment)),
+                ..file
+            });
+        }
+    }
+
+    missing.sort_by(|a, b| a.path.cmp(&b.path).then_with(|| a.line.cmp(&b.line)));
+    missing
+}
+
+/// Text of the comments ending on the line above `line`, one after another
+/// with no blank line between them, without comment markers and directives;
+/// `None` when nothing is left
+fn doc_comment(comments: &[CommentInfo], line: usize) -> Option<String> {
+    let mut block: Vec<&CommentInfo> = Vec::new();
+    let mut next_line = line;
+    for comment in comments.iter().rev() {
+        let end_line = comment.line + comment.text.lines().count().max(1) - 1;
+        if end_line >= next_line {
+            continue;
+        }
+        if end_line + 1 != next_line {
+            break;
+        }
+        block.push(comment);
+        next_line = comment.line;
+    }
+
+    let text: Vec<&str> = block
+        .iter()
+        .rev()
+        .flat_map(|comment| comment.text.lines())
+        .filter_map(comment_line)
+        .collect();
+    let text = text.join("\n").trim().to_string();
+    (!text.is_empty()).then_some(text)
+}
+
+/// A comment line without its markers, or `None` for a directive such as
+/// `//go:embed`, which has no space after the slashes
+fn comment_line(line: &str) -> Option<&str> {
+    let line = line.trim();
+    if let Some(rest) = line.strip_prefix("//") {
+        let word = rest.split_whitespace().next().unwrap_or_default();
+        let directive = !rest.starts_with(char::is_whitespace) && word.contains(':');
+        return (!directive).then(|| rest.trim());
+    }
+    let line = line.strip_prefix("/*").unwrap_or(line);
+    let line = line.strip_suffix("*/").unwrap_or(line);
+    Some(line.trim().trim_start_matches('*').trim())
+}
+
+fn first_line(text: &str) -> String {
+    text.lines().next().unwrap_or_default().to_string()
+}
+
+/// Whether `comment` starts with the words of `expected`, optionally after
+/// an article
+fn starts_with_name(comment: &str, expected: &str, allow_article: bool) -> bool {
+    let starts = |text: &str| {
+        text.strip_prefix(expected).is_some_and(|rest| {
+            rest.is_empty() || rest.starts_with(|c: char| !c.is_alphanumeric() && c != '_')
+        })
+    };
+    starts(comment)
+        || (allow_article
+            && ["A ", "An ", "The "]
+                .iter()
+                .any(|article| comment.strip_prefix(article).is_some_and(starts)))
+}
+
+/// Format missing doc comments as a `MISSING DOCS:` section with paths relative to `base`
+pub fn format_missing_docs(base: &Path, missing: &[MissingDoc]) -> String {
+    if missing.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nMISSING DOCS:\n");
+    for entry in missing {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {}\n",
+            path.display(),
+            entry.line,
+            entry.describe()
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::{ClassInfo, FunctionInfo, PackageClause};
+
+    fn comment(line: usize, text: &str) -> CommentInfo {
+        CommentInfo {
+            line,
+            text: text.into(),
+        }
+    }
+
+    fn function(name: &str, receiver: Option<&str>, line: usize) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            receiver: receiver.map(|r| r.to_string()),
+            line,
+            end_line: line + 2,
+            exported: name.starts_with(char::is_uppercase),
+            ..Default::default()
+        }
+    }
+
+    fn class(name: &str, line: usize) -> ClassInfo {
+        ClassInfo {
+            name: name.into(),
+            line,
+            methods: vec![],
+            fields: vec![],
+            exported: name.starts_with(char::is_uppercase),
+            interface: None,
+        }
+    }
+
+    /// greet.go: a package comment, Greeter documented with an article,
+    /// Greet without a comment, Hello with a misnamed one
+    fn greet() -> AnalysisResult {
+        let mut result = AnalysisResult::empty(30);
+        result.package = Some(PackageClause {
+            name: "greet".into(),
+            line: 2,
+        });
+        result.comments = vec![
+            comment(1, "// Package greet says hello."),
+            comment(4, "// A Greeter greets people."),
+            comment(5, "//go:generate stringer -type Greeter"),
+            comment(14, "// hello returns a greeting."),
+            comment(20, "//analyzer:ignore missing-docs"),
+        ];
+        result.classes = vec![class("Greeter", 6)];
+        result.functions = vec![
+            function("Greet", Some("*Greeter"), 9),
+            function("Hello", None, 15),
+            function("helper", None, 18),
+            function("Generated", None, 21),
+            function("Shout", Some("*inner"), 25),
+        ];
+        result.functions[3].ignored_checks = vec!["missing-docs".into()];
+        result
+    }
+
+    fn described(missing: &[MissingDoc]) -> Vec<String> {
+        missing
+            .iter()
+            .map(|m| format!("{}:{} {}", m.path.display(), m.line, m.describe()))
+            .collect()
+    }
+
+    #[test]
+    fn exported_declarations_need_a_comment_starting_with_their_name() {
+        let results = vec![(PathBuf::from("/p/greet.go"), greet())];
+        assert_eq!(
+            described(&find_missing_docs(&results, false)),
+            vec![
+                "/p/greet.go:9 method Greeter.Greet has no doc comment",
+                "/p/greet.go:15 function Hello doc comment should start with \"Hello\"",
+            ]
+        );
+        assert_eq!(
+            described(&find_missing_docs(&results, true)),
+            vec!["/p/greet.go:9 method Greeter.Greet has no doc comment"]
+        );
+    }
+
+    #[test]
+    fn packages_need_one_package_comment() {
+        let mut other = AnalysisResult::empty(5);
+        other.package = Some(PackageClause {
+            name: "greet".into(),
+            line: 1,
+        });
+        let mut undocumented = other.clone();
+        undocumented.package = Some(PackageClause {
+            name: "store".into(),
+            line: 3,
+        });
+        let mut misnamed = undocumented.clone();
+        misnamed.package = Some(PackageClause {
+            name: "cache".into(),
+            line: 2,
+        });
+        misnamed.comments = vec![comment(1, "// cache keeps recent entries.")];
+        let results = vec![
+            (PathBuf::from("/p/greet.go"), greet()),
+            (PathBuf::from("/p/other.go"), other),
+            (PathBuf::from("/p/store/b.go"), undocumented.clone()),
+            (PathBuf::from("/p/store/a.go"), undocumented),
+            (PathBuf::from("/p/cache/lru.go"), misnamed),
+            (PathBuf::from("/p/store/a_test.go"), greet()),
+        ];
+        let packages: Vec<String> = described(&find_missing_docs(&results, false))
+            .into_iter()
+            .filter(|line| line.contains("package"))
+            .collect();
+        assert_eq!(
+            packages,
+            vec![
+                "/p/cache/lru.go:2 package cache doc comment should start with \"Package cache\"",
+                "/p/store/a.go:3 package store has no package comment",
+            ]
+        );
+    }
+
+    #[test]
+    fn doc_comments_end_right_above_the_declaration() {
+        let comments = vec![
+            comment(1, "// Detached is separated by a blank line."),
+            comment(3, "/* Parse reads\n   a config. */"),
+            comment(7, "// Load reads"),
+            comment(8, "// the config."),
+        ];
+        assert_eq!(doc_comment(&comments, 3), None);
+        assert_eq!(
+            doc_comment(&comments, 5).as_deref(),
+            Some("Parse reads\na config.")
+        );
+        assert_eq!(
+            doc_comment(&comments, 9).as_deref(),
+            Some("Load reads\nthe config.")
+        );
+        assert_eq!(doc_comment(&comments, 11), None);
+        assert!(starts_with_name("Load reads", "Load", false));
+        assert!(!starts_with_name("Loader reads", "Load", false));
+        assert!(starts_with_name("The Config of a run", "Config", true));
+    }
+
+    #[test]
+    fn go_package_clause_is_recorded() {
+        let code = "// Package greet says hello.\npackage greet\n\nfunc Greet() {}\n";
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        assert_eq!(
+            result.package,
+            Some(PackageClause {
+                name: "greet".into(),
+                line: 2,
+            })
+        );
+    }
+
+    #[test]
+    fn format_describes_each_declaration() {
+        let missing = find_missing_docs(&[(PathBuf::from("/p/greet.go"), greet())], false);
+        assert_eq!(
+            format_missing_docs(Path::new("/p"), &missing),
+            "\nMISSING DOCS:\n  greet.go:9 method Greeter.Greet has no doc comment\n  greet.go:15 function Hello doc comment should start with \"Hello\"\n"
+        );
+        assert!(format_missing_docs(Path::new("/p"), &[]).is_empty());
+    }
+}
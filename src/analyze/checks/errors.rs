@@ -0,0 +1,531 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::HashMap;
+use std::hash::Hash;
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_IGNORED_ERRORS;
+use crate::analyze::imports::{GoModule, find_go_module, parse_go_import_specs};
+use crate::analyze::types::{AnalysisResult, DiscardedCall, FunctionInfo, ParamInfo};
+use crate::lang;
+
+/// Standard library functions returning an error as their last result, with
+/// their result count
+const STDLIB_ERROR_RESULTS: &[(&str, usize)] = &[
+    ("io.Copy", 2),
+    ("io.ReadAll", 2),
+    ("json.Marshal", 2),
+    ("json.MarshalIndent", 2),
+    ("json.Unmarshal", 1),
+    ("os.Chdir", 1),
+    ("os.Chmod", 1),
+    ("os.Create", 2),
+    ("os.Mkdir", 1),
+    ("os.MkdirAll", 1),
+    ("os.Open", 2),
+    ("os.OpenFile", 2),
+    ("os.ReadFile", 2),
+    ("os.Remove", 1),
+    ("os.RemoveAll", 1),
+    ("os.Rename", 1),
+    ("os.Setenv", 1),
+    ("os.Unsetenv", 1),
+    ("os.WriteFile", 1),
+    ("strconv.Atoi", 2),
+    ("strconv.ParseBool", 2),
+    ("strconv.ParseFloat", 2),
+    ("strconv.ParseInt", 2),
+    ("strconv.ParseUint", 2),
+];
+
+/// Methods returning only an error wherever the standard library declares
+/// them, assumed for callees not declared in the analyzed files
+const ERROR_ONLY_METHODS: &[&str] = &["Close"];
+
+/// How an error result was lost
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum IgnoredErrorKind {
+    /// The call is a statement of its own, or run with `go`
+    Unchecked,
+    /// The call is deferred
+    Deferred,
+    /// The error is assigned to `_`
+    Blank,
+}
+
+impl IgnoredErrorKind {
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            Self::Unchecked => "unchecked",
+            Self::Deferred => "deferred",
+            Self::Blank => "blank",
+        }
+    }
+}
+
+/// A call site whose error result is never looked at
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct IgnoredError {
+    pub path: PathBuf,
+    /// Function containing the call
+    pub function: String,
+    pub call: DiscardedCall,
+    pub kind: IgnoredErrorKind,
+}
+
+/// Result count and positions of the `error` results of a callee
+type ErrorSlots = (usize, Vec<usize>);
+
+/// Error slots of the callees declared in the analyzed Go files; `None` for
+/// names declared with different results
+#[derive(Debug, Default)]
+struct Declared<'a> {
+    /// Free functions by package directory and name
+    functions: HashMap<(&'a Path, &'a str), Option<ErrorSlots>>,
+    /// Methods and interface methods by name, whatever their type
+    methods: HashMap<&'a str, Option<ErrorSlots>>,
+}
+
+/// Find Go calls that drop an `error` result, ordered by path and position.
+///
+/// There is no type checker: the results of a callee come from the
+/// declarations in the analyzed files and from a short list of standard
+/// library functions. Each directory is one package. An unqualified call
+/// resolves to a function of its own package; `pkg.Name` with `pkg` an
+/// import of the calling file resolves to a function of the imported
+/// package when it is analyzed, and is unknown otherwise; any other
+/// `x.Name` is a method call, matched by name against the methods and
+/// interface methods declared anywhere. Names declared with different
+/// results are skipped, as are calls through function values. Deferred calls
+/// are reported unless `skip_deferred` is set; functions under an
+/// `analyzer:ignore ignored-errors` comment are skipped.
+pub fn find_ignored_errors(
+    results: &[(PathBuf, AnalysisResult)],
+    skip_deferred: bool,
+) -> Vec<IgnoredError> {
+    let go_files: Vec<&(PathBuf, AnalysisResult)> = results
+        .iter()
+        .filter(|(path, _)| lang::get_language_identifier(path) == "go")
+        .collect();
+    let declared = declared_error_slots(&go_files);
+    let mut modules: HashMap<&Path, Option<GoModule>> = HashMap::new();
+    let dirs: Vec<&Path> = go_files.iter().map(|(path, _)| package_dir(path)).collect();
+
+    let mut ignored = Vec::new();
+    for (path, result) in &go_files {
+        let dir = package_dir(path);
+        let module = modules
+            .entry(dir)
+            .or_insert_with(|| find_go_module(dir))
+            .as_ref();
+        let imports = imported_packages(result, module, &dirs);
+
+        let calls = result
+            .functions
+            .iter()
+            .filter(|f| !f.is_ignored(CHECK_IGNORED_ERRORS))
+            .flat_map(|f| f.discarded_calls.iter().map(move |call| (f, call)));
+        for (function, call) in calls {
+            let Some((count, slots)) = error_slots(&call.callee, dir, &imports, &declared) else {
+                continue;
+            };
+            let kind = match &call.blank_results {
+                None if call.deferred => IgnoredErrorKind::Deferred,
+                None => IgnoredErrorKind::Unchecked,
+                Some(blanks)
+                    if call.assigned == count && blanks.iter().any(|i| slots.contains(i)) =>
+                {
+                    IgnoredErrorKind::Blank
+                }
+                Some(_) => continue,
+            };
+            if slots.is_empty() || (skip_deferred && kind == IgnoredErrorKind::Deferred) {
+                continue;
+            }
+            ignored.push(IgnoredError {
+                path: path.clone(),
+                function: function.name.clone(),
+                call: call.clone(),
+                kind,
+            });
+        }
+    }
+
+    ignored.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| (a.call.line, a.call.column).cmp(&(b.call.line, b.call.column)))
+    });
+    ignored
+}
+
+fn package_dir(path: &Path) -> &Path {
+    path.parent().unwrap_or(path)
+}
+
+fn declared_error_slots<'a>(files: &[&'a (PathBuf, AnalysisResult)]) -> Declared<'a> {
+    fn declare<K: Hash + Eq>(
+        map: &mut HashMap<K, Option<ErrorSlots>>,
+        key: K,
+        function: &FunctionInfo,
+    ) {
+        let slots = Some(slots_of(&function.returns));
+        map.entry(key)
+            .and_modify(|existing| {
+                if *existing != slots {
+                    *existing = None;
+                }
+            })
+            .or_insert(slots);
+    }
+
+    let mut declared = Declared::default();
+    for (path, result) in files {
+        let interface_methods = result
+            .classes
+            .iter()
+            .filter_map(|class| class.interface.as_ref())
+            .flat_map(|interface| &interface.methods);
+        for function in &result.functions {
+            match function.receiver {
+                Some(_) => declare(&mut declared.methods, function.name.as_str(), function),
+                None => declare(
+                    &mut declared.functions,
+                    (package_dir(path), function.name.as_str()),
+                    function,
+                ),
+            }
+        }
+        for method in interface_methods {
+            declare(&mut declared.methods, method.name.as_str(), method);
+        }
+    }
+    declared
+}
+
+/// The names a Go file binds its imports to, each with the analyzed package
+/// directory it resolves to, if any. Blank and dot imports bind no name.
+fn imported_packages<'a>(
+    result: &AnalysisResult,
+    module: Option<&GoModule>,
+    dirs: &[&'a Path],
+) -> HashMap<String, Option<&'a Path>> {
+    let mut imports = HashMap::new();
+    for declaration in &result.imports {
+        for (name, path) in parse_go_import_specs(declaration) {
+            let name = match name {
+                Some(name) if name == "_" || name == "." => continue,
+                Some(name) => name,
+                None => default_package_name(&path).to_string(),
+            };
+            imports.insert(name, resolve_import(&path, module, dirs));
+        }
+    }
+    imports
+}
+
+/// Name a package is imported under by default: the last element of its
+/// path, or the one before a major version suffix such as `/v2`
+fn default_package_name(path: &str) -> &str {
+    let mut elements = path.rsplit('/');
+    let last = elements.next().unwrap_or(path);
+    let is_version =
+        last.len() > 1 && last.starts_with('v') && last[1..].chars().all(|c| c.is_ascii_digit());
+    match elements.next() {
+        Some(previous) if is_version => previous,
+        _ => last,
+    }
+}
+
+/// Analyzed package directory of an import path: under the module when
+/// there is one, otherwise the one directory whose trailing elements match
+/// the most elements of the path
+fn resolve_import<'a>(
+    path: &str,
+    module: Option<&GoModule>,
+    dirs: &[&'a Path],
+) -> Option<&'a Path> {
+    if let Some(module) = module {
+        let relative = if path == module.path {
+            ""
+        } else {
+            path.strip_prefix(&module.path)?.strip_prefix('/')?
+        };
+        let dir = module.dir.join(relative);
+        return dirs.iter().copied().find(|candidate| **candidate == dir);
+    }
+
+    let elements: Vec<&str> = path.split('/').rev().collect();
+    let matched = |dir: &Path| {
+        dir.iter()
+            .rev()
+            .zip(&elements)
+            .take_while(|(component, element)| component.to_str() == Some(**element))
+            .count()
+    };
+    let mut best: Option<(usize, &'a Path)> = None;
+    let mut tied = false;
+    for &dir in dirs {
+        let count = matched(dir);
+        if count == 0 {
+            continue;
+        }
+        match best {
+            Some((most, known)) if count == most && known != dir => tied = true,
+            Some((most, _)) if count <= most => {}
+            _ => {
+                best = Some((count, dir));
+                tied = false;
+            }
+        }
+    }
+    best.filter(|_| !tied).map(|(_, dir)| dir)
+}
+
+fn slots_of(returns: &[ParamInfo]) -> ErrorSlots {
+    let slots = returns
+        .iter()
+        .enumerate()
+        .filter(|(_, result)| result.type_name.as_deref() == Some("error"))
+        .map(|(i, _)| i)
+        .collect();
+    (returns.len(), slots)
+}
+
+/// Results of `callee` as written at a call in the package in `dir`, if they
+/// are known
+fn error_slots(
+    callee: &str,
+    dir: &Path,
+    imports: &HashMap<String, Option<&Path>>,
+    declared: &Declared,
+) -> Option<ErrorSlots> {
+    if let Some(&(_, count)) = STDLIB_ERROR_RESULTS
+        .iter()
+        .find(|(name, _)| *name == callee)
+    {
+        return Some((count, vec![count - 1]));
+    }
+
+    let Some((qualifier, name)) = callee.rsplit_once('.') else {
+        return declared.functions.get(&(dir, callee)).cloned().flatten();
+    };
+    if let Some(package) = imports.get(qualifier) {
+        // A function of another package, known only when that one is analyzed
+        return declared
+            .functions
+            .get(&((*package)?, name))
+            .cloned()
+            .flatten();
+    }
+    match declared.methods.get(name) {
+        Some(slots) => slots.clone(),
+        None if ERROR_ONLY_METHODS.contains(&name) => Some((1, vec![0])),
+        None => None,
+    }
+}
+
+/// Format ignored errors as an `IGNORED ERRORS:` section with paths relative to `base`
+pub fn format_ignored_errors(base: &Path, ignored: &[IgnoredError]) -> String {
+    if ignored.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nIGNORED ERRORS:\n");
+    for entry in ignored {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{}:{} {} ({})\n",
+            path.display(),
+            entry.call.line,
+            entry.call.column,
+            entry.call.call,
+            entry.kind.as_str()
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::FunctionInfo;
+
+    fn discarded(
+        callee: &str,
+        line: usize,
+        blank_results: Option<Vec<usize>>,
+        assigned: usize,
+    ) -> DiscardedCall {
+        DiscardedCall {
+            callee: callee.into(),
+            call: format!("{callee}()"),
+            line,
+            column: 2,
+            deferred: false,
+            blank_results,
+            assigned,
+        }
+    }
+
+    fn declared(name: &str, returns: &[&str]) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            returns: returns.iter().map(|t| ParamInfo::unnamed(t)).collect(),
+            ..Default::default()
+        }
+    }
+
+    fn results(calls: Vec<DiscardedCall>) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut caller = declared("run", &[]);
+        caller.discarded_calls = calls;
+        let mut result = AnalysisResult::empty(50);
+        result.functions = vec![
+            declared("save", &["error"]),
+            declared("load", &["string", "error"]),
+            declared("count", &["int"]),
+            caller,
+        ];
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    fn kinds(ignored: &[IgnoredError]) -> Vec<(usize, IgnoredErrorKind)> {
+        ignored.iter().map(|e| (e.call.line, e.kind)).collect()
+    }
+
+    #[test]
+    fn dropped_and_blank_errors_of_known_callees_are_reported() {
+        let results = results(vec![
+            discarded("save", 1, None, 0),
+            discarded("count", 2, None, 0),
+            discarded("load", 3, Some(vec![1]), 2),
+            discarded("load", 4, Some(vec![0]), 2),
+            discarded("os.Remove", 5, Some(vec![0]), 1),
+            discarded("helper.unknown", 6, None, 0),
+            discarded("f.Close", 7, None, 0),
+        ]);
+        assert_eq!(
+            kinds(&find_ignored_errors(&results, false)),
+            vec![
+                (1, IgnoredErrorKind::Unchecked),
+                (3, IgnoredErrorKind::Blank),
+                (5, IgnoredErrorKind::Blank),
+                (7, IgnoredErrorKind::Unchecked),
+            ]
+        );
+    }
+
+    #[test]
+    fn deferred_calls_can_be_exempted() {
+        let mut close = discarded("f.Close", 9, None, 0);
+        close.deferred = true;
+        let results = results(vec![close]);
+        assert_eq!(
+            kinds(&find_ignored_errors(&results, false)),
+            vec![(9, IgnoredErrorKind::Deferred)]
+        );
+        assert!(find_ignored_errors(&results, true).is_empty());
+    }
+
+    #[test]
+    fn conflicting_declarations_and_ignored_functions_are_skipped() {
+        let mut results = results(vec![discarded("save", 1, None, 0)]);
+        results[0].1.functions.push(declared("save", &[]));
+        assert!(find_ignored_errors(&results, false).is_empty());
+
+        let mut results = self::results(vec![discarded("save", 1, None, 0)]);
+        results[0].1.functions[3].ignored_checks = vec!["ignored-errors".into()];
+        assert!(find_ignored_errors(&results, false).is_empty());
+    }
+
+    #[test]
+    fn calls_resolve_within_their_package() {
+        let mut caller = declared("run", &[]);
+        caller.discarded_calls = vec![
+            discarded("store.Save", 1, None, 0),
+            discarded("cache.Flush", 2, None, 0),
+            discarded("Flush", 3, None, 0),
+            discarded("v2.Save", 4, None, 0),
+        ];
+        let mut main = AnalysisResult::empty(20);
+        main.imports =
+            vec!["import (\n\t\"example.com/app/store\"\n\t\"github.com/x/cache\"\n)".into()];
+        main.functions = vec![caller];
+        let mut store = AnalysisResult::empty(5);
+        store.functions = vec![declared("Save", &["error"])];
+        let mut other = AnalysisResult::empty(5);
+        other.functions = vec![declared("Flush", &["error"])];
+        let results = vec![
+            (PathBuf::from("/p/main.go"), main),
+            (PathBuf::from("/p/store/store.go"), store),
+            (PathBuf::from("/p/other/other.go"), other),
+        ];
+
+        // Only the call into the analyzed, imported package is known
+        assert_eq!(
+            kinds(&find_ignored_errors(&results, false)),
+            vec![(1, IgnoredErrorKind::Unchecked)]
+        );
+    }
+
+    #[test]
+    fn import_paths_name_and_resolve_packages() {
+        assert_eq!(default_package_name("example.com/app/store"), "store");
+        assert_eq!(default_package_name("github.com/org/lib/v2"), "lib");
+        assert_eq!(default_package_name("fmt"), "fmt");
+
+        let dirs = [
+            Path::new("/p/store"),
+            Path::new("/q/store"),
+            Path::new("/p/app/cache"),
+        ];
+        assert_eq!(
+            resolve_import("example.com/app/cache", None, &dirs),
+            Some(Path::new("/p/app/cache"))
+        );
+        assert_eq!(resolve_import("example.com/store", None, &dirs), None);
+        assert_eq!(resolve_import("example.com/queue", None, &dirs), None);
+    }
+
+    #[test]
+    fn format_lists_call_position_and_kind() {
+        let ignored = find_ignored_errors(&results(vec![discarded("save", 4, None, 0)]), false);
+        assert_eq!(
+            format_ignored_errors(Path::new("/p"), &ignored),
+            "\nIGNORED ERRORS:\n  a.go:4:2 save() (unchecked)\n"
+        );
+        assert!(format_ignored_errors(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn go_statements_and_assignments_record_discarded_calls() {
+        let code = "package main\n\nfunc run() {\n\tsave()\n\tdefer f.Close()\n\tv, _ := load()\n\t_, n := 1, count()\n\tvar _ = save()\n\tx := save()\n\tx += count()\n}\n";
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+        let calls: Vec<_> = result.functions[0]
+            .discarded_calls
+            .iter()
+            .map(|c| {
+                (
+                    c.line,
+                    c.callee.as_str(),
+                    c.deferred,
+                    c.blank_results.clone(),
+                    c.assigned,
+                )
+            })
+            .collect();
+        assert_eq!(
+            calls,
+            vec![
+                (4, "save", false, None, 0),
+                (5, "f.Close", true, None, 0),
+                (6, "load", false, Some(vec![1]), 2),
+                (8, "save", false, Some(vec![0]), 1),
+            ]
+        );
+    }
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::{HashMap, HashSet};
+use std::path::{Path, PathBuf};
+
+use crate::analyze::types::{AnalysisResult, FieldAccess, FieldInfo};
+use crate::lang;
+
+/// A Go struct field that no code of its package reads or writes
+#[derive(Debug, Clone)]
+pub struct UnusedField {
+    pub path: PathBuf,
+    /// Struct declaring the field
+    pub type_name: String,
+    pub field: FieldInfo,
+}
+
+/// Find Go struct fields never named by a selector or composite literal key
+/// in their package, ordered by path and line.
+///
+/// A package is the directory of its files. Without type information, a
+/// selector only counts for a particular struct when its operand is the
+/// receiver of a method on it, and a literal key when the literal names the
+/// struct; any other selector, such as `x.name`, counts for every struct of
+/// the package with a `name` field. Same-named fields may therefore hide an
+/// unused one, but a used field is not reported. Exported fields, which other
+/// packages may use, are skipped unless `include_exported` is set, and so
+/// are embedded fields, blank `_` fields and fields with a struct tag, which
+/// encoding packages access through reflection.
+pub fn find_unused_fields(
+    results: &[(PathBuf, AnalysisResult)],
+    include_exported: bool,
+) -> Vec<UnusedField> {
+    let go_files = || {
+        results
+            .iter()
+            .filter(|(path, _)| lang::get_language_identifier(path) == "go")
+    };
+
+    let mut accesses: HashMap<&Path, Vec<&FieldAccess>> = HashMap::new();
+    // Structs embedding another type, whose selectors may reach promoted fields
+    let mut embedding: HashSet<(&Path, &str)> = HashSet::new();
+    for (path, result) in go_files() {
+        accesses
+            .entry(package(path))
+            .or_default()
+            .extend(&result.field_accesses);
+        for class in &result.classes {
+            if class.fields.iter().any(|field| field.embedded) {
+                embedding.insert((package(path), class.name.as_str()));
+            }
+        }
+    }
+
+    let mut unused: Vec<UnusedField> = go_files()
+        .flat_map(|(path, result)| {
+            let package = package(path);
+            let accesses = accesses.get(package).map(Vec::as_slice).unwrap_or(&[]);
+            let embedding = &embedding;
+            result
+                .classes
+                .iter()
+                .filter(|class| class.interface.is_none())
+                .flat_map(move |class| {
+                    class
+                        .fields
+                        .iter()
+                        .filter(move |field| {
+                            !field.embedded
+                                && field.name != "_"
+                                && field.tag.is_none()
+                                && (include_exported || !field.exported)
+                        })
+                        .filter(move |field| {
+                            !accesses.iter().any(|access| {
+                                let matches_type = match access.type_name.as_deref() {
+                                    None => true,
+                                    Some(type_name) => {
+                                        type_name == class.name
+                                            || embedding.contains(&(package, type_name))
+                                    }
+                                };
+                                match access.field.as_deref() {
+                                    Some(name) => matches_type && name == field.name,
+                                    // An unkeyed literal sets exactly its own type's fields
+                                    None => {
+                                        access.type_name.as_deref() == Some(class.name.as_str())
+                                    }
+                                }
+                            })
+                        })
+                        .map(move |field| UnusedField {
+                            path: path.clone(),
+                            type_name: class.name.clone(),
+                            field: field.clone(),
+                        })
+                })
+        })
+        .collect();
+
+    unused.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.field.line.cmp(&b.field.line))
+    });
+    unused
+}
+
+/// Directory of a file, taken to be its Go package
+fn package(path: &Path) -> &Path {
+    path.parent().unwrap_or(Path::new(""))
+}
+
+/// Format unused fields as an `UNUSED FIELDS:` section with paths relative to `base`
+pub fn format_unused_fields(base: &Path, unused: &[UnusedField]) -> String {
+    if unused.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nUNUSED FIELDS:\n");
+    for entry in unused {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {}.{}\n",
+            path.display(),
+            entry.field.line,
+            entry.type_name,
+            entry.field.name
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::ClassInfo;
+
+    fn field(name: &str, line: usize) -> FieldInfo {
+        FieldInfo {
+            name: name.into(),
+            line,
+            type_name: Some("string".into()),
+            exported: name.starts_with(char::is_uppercase),
+            ..Default::default()
+        }
+    }
+
+    fn class(name: &str, line: usize, fields: Vec<FieldInfo>) -> ClassInfo {
+        ClassInfo {
+            name: name.into(),
+            line,
+            methods: vec![],
+            fields,
+            exported: true,
+            interface: None,
+        }
+    }
+
+    fn access(field: Option<&str>, type_name: Option<&str>, line: usize) -> FieldAccess {
+        FieldAccess {
+            field: field.map(|f| f.to_string()),
+            type_name: type_name.map(|t| t.to_string()),
+            line,
+        }
+    }
+
+    fn names(unused: &[UnusedField]) -> Vec<String> {
+        unused
+            .iter()
+            .map(|u| format!("{}.{}", u.type_name, u.field.name))
+            .collect()
+    }
+
+    fn results() -> Vec<(PathBuf, AnalysisResult)> {
+        let mut types = AnalysisResult::empty(20);
+        types.classes = vec![
+            class("Greeter", 3, vec![field("Name", 4), field("count", 5)]),
+            class("Counter", 8, vec![field("count", 9), field("step", 10)]),
+        ];
+        let mut methods = AnalysisResult::empty(20);
+        // g.Name in a Greeter method, c.count in a Counter method
+        methods.field_accesses = vec![
+            access(Some("Name"), Some("Greeter"), 4),
+            access(Some("count"), Some("Counter"), 9),
+        ];
+        vec![
+            (PathBuf::from("/p/pkg/types.go"), types),
+            (PathBuf::from("/p/pkg/methods.go"), methods),
+        ]
+    }
+
+    #[test]
+    fn resolved_accesses_do_not_count_for_same_named_fields() {
+        assert_eq!(
+            names(&find_unused_fields(&results(), true)),
+            vec!["Greeter.count", "Counter.step"]
+        );
+        assert_eq!(
+            names(&find_unused_fields(&results(), false)),
+            vec!["Greeter.count", "Counter.step"]
+        );
+
+        // Another package does not use the fields
+        let mut results = results();
+        results[1].0 = PathBuf::from("/p/other/methods.go");
+        assert_eq!(
+            names(&find_unused_fields(&results, true)),
+            vec![
+                "Greeter.Name",
+                "Greeter.count",
+                "Counter.count",
+                "Counter.step"
+            ]
+        );
+    }
+
+    #[test]
+    fn unresolved_accesses_and_unkeyed_literals_count() {
+        let mut results = results();
+        results[1].1.field_accesses = vec![
+            access(Some("count"), None, 12),
+            access(None, Some("Counter"), 14),
+        ];
+        assert_eq!(
+            names(&find_unused_fields(&results, false)),
+            Vec::<String>::new()
+        );
+        assert_eq!(
+            names(&find_unused_fields(&results, true)),
+            vec!["Greeter.Name"]
+        );
+    }
+
+    #[test]
+    fn embedded_tagged_and_blank_fields_are_skipped() {
+        let mut tagged = field("raw", 6);
+        tagged.tag = Some("json:\"raw\"".into());
+        let embedded = FieldInfo {
+            embedded: true,
+            ..field("sync", 7)
+        };
+        let mut result = AnalysisResult::empty(10);
+        result.classes = vec![class("Config", 5, vec![tagged, embedded, field("_", 8)])];
+        assert!(find_unused_fields(&[(PathBuf::from("/p/config.go"), result)], true).is_empty());
+    }
+
+    #[test]
+    fn go_selectors_and_literal_keys_record_field_accesses() {
+        let code = "package greet\n\ntype Greeter struct {\n\tname  string\n\tcount int\n}\n\nfunc (g *Greeter) Greet() string {\n\treturn g.name\n}\n\nfunc build(o Options) Greeter {\n\t_ = Point{1, 2}\n\treturn Greeter{count: o.count}\n}\n";
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        assert_eq!(
+            result.field_accesses,
+            vec![
+                access(Some("name"), Some("Greeter"), 9),
+                access(None, Some("Point"), 13),
+                access(Some("count"), Some("Greeter"), 14),
+                access(Some("count"), None, 14),
+            ]
+        );
+    }
+
+    #[test]
+    fn format_lists_type_and_field() {
+        assert_eq!(
+            format_unused_fields(Path::new("/p"), &find_unused_fields(&results(), false)),
+            "\nUNUSED FIELDS:\n  pkg/types.go:5 Greeter.count\n  pkg/types.go:10 Counter.step\n"
+        );
+        assert!(format_unused_fields(Path::new("/p"), &[]).is_empty());
+    }
+}
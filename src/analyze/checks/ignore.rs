@@ -0,0 +1,136 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! `analyzer:ignore` comments that suppress findings for one declaration.
+//!
+//! `//analyzer:ignore` directly above a function suppresses every check for
+//! it; `//analyzer:ignore complexity,unused` only the named ones. Anything
+//! after the list is free text, e.g. a reason. When several ignore comments
+//! lead the same declaration their checks add up, and a bare one wins over
+//! any named list.
+
+/// Comment prefix, after the comment marker, that introduces a directive
+pub const IGNORE_DIRECTIVE: &str = "analyzer:ignore";
+
+/// Recorded for a bare directive; suppresses every check
+pub const IGNORE_ALL: &str = "all";
+/// `--max-complexity`
+pub const CHECK_COMPLEXITY: &str = "complexity";
+/// `--max-function-loc`
+pub const CHECK_FUNCTION_LOC: &str = "function-loc";
+/// `--max-params`
+pub const CHECK_PARAMS: &str = "params";
+/// `--max-nesting`
+pub const CHECK_NESTING: &str = "nesting";
+/// `--unused`
+pub const CHECK_UNUSED: &str = "unused";
+/// `--naked-returns`
+pub const CHECK_NAKED_RETURNS: &str = "naked-returns";
+/// `--unused-receivers`
+pub const CHECK_UNUSED_RECEIVERS: &str = "unused-receivers";
+/// `--clones`
+pub const CHECK_CLONES: &str = "clones";
+/// `--ignored-errors`
+pub const CHECK_IGNORED_ERRORS: &str = "ignored-errors";
+/// `--magic-numbers`
+pub const CHECK_MAGIC_NUMBERS: &str = "magic-numbers";
+/// `--panics`
+pub const CHECK_PANICS: &str = "panics";
+/// `--mixed-receivers`
+pub const CHECK_MIXED_RECEIVERS: &str = "mixed-receivers";
+/// `--string-concat`
+pub const CHECK_STRING_CONCAT: &str = "string-concat";
+/// `--unwrapped-errors`
+pub const CHECK_UNWRAPPED_ERRORS: &str = "unwrapped-errors";
+/// `--missing-docs`
+pub const CHECK_MISSING_DOCS: &str = "missing-docs";
+/// `--unreachable`
+pub const CHECK_UNREACHABLE: &str = "unreachable";
+/// `--slice-appends`
+pub const CHECK_SLICE_APPENDS: &str = "slice-appends";
+
+/// Every name a directive gives a built-in check, `all` included; custom
+/// checks may not take one
+pub const CHECKS: &[&str] = &[
+    IGNORE_ALL,
+    CHECK_COMPLEXITY,
+    CHECK_FUNCTION_LOC,
+    CHECK_PARAMS,
+    CHECK_NESTING,
+    CHECK_UNUSED,
+    CHECK_NAKED_RETURNS,
+    CHECK_UNUSED_RECEIVERS,
+    CHECK_CLONES,
+    CHECK_IGNORED_ERRORS,
+    CHECK_MAGIC_NUMBERS,
+    CHECK_PANICS,
+    CHECK_MIXED_RECEIVERS,
+    CHECK_STRING_CONCAT,
+    CHECK_UNWRAPPED_ERRORS,
+    CHECK_MISSING_DOCS,
+    CHECK_UNREACHABLE,
+    CHECK_SLICE_APPENDS,
+];
+
+/// Checks named by an ignore comment, or `None` if the comment is not a
+/// directive. Accepts any of the supported comment markers (`//`, `#`,
+/// `/* */`), with or without a space before the directive.
+pub fn parse_ignore_directive(comment: &str) -> Option<Vec<String>> {
+    let text = comment
+        .trim()
+        .trim_start_matches(['/', '*', '#', '!'])
+        .trim_start();
+    let rest = text.strip_prefix(IGNORE_DIRECTIVE)?;
+    if !rest.is_empty() && !rest.starts_with(char::is_whitespace) {
+        return None;
+    }
+
+    let list = rest
+        .split_whitespace()
+        .next()
+        .filter(|list| !list.starts_with("*/"))
+        .unwrap_or_default();
+    let checks: Vec<String> = list
+        .split(',')
+        .filter(|check| !check.is_empty())
+        .map(|check| check.to_string())
+        .collect();
+
+    if checks.is_empty() {
+        Some(vec![IGNORE_ALL.to_string()])
+    } else {
+        Some(checks)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn bare_directive_ignores_all_checks() {
+        assert_eq!(
+            parse_ignore_directive("//analyzer:ignore"),
+            Some(vec![IGNORE_ALL.to_string()])
+        );
+        assert_eq!(
+            parse_ignore_directive("/* analyzer:ignore */"),
+            Some(vec![IGNORE_ALL.to_string()])
+        );
+    }
+
+    #[test]
+    fn named_checks_stop_at_the_reason() {
+        assert_eq!(
+            parse_ignore_directive("# analyzer:ignore complexity,unused generated by yacc"),
+            Some(vec![CHECK_COMPLEXITY.to_string(), CHECK_UNUSED.to_string()])
+        );
+    }
+
+    #[test]
+    fn other_comments_are_not_directives() {
+        assert_eq!(parse_ignore_directive("// regular comment"), None);
+        assert_eq!(parse_ignore_directive("//analyzer:ignored"), None);
+        assert_eq!(parse_ignore_directive("// see analyzer:ignore"), None);
+    }
+}
@@ -0,0 +1,297 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_MAGIC_NUMBERS;
+use crate::analyze::languages::LanguageInfo;
+use crate::analyze::types::{AnalysisResult, NumberLiteral};
+
+/// Values too common to deserve a name, never reported
+pub const EXEMPT_MAGIC_NUMBERS: &[&str] = &["0", "1", "-1"];
+
+/// A numeric literal that should be a named constant
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct MagicNumber {
+    pub path: PathBuf,
+    /// Function containing the literal
+    pub function: String,
+    pub literal: NumberLiteral,
+}
+
+/// Numeric literals in the body of a declaration node, in source order.
+/// Literals inside constant declarations are left out, a unary minus is
+/// folded into its operand, and literals in closures count as the
+/// declaration's own.
+pub fn number_literals(
+    decl: &tree_sitter::Node,
+    source: &str,
+    info: &LanguageInfo,
+) -> Vec<NumberLiteral> {
+    let mut literals = Vec::new();
+    let mut stack = vec![decl.child_by_field_name("body").unwrap_or(*decl)];
+
+    while let Some(node) = stack.pop() {
+        if info.constant_declaration_kinds.contains(&node.kind())
+            || node.child(0).is_some_and(|first| first.kind() == "const")
+        {
+            continue;
+        }
+        if info.number_literal_kinds.contains(&node.kind()) {
+            if let Some(literal) = number_literal(&node, source, info) {
+                literals.push(literal);
+            }
+            continue;
+        }
+        stack.extend(
+            (0..node.child_count() as u32)
+                .rev()
+                .filter_map(|i| node.child(i)),
+        );
+    }
+
+    literals
+}
+
+fn number_literal(
+    node: &tree_sitter::Node,
+    source: &str,
+    info: &LanguageInfo,
+) -> Option<NumberLiteral> {
+    let text = source.get(node.byte_range())?;
+
+    // `-2` parses as a unary expression whose operand is the literal
+    let negation = node.parent().filter(|parent| {
+        parent.child_count() == 2 && parent.child(0).is_some_and(|op| op.kind() == "-")
+    });
+    let (expression, value) = match negation {
+        Some(parent) => (parent, format!("-{}", text)),
+        None => (*node, text.to_string()),
+    };
+
+    let index = expression.parent().is_some_and(|parent| {
+        info.index_expression_kinds.contains(&parent.kind())
+            && parent
+                .named_child(0)
+                .is_some_and(|indexed| indexed != expression)
+    });
+
+    let start = expression.start_position();
+    Some(NumberLiteral {
+        value,
+        line: start.row + 1,
+        column: start.column + 1,
+        index,
+    })
+}
+
+/// Find numeric literals used directly in function bodies, ordered by path
+/// and position.
+///
+/// `0`, `1` and `-1` are never reported, nor are the values in `allowed`.
+/// Values are compared numerically, so `0x10` matches an allowed `16` and
+/// `2.0` matches `2`. Literals indexing an array or map are skipped when
+/// `ignore_indices` is set; functions under an
+/// `analyzer:ignore magic-numbers` comment are skipped.
+pub fn find_magic_numbers(
+    results: &[(PathBuf, AnalysisResult)],
+    allowed: &[String],
+    ignore_indices: bool,
+) -> Vec<MagicNumber> {
+    let permitted: Vec<&str> = EXEMPT_MAGIC_NUMBERS
+        .iter()
+        .copied()
+        .chain(allowed.iter().map(|value| value.trim()))
+        .collect();
+    let is_permitted = |value: &str| permitted.iter().any(|allowed| same_number(allowed, value));
+
+    let mut magic: Vec<MagicNumber> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.is_ignored(CHECK_MAGIC_NUMBERS))
+                .flat_map(move |f| {
+                    f.number_literals
+                        .iter()
+                        .map(move |literal| (path, f, literal))
+                })
+        })
+        .filter(|(_, _, literal)| !(ignore_indices && literal.index))
+        .filter(|(_, _, literal)| !is_permitted(&literal.value))
+        .map(|(path, function, literal)| MagicNumber {
+            path: path.clone(),
+            function: function.name.clone(),
+            literal: literal.clone(),
+        })
+        .collect();
+
+    magic.sort_by(|a, b| {
+        a.path.cmp(&b.path).then_with(|| {
+            (a.literal.line, a.literal.column).cmp(&(b.literal.line, b.literal.column))
+        })
+    });
+    magic
+}
+
+/// Whether two literals denote the same number; literals that cannot be
+/// read as one are compared as written
+fn same_number(a: &str, b: &str) -> bool {
+    match (numeric_value(a), numeric_value(b)) {
+        (Some(a), Some(b)) => a == b,
+        _ => a == b,
+    }
+}
+
+/// Value of a numeric literal in any of the supported languages: digit
+/// separators, radix prefixes and type suffixes such as `u8` or `L` are
+/// understood
+fn numeric_value(literal: &str) -> Option<f64> {
+    let (negative, digits) = match literal.strip_prefix('-') {
+        Some(rest) => (true, rest),
+        None => (false, literal),
+    };
+    let digits = digits.replace('_', "").to_ascii_lowercase();
+
+    let radix = [("0x", 16), ("0o", 8), ("0b", 2)]
+        .iter()
+        .find_map(|(prefix, radix)| Some((digits.strip_prefix(prefix)?, *radix)));
+    let value = match radix {
+        Some((rest, radix)) => {
+            let end = rest
+                .find(|c: char| !c.is_digit(radix))
+                .unwrap_or(rest.len());
+            u64::from_str_radix(&rest[..end], radix).ok()? as f64
+        }
+        None => match digits.parse::<f64>() {
+            Ok(value) => value,
+            Err(_) => {
+                // `2u8`, `1.5f32`, `10L`
+                let unsuffixed = digits
+                    .trim_end_matches(|c: char| c.is_ascii_digit())
+                    .trim_end_matches(|c: char| c.is_ascii_alphabetic());
+                unsuffixed.parse::<f64>().ok()?
+            }
+        },
+    };
+
+    Some(if negative { -value } else { value })
+}
+
+/// Format magic numbers as a `MAGIC NUMBERS:` section with paths relative to `base`
+pub fn format_magic_numbers(base: &Path, magic: &[MagicNumber]) -> String {
+    if magic.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nMAGIC NUMBERS:\n");
+    for entry in magic {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{}:{} {} in {}\n",
+            path.display(),
+            entry.literal.line,
+            entry.literal.column,
+            entry.literal.value,
+            entry.function
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::FunctionInfo;
+
+    fn literal(value: &str, line: usize, index: bool) -> NumberLiteral {
+        NumberLiteral {
+            value: value.into(),
+            line,
+            column: 5,
+            index,
+        }
+    }
+
+    fn results(literals: Vec<NumberLiteral>) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(20);
+        result.functions = vec![FunctionInfo {
+            name: "scale".into(),
+            number_literals: literals,
+            ..Default::default()
+        }];
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    fn values(magic: &[MagicNumber]) -> Vec<&str> {
+        magic.iter().map(|m| m.literal.value.as_str()).collect()
+    }
+
+    #[test]
+    fn common_and_allowed_values_are_not_reported() {
+        let results = results(vec![
+            literal("0", 1, false),
+            literal("1.0", 2, false),
+            literal("-1", 3, false),
+            literal("42", 4, false),
+            literal("0x10", 5, false),
+            literal("2u8", 6, false),
+            literal("-7", 7, false),
+        ]);
+        assert_eq!(
+            values(&find_magic_numbers(&results, &[], false)),
+            vec!["42", "0x10", "2u8", "-7"]
+        );
+        let allowed = vec!["16".to_string(), " 2".to_string(), "-7".to_string()];
+        assert_eq!(
+            values(&find_magic_numbers(&results, &allowed, false)),
+            vec!["42"]
+        );
+    }
+
+    #[test]
+    fn index_literals_and_ignored_functions_can_be_skipped() {
+        let mut results = results(vec![literal("3", 1, true), literal("8", 2, false)]);
+        assert_eq!(
+            values(&find_magic_numbers(&results, &[], false)),
+            vec!["3", "8"]
+        );
+        assert_eq!(values(&find_magic_numbers(&results, &[], true)), vec!["8"]);
+
+        results[0].1.functions[0].ignored_checks = vec!["magic-numbers".into()];
+        assert!(find_magic_numbers(&results, &[], false).is_empty());
+    }
+
+    #[test]
+    fn format_lists_position_value_and_function() {
+        let magic = find_magic_numbers(&results(vec![literal("42", 4, false)]), &[], false);
+        assert_eq!(
+            format_magic_numbers(Path::new("/p"), &magic),
+            "\nMAGIC NUMBERS:\n  a.go:4:5 42 in scale\n"
+        );
+        assert!(format_magic_numbers(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn go_literals_outside_constants_are_recorded() {
+        let code = "package main\n\nfunc run(xs []int) int {\n\tconst limit = 10\n\tn := xs[3] * -2\n\treturn n + 1.5\n}\n";
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+        let literals: Vec<_> = result.functions[0]
+            .number_literals
+            .iter()
+            .map(|l| (l.value.as_str(), l.line, l.column, l.index))
+            .collect();
+        assert_eq!(
+            literals,
+            vec![
+                ("3", 5, 10, true),
+                ("-2", 5, 15, false),
+                ("1.5", 6, 13, false)
+            ]
+        );
+    }
+}
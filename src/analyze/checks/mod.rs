@@ -0,0 +1,696 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+pub mod any;
+pub mod append;
+pub mod builtin;
+pub mod clones;
+pub mod concat;
+pub mod custom;
+pub mod docs;
+pub mod errors;
+pub mod fields;
+pub mod ignore;
+pub mod magic;
+pub mod naked;
+pub mod panics;
+pub mod receiver;
+pub mod receiver_kinds;
+pub mod shadow;
+pub mod tags;
+pub mod todo;
+pub mod unreachable;
+pub mod unused;
+pub mod wrapping;
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use self::any::EmptyInterfaceType;
+use self::append::SliceAppendInLoop;
+use self::clones::CloneGroup;
+use self::concat::StringConcatInLoop;
+use self::docs::MissingDoc;
+use self::errors::{IgnoredError, IgnoredErrorKind};
+use self::fields::UnusedField;
+use self::magic::MagicNumber;
+use self::naked::NakedReturn;
+use self::panics::PanicCall;
+use self::receiver::UnusedReceiver;
+use self::receiver_kinds::MixedReceivers;
+use self::shadow::ShadowedVariable;
+use self::tags::DuplicateJsonTag;
+use self::todo::TodoComment;
+use self::unreachable::UnreachableStatement;
+use self::unused::UnusedFunction;
+use self::wrapping::UnwrappedError;
+use super::metrics::{ComplexityViolation, LengthViolation, NestingViolation, ParamCountViolation};
+
+/// Rule ID for functions above the configured cyclomatic complexity
+pub const RULE_COMPLEXITY: &str = "cyclomatic-complexity";
+/// Rule ID for functions with more lines of code than the configured maximum
+pub const RULE_FUNCTION_LENGTH: &str = "function-length";
+/// Rule ID for functions declaring too many parameters
+pub const RULE_TOO_MANY_PARAMS: &str = "too-many-params";
+/// Rule ID for functions nesting control flow more deeply than the configured maximum
+pub const RULE_NESTING_DEPTH: &str = "nesting-depth";
+/// Rule ID for unexported functions that are never referenced
+pub const RULE_UNUSED_FUNCTION: &str = "unused-function";
+/// Rule ID for methods that never use their receiver
+pub const RULE_UNUSED_RECEIVER: &str = "unused-receiver";
+/// Rule ID for struct fields that encode to the same JSON key
+pub const RULE_DUPLICATE_JSON_TAG: &str = "duplicate-json-tag";
+/// Rule ID for local variables hiding a declaration of an enclosing scope
+pub const RULE_SHADOWED_VARIABLE: &str = "shadowed-variable";
+/// Rule ID for bare returns in long functions with named results
+pub const RULE_NAKED_RETURN: &str = "naked-return";
+/// Rule ID for comments marking deferred work, such as `TODO`
+pub const RULE_TODO_COMMENT: &str = "todo-comment";
+/// Rule ID for statement sequences copied in several places
+pub const RULE_DUPLICATE_CODE: &str = "duplicate-code";
+/// Rule ID for calls whose `error` result is dropped or assigned to `_`
+pub const RULE_IGNORED_ERROR: &str = "ignored-error";
+/// Rule ID for numeric literals that should be named constants
+pub const RULE_MAGIC_NUMBER: &str = "magic-number";
+/// Rule ID for Go code that panics or exits the process
+pub const RULE_PANIC: &str = "panic";
+/// Rule ID for Go types with methods on both value and pointer receivers
+pub const RULE_MIXED_RECEIVERS: &str = "mixed-receivers";
+/// Rule ID for Go struct fields that their package never reads or writes
+pub const RULE_UNUSED_FIELD: &str = "unused-field";
+/// Rule ID for Go strings built by concatenation inside a loop
+pub const RULE_STRING_CONCAT: &str = "string-concat";
+/// Rule ID for Go errors from a call returned without added context
+pub const RULE_UNWRAPPED_ERROR: &str = "unwrapped-error";
+/// Rule ID for Go `interface{}` and `any` types
+pub const RULE_EMPTY_INTERFACE: &str = "empty-interface";
+/// Rule ID for exported Go declarations and packages without a proper doc comment
+pub const RULE_MISSING_DOC: &str = "missing-doc";
+/// Rule ID for Go statements that can never run
+pub const RULE_UNREACHABLE_CODE: &str = "unreachable-code";
+/// Rule ID for Go slices grown by `append` in a loop without preallocated capacity
+pub const RULE_SLICE_APPEND: &str = "slice-append";
+
+/// Every rule the analyzer can report, with a one-line description
+pub const RULES: &[(&str, &str)] = &[
+    (
+        RULE_COMPLEXITY,
+        "Function cyclomatic complexity exceeds the configured maximum",
+    ),
+    (
+        RULE_FUNCTION_LENGTH,
+        "Function has more lines of code than the configured maximum",
+    ),
+    (
+        RULE_TOO_MANY_PARAMS,
+        "Function declares more parameters than the configured maximum",
+    ),
+    (
+        RULE_NESTING_DEPTH,
+        "Function nests control flow more deeply than the configured maximum",
+    ),
+    (
+        RULE_UNUSED_FUNCTION,
+        "Unexported function is never referenced",
+    ),
+    (
+        RULE_UNUSED_RECEIVER,
+        "Method never uses its receiver and could be a plain function",
+    ),
+    (
+        RULE_DUPLICATE_JSON_TAG,
+        "Struct fields encode to the same JSON key",
+    ),
+    (
+        RULE_SHADOWED_VARIABLE,
+        "Variable shadows a declaration of an enclosing scope",
+    ),
+    (
+        RULE_NAKED_RETURN,
+        "Bare return in a long function with named results",
+    ),
+    (
+        RULE_TODO_COMMENT,
+        "Comment marks deferred work such as TODO or FIXME",
+    ),
+    (
+        RULE_DUPLICATE_CODE,
+        "Statement sequence is duplicated elsewhere",
+    ),
+    (
+        RULE_IGNORED_ERROR,
+        "Error returned by a call is never checked",
+    ),
+    (
+        RULE_MAGIC_NUMBER,
+        "Numeric literal should be a named constant",
+    ),
+    (
+        RULE_PANIC,
+        "Code panics or exits instead of returning an error",
+    ),
+    (
+        RULE_MIXED_RECEIVERS,
+        "Type declares methods on both value and pointer receivers",
+    ),
+    (RULE_UNUSED_FIELD, "Struct field is never read or written"),
+    (
+        RULE_STRING_CONCAT,
+        "String is concatenated in a loop instead of using strings.Builder",
+    ),
+    (
+        RULE_UNWRAPPED_ERROR,
+        "Error from a call is returned without wrapping it in context",
+    ),
+    (
+        RULE_EMPTY_INTERFACE,
+        "Type is interface{} or any, giving up static type checking",
+    ),
+    (
+        RULE_MISSING_DOC,
+        "Exported declaration or package lacks a doc comment starting with its name",
+    ),
+    (
+        RULE_UNREACHABLE_CODE,
+        "Statement follows a return, panic, break or other statement that always leaves its block",
+    ),
+    (
+        RULE_SLICE_APPEND,
+        "Slice is grown by append in a loop instead of being made with the capacity it needs",
+    ),
+];
+
+/// Description of `rule_id` in [`RULES`], or an empty string for a rule
+/// that is not built in
+pub fn rule_description(rule_id: &str) -> &'static str {
+    RULES
+        .iter()
+        .find(|(rule, _)| *rule == rule_id)
+        .map_or("", |(_, description)| description)
+}
+
+/// A single reported problem, independent of the check that produced it
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Finding {
+    pub rule_id: &'static str,
+    pub message: String,
+    pub path: PathBuf,
+    /// 1-based first line of the offending declaration
+    pub start_line: usize,
+    /// 1-based last line of the offending declaration
+    pub end_line: usize,
+}
+
+impl From<&ComplexityViolation> for Finding {
+    fn from(violation: &ComplexityViolation) -> Self {
+        let function = &violation.function;
+        Self {
+            rule_id: RULE_COMPLEXITY,
+            message: format!(
+                "{} has cyclomatic complexity {} (max {})",
+                function.name, function.complexity, violation.max
+            ),
+            path: violation.path.clone(),
+            start_line: function.line,
+            end_line: function.end_line.max(function.line),
+        }
+    }
+}
+
+impl From<&LengthViolation> for Finding {
+    fn from(violation: &LengthViolation) -> Self {
+        let function = &violation.function;
+        Self {
+            rule_id: RULE_FUNCTION_LENGTH,
+            message: format!(
+                "{} has {} lines of code (max {})",
+                function.name, function.lines_of_code, violation.max
+            ),
+            path: violation.path.clone(),
+            start_line: function.line,
+            end_line: function.end_line.max(function.line),
+        }
+    }
+}
+
+impl From<&ParamCountViolation> for Finding {
+    fn from(violation: &ParamCountViolation) -> Self {
+        let function = &violation.function;
+        Self {
+            rule_id: RULE_TOO_MANY_PARAMS,
+            message: format!(
+                "{} has {} parameters (max {})",
+                function.name,
+                function.params.len(),
+                violation.max
+            ),
+            path: violation.path.clone(),
+            start_line: function.line,
+            end_line: function.end_line.max(function.line),
+        }
+    }
+}
+
+impl From<&NestingViolation> for Finding {
+    fn from(violation: &NestingViolation) -> Self {
+        let function = &violation.function;
+        Self {
+            rule_id: RULE_NESTING_DEPTH,
+            message: format!(
+                "{} has nesting depth {} (max {})",
+                function.name, function.max_nesting_depth, violation.max
+            ),
+            path: violation.path.clone(),
+            start_line: function.line,
+            end_line: function.end_line.max(function.line),
+        }
+    }
+}
+
+impl From<&UnusedFunction> for Finding {
+    fn from(unused: &UnusedFunction) -> Self {
+        let function = &unused.function;
+        Self {
+            rule_id: RULE_UNUSED_FUNCTION,
+            message: format!("{} is never referenced", function.name),
+            path: unused.path.clone(),
+            start_line: function.line,
+            end_line: function.end_line.max(function.line),
+        }
+    }
+}
+
+impl From<&UnusedReceiver> for Finding {
+    fn from(unused: &UnusedReceiver) -> Self {
+        let function = &unused.function;
+        Self {
+            rule_id: RULE_UNUSED_RECEIVER,
+            message: format!(
+                "{} never uses its receiver {} ({})",
+                function.name,
+                unused.receiver_name(),
+                unused.receiver_type()
+            ),
+            path: unused.path.clone(),
+            start_line: function.line,
+            end_line: function.end_line.max(function.line),
+        }
+    }
+}
+
+impl From<&DuplicateJsonTag> for Finding {
+    fn from(duplicate: &DuplicateJsonTag) -> Self {
+        Self {
+            rule_id: RULE_DUPLICATE_JSON_TAG,
+            message: format!(
+                "{} fields {} encode to the same JSON key \"{}\"",
+                duplicate.type_name,
+                duplicate.field_names(),
+                duplicate.key
+            ),
+            path: duplicate.path.clone(),
+            start_line: duplicate.line(),
+            end_line: duplicate
+                .fields
+                .last()
+                .map_or(duplicate.line(), |field| field.line),
+        }
+    }
+}
+
+impl From<&ShadowedVariable> for Finding {
+    fn from(shadowed: &ShadowedVariable) -> Self {
+        let shadow = &shadowed.shadow;
+        Self {
+            rule_id: RULE_SHADOWED_VARIABLE,
+            message: format!(
+                "declaration of \"{}\" shadows declaration at line {}",
+                shadow.name, shadow.shadowed_line
+            ),
+            path: shadowed.path.clone(),
+            start_line: shadow.line,
+            end_line: shadow.line,
+        }
+    }
+}
+
+impl From<&NakedReturn> for Finding {
+    fn from(naked: &NakedReturn) -> Self {
+        Self {
+            rule_id: RULE_NAKED_RETURN,
+            message: format!(
+                "naked return in {} ({} lines of code) with named results",
+                naked.function.name, naked.function.lines_of_code
+            ),
+            path: naked.path.clone(),
+            start_line: naked.line,
+            end_line: naked.line,
+        }
+    }
+}
+
+impl From<&TodoComment> for Finding {
+    fn from(todo: &TodoComment) -> Self {
+        Self {
+            rule_id: RULE_TODO_COMMENT,
+            message: if todo.text.is_empty() {
+                todo.marker.clone()
+            } else {
+                format!("{}: {}", todo.marker, todo.text)
+            },
+            path: todo.path.clone(),
+            start_line: todo.line,
+            end_line: todo.line,
+        }
+    }
+}
+
+impl From<&IgnoredError> for Finding {
+    fn from(ignored: &IgnoredError) -> Self {
+        let call = &ignored.call.call;
+        Self {
+            rule_id: RULE_IGNORED_ERROR,
+            message: match ignored.kind {
+                IgnoredErrorKind::Unchecked => format!("error returned by {} is not checked", call),
+                IgnoredErrorKind::Deferred => {
+                    format!("error returned by deferred {} is not checked", call)
+                }
+                IgnoredErrorKind::Blank => format!("error returned by {} is assigned to _", call),
+            },
+            path: ignored.path.clone(),
+            start_line: ignored.call.line,
+            end_line: ignored.call.line,
+        }
+    }
+}
+
+impl From<&MagicNumber> for Finding {
+    fn from(magic: &MagicNumber) -> Self {
+        Self {
+            rule_id: RULE_MAGIC_NUMBER,
+            message: format!(
+                "magic number {} in {}; name it as a constant",
+                magic.literal.value, magic.function
+            ),
+            path: magic.path.clone(),
+            start_line: magic.literal.line,
+            end_line: magic.literal.line,
+        }
+    }
+}
+
+impl From<&PanicCall> for Finding {
+    fn from(panic: &PanicCall) -> Self {
+        Self {
+            rule_id: RULE_PANIC,
+            message: format!(
+                "{} in {} function {}",
+                panic.callee,
+                panic.context.as_str(),
+                panic.function
+            ),
+            path: panic.path.clone(),
+            start_line: panic.line,
+            end_line: panic.line,
+        }
+    }
+}
+
+impl From<&MixedReceivers> for Finding {
+    fn from(mixed: &MixedReceivers) -> Self {
+        Self {
+            rule_id: RULE_MIXED_RECEIVERS,
+            message: format!(
+                "{} has value receivers ({}) and pointer receivers ({})",
+                mixed.type_name,
+                mixed.value_methods.join(", "),
+                mixed.pointer_methods.join(", ")
+            ),
+            path: mixed.path.clone(),
+            start_line: mixed.line,
+            end_line: mixed.line,
+        }
+    }
+}
+
+impl From<&UnusedField> for Finding {
+    fn from(unused: &UnusedField) -> Self {
+        Self {
+            rule_id: RULE_UNUSED_FIELD,
+            message: format!(
+                "field {}.{} is never read or written",
+                unused.type_name, unused.field.name
+            ),
+            path: unused.path.clone(),
+            start_line: unused.field.line,
+            end_line: unused.field.line,
+        }
+    }
+}
+
+impl From<&StringConcatInLoop> for Finding {
+    fn from(entry: &StringConcatInLoop) -> Self {
+        Self {
+            rule_id: RULE_STRING_CONCAT,
+            message: format!(
+                "{} is concatenated in a loop in {}; use strings.Builder",
+                entry.concat.variable, entry.function
+            ),
+            path: entry.path.clone(),
+            start_line: entry.concat.line,
+            end_line: entry.concat.line,
+        }
+    }
+}
+
+impl From<&UnwrappedError> for Finding {
+    fn from(entry: &UnwrappedError) -> Self {
+        let error_return = &entry.error_return;
+        Self {
+            rule_id: RULE_UNWRAPPED_ERROR,
+            message: format!(
+                "{} returns {} from {} (line {}) unwrapped; add context with fmt.Errorf(\"...: %w\", {})",
+                entry.function,
+                error_return.variable,
+                error_return.callee,
+                error_return.call_line,
+                error_return.variable
+            ),
+            path: entry.path.clone(),
+            start_line: error_return.line,
+            end_line: error_return.line,
+        }
+    }
+}
+
+impl From<&EmptyInterfaceType> for Finding {
+    fn from(entry: &EmptyInterfaceType) -> Self {
+        let empty_interface = &entry.empty_interface;
+        let mut message = format!("empty interface type ({})", empty_interface.usage.as_str());
+        if !empty_interface.declaration.is_empty() {
+            message.push_str(&format!(" in {}", empty_interface.declaration));
+        }
+        Self {
+            rule_id: RULE_EMPTY_INTERFACE,
+            message,
+            path: entry.path.clone(),
+            start_line: empty_interface.line,
+            end_line: empty_interface.line,
+        }
+    }
+}
+
+impl From<&MissingDoc> for Finding {
+    fn from(entry: &MissingDoc) -> Self {
+        Self {
+            rule_id: RULE_MISSING_DOC,
+            message: entry.describe(),
+            path: entry.path.clone(),
+            start_line: entry.line,
+            end_line: entry.line,
+        }
+    }
+}
+
+impl From<&UnreachableStatement> for Finding {
+    fn from(entry: &UnreachableStatement) -> Self {
+        let unreachable = &entry.unreachable;
+        Self {
+            rule_id: RULE_UNREACHABLE_CODE,
+            message: format!(
+                "unreachable code in {} after {} (line {})",
+                entry.function, unreachable.after, unreachable.after_line
+            ),
+            path: entry.path.clone(),
+            start_line: unreachable.line,
+            end_line: unreachable.line,
+        }
+    }
+}
+
+impl From<&SliceAppendInLoop> for Finding {
+    fn from(entry: &SliceAppendInLoop) -> Self {
+        Self {
+            rule_id: RULE_SLICE_APPEND,
+            message: entry.describe(),
+            path: entry.path.clone(),
+            start_line: entry.append.line,
+            end_line: entry.append.line,
+        }
+    }
+}
+
+/// One finding per copy of a duplicated sequence, naming the other copies
+pub fn clone_findings(group: &CloneGroup) -> Vec<Finding> {
+    group
+        .locations
+        .iter()
+        .enumerate()
+        .map(|(index, location)| {
+            let others: Vec<String> = group
+                .locations
+                .iter()
+                .enumerate()
+                .filter(|&(other, _)| other != index)
+                .map(|(_, other)| {
+                    let file = other.path.file_name().unwrap_or(other.path.as_os_str());
+                    format!(
+                        "{} ({}:{})",
+                        other.function,
+                        file.to_string_lossy(),
+                        other.start_line
+                    )
+                })
+                .collect();
+            Finding {
+                rule_id: RULE_DUPLICATE_CODE,
+                message: format!(
+                    "{} statements duplicated in {}",
+                    group.statements,
+                    others.join(", ")
+                ),
+                path: location.path.clone(),
+                start_line: location.start_line,
+                end_line: location.end_line,
+            }
+        })
+        .collect()
+}
+
+/// Findings grouped by file in path order, each file's in line order.
+/// Findings on the same line keep their order in `findings`.
+pub fn findings_by_file(findings: &[Finding]) -> Vec<(&Path, Vec<&Finding>)> {
+    let mut files: BTreeMap<&Path, Vec<&Finding>> = BTreeMap::new();
+    for finding in findings {
+        files.entry(&finding.path).or_default().push(finding);
+    }
+    files
+        .into_iter()
+        .map(|(path, mut file_findings)| {
+            file_findings.sort_by_key(|finding| finding.start_line);
+            (path, file_findings)
+        })
+        .collect()
+}
+
+/// Format findings as a `FINDINGS BY FILE:` section with a block per file,
+/// paths relative to `base`
+pub fn format_findings_by_file(base: &Path, findings: &[Finding]) -> String {
+    if findings.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nFINDINGS BY FILE:\n");
+    for (path, file_findings) in findings_by_file(findings) {
+        let path = path.strip_prefix(base).unwrap_or(path);
+        output.push_str(&format!("  {}:\n", path.display()));
+        for finding in file_findings {
+            output.push_str(&format!(
+                "    {} {}: {}\n",
+                finding.start_line, finding.rule_id, finding.message
+            ));
+        }
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::FunctionInfo;
+
+    #[test]
+    fn findings_by_file_order_by_path_then_line() {
+        let finding = |rule_id, path: &str, line| Finding {
+            rule_id,
+            message: format!("at {}", line),
+            path: PathBuf::from(path),
+            start_line: line,
+            end_line: line,
+        };
+        // As `AnalysisOutput::findings` lists them: by check, then path and line
+        let findings = vec![
+            finding(RULE_COMPLEXITY, "/p/b.go", 4),
+            finding(RULE_UNUSED_FUNCTION, "/p/a.go", 9),
+            finding(RULE_UNUSED_FUNCTION, "/p/b.go", 4),
+            finding(RULE_TODO_COMMENT, "/p/a.go", 2),
+        ];
+        assert_eq!(
+            format_findings_by_file(Path::new("/p"), &findings),
+            "\nFINDINGS BY FILE:\n  a.go:\n    2 todo-comment: at 2\n    9 unused-function: at 9\n  b.go:\n    4 cyclomatic-complexity: at 4\n    4 unused-function: at 4\n"
+        );
+        assert!(format_findings_by_file(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn complexity_violation_becomes_finding() {
+        let violation = ComplexityViolation {
+            path: PathBuf::from("/p/a.go"),
+            function: FunctionInfo {
+                name: "branchy".into(),
+                line: 3,
+                end_line: 20,
+                complexity: 12,
+                ..Default::default()
+            },
+            max: 10,
+        };
+        let finding = Finding::from(&violation);
+        assert_eq!(finding.rule_id, RULE_COMPLEXITY);
+        assert_eq!(
+            finding.message,
+            "branchy has cyclomatic complexity 12 (max 10)"
+        );
+        assert_eq!((finding.start_line, finding.end_line), (3, 20));
+    }
+
+    #[test]
+    fn every_rule_is_described() {
+        for rule in [
+            RULE_COMPLEXITY,
+            RULE_FUNCTION_LENGTH,
+            RULE_TOO_MANY_PARAMS,
+            RULE_NESTING_DEPTH,
+            RULE_UNUSED_FUNCTION,
+            RULE_UNUSED_RECEIVER,
+            RULE_DUPLICATE_JSON_TAG,
+            RULE_SHADOWED_VARIABLE,
+            RULE_NAKED_RETURN,
+            RULE_TODO_COMMENT,
+            RULE_DUPLICATE_CODE,
+            RULE_IGNORED_ERROR,
+            RULE_MAGIC_NUMBER,
+            RULE_PANIC,
+            RULE_MIXED_RECEIVERS,
+            RULE_UNUSED_FIELD,
+            RULE_STRING_CONCAT,
+            RULE_UNWRAPPED_ERROR,
+            RULE_EMPTY_INTERFACE,
+            RULE_MISSING_DOC,
+            RULE_UNREACHABLE_CODE,
+            RULE_SLICE_APPEND,
+        ] {
+            assert!(RULES.iter().any(|(id, _)| *id == rule));
+        }
+    }
+}
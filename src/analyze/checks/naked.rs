@@ -0,0 +1,141 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_NAKED_RETURNS;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// Functions of at most this many lines of code may use naked returns, as
+/// with golangci-lint's `nakedret`
+pub const DEFAULT_NAKED_RETURN_MAX_LOC: usize = 30;
+
+/// A bare `return` in a function with named results
+#[derive(Debug, Clone)]
+pub struct NakedReturn {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+    /// 1-based line of the `return` statement
+    pub line: usize,
+}
+
+/// Find bare `return` statements in functions that name their results and
+/// have more than `max_loc` lines of code, ordered by path and line. Short
+/// functions are exempt because a naked return is easy to follow there;
+/// functions with unnamed results cannot have one. Functions under an
+/// `analyzer:ignore naked-returns` comment are skipped.
+pub fn find_naked_returns(
+    results: &[(PathBuf, AnalysisResult)],
+    max_loc: usize,
+) -> Vec<NakedReturn> {
+    let mut naked: Vec<NakedReturn> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(move |f| f.lines_of_code > max_loc && !f.is_ignored(CHECK_NAKED_RETURNS))
+                .flat_map(move |f| {
+                    f.naked_returns.iter().map(move |&line| NakedReturn {
+                        path: path.clone(),
+                        function: f.clone(),
+                        line,
+                    })
+                })
+        })
+        .collect();
+
+    naked.sort_by(|a, b| a.path.cmp(&b.path).then_with(|| a.line.cmp(&b.line)));
+    naked
+}
+
+/// Format naked returns as a `NAKED RETURNS:` section with paths relative to `base`
+pub fn format_naked_returns(base: &Path, naked: &[NakedReturn]) -> String {
+    if naked.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nNAKED RETURNS:\n");
+    for entry in naked {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {} (declared at line {})\n",
+            path.display(),
+            entry.line,
+            entry.function.name,
+            entry.function.line
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::ElementExtractor;
+    use crate::analyze::parser::ParserManager;
+
+    fn function(name: &str, lines_of_code: usize, naked_returns: &[usize]) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line: 1,
+            lines_of_code,
+            naked_returns: naked_returns.to_vec(),
+            ..Default::default()
+        }
+    }
+
+    fn naked_lines(code: &str) -> Vec<(String, Vec<usize>)> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None)
+            .unwrap()
+            .functions
+            .into_iter()
+            .map(|f| (f.name, f.naked_returns))
+            .collect()
+    }
+
+    #[test]
+    fn short_and_ignored_functions_are_exempt() {
+        let mut ignored = function("generated", 50, &[40]);
+        ignored.ignored_checks = vec!["naked-returns".into()];
+        let mut result = AnalysisResult::empty(100);
+        result.functions = vec![
+            function("short", 5, &[4]),
+            function("long", 31, &[12, 30]),
+            ignored,
+        ];
+        let naked = find_naked_returns(&[(PathBuf::from("/p/a.go"), result)], 30);
+        let lines: Vec<(&str, usize)> = naked
+            .iter()
+            .map(|n| (n.function.name.as_str(), n.line))
+            .collect();
+        assert_eq!(lines, vec![("long", 12), ("long", 30)]);
+    }
+
+    #[test]
+    fn format_names_function_and_return_line() {
+        let mut result = AnalysisResult::empty(100);
+        result.functions = vec![function("split", 40, &[12])];
+        let naked = find_naked_returns(&[(PathBuf::from("/p/a.go"), result)], 30);
+        assert_eq!(
+            format_naked_returns(Path::new("/p"), &naked),
+            "\nNAKED RETURNS:\n  a.go:12 split (declared at line 1)\n"
+        );
+        assert!(format_naked_returns(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn go_only_named_results_record_bare_returns() {
+        let code = "package main\n\nfunc split(n int) (a, b int) {\n\tif n < 0 {\n\t\treturn\n\t}\n\tf := func() (x int) {\n\t\treturn\n\t}\n\ta = f()\n\treturn a, n\n}\n\nfunc done() {\n\treturn\n}\n\nfunc pair() (int, error) {\n\treturn 0, nil\n}\n";
+        assert_eq!(
+            naked_lines(code),
+            vec![
+                ("split".to_string(), vec![5]),
+                ("done".to_string(), vec![]),
+                ("pair".to_string(), vec![]),
+            ]
+        );
+    }
+}
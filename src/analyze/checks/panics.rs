@@ -0,0 +1,262 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_PANICS;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+use crate::lang;
+
+/// `log` functions that print and then call `os.Exit(1)`
+const FATAL_FUNCTIONS: &[&str] = &["Fatal", "Fatalf", "Fatalln"];
+
+/// Where a panic is, which decides whether it is likely to be deliberate
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum PanicContext {
+    /// An exported function or method
+    Exported,
+    /// An unexported function or method
+    Unexported,
+    /// An `init` function, where failing fast at startup is the norm
+    Init,
+    /// The `main` function of a command
+    Main,
+    /// Any function of a `_test.go` file
+    Test,
+}
+
+impl PanicContext {
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            Self::Exported => "exported",
+            Self::Unexported => "unexported",
+            Self::Init => "init",
+            Self::Main => "main",
+            Self::Test => "test",
+        }
+    }
+
+    /// Whether a panic here is usually acceptable: in `init`, `main` or a test
+    pub fn is_acceptable(&self) -> bool {
+        matches!(self, Self::Init | Self::Main | Self::Test)
+    }
+}
+
+/// A call to `panic`, or to a `log.Fatal` function, in Go code
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct PanicCall {
+    pub path: PathBuf,
+    /// Function containing the call
+    pub function: String,
+    /// Called function as written, `panic` or e.g. `log.Fatalf`
+    pub callee: String,
+    /// 1-based position of the call
+    pub line: usize,
+    pub column: usize,
+    pub context: PanicContext,
+}
+
+/// Find calls to the `panic` builtin in Go functions, ordered by path and
+/// position. With `include_fatal`, `log.Fatal`, `log.Fatalf` and
+/// `log.Fatalln` are reported as well, since they end the process just as
+/// abruptly. Calls in `init`, `main` and test files are only reported with
+/// `include_acceptable`. Calls outside any function declaration, such as in
+/// a function literal initializing a package variable, are never reported,
+/// and neither are functions under an `analyzer:ignore panics` comment.
+pub fn find_panics(
+    results: &[(PathBuf, AnalysisResult)],
+    include_fatal: bool,
+    include_acceptable: bool,
+) -> Vec<PanicCall> {
+    let mut panics: Vec<PanicCall> = results
+        .iter()
+        .filter(|(path, _)| lang::get_language_identifier(path) == "go")
+        .flat_map(|(path, result)| {
+            result
+                .calls
+                .iter()
+                .filter(|call| !call.is_reference)
+                .filter(move |call| match call.qualifier.as_deref() {
+                    None => call.callee_name == "panic",
+                    Some("log") => {
+                        include_fatal && FATAL_FUNCTIONS.contains(&call.callee_name.as_str())
+                    }
+                    Some(_) => false,
+                })
+                .filter_map(move |call| {
+                    let function = enclosing_function(result, call.line)?;
+                    if function.is_ignored(CHECK_PANICS) {
+                        return None;
+                    }
+                    let (callee, column) = match &call.qualifier {
+                        // The recorded column is that of the selected name
+                        Some(qualifier) => (
+                            format!("{}.{}", qualifier, call.callee_name),
+                            call.column.saturating_sub(qualifier.len() + 1) + 1,
+                        ),
+                        None => (call.callee_name.clone(), call.column + 1),
+                    };
+                    Some(PanicCall {
+                        path: path.clone(),
+                        function: function.name.clone(),
+                        callee,
+                        line: call.line,
+                        column,
+                        context: context(path, function),
+                    })
+                })
+        })
+        .filter(|panic| include_acceptable || !panic.context.is_acceptable())
+        .collect();
+
+    panics.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| (a.line, a.column).cmp(&(b.line, b.column)))
+    });
+    panics
+}
+
+/// Innermost function declaration spanning `line`
+fn enclosing_function(result: &AnalysisResult, line: usize) -> Option<&FunctionInfo> {
+    result
+        .functions
+        .iter()
+        .filter(|f| f.line <= line && line <= f.end_line)
+        .max_by_key(|f| f.line)
+}
+
+fn context(path: &Path, function: &FunctionInfo) -> PanicContext {
+    let is_test = path
+        .file_name()
+        .is_some_and(|name| name.to_string_lossy().ends_with("_test.go"));
+    match function.name.as_str() {
+        _ if is_test => PanicContext::Test,
+        "init" if function.receiver.is_none() => PanicContext::Init,
+        "main" if function.receiver.is_none() => PanicContext::Main,
+        _ if function.exported => PanicContext::Exported,
+        _ => PanicContext::Unexported,
+    }
+}
+
+/// Format panics as a `PANICS:` section with paths relative to `base`
+pub fn format_panics(base: &Path, panics: &[PanicCall]) -> String {
+    if panics.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nPANICS:\n");
+    for entry in panics {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{}:{} {} in {} ({})\n",
+            path.display(),
+            entry.line,
+            entry.column,
+            entry.callee,
+            entry.function,
+            entry.context.as_str()
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::CallInfo;
+
+    fn function(name: &str, line: usize, end_line: usize, exported: bool) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            end_line,
+            exported,
+            ..Default::default()
+        }
+    }
+
+    fn call(qualifier: Option<&str>, callee: &str, line: usize) -> CallInfo {
+        CallInfo {
+            caller_name: None,
+            callee_name: callee.into(),
+            qualifier: qualifier.map(|q| q.to_string()),
+            is_reference: false,
+            line,
+            column: 5,
+            context: String::new(),
+        }
+    }
+
+    fn results(file: &str) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(40);
+        result.functions = vec![
+            function("init", 1, 3, false),
+            function("Parse", 5, 12, true),
+            function("helper", 14, 18, false),
+            function("main", 20, 24, false),
+        ];
+        result.calls = vec![
+            call(None, "panic", 2),
+            call(None, "panic", 7),
+            call(Some("log"), "Fatalf", 16),
+            call(Some("errors"), "New", 17),
+            call(None, "panic", 22),
+            call(None, "panic", 30),
+        ];
+        vec![(PathBuf::from(file), result)]
+    }
+
+    fn contexts(panics: &[PanicCall]) -> Vec<(usize, &str, &str)> {
+        panics
+            .iter()
+            .map(|p| (p.line, p.callee.as_str(), p.context.as_str()))
+            .collect()
+    }
+
+    #[test]
+    fn library_panics_are_reported_by_default() {
+        let results = results("/p/lib.go");
+        assert_eq!(
+            contexts(&find_panics(&results, false, false)),
+            vec![(7, "panic", "exported")]
+        );
+        assert_eq!(
+            contexts(&find_panics(&results, true, false)),
+            vec![(7, "panic", "exported"), (16, "log.Fatalf", "unexported")]
+        );
+    }
+
+    #[test]
+    fn acceptable_panics_are_labelled_when_included() {
+        assert_eq!(
+            contexts(&find_panics(&results("/p/lib.go"), false, true)),
+            vec![
+                (2, "panic", "init"),
+                (7, "panic", "exported"),
+                (22, "panic", "main")
+            ]
+        );
+        assert_eq!(
+            contexts(&find_panics(&results("/p/lib_test.go"), false, true))
+                .iter()
+                .map(|(_, _, context)| *context)
+                .collect::<Vec<_>>(),
+            vec!["test", "test", "test"]
+        );
+        assert!(find_panics(&results("/p/lib_test.go"), false, false).is_empty());
+        assert!(find_panics(&results("/p/lib.py"), true, true).is_empty());
+    }
+
+    #[test]
+    fn format_lists_call_function_and_context() {
+        let mut results = results("/p/lib.go");
+        results[0].1.functions[2].ignored_checks = vec!["panics".into()];
+        let panics = find_panics(&results, true, false);
+        assert_eq!(
+            format_panics(Path::new("/p"), &panics),
+            "\nPANICS:\n  lib.go:7:6 panic in Parse (exported)\n"
+        );
+        assert!(format_panics(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_UNUSED_RECEIVERS;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// A method whose body never refers to its receiver
+#[derive(Debug, Clone)]
+pub struct UnusedReceiver {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+}
+
+impl UnusedReceiver {
+    /// Identifier the receiver is bound to, e.g. `g`
+    pub fn receiver_name(&self) -> &str {
+        self.function.receiver_name.as_deref().unwrap_or_default()
+    }
+
+    /// Receiver type, e.g. `*Greeter`
+    pub fn receiver_type(&self) -> &str {
+        self.function.receiver.as_deref().unwrap_or_default()
+    }
+}
+
+/// Find methods with a named receiver that their body never uses; such
+/// methods could be plain functions. Blank receivers (`_`) opt out
+/// explicitly and unnamed ones cannot be used, so neither is reported, and
+/// neither are methods under an `analyzer:ignore unused-receivers` comment.
+pub fn find_unused_receivers(results: &[(PathBuf, AnalysisResult)]) -> Vec<UnusedReceiver> {
+    let mut unused: Vec<UnusedReceiver> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| {
+                    f.receiver_name
+                        .as_deref()
+                        .is_some_and(|name| name != "_" && !f.receiver_used)
+                        && !f.is_ignored(CHECK_UNUSED_RECEIVERS)
+                })
+                .map(move |f| UnusedReceiver {
+                    path: path.clone(),
+                    function: f.clone(),
+                })
+        })
+        .collect();
+
+    unused.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    unused
+}
+
+/// Format unused receivers as an `UNUSED RECEIVERS:` section with paths relative to `base`
+pub fn format_unused_receivers(base: &Path, unused: &[UnusedReceiver]) -> String {
+    if unused.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nUNUSED RECEIVERS:\n");
+    for entry in unused {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {} ({} {})\n",
+            path.display(),
+            entry.function.line,
+            entry.function.name,
+            entry.receiver_name(),
+            entry.receiver_type()
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn method(name: &str, line: usize, receiver_name: Option<&str>, used: bool) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            receiver: Some("*Greeter".into()),
+            receiver_name: receiver_name.map(|r| r.to_string()),
+            receiver_used: used,
+            ..Default::default()
+        }
+    }
+
+    fn results(functions: Vec<FunctionInfo>) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(10);
+        result.functions = functions;
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    #[test]
+    fn reports_methods_ignoring_their_receiver() {
+        let results = results(vec![
+            method("Greet", 3, Some("g"), false),
+            method("Name", 7, Some("g"), true),
+        ]);
+        let unused = find_unused_receivers(&results);
+        assert_eq!(unused.len(), 1);
+        assert_eq!(unused[0].function.name, "Greet");
+        assert_eq!(unused[0].receiver_name(), "g");
+        assert_eq!(unused[0].receiver_type(), "*Greeter");
+    }
+
+    #[test]
+    fn skips_blank_and_unnamed_receivers() {
+        let results = results(vec![
+            method("Blank", 3, Some("_"), false),
+            method("Unnamed", 5, None, false),
+        ]);
+        assert!(find_unused_receivers(&results).is_empty());
+    }
+
+    #[test]
+    fn format_lists_position_and_receiver() {
+        let results = results(vec![method("Greet", 3, Some("g"), false)]);
+        let out = format_unused_receivers(Path::new("/p"), &find_unused_receivers(&results));
+        assert_eq!(out, "\nUNUSED RECEIVERS:\n  a.go:3 Greet (g *Greeter)\n");
+    }
+
+    #[test]
+    fn format_empty_is_blank() {
+        assert!(format_unused_receivers(Path::new("/p"), &[]).is_empty());
+    }
+}
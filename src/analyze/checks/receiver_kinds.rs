@@ -0,0 +1,198 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_MIXED_RECEIVERS;
+use crate::analyze::api::receiver_type_name;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+use crate::lang;
+
+/// A Go type with methods on both value and pointer receivers
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct MixedReceivers {
+    /// File declaring the type
+    pub path: PathBuf,
+    pub type_name: String,
+    /// Line of the type declaration
+    pub line: usize,
+    /// Methods on `T`, in file and declaration order
+    pub value_methods: Vec<String>,
+    /// Methods on `*T`, in file and declaration order
+    pub pointer_methods: Vec<String>,
+}
+
+/// Value and pointer methods of one type
+#[derive(Default)]
+struct ReceiverKinds {
+    value_methods: Vec<String>,
+    pointer_methods: Vec<String>,
+}
+
+/// Find Go types declaring methods on both `T` and `*T`, ordered by path and
+/// line of the type declaration.
+///
+/// A package is the directory of its files, so methods are matched with a
+/// type declared in another file of the same directory. Types declared
+/// outside the analyzed files are not reported, and methods under an
+/// `analyzer:ignore mixed-receivers` comment are left out of the comparison.
+pub fn find_mixed_receivers(results: &[(PathBuf, AnalysisResult)]) -> Vec<MixedReceivers> {
+    let go_files = || {
+        results
+            .iter()
+            .filter(|(path, _)| lang::get_language_identifier(path) == "go")
+    };
+
+    // Declaration order, whatever order the functions were sorted in
+    let mut declared: Vec<(&PathBuf, &FunctionInfo, &str)> = go_files()
+        .flat_map(|(path, result)| {
+            result.functions.iter().filter_map(move |function| {
+                let receiver = function.receiver.as_deref()?;
+                Some((path, function, receiver))
+            })
+        })
+        .filter(|(_, function, _)| !function.is_ignored(CHECK_MIXED_RECEIVERS))
+        .collect();
+    declared.sort_by(|a, b| a.0.cmp(b.0).then_with(|| a.1.line.cmp(&b.1.line)));
+
+    // (package directory, type name) -> receiver kinds
+    let mut methods: BTreeMap<(&Path, &str), ReceiverKinds> = BTreeMap::new();
+    for (path, function, receiver) in declared {
+        let package = path.parent().unwrap_or(Path::new(""));
+        let kinds = methods
+            .entry((package, receiver_type_name(receiver)))
+            .or_default();
+        if receiver.trim_start().starts_with('*') {
+            kinds.pointer_methods.push(function.name.clone());
+        } else {
+            kinds.value_methods.push(function.name.clone());
+        }
+    }
+
+    let mut mixed: Vec<MixedReceivers> = go_files()
+        .flat_map(|(path, result)| {
+            let package = path.parent().unwrap_or(Path::new(""));
+            result
+                .classes
+                .iter()
+                .filter(|class| class.interface.is_none())
+                .map(move |class| (path, package, class))
+        })
+        .filter_map(|(path, package, class)| {
+            let kinds = methods.remove(&(package, class.name.as_str()))?;
+            if kinds.value_methods.is_empty() || kinds.pointer_methods.is_empty() {
+                return None;
+            }
+            Some(MixedReceivers {
+                path: path.clone(),
+                type_name: class.name.clone(),
+                line: class.line,
+                value_methods: kinds.value_methods,
+                pointer_methods: kinds.pointer_methods,
+            })
+        })
+        .collect();
+
+    mixed.sort_by(|a, b| a.path.cmp(&b.path).then_with(|| a.line.cmp(&b.line)));
+    mixed
+}
+
+/// Format mixed receivers as a `MIXED RECEIVERS:` section with paths relative to `base`
+pub fn format_mixed_receivers(base: &Path, mixed: &[MixedReceivers]) -> String {
+    if mixed.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nMIXED RECEIVERS:\n");
+    for entry in mixed {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {}: value {}; pointer {}\n",
+            path.display(),
+            entry.line,
+            entry.type_name,
+            entry.value_methods.join(", "),
+            entry.pointer_methods.join(", ")
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::ClassInfo;
+
+    fn method(name: &str, receiver: &str, line: usize) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            receiver: Some(receiver.into()),
+            ..Default::default()
+        }
+    }
+
+    fn class(name: &str, line: usize) -> ClassInfo {
+        ClassInfo {
+            name: name.into(),
+            line,
+            methods: vec![],
+            fields: vec![],
+            exported: true,
+            interface: None,
+        }
+    }
+
+    fn results() -> Vec<(PathBuf, AnalysisResult)> {
+        let mut types = AnalysisResult::empty(20);
+        types.classes = vec![class("Greeter", 3), class("Counter", 8)];
+        types.functions = vec![method("Greet", "*Greeter", 12)];
+        let mut methods = AnalysisResult::empty(30);
+        methods.functions = vec![
+            method("Name", "Greeter", 3),
+            method("SetName", "*Greeter", 7),
+            method("Inc", "*Counter", 11),
+            method("Value", "*Counter", 15),
+        ];
+        vec![
+            (PathBuf::from("/p/pkg/greeter.go"), types),
+            (PathBuf::from("/p/pkg/methods.go"), methods),
+        ]
+    }
+
+    #[test]
+    fn methods_across_files_of_a_package_are_grouped_by_receiver_kind() {
+        let mixed = find_mixed_receivers(&results());
+        assert_eq!(
+            mixed,
+            vec![MixedReceivers {
+                path: PathBuf::from("/p/pkg/greeter.go"),
+                type_name: "Greeter".into(),
+                line: 3,
+                value_methods: vec!["Name".into()],
+                pointer_methods: vec!["Greet".into(), "SetName".into()],
+            }]
+        );
+    }
+
+    #[test]
+    fn other_packages_and_ignored_methods_do_not_count() {
+        let mut results = results();
+        results[1].0 = PathBuf::from("/p/other/methods.go");
+        assert!(find_mixed_receivers(&results).is_empty());
+
+        let mut results = self::results();
+        results[1].1.functions[0].ignored_checks = vec!["mixed-receivers".into()];
+        assert!(find_mixed_receivers(&results).is_empty());
+    }
+
+    #[test]
+    fn format_lists_methods_by_receiver_kind() {
+        assert_eq!(
+            format_mixed_receivers(Path::new("/p"), &find_mixed_receivers(&results())),
+            "\nMIXED RECEIVERS:\n  pkg/greeter.go:3 Greeter: value Name; pointer Greet, SetName\n"
+        );
+        assert!(format_mixed_receivers(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use crate::analyze::types::{AnalysisResult, ShadowInfo};
+
+/// A local variable that hides a declaration of an enclosing scope
+#[derive(Debug, Clone)]
+pub struct ShadowedVariable {
+    pub path: PathBuf,
+    pub shadow: ShadowInfo,
+}
+
+/// Shadowing that is usually deliberate: `err`, and single-letter loop
+/// variables such as `i` being shadowed or shadowing a loop's `i`
+fn is_common(shadow: &ShadowInfo) -> bool {
+    shadow.name == "err" || (shadow.loop_variable && shadow.name.chars().count() == 1)
+}
+
+/// Collect shadowed variables found while parsing, ordered by path and
+/// position. With `skip_common`, `err` and single-letter loop variables are
+/// left out.
+pub fn find_shadowed_variables(
+    results: &[(PathBuf, AnalysisResult)],
+    skip_common: bool,
+) -> Vec<ShadowedVariable> {
+    let mut shadowed: Vec<ShadowedVariable> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .shadowed
+                .iter()
+                .filter(move |shadow| !(skip_common && is_common(shadow)))
+                .map(move |shadow| ShadowedVariable {
+                    path: path.clone(),
+                    shadow: shadow.clone(),
+                })
+        })
+        .collect();
+
+    shadowed.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| (a.shadow.line, a.shadow.column).cmp(&(b.shadow.line, b.shadow.column)))
+    });
+    shadowed
+}
+
+/// Format shadowed variables as a `SHADOWED:` section with paths relative to `base`
+pub fn format_shadowed_variables(base: &Path, shadowed: &[ShadowedVariable]) -> String {
+    if shadowed.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nSHADOWED:\n");
+    for entry in shadowed {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{}:{} {} shadows declaration at {}:{}\n",
+            path.display(),
+            entry.shadow.line,
+            entry.shadow.column,
+            entry.shadow.name,
+            entry.shadow.shadowed_line,
+            entry.shadow.shadowed_column
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::languages::go;
+    use crate::analyze::parser::ParserManager;
+
+    fn shadow(name: &str, line: usize, loop_variable: bool) -> ShadowInfo {
+        ShadowInfo {
+            name: name.into(),
+            line,
+            column: 3,
+            shadowed_line: 2,
+            shadowed_column: 2,
+            loop_variable,
+        }
+    }
+
+    fn results(shadowed: Vec<ShadowInfo>) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(10);
+        result.shadowed = shadowed;
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    fn shadowed_in(code: &str) -> Vec<(String, usize, usize)> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        go::find_shadowed(&tree.root_node(), code)
+            .into_iter()
+            .map(|s| (s.name, s.line, s.shadowed_line))
+            .collect()
+    }
+
+    #[test]
+    fn skip_common_drops_err_and_short_loop_variables() {
+        let results = results(vec![
+            shadow("err", 5, false),
+            shadow("i", 6, true),
+            shadow("x", 7, false),
+            shadow("item", 8, true),
+        ]);
+        assert_eq!(find_shadowed_variables(&results, false).len(), 4);
+
+        let names: Vec<String> = find_shadowed_variables(&results, true)
+            .into_iter()
+            .map(|s| s.shadow.name)
+            .collect();
+        assert_eq!(names, vec!["x", "item"]);
+    }
+
+    #[test]
+    fn format_lists_both_positions() {
+        let out = format_shadowed_variables(
+            Path::new("/p"),
+            &find_shadowed_variables(&results(vec![shadow("err", 5, false)]), false),
+        );
+        assert_eq!(
+            out,
+            "\nSHADOWED:\n  a.go:5:3 err shadows declaration at 2:2\n"
+        );
+        assert!(format_shadowed_variables(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn go_inner_blocks_shadow_outer_declarations() {
+        let code = "package main\n\nfunc f(n int) error {\n\terr := g()\n\tif n > 0 {\n\t\terr := h()\n\t\t_ = err\n\t}\n\tfor i := 0; i < n; i++ {\n\t\tn := i\n\t\t_ = n\n\t}\n\treturn err\n}\n";
+        assert_eq!(
+            shadowed_in(code),
+            vec![("err".to_string(), 6, 4), ("n".to_string(), 10, 3)]
+        );
+    }
+
+    #[test]
+    fn go_same_scope_redeclaration_and_later_declarations_do_not_shadow() {
+        // `:=` reuses `a` in the same scope; the inner `b` comes before the outer one
+        let code = "package main\n\nfunc f() {\n\ta := 1\n\ta, c := 2, 3\n\t{\n\t\tb := a\n\t\t_ = b\n\t}\n\tb := c\n\t_ = b\n}\n";
+        assert!(shadowed_in(code).is_empty());
+    }
+
+    #[test]
+    fn go_idiomatic_copies_are_not_reported() {
+        let code = "package main\n\nfunc f(v any, xs []int) {\n\tfor _, x := range xs {\n\t\tx := x\n\t\tgo func() { _ = x }()\n\t}\n\tswitch v := v.(type) {\n\tcase int:\n\t\t_ = v\n\t}\n}\n";
+        assert!(shadowed_in(code).is_empty());
+    }
+
+    #[test]
+    fn go_closures_see_enclosing_variables() {
+        let code = "package main\n\nfunc f(apply func(x int)) {\n\tx := 1\n\tapply(func(x int) {})\n\t_ = x\n}\n";
+        // `x` of the func-typed parameter is not a declaration of f, the literal's is
+        assert_eq!(shadowed_in(code), vec![("x".to_string(), 5, 4)]);
+    }
+}
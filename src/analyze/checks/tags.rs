@@ -0,0 +1,244 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use crate::analyze::languages::go;
+use crate::analyze::types::{AnalysisResult, FieldInfo};
+use crate::lang;
+
+/// Fields of one struct that encode to the same JSON object key, which makes
+/// `encoding/json` silently drop all of them
+#[derive(Debug, Clone)]
+pub struct DuplicateJsonTag {
+    pub path: PathBuf,
+    /// Name of the struct declaring the fields
+    pub type_name: String,
+    /// The JSON key the fields share
+    pub key: String,
+    /// The conflicting fields in declaration order
+    pub fields: Vec<FieldInfo>,
+}
+
+impl DuplicateJsonTag {
+    /// Line of the first conflicting field
+    pub fn line(&self) -> usize {
+        self.fields.first().map(|f| f.line).unwrap_or_default()
+    }
+
+    /// Names of the conflicting fields, comma separated
+    pub fn field_names(&self) -> String {
+        self.fields
+            .iter()
+            .map(|f| f.name.as_str())
+            .collect::<Vec<_>>()
+            .join(", ")
+    }
+}
+
+/// JSON key a Go struct field encodes to, or `None` if `encoding/json` skips
+/// it: unexported fields, `json:"-"`, and untagged embedded structs whose
+/// fields are promoted instead. Embedded fields are recognized by being
+/// named after their type.
+fn json_key(field: &FieldInfo) -> Option<String> {
+    if !field.exported {
+        return None;
+    }
+
+    match field
+        .tag
+        .as_deref()
+        .and_then(|tag| go::struct_tag_lookup(tag, "json"))
+    {
+        Some(value) if value == "-" => None,
+        Some(value) => {
+            let name = value.split(',').next().unwrap_or_default();
+            Some(if name.is_empty() {
+                field.name.clone()
+            } else {
+                name.to_string()
+            })
+        }
+        None => {
+            let embedded = field.type_name.as_deref().is_some_and(|type_name| {
+                let base = type_name.trim_start_matches('*');
+                base.rsplit('.').next().unwrap_or(base) == field.name
+            });
+            (!embedded).then(|| field.name.clone())
+        }
+    }
+}
+
+/// Find Go structs where two or more fields map to the same JSON key.
+/// Keys come from the `json` struct tag, falling back to the field name.
+pub fn find_duplicate_json_tags(results: &[(PathBuf, AnalysisResult)]) -> Vec<DuplicateJsonTag> {
+    let mut duplicates = Vec::new();
+
+    for (path, result) in results {
+        if lang::get_language_identifier(path) != "go" {
+            continue;
+        }
+
+        for class in &result.classes {
+            let mut by_key: BTreeMap<String, Vec<FieldInfo>> = BTreeMap::new();
+            for field in &class.fields {
+                if let Some(key) = json_key(field) {
+                    by_key.entry(key).or_default().push(field.clone());
+                }
+            }
+
+            duplicates.extend(
+                by_key
+                    .into_iter()
+                    .filter(|(_, fields)| fields.len() > 1)
+                    .map(|(key, fields)| DuplicateJsonTag {
+                        path: path.clone(),
+                        type_name: class.name.clone(),
+                        key,
+                        fields,
+                    }),
+            );
+        }
+    }
+
+    duplicates.sort_by(|a, b| a.path.cmp(&b.path).then_with(|| a.line().cmp(&b.line())));
+    duplicates
+}
+
+/// Format duplicate JSON keys as a `DUPLICATE JSON TAGS:` section with paths relative to `base`
+pub fn format_duplicate_json_tags(base: &Path, duplicates: &[DuplicateJsonTag]) -> String {
+    if duplicates.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nDUPLICATE JSON TAGS:\n");
+    for entry in duplicates {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {} \"{}\": {}\n",
+            path.display(),
+            entry.line(),
+            entry.type_name,
+            entry.key,
+            entry.field_names()
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::ClassInfo;
+
+    fn field(name: &str, line: usize, tag: Option<&str>) -> FieldInfo {
+        FieldInfo {
+            name: name.into(),
+            line,
+            type_name: Some("string".into()),
+            exported: name.starts_with(char::is_uppercase),
+            tag: tag.map(|t| t.to_string()),
+            embedded: false,
+        }
+    }
+
+    fn results(path: &str, fields: Vec<FieldInfo>) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(10);
+        result.classes = vec![ClassInfo {
+            name: "Greeter".into(),
+            line: 3,
+            methods: vec![],
+            fields,
+            exported: true,
+            interface: None,
+        }];
+        vec![(PathBuf::from(path), result)]
+    }
+
+    #[test]
+    fn reports_fields_sharing_a_json_key() {
+        let results = results(
+            "/p/a.go",
+            vec![
+                field("Name", 4, Some(r#"json:"name""#)),
+                field("Alias", 5, Some(r#"db:"alias" json:"name,omitempty""#)),
+                field("Title", 6, Some(r#"json:"title""#)),
+            ],
+        );
+        let duplicates = find_duplicate_json_tags(&results);
+        assert_eq!(duplicates.len(), 1);
+        assert_eq!(duplicates[0].key, "name");
+        assert_eq!(duplicates[0].field_names(), "Name, Alias");
+        assert_eq!(duplicates[0].line(), 4);
+    }
+
+    #[test]
+    fn untagged_fields_use_their_name() {
+        let results = results(
+            "/p/a.go",
+            vec![
+                field("Name", 4, None),
+                field("Other", 5, Some(r#"json:"Name""#)),
+            ],
+        );
+        assert_eq!(find_duplicate_json_tags(&results)[0].key, "Name");
+    }
+
+    #[test]
+    fn skips_dash_unexported_and_embedded_fields() {
+        let mut embedded = field("Base", 7, None);
+        embedded.type_name = Some("*pkg.Base".into());
+        let results = results(
+            "/p/a.go",
+            vec![
+                field("Name", 4, Some(r#"json:"name""#)),
+                field("Secret", 5, Some(r#"json:"-""#)),
+                field("name", 6, Some(r#"json:"name""#)),
+                embedded,
+                field("Dash", 8, Some(r#"json:"-,""#)),
+                field("Minus", 9, Some(r#"json:"-""#)),
+            ],
+        );
+        assert!(find_duplicate_json_tags(&results).is_empty());
+    }
+
+    #[test]
+    fn ignores_non_go_files() {
+        let results = results(
+            "/p/a.rs",
+            vec![field("Name", 4, None), field("Name", 5, None)],
+        );
+        assert!(find_duplicate_json_tags(&results).is_empty());
+    }
+
+    #[test]
+    fn struct_tag_lookup_follows_reflect_semantics() {
+        let tag = r#"db:"id" json:"a\"b,omitempty"  xml:"x""#;
+        assert_eq!(
+            go::struct_tag_lookup(tag, "json").as_deref(),
+            Some("a\"b,omitempty")
+        );
+        assert_eq!(go::struct_tag_lookup(tag, "xml").as_deref(), Some("x"));
+        assert_eq!(go::struct_tag_lookup(tag, "yaml"), None);
+        // Parsing stops at a malformed pair
+        assert_eq!(go::struct_tag_lookup(r#"db:id json:"a""#, "json"), None);
+    }
+
+    #[test]
+    fn format_lists_key_and_fields() {
+        let results = results(
+            "/p/a.go",
+            vec![
+                field("Name", 4, Some(r#"json:"name""#)),
+                field("Alias", 5, Some(r#"json:"name""#)),
+            ],
+        );
+        let out = format_duplicate_json_tags(Path::new("/p"), &find_duplicate_json_tags(&results));
+        assert_eq!(
+            out,
+            "\nDUPLICATE JSON TAGS:\n  a.go:4 Greeter \"name\": Name, Alias\n"
+        );
+        assert!(format_duplicate_json_tags(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use crate::analyze::types::AnalysisResult;
+
+/// Markers reported when none are configured
+pub const DEFAULT_TODO_MARKERS: &[&str] = &["TODO", "FIXME", "HACK"];
+
+/// A comment line holding a deferred-work marker such as `TODO`
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TodoComment {
+    pub path: PathBuf,
+    /// 1-based line of the marker
+    pub line: usize,
+    /// Marker as configured, e.g. `FIXME` for a `fixme:` comment
+    pub marker: String,
+    /// Text after the marker with separators and comment closers trimmed
+    pub text: String,
+}
+
+/// Find marker comments in the parsed files, ordered by path and line.
+///
+/// Markers match case-insensitively as whole words, so `TODO` matches
+/// `todo:` but not `TODOS`; the earliest marker on a line wins. Only comment
+/// nodes are scanned, so string literals that look like comments are not.
+pub fn find_todo_comments(
+    results: &[(PathBuf, AnalysisResult)],
+    markers: &[String],
+) -> Vec<TodoComment> {
+    let mut todos: Vec<TodoComment> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result.comments.iter().flat_map(move |comment| {
+                comment
+                    .text
+                    .lines()
+                    .enumerate()
+                    .filter_map(move |(offset, line)| {
+                        let (marker, text) = find_marker(line, markers)?;
+                        Some(TodoComment {
+                            path: path.clone(),
+                            line: comment.line + offset,
+                            marker: marker.to_string(),
+                            text,
+                        })
+                    })
+            })
+        })
+        .collect();
+
+    todos.sort_by(|a, b| a.path.cmp(&b.path).then_with(|| a.line.cmp(&b.line)));
+    todos
+}
+
+/// First marker on a comment line and the text following it
+fn find_marker<'a>(line: &str, markers: &'a [String]) -> Option<(&'a str, String)> {
+    let lowered = line.to_ascii_lowercase();
+    let is_word = |c: char| c.is_alphanumeric() || c == '_';
+
+    let (start, marker) = markers
+        .iter()
+        .filter(|marker| !marker.is_empty())
+        .filter_map(|marker| {
+            let needle = marker.to_ascii_lowercase();
+            lowered
+                .match_indices(&needle)
+                .find(|&(start, _)| {
+                    let before = lowered[..start].chars().next_back();
+                    let after = lowered[start + needle.len()..].chars().next();
+                    !before.is_some_and(is_word) && !after.is_some_and(is_word)
+                })
+                .map(|(start, _)| (start, marker))
+        })
+        .min_by_key(|&(start, _)| start)?;
+
+    let text = line[start + marker.len()..]
+        .trim_end()
+        .trim_end_matches("*/")
+        .trim_start_matches([':', '-', ' ', '\t'])
+        .trim()
+        .to_string();
+    Some((marker.as_str(), text))
+}
+
+/// Format marker comments as a `TODO:` section with paths relative to `base`
+pub fn format_todo_comments(base: &Path, todos: &[TodoComment]) -> String {
+    if todos.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nTODO:\n");
+    for todo in todos {
+        let path = todo.path.strip_prefix(base).unwrap_or(&todo.path);
+        output.push_str(&format!(
+            "  {}:{} {}",
+            path.display(),
+            todo.line,
+            todo.marker
+        ));
+        if !todo.text.is_empty() {
+            output.push_str(&format!(" {}", todo.text));
+        }
+        output.push('\n');
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::CommentInfo;
+
+    fn default_markers() -> Vec<String> {
+        DEFAULT_TODO_MARKERS.iter().map(|m| m.to_string()).collect()
+    }
+
+    fn results(comments: &[(usize, &str)]) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(20);
+        result.comments = comments
+            .iter()
+            .map(|&(line, text)| CommentInfo {
+                line,
+                text: text.to_string(),
+            })
+            .collect();
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    fn markers_in(results: &[(PathBuf, AnalysisResult)]) -> Vec<(usize, String, String)> {
+        find_todo_comments(results, &default_markers())
+            .into_iter()
+            .map(|t| (t.line, t.marker, t.text))
+            .collect()
+    }
+
+    #[test]
+    fn markers_match_whole_words_in_any_case() {
+        let results = results(&[
+            (2, "// TODO: handle overflow"),
+            (3, "// fixme - flaky on Windows"),
+            (4, "// TODOS are tracked elsewhere"),
+            (5, "# hack"),
+            (6, "// mastodon"),
+        ]);
+        assert_eq!(
+            markers_in(&results),
+            vec![
+                (2, "TODO".into(), "handle overflow".into()),
+                (3, "FIXME".into(), "flaky on Windows".into()),
+                (5, "HACK".into(), String::new()),
+            ]
+        );
+    }
+
+    #[test]
+    fn block_comments_report_the_marker_line() {
+        let results = results(&[(10, "/*\n * Parses input.\n * FIXME(ana): quadratic */")]);
+        assert_eq!(
+            markers_in(&results),
+            vec![(12, "FIXME".into(), "(ana): quadratic".into())]
+        );
+    }
+
+    #[test]
+    fn custom_markers_replace_the_defaults() {
+        let results = results(&[(1, "// XXX: remove"), (2, "// TODO: keep")]);
+        let todos = find_todo_comments(&results, &["XXX".to_string()]);
+        assert_eq!(todos.len(), 1);
+        assert_eq!((todos[0].line, todos[0].marker.as_str()), (1, "XXX"));
+    }
+
+    #[test]
+    fn format_lists_marker_and_text() {
+        let todos = find_todo_comments(
+            &results(&[(2, "// TODO: handle overflow"), (5, "# hack")]),
+            &default_markers(),
+        );
+        assert_eq!(
+            format_todo_comments(Path::new("/p"), &todos),
+            "\nTODO:\n  a.go:2 TODO handle overflow\n  a.go:5 HACK\n"
+        );
+        assert!(format_todo_comments(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn string_literals_are_not_comments() {
+        let code = "package main\n\n// TODO: real\nfunc f() string {\n\treturn \"// TODO: not a comment\"\n}\n";
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        let todos = find_todo_comments(&[(PathBuf::from("a.go"), result)], &default_markers());
+        assert_eq!(todos.len(), 1);
+        assert_eq!(todos[0].line, 3);
+    }
+}
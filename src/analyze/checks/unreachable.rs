@@ -0,0 +1,270 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_UNREACHABLE;
+use crate::analyze::types::{AnalysisResult, UnreachableCode};
+
+/// A statement that can never run
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct UnreachableStatement {
+    pub path: PathBuf,
+    /// Function containing the statement
+    pub function: String,
+    pub unreachable: UnreachableCode,
+}
+
+/// Collect the unreachable statements found while parsing, ordered by path
+/// and position. Functions under an `analyzer:ignore unreachable` comment
+/// are skipped.
+pub fn find_unreachable_code(results: &[(PathBuf, AnalysisResult)]) -> Vec<UnreachableStatement> {
+    let mut found: Vec<UnreachableStatement> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.is_ignored(CHECK_UNREACHABLE))
+                .flat_map(move |f| {
+                    f.unreachable_code
+                        .iter()
+                        .map(move |unreachable| UnreachableStatement {
+                            path: path.clone(),
+                            function: f.name.clone(),
+                            unreachable: unreachable.clone(),
+                        })
+                })
+        })
+        .collect();
+
+    found.sort_by(|a, b| {
+        a.path.cmp(&b.path).then_with(|| {
+            (a.unreachable.line, a.unreachable.column)
+                .cmp(&(b.unreachable.line, b.unreachable.column))
+        })
+    });
+    found
+}
+
+/// Format unreachable statements as an `UNREACHABLE CODE:` section with
+/// paths relative to `base`
+pub fn format_unreachable_code(base: &Path, found: &[UnreachableStatement]) -> String {
+    if found.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nUNREACHABLE CODE:\n");
+    for entry in found {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        let unreachable = &entry.unreachable;
+        output.push_str(&format!(
+            "  {}:{}:{} after {} (line {}) in {}\n",
+            path.display(),
+            unreachable.line,
+            unreachable.column,
+            unreachable.after,
+            unreachable.after_line,
+            entry.function
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::FunctionInfo;
+
+    fn unreachable(code: &str) -> Vec<(usize, usize, String, usize)> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        result
+            .functions
+            .iter()
+            .flat_map(|f| &f.unreachable_code)
+            .map(|u| (u.line, u.column, u.after.clone(), u.after_line))
+            .collect()
+    }
+
+    fn found(
+        line: usize,
+        column: usize,
+        after: &str,
+        after_line: usize,
+    ) -> (usize, usize, String, usize) {
+        (line, column, after.to_string(), after_line)
+    }
+
+    #[test]
+    fn statements_after_leaving_the_block_are_found() {
+        let code = r#"package main
+
+func run(items []int) int {
+	for _, item := range items {
+		if item < 0 {
+			continue
+			log("negative")
+		}
+		if item == 0 {
+			break
+			log("zero")
+		}
+	}
+	if len(items) > 10 {
+		os.Exit(1)
+		cleanup()
+	}
+	return len(items)
+	log("done")
+	log("twice")
+}
+
+func fail() {
+	panic("boom")
+	cleanup()
+}
+"#;
+        assert_eq!(
+            unreachable(code),
+            vec![
+                found(7, 4, "continue", 6),
+                found(11, 4, "break", 10),
+                found(16, 3, "os.Exit", 15),
+                found(19, 2, "return", 18),
+                found(25, 2, "panic", 24),
+            ]
+        );
+    }
+
+    #[test]
+    fn terminating_compound_statements_are_followed() {
+        let code = r#"package main
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	} else {
+		return 1
+	}
+	return 0
+}
+
+func serve() {
+	for {
+		handle()
+	}
+	shutdown()
+}
+
+func kind(v int) string {
+	switch v {
+	case 0:
+		return "zero"
+	case 1:
+		fallthrough
+	default:
+		panic("unknown")
+	}
+	return ""
+}
+
+func wait() {
+	select {}
+	cleanup()
+}
+"#;
+        assert_eq!(
+            unreachable(code),
+            vec![
+                found(9, 2, "if", 4),
+                found(16, 2, "for", 13),
+                found(28, 2, "switch", 20),
+                found(33, 2, "select", 32),
+            ]
+        );
+    }
+
+    #[test]
+    fn breaks_gotos_and_partial_branches_keep_code_reachable() {
+        let code = r#"package main
+
+func poll(ch chan int) int {
+	for {
+		select {
+		case v := <-ch:
+			if v > 0 {
+				return v
+			}
+		}
+		if done() {
+			break
+		}
+	}
+	if ready() {
+		return 1
+	}
+	switch {
+	case ready():
+		return 2
+	}
+outer:
+	for {
+		for {
+			break outer
+		}
+	}
+	goto retry
+retry:
+	go func() {
+		for {
+			break
+		}
+	}()
+	return 0
+}
+"#;
+        assert!(unreachable(code).is_empty(), "{:?}", unreachable(code));
+    }
+
+    #[test]
+    fn format_names_the_leaving_statement() {
+        let mut result = AnalysisResult::empty(20);
+        result.functions = vec![
+            FunctionInfo {
+                name: "run".into(),
+                line: 3,
+                end_line: 9,
+                unreachable_code: vec![UnreachableCode {
+                    line: 6,
+                    column: 2,
+                    after: "return".into(),
+                    after_line: 5,
+                }],
+                ..Default::default()
+            },
+            FunctionInfo {
+                name: "quiet".into(),
+                line: 11,
+                end_line: 16,
+                ignored_checks: vec!["unreachable".into()],
+                unreachable_code: vec![UnreachableCode {
+                    line: 14,
+                    column: 2,
+                    after: "panic".into(),
+                    after_line: 13,
+                }],
+                ..Default::default()
+            },
+        ];
+        let found = find_unreachable_code(&[(PathBuf::from("/p/main.go"), result)]);
+        assert_eq!(
+            format_unreachable_code(Path::new("/p"), &found),
+            "\nUNREACHABLE CODE:\n  main.go:6:2 after return (line 5) in run\n"
+        );
+        assert!(format_unreachable_code(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::HashSet;
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_UNUSED;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// An unexported function that is never referenced by the analyzed files
+#[derive(Debug, Clone)]
+pub struct UnusedFunction {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+}
+
+/// Find unexported free functions that are declared but never referenced.
+///
+/// Each directory is treated as one package: a function counts as used when
+/// its name appears as an identifier anywhere in a file of its directory (a
+/// call, a callback argument, an assignment). Methods are never reported
+/// because they may be invoked through an interface or trait that the
+/// analyzer cannot resolve. Functions under an `analyzer:ignore unused`
+/// comment are skipped.
+pub fn find_unused_functions(results: &[(PathBuf, AnalysisResult)]) -> Vec<UnusedFunction> {
+    let referenced: HashSet<(&Path, &str)> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            let dir = package_dir(path);
+            result
+                .referenced_names
+                .iter()
+                .map(String::as_str)
+                .chain(result.calls.iter().map(|call| call.callee_name.as_str()))
+                .map(move |name| (dir, name))
+        })
+        .collect();
+
+    let mut unused: Vec<UnusedFunction> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.exported && f.receiver.is_none() && !f.is_ignored(CHECK_UNUSED))
+                .filter(|f| !referenced.contains(&(package_dir(path), f.name.as_str())))
+                .map(move |f| UnusedFunction {
+                    path: path.clone(),
+                    function: f.clone(),
+                })
+        })
+        .collect();
+
+    unused.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    unused
+}
+
+fn package_dir(path: &Path) -> &Path {
+    path.parent().unwrap_or(path)
+}
+
+/// Format unused functions as an `UNUSED:` section with paths relative to `base`
+pub fn format_unused_functions(base: &Path, unused: &[UnusedFunction]) -> String {
+    if unused.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nUNUSED:\n");
+    for entry in unused {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        output.push_str(&format!(
+            "  {}:{} {}\n",
+            path.display(),
+            entry.function.line,
+            entry.function.name
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::CallInfo;
+
+    fn function(name: &str, line: usize, exported: bool) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            exported,
+            ..Default::default()
+        }
+    }
+
+    fn result_with(functions: Vec<FunctionInfo>, referenced: &[&str]) -> AnalysisResult {
+        let mut result = AnalysisResult::empty(10);
+        result.functions = functions;
+        result.referenced_names = referenced.iter().map(|s| s.to_string()).collect();
+        result
+    }
+
+    #[test]
+    fn reports_unreferenced_unexported_function() {
+        let results = vec![(
+            PathBuf::from("/p/a.go"),
+            result_with(
+                vec![function("dead", 3, false), function("helper", 7, false)],
+                &["helper"],
+            ),
+        )];
+        let unused = find_unused_functions(&results);
+        assert_eq!(unused.len(), 1);
+        assert_eq!(unused[0].function.name, "dead");
+    }
+
+    #[test]
+    fn ignores_exported_functions_and_methods() {
+        let mut method = function("greet", 5, false);
+        method.receiver = Some("*Greeter".into());
+        let results = vec![(
+            PathBuf::from("/p/a.go"),
+            result_with(vec![function("Public", 1, true), method], &[]),
+        )];
+        assert!(find_unused_functions(&results).is_empty());
+    }
+
+    #[test]
+    fn skips_functions_ignoring_the_check() {
+        let mut ignored = function("generated", 3, false);
+        ignored.ignored_checks = vec!["unused".into()];
+        let mut other = function("legacy", 7, false);
+        other.ignored_checks = vec!["complexity".into()];
+        let results = vec![(
+            PathBuf::from("/p/a.go"),
+            result_with(vec![ignored, other], &[]),
+        )];
+        let unused = find_unused_functions(&results);
+        assert_eq!(unused.len(), 1);
+        assert_eq!(unused[0].function.name, "legacy");
+    }
+
+    #[test]
+    fn references_in_other_files_count() {
+        let results = vec![
+            (
+                PathBuf::from("/p/a.go"),
+                result_with(vec![function("callback", 1, false)], &[]),
+            ),
+            (PathBuf::from("/p/b.go"), result_with(vec![], &["callback"])),
+        ];
+        assert!(find_unused_functions(&results).is_empty());
+    }
+
+    #[test]
+    fn references_in_other_directories_do_not_count() {
+        let results = vec![
+            (
+                PathBuf::from("/p/a.go"),
+                result_with(vec![function("helper", 1, false)], &[]),
+            ),
+            (
+                PathBuf::from("/p/sub/b.go"),
+                result_with(vec![function("helper", 1, false)], &["helper"]),
+            ),
+        ];
+        let unused = find_unused_functions(&results);
+        assert_eq!(unused.len(), 1);
+        assert_eq!(unused[0].path, PathBuf::from("/p/a.go"));
+    }
+
+    #[test]
+    fn calls_count_as_references() {
+        let mut result = result_with(vec![function("helper", 1, false)], &[]);
+        result.calls.push(CallInfo {
+            caller_name: Some("main".into()),
+            callee_name: "helper".into(),
+            qualifier: None,
+            is_reference: false,
+            line: 5,
+            column: 0,
+            context: String::new(),
+        });
+        let results = vec![(PathBuf::from("/p/a.go"), result)];
+        assert!(find_unused_functions(&results).is_empty());
+    }
+
+    #[test]
+    fn format_lists_relative_paths() {
+        let unused = vec![UnusedFunction {
+            path: PathBuf::from("/p/a.go"),
+            function: function("dead", 3, false),
+        }];
+        let out = format_unused_functions(Path::new("/p"), &unused);
+        assert_eq!(out, "\nUNUSED:\n  a.go:3 dead\n");
+        assert!(format_unused_functions(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::{Path, PathBuf};
+
+use super::ignore::CHECK_UNWRAPPED_ERRORS;
+use crate::analyze::types::{AnalysisResult, ErrorReturn};
+
+/// An error from a call returned without adding context
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct UnwrappedError {
+    pub path: PathBuf,
+    /// Function containing the `return`
+    pub function: String,
+    pub error_return: ErrorReturn,
+}
+
+/// Collect the returns passing on a call's error unchanged, ordered by path
+/// and position. Wrapping the error with `fmt.Errorf("...: %w", err)` says
+/// where it came from while keeping it matchable with `errors.Is`. Functions
+/// under an `analyzer:ignore unwrapped-errors` comment are skipped.
+pub fn find_unwrapped_errors(results: &[(PathBuf, AnalysisResult)]) -> Vec<UnwrappedError> {
+    let mut unwrapped: Vec<UnwrappedError> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(|f| !f.is_ignored(CHECK_UNWRAPPED_ERRORS))
+                .flat_map(move |f| {
+                    f.error_returns
+                        .iter()
+                        .map(move |error_return| UnwrappedError {
+                            path: path.clone(),
+                            function: f.name.clone(),
+                            error_return: error_return.clone(),
+                        })
+                })
+        })
+        .collect();
+
+    unwrapped.sort_by(|a, b| {
+        a.path.cmp(&b.path).then_with(|| {
+            (a.error_return.line, a.error_return.column)
+                .cmp(&(b.error_return.line, b.error_return.column))
+        })
+    });
+    unwrapped
+}
+
+/// Format unwrapped errors as an `UNWRAPPED ERRORS:` section with paths relative to `base`
+pub fn format_unwrapped_errors(base: &Path, unwrapped: &[UnwrappedError]) -> String {
+    if unwrapped.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nUNWRAPPED ERRORS:\n");
+    for entry in unwrapped {
+        let path = entry.path.strip_prefix(base).unwrap_or(&entry.path);
+        let error_return = &entry.error_return;
+        output.push_str(&format!(
+            "  {}:{}:{} {} from {} (line {}) in {}\n",
+            path.display(),
+            error_return.line,
+            error_return.column,
+            error_return.variable,
+            error_return.callee,
+            error_return.call_line,
+            entry.function
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+    use crate::analyze::types::FunctionInfo;
+
+    fn error_returns(code: &str) -> Vec<(String, usize, String, usize)> {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, "go").unwrap();
+        let result =
+            ElementExtractor::extract_with_depth(&tree, code, "go", "semantic", None).unwrap();
+        result
+            .functions
+            .iter()
+            .flat_map(|f| &f.error_returns)
+            .map(|r| (r.variable.clone(), r.line, r.callee.clone(), r.call_line))
+            .collect()
+    }
+
+    #[test]
+    fn errors_of_calls_returned_as_is_are_found() {
+        let code = r#"package config
+
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	err = c.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+"#;
+        assert_eq!(
+            error_returns(code),
+            vec![
+                ("err".to_string(), 6, "os.ReadFile".to_string(), 4),
+                ("err".to_string(), 10, "json.Unmarshal".to_string(), 9),
+                ("err".to_string(), 14, "c.validate".to_string(), 12),
+            ]
+        );
+    }
+
+    #[test]
+    fn wrapped_created_and_passed_in_errors_are_not_found() {
+        let code = r#"package config
+
+func check(cause error) error {
+	if err := ping(); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	err := errors.New("down")
+	if cause != nil {
+		return cause
+	}
+	return err
+}
+
+func count() int {
+	n, err := size()
+	_ = err
+	return n
+}
+"#;
+        assert!(error_returns(code).is_empty(), "{:?}", error_returns(code));
+    }
+
+    #[test]
+    fn function_literals_are_checked_against_their_own_results() {
+        let code = r#"package config
+
+func run(paths []string) {
+	walk := func(p string) error {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	_ = walk
+}
+"#;
+        assert_eq!(
+            error_returns(code),
+            vec![("err".to_string(), 7, "os.Open".to_string(), 5)]
+        );
+    }
+
+    #[test]
+    fn format_names_the_originating_call() {
+        let mut result = AnalysisResult::empty(20);
+        result.functions = vec![
+            FunctionInfo {
+                name: "Load".into(),
+                line: 3,
+                end_line: 9,
+                error_returns: vec![ErrorReturn {
+                    variable: "err".into(),
+                    line: 6,
+                    column: 3,
+                    callee: "os.ReadFile".into(),
+                    call_line: 4,
+                }],
+                ..Default::default()
+            },
+            FunctionInfo {
+                name: "quiet".into(),
+                line: 11,
+                end_line: 16,
+                ignored_checks: vec!["unwrapped-errors".into()],
+                error_returns: vec![ErrorReturn {
+                    variable: "err".into(),
+                    line: 14,
+                    column: 3,
+                    callee: "ping".into(),
+                    call_line: 12,
+                }],
+                ..Default::default()
+            },
+        ];
+        let found = find_unwrapped_errors(&[(PathBuf::from("/p/config.go"), result)]);
+        assert_eq!(
+            format_unwrapped_errors(Path::new("/p"), &found),
+            "\nUNWRAPPED ERRORS:\n  config.go:6:3 err from os.ReadFile (line 4) in Load\n"
+        );
+        assert!(format_unwrapped_errors(Path::new("/p"), &[]).is_empty());
+    }
+}
@@ -0,0 +1,335 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Function metrics compared between two analysis runs, e.g. of two commits.
+//!
+//! Runs are compared through their JSON reports, so a saved `--format json`
+//! run can serve as the baseline of a later one. Functions are matched by
+//! qualified name rather than position, so moving a function within its file
+//! or package is not reported.
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use super::output::json::{JsonFile, JsonFunction};
+
+/// A function present in only one of the runs
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct FunctionMetrics {
+    /// Qualified name, see [`qualified_name`]
+    pub name: String,
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub line: usize,
+    pub complexity: usize,
+    pub lines_of_code: usize,
+}
+
+/// A function whose complexity or length changed between the runs
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct MetricsChange {
+    /// Qualified name, see [`qualified_name`]
+    pub name: String,
+    /// Path and line in the new run
+    pub path: String,
+    pub line: usize,
+    pub old_complexity: usize,
+    pub new_complexity: usize,
+    pub old_lines_of_code: usize,
+    pub new_lines_of_code: usize,
+}
+
+impl MetricsChange {
+    pub fn complexity_delta(&self) -> i64 {
+        self.new_complexity as i64 - self.old_complexity as i64
+    }
+
+    pub fn lines_of_code_delta(&self) -> i64 {
+        self.new_lines_of_code as i64 - self.old_lines_of_code as i64
+    }
+}
+
+/// Functions added, removed and changed between two runs, each ordered by
+/// path and line; removed functions by their place in the old run
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct MetricsDiff {
+    pub added: Vec<FunctionMetrics>,
+    pub removed: Vec<FunctionMetrics>,
+    pub changed: Vec<MetricsChange>,
+}
+
+impl MetricsDiff {
+    pub fn is_empty(&self) -> bool {
+        self.added.is_empty() && self.removed.is_empty() && self.changed.is_empty()
+    }
+}
+
+/// Name identifying a function across runs: package, receiver and name, as
+/// in `pkg/store.(*Cache).Get`. Go functions belong to the package of their
+/// directory; in other languages the file, without its extension, is the
+/// package. Files at the top of the analyzed directory have no Go package
+/// prefix.
+pub fn qualified_name(path: &str, language: &str, receiver: Option<&str>, name: &str) -> String {
+    let path = Path::new(path);
+    let package = if language == "go" {
+        path.parent().map(Path::to_path_buf).unwrap_or_default()
+    } else {
+        path.with_extension("")
+    };
+
+    let mut qualified = package.display().to_string();
+    if !qualified.is_empty() {
+        qualified.push('.');
+    }
+    match receiver {
+        Some(receiver) if receiver.starts_with('*') => {
+            qualified.push_str(&format!("({}).", receiver))
+        }
+        Some(receiver) => qualified.push_str(&format!("{}.", receiver)),
+        None => {}
+    }
+    qualified.push_str(name);
+    qualified
+}
+
+/// Compare the functions of two runs. Functions sharing a qualified name,
+/// such as several Go `init` functions of one package, are paired in the
+/// order they appear.
+pub fn compare_runs(old: &[JsonFile], new: &[JsonFile]) -> MetricsDiff {
+    let mut old_functions = functions_by_name(old);
+    let mut diff = MetricsDiff::default();
+
+    for (name, new_functions) in functions_by_name(new) {
+        let mut previous = old_functions.remove(&name).unwrap_or_default().into_iter();
+        for (path, function) in new_functions {
+            match previous.next() {
+                Some((_, before))
+                    if before.complexity != function.complexity
+                        || before.lines_of_code != function.lines_of_code =>
+                {
+                    diff.changed.push(MetricsChange {
+                        name: name.clone(),
+                        path: path.to_string(),
+                        line: function.start_line,
+                        old_complexity: before.complexity,
+                        new_complexity: function.complexity,
+                        old_lines_of_code: before.lines_of_code,
+                        new_lines_of_code: function.lines_of_code,
+                    });
+                }
+                Some(_) => {}
+                None => diff.added.push(metrics(&name, path, function)),
+            }
+        }
+        diff.removed
+            .extend(previous.map(|(path, before)| metrics(&name, path, before)));
+    }
+    for (name, functions) in old_functions {
+        diff.removed.extend(
+            functions
+                .into_iter()
+                .map(|(path, before)| metrics(&name, path, before)),
+        );
+    }
+
+    let by_position =
+        |a: &FunctionMetrics, b: &FunctionMetrics| (&a.path, a.line).cmp(&(&b.path, b.line));
+    diff.added.sort_by(by_position);
+    diff.removed.sort_by(by_position);
+    diff.changed
+        .sort_by(|a, b| (&a.path, a.line).cmp(&(&b.path, b.line)));
+    diff
+}
+
+/// Functions of a run by qualified name, in file and declaration order
+fn functions_by_name(files: &[JsonFile]) -> BTreeMap<String, Vec<(&str, &JsonFunction)>> {
+    let mut functions: BTreeMap<String, Vec<(&str, &JsonFunction)>> = BTreeMap::new();
+    for file in files {
+        for function in &file.functions {
+            let name = qualified_name(
+                &file.path,
+                &file.language,
+                function.receiver.as_deref(),
+                &function.name,
+            );
+            functions
+                .entry(name)
+                .or_default()
+                .push((file.path.as_str(), function));
+        }
+    }
+    functions
+}
+
+fn metrics(name: &str, path: &str, function: &JsonFunction) -> FunctionMetrics {
+    FunctionMetrics {
+        name: name.to_string(),
+        path: path.to_string(),
+        line: function.start_line,
+        complexity: function.complexity,
+        lines_of_code: function.lines_of_code,
+    }
+}
+
+/// Format a comparison as a `METRICS CHANGES:` section
+pub fn format_metrics_diff(diff: &MetricsDiff) -> String {
+    if diff.is_empty() {
+        return "\nMETRICS CHANGES: none\n".to_string();
+    }
+
+    let mut output = String::from("\nMETRICS CHANGES:\n");
+    for (label, functions) in [("added", &diff.added), ("removed", &diff.removed)] {
+        for function in functions {
+            output.push_str(&format!(
+                "  {:<7} {}:{} {} (complexity {}, {} LOC)\n",
+                label,
+                function.path,
+                function.line,
+                function.name,
+                function.complexity,
+                function.lines_of_code
+            ));
+        }
+    }
+    for change in &diff.changed {
+        output.push_str(&format!(
+            "  changed {}:{} {} complexity {}, LOC {}\n",
+            change.path,
+            change.line,
+            change.name,
+            format_change(change.old_complexity, change.new_complexity),
+            format_change(change.old_lines_of_code, change.new_lines_of_code)
+        ));
+    }
+    output
+}
+
+/// `3`, or `3 -> 5 (+2)` when the value changed
+pub fn format_change(old: usize, new: usize) -> String {
+    if old == new {
+        new.to_string()
+    } else {
+        format!("{} -> {} ({:+})", old, new, new as i64 - old as i64)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn function(
+        name: &str,
+        receiver: Option<&str>,
+        line: usize,
+        complexity: usize,
+    ) -> JsonFunction {
+        JsonFunction {
+            name: name.into(),
+            receiver: receiver.map(|r| r.to_string()),
+            param_count: 0,
+            return_count: 0,
+            start_line: line,
+            end_line: line + 2,
+            complexity,
+            cognitive_complexity: 0,
+            max_nesting_depth: 0,
+            lines_of_code: complexity * 2,
+            hotspot_score: 0.0,
+            params: vec![],
+            returns: vec![],
+        }
+    }
+
+    fn file(path: &str, functions: Vec<JsonFunction>) -> JsonFile {
+        JsonFile {
+            path: path.into(),
+            language: "go".into(),
+            line_count: 40,
+            code_lines: 30,
+            functions,
+            classes: vec![],
+            imports: vec![],
+            error: None,
+        }
+    }
+
+    #[test]
+    fn names_carry_package_and_receiver() {
+        assert_eq!(
+            qualified_name("pkg/store/cache.go", "go", Some("*Cache"), "Get"),
+            "pkg/store.(*Cache).Get"
+        );
+        assert_eq!(qualified_name("main.go", "go", None, "main"), "main");
+        assert_eq!(
+            qualified_name("src/util.py", "python", Some("Parser"), "run"),
+            "src/util.Parser.run"
+        );
+    }
+
+    #[test]
+    fn reordered_functions_are_matched_by_name() {
+        let old = vec![file(
+            "sample.go",
+            vec![
+                function("Greet", Some("*Greeter"), 9, 1),
+                function("helper", None, 13, 1),
+                function("legacy", None, 20, 2),
+            ],
+        )];
+        let new = vec![file(
+            "sample.go",
+            vec![
+                function("helper", None, 5, 1),
+                function("Greet", Some("*Greeter"), 9, 3),
+                function("fresh", None, 30, 1),
+            ],
+        )];
+        let diff = compare_runs(&old, &new);
+
+        let names = |functions: &[FunctionMetrics]| -> Vec<String> {
+            functions.iter().map(|f| f.name.clone()).collect()
+        };
+        assert_eq!(names(&diff.added), vec!["fresh"]);
+        assert_eq!(names(&diff.removed), vec!["legacy"]);
+        assert_eq!(diff.changed.len(), 1);
+        assert_eq!(diff.changed[0].name, "(*Greeter).Greet");
+        assert_eq!(diff.changed[0].complexity_delta(), 2);
+        assert_eq!(diff.changed[0].lines_of_code_delta(), 4);
+    }
+
+    #[test]
+    fn functions_sharing_a_name_are_paired_in_order() {
+        let old = vec![file("a.go", vec![function("init", None, 3, 1)])];
+        let new = vec![file(
+            "a.go",
+            vec![function("init", None, 3, 1), function("init", None, 9, 1)],
+        )];
+        let diff = compare_runs(&old, &new);
+        assert_eq!(diff.added.len(), 1);
+        assert_eq!(diff.added[0].line, 9);
+        assert!(diff.removed.is_empty() && diff.changed.is_empty());
+    }
+
+    #[test]
+    fn format_lists_each_kind_of_change() {
+        let old = vec![file(
+            "a.go",
+            vec![function("gone", None, 1, 2), function("grow", None, 5, 1)],
+        )];
+        let new = vec![file(
+            "a.go",
+            vec![function("grow", None, 5, 3), function("new", None, 12, 1)],
+        )];
+        assert_eq!(
+            format_metrics_diff(&compare_runs(&old, &new)),
+            "\nMETRICS CHANGES:\n  \
+             added   a.go:12 new (complexity 1, 2 LOC)\n  \
+             removed a.go:1 gone (complexity 2, 4 LOC)\n  \
+             changed a.go:5 grow complexity 1 -> 3 (+2), LOC 2 -> 6 (+4)\n"
+        );
+        assert_eq!(
+            format_metrics_diff(&MetricsDiff::default()),
+            "\nMETRICS CHANGES: none\n"
+        );
+    }
+}
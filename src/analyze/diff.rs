@@ -0,0 +1,285 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Changed line ranges, for reporting only the functions a change touches.
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use super::types::{AnalysisResult, ClassInfo};
+
+/// Lines changed per file, as inclusive 1-based ranges in the new version
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct ChangedLines {
+    files: BTreeMap<PathBuf, Vec<(usize, usize)>>,
+}
+
+impl ChangedLines {
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Mark lines `start..=end` of `path` as changed
+    pub fn add(&mut self, path: impl Into<PathBuf>, start: usize, end: usize) {
+        let ranges = self.files.entry(path.into()).or_default();
+        ranges.push((start.min(end), start.max(end)));
+        ranges.sort_unstable();
+
+        let mut merged: Vec<(usize, usize)> = Vec::with_capacity(ranges.len());
+        for &(start, end) in ranges.iter() {
+            match merged.last_mut() {
+                Some(last) if start <= last.1 + 1 => last.1 = last.1.max(end),
+                _ => merged.push((start, end)),
+            }
+        }
+        *ranges = merged;
+    }
+
+    /// Changed lines of a unified diff such as `git diff` prints. Added lines
+    /// count as changed, and a deletion marks the line now at its position;
+    /// context lines and deleted files are ignored. Paths lose their `b/`
+    /// prefix and stay relative, as written in the diff.
+    pub fn from_unified_diff(diff: &str) -> Result<Self, String> {
+        let mut changes = Self::new();
+        let mut file: Option<PathBuf> = None;
+        let mut line = 0;
+        let mut old_remaining: usize = 0;
+        let mut new_remaining: usize = 0;
+
+        for (index, text) in diff.lines().enumerate() {
+            if old_remaining > 0 || new_remaining > 0 {
+                match text.as_bytes().first() {
+                    Some(b'+') => {
+                        if let Some(path) = &file {
+                            changes.add(path.clone(), line, line);
+                        }
+                        line += 1;
+                        new_remaining = new_remaining.saturating_sub(1);
+                    }
+                    Some(b'-') => {
+                        if let Some(path) = &file {
+                            changes.add(path.clone(), line.max(1), line.max(1));
+                        }
+                        old_remaining = old_remaining.saturating_sub(1);
+                    }
+                    Some(b'\\') => {}
+                    _ => {
+                        line += 1;
+                        old_remaining = old_remaining.saturating_sub(1);
+                        new_remaining = new_remaining.saturating_sub(1);
+                    }
+                }
+                continue;
+            }
+
+            if let Some(target) = text.strip_prefix("+++ ") {
+                let target = target.split('\t').next().unwrap_or(target).trim_end();
+                file = (target != "/dev/null")
+                    .then(|| PathBuf::from(target.strip_prefix("b/").unwrap_or(target)));
+            } else if let Some(header) = text.strip_prefix("@@ ") {
+                let (old, new) = parse_hunk_header(header).ok_or_else(|| {
+                    format!("Malformed hunk header on line {}: {}", index + 1, text)
+                })?;
+                (line, new_remaining) = new;
+                old_remaining = old;
+            }
+        }
+
+        Ok(changes)
+    }
+
+    /// The same changes with relative paths resolved against `base`
+    pub fn resolve(&self, base: &Path) -> Self {
+        Self {
+            files: self
+                .files
+                .iter()
+                .map(|(path, ranges)| (base.join(path), ranges.clone()))
+                .collect(),
+        }
+    }
+
+    /// Whether any line of `path` changed
+    pub fn contains_file(&self, path: &Path) -> bool {
+        self.files.contains_key(path)
+    }
+
+    /// Whether line `line` of `path` changed
+    pub fn contains_line(&self, path: &Path, line: usize) -> bool {
+        self.overlaps(path, line, line)
+    }
+
+    /// Whether a changed line of `path` falls within `start..=end`
+    pub fn overlaps(&self, path: &Path, start: usize, end: usize) -> bool {
+        self.files.get(path).is_some_and(|ranges| {
+            ranges
+                .iter()
+                .any(|&(first, last)| first <= end && start <= last)
+        })
+    }
+
+    /// Drop what each result reports that has no changed line: functions
+    /// and types by their span, comments, shadowed variables and empty
+    /// interfaces by their line. Calls, references and field accesses stay,
+    /// since they only count toward other declarations.
+    pub fn retain_changed(&self, results: &mut [(PathBuf, AnalysisResult)]) {
+        for (path, result) in results {
+            result
+                .functions
+                .retain(|f| self.overlaps(path, f.line, f.end_line.max(f.line)));
+            result.function_count = result.functions.len();
+            result
+                .classes
+                .retain(|class| self.overlaps(path, class.line, class_end_line(class)));
+            result.class_count = result.classes.len();
+            result
+                .comments
+                .retain(|comment| self.contains_line(path, comment.line));
+            result
+                .shadowed
+                .retain(|shadow| self.contains_line(path, shadow.line));
+            result
+                .empty_interfaces
+                .retain(|empty| self.contains_line(path, empty.line));
+        }
+    }
+}
+
+/// Last line of a type declaration that any of its fields or methods is on,
+/// since types record no end line of their own
+fn class_end_line(class: &ClassInfo) -> usize {
+    let interface_methods = class.interface.iter().flat_map(|i| &i.methods);
+    class
+        .fields
+        .iter()
+        .map(|field| field.line)
+        .chain(
+            class
+                .methods
+                .iter()
+                .chain(interface_methods)
+                .map(|m| m.line),
+        )
+        .fold(class.line, usize::max)
+}
+
+/// Old line count and new `(start, count)` of a hunk header such as
+/// `-10,3 +12,4 @@ func main() {`; a missing count means one line
+fn parse_hunk_header(header: &str) -> Option<(usize, (usize, usize))> {
+    let mut parts = header.split_whitespace();
+    let old = parts.next()?.strip_prefix('-')?;
+    let new = parts.next()?.strip_prefix('+')?;
+    let count = |range: &str| -> Option<(usize, usize)> {
+        match range.split_once(',') {
+            Some((start, count)) => Some((start.parse().ok()?, count.parse().ok()?)),
+            None => Some((range.parse().ok()?, 1)),
+        }
+    };
+    Some((count(old)?.1, count(new)?))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::{CommentInfo, FieldInfo, FunctionInfo};
+
+    #[test]
+    fn adjacent_and_overlapping_ranges_merge() {
+        let mut changes = ChangedLines::new();
+        changes.add("a.go", 10, 12);
+        changes.add("a.go", 13, 13);
+        changes.add("a.go", 20, 18);
+        changes.add("a.go", 11, 19);
+        assert_eq!(changes.files[Path::new("a.go")], vec![(10, 20)]);
+    }
+
+    #[test]
+    fn unified_diff_marks_added_and_deleted_lines() {
+        let diff = "\
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -9,3 +9,4 @@ type Greeter struct {
+ func (g *Greeter) Greet() string {
+-\treturn \"hi\"
++\tname := g.Name
++\treturn fmt.Sprintf(\"Hello, %s!\", name)
+ }
+@@ -30 +31,0 @@ func main() {
+-\tdebug()
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-
+";
+        let changes = ChangedLines::from_unified_diff(diff).unwrap();
+        assert_eq!(
+            changes.files,
+            BTreeMap::from([(PathBuf::from("main.go"), vec![(10, 11), (31, 31)])])
+        );
+    }
+
+    #[test]
+    fn malformed_hunk_header_is_an_error() {
+        let err = ChangedLines::from_unified_diff("+++ b/a.go\n@@ -x +1 @@\n").unwrap_err();
+        assert!(err.contains("line 2"), "{err}");
+    }
+
+    #[test]
+    fn only_declarations_overlapping_a_change_remain() {
+        let function = |name: &str, line, end_line| FunctionInfo {
+            name: name.into(),
+            line,
+            end_line,
+            ..Default::default()
+        };
+        let class = |name: &str, line, field_line| ClassInfo {
+            name: name.into(),
+            line,
+            methods: vec![],
+            fields: vec![FieldInfo {
+                name: "Name".into(),
+                line: field_line,
+                ..Default::default()
+            }],
+            exported: true,
+            interface: None,
+        };
+        let comment = |line| CommentInfo {
+            line,
+            text: "// note".into(),
+        };
+        let mut result = AnalysisResult::empty(23);
+        result.functions = vec![
+            function("Greet", 9, 11),
+            function("helper", 13, 15),
+            function("main", 17, 23),
+        ];
+        result.function_count = 3;
+        result.classes = vec![class("Greeter", 5, 7), class("Other", 2, 3)];
+        result.class_count = 2;
+        result.comments = vec![comment(4), comment(10)];
+        let mut results = vec![(PathBuf::from("/p/sample.go"), result)];
+
+        let mut changes = ChangedLines::new();
+        changes.add("sample.go", 7, 7);
+        changes.add("sample.go", 10, 12);
+        changes
+            .resolve(Path::new("/p"))
+            .retain_changed(&mut results);
+
+        let result = &results[0].1;
+        let names: Vec<&str> = result.functions.iter().map(|f| f.name.as_str()).collect();
+        assert_eq!(names, vec!["Greet"]);
+        assert_eq!(result.function_count, 1);
+        let names: Vec<&str> = result.classes.iter().map(|c| c.name.as_str()).collect();
+        assert_eq!(names, vec!["Greeter"]);
+        assert_eq!(result.class_count, 1);
+        let lines: Vec<usize> = result.comments.iter().map(|c| c.line).collect();
+        assert_eq!(lines, vec![10]);
+    }
+}
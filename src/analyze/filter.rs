@@ -0,0 +1,120 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::path::Path;
+
+use globset::{Glob, GlobBuilder, GlobSet, GlobSetBuilder};
+
+/// Include and exclude glob patterns selecting the files of a directory walk.
+///
+/// Patterns are matched against paths relative to the analyzed directory,
+/// with `/` as separator: `*` and `?` stay within one path component, `**`
+/// spans any number of them, so `**/*_test.go` matches test files at any
+/// depth while `*_test.go` only matches those at the top.
+#[derive(Debug, Clone, Default)]
+pub struct PathFilter {
+    /// `None` includes every file
+    include: Option<GlobSet>,
+    exclude: Option<GlobSet>,
+}
+
+impl PathFilter {
+    /// Compile the patterns, failing on the first invalid one
+    pub fn new(include: &[String], exclude: &[String]) -> Result<Self, String> {
+        Ok(Self {
+            include: build_set(include)?,
+            exclude: build_set(exclude)?,
+        })
+    }
+
+    /// Whether a file at `relative` is analyzed: it must match an include
+    /// pattern, if any were given, and no exclude pattern. Exclude wins when
+    /// both match.
+    pub fn includes_file(&self, relative: &Path) -> bool {
+        !self.excludes(relative)
+            && self
+                .include
+                .as_ref()
+                .is_none_or(|include| include.is_match(relative))
+    }
+
+    /// Whether a directory at `relative`, and everything below it, is left
+    /// out. Only exclude patterns prune directories, so `vendor` or
+    /// `vendor/**` both skip the whole tree.
+    pub fn excludes_dir(&self, relative: &Path) -> bool {
+        self.excludes(relative)
+    }
+
+    fn excludes(&self, relative: &Path) -> bool {
+        self.exclude
+            .as_ref()
+            .is_some_and(|exclude| exclude.is_match(relative))
+    }
+}
+
+fn build_set(patterns: &[String]) -> Result<Option<GlobSet>, String> {
+    if patterns.is_empty() {
+        return Ok(None);
+    }
+    let mut builder = GlobSetBuilder::new();
+    for pattern in patterns {
+        builder.add(compile(pattern)?);
+        // `dir/**` also names the directory itself, so the walk can prune it
+        if let Some(dir) = pattern.strip_suffix("/**") {
+            builder.add(compile(dir)?);
+        }
+    }
+    builder
+        .build()
+        .map(Some)
+        .map_err(|e| format!("Invalid glob patterns: {}", e))
+}
+
+fn compile(pattern: &str) -> Result<Glob, String> {
+    GlobBuilder::new(pattern)
+        .literal_separator(true)
+        .build()
+        .map_err(|e| format!("Invalid glob '{}': {}", pattern, e))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn filter(include: &[&str], exclude: &[&str]) -> PathFilter {
+        let strings =
+            |patterns: &[&str]| patterns.iter().map(|p| p.to_string()).collect::<Vec<_>>();
+        PathFilter::new(&strings(include), &strings(exclude)).unwrap()
+    }
+
+    #[test]
+    fn double_star_matches_at_any_depth() {
+        let f = filter(&["pkg/**/*.go"], &[]);
+        assert!(f.includes_file(Path::new("pkg/a.go")));
+        assert!(f.includes_file(Path::new("pkg/store/cache/lru.go")));
+        assert!(!f.includes_file(Path::new("cmd/main.go")));
+
+        let f = filter(&["*.go"], &[]);
+        assert!(f.includes_file(Path::new("main.go")));
+        assert!(!f.includes_file(Path::new("pkg/a.go")));
+    }
+
+    #[test]
+    fn exclude_wins_over_include() {
+        let f = filter(&["**/*.go"], &["**/*_test.go", "internal/gen/**"]);
+        assert!(f.includes_file(Path::new("pkg/a.go")));
+        assert!(!f.includes_file(Path::new("pkg/a_test.go")));
+        assert!(f.excludes_dir(Path::new("internal/gen")));
+        assert!(!f.excludes_dir(Path::new("internal")));
+
+        let f = PathFilter::default();
+        assert!(f.includes_file(Path::new("anything.rs")));
+        assert!(!f.excludes_dir(Path::new("vendor")));
+    }
+
+    #[test]
+    fn invalid_pattern_is_an_error() {
+        let err = PathFilter::new(&["pkg/[".to_string()], &[]).unwrap_err();
+        assert!(err.starts_with("Invalid glob 'pkg/['"), "{}", err);
+    }
+}
@@ -292,6 +292,11 @@ impl Formatter {
             ));
         }
 
+        let failed = files.iter().filter(|r| r.error.is_some()).count();
+        if failed > 0 {
+            output.push_str(&format!("Failed: {} files (see error flags)\n", failed));
+        }
+
         Self::append_language_stats(output, results, total_lines);
     }
 
@@ -389,6 +394,9 @@ impl Formatter {
                 if let Some(main_line) = result.main_line {
                     output.push_str(&format!(" main:{}", main_line));
                 }
+                if let Some(error) = &result.error {
+                    output.push_str(&format!(" error:{}", error));
+                }
                 output.push('\n');
             }
         }
@@ -658,18 +666,21 @@ mod tests {
                 FunctionInfo {
                     name: "main".into(),
                     line: 10,
-                    params: vec![],
+                    ..Default::default()
                 },
                 FunctionInfo {
                     name: "helper".into(),
                     line: 20,
-                    params: vec![],
+                    ..Default::default()
                 },
             ],
             classes: vec![ClassInfo {
                 name: "Config".into(),
                 line: 5,
                 methods: vec![],
+                fields: vec![],
+                exported: true,
+                interface: None,
             }],
             imports: vec!["use std::io".into()],
             calls: vec![],
@@ -679,6 +690,14 @@ mod tests {
             line_count: 30,
             import_count: 1,
             main_line: Some(10),
+            referenced_names: HashSet::new(),
+            error: None,
+            code_lines: 0,
+            shadowed: vec![],
+            comments: vec![],
+            field_accesses: vec![],
+            empty_interfaces: vec![],
+            package: None,
         }
     }
 
@@ -761,6 +780,25 @@ mod tests {
         assert!(out.contains("SUMMARY:"));
         assert!(out.contains("2 files"));
         assert!(out.contains("PATH [LOC, FUNCTIONS, CLASSES]"));
+        assert!(!out.contains("Failed:"));
+    }
+
+    #[test]
+    fn format_directory_structure_flags_failed_files() {
+        let results = vec![
+            (
+                PathBuf::from("/proj/a.rs"),
+                EntryType::File(sample_result()),
+            ),
+            (
+                PathBuf::from("/proj/b.rs"),
+                EntryType::File(AnalysisResult::failed("parse failed".into())),
+            ),
+        ];
+        let out = Formatter::format_directory_structure(Path::new("/proj"), &results, 3);
+        assert!(out.contains("2 files"));
+        assert!(out.contains("Failed: 1 files"));
+        assert!(out.contains("b.rs [0L] error:parse failed"));
     }
 
     #[test]
@@ -2,7 +2,7 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
-use std::collections::{HashMap, HashSet, VecDeque};
+use std::collections::{BTreeMap, BTreeSet, HashMap, HashSet, VecDeque};
 use std::path::PathBuf;
 
 use super::types::{AnalysisResult, CallChain, ReferenceType};
@@ -10,11 +10,44 @@ use super::types::{AnalysisResult, CallChain, ReferenceType};
 /// Sentinel value used to represent type references as callers in the call graph
 const REFERENCE_CALLER: &str = "<reference>";
 
+/// A function or method in the call graph
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GraphNode {
+    /// Function name, or `qualifier.name` for external calls
+    pub name: String,
+    /// Receiver or enclosing type for methods
+    pub receiver: Option<String>,
+    /// File and line of the definition, `None` for external nodes
+    pub location: Option<(PathBuf, usize)>,
+    /// Called but not defined in any analyzed file (other packages, builtins)
+    pub external: bool,
+}
+
+impl GraphNode {
+    fn external(name: String) -> Self {
+        Self {
+            name,
+            receiver: None,
+            location: None,
+            external: true,
+        }
+    }
+}
+
+/// A directed caller → callee edge between node names
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub struct GraphEdge {
+    pub caller: String,
+    pub callee: String,
+}
+
 #[derive(Debug, Clone, Default)]
 pub struct CallGraph {
     callers: HashMap<String, Vec<(PathBuf, usize, String)>>,
     callees: HashMap<String, Vec<(PathBuf, usize, String)>>,
     pub definitions: HashMap<String, Vec<(PathBuf, usize)>>,
+    nodes: BTreeMap<String, GraphNode>,
+    edges: BTreeSet<GraphEdge>,
 }
 
 impl CallGraph {
@@ -32,6 +65,16 @@ impl CallGraph {
                     .entry(func.name.clone())
                     .or_default()
                     .push((file_path.clone(), func.line));
+
+                graph
+                    .nodes
+                    .entry(func.name.clone())
+                    .or_insert_with(|| GraphNode {
+                        name: func.name.clone(),
+                        receiver: func.receiver.clone(),
+                        location: Some((file_path.clone(), func.line)),
+                        external: false,
+                    });
             }
 
             for class in &result.classes {
@@ -93,9 +136,93 @@ impl CallGraph {
             }
         }
 
+        graph.add_call_edges(results);
+
         graph
     }
 
+    /// Add caller → callee edges once every definition is known, so calls to
+    /// functions in later files are not mistaken for external ones
+    fn add_call_edges(&mut self, results: &[(PathBuf, AnalysisResult)]) {
+        for (_, result) in results {
+            for call in &result.calls {
+                if call.is_reference {
+                    continue;
+                }
+                let Some(caller) = &call.caller_name else {
+                    continue;
+                };
+
+                let defined = self
+                    .nodes
+                    .get(&call.callee_name)
+                    .is_some_and(|node| !node.external);
+                let callee = if defined {
+                    call.callee_name.clone()
+                } else {
+                    let name = match &call.qualifier {
+                        Some(qualifier) => format!("{}.{}", qualifier, call.callee_name),
+                        None => call.callee_name.clone(),
+                    };
+                    self.nodes
+                        .entry(name.clone())
+                        .or_insert_with(|| GraphNode::external(name.clone()));
+                    name
+                };
+
+                self.edges.insert(GraphEdge {
+                    caller: caller.clone(),
+                    callee,
+                });
+            }
+        }
+    }
+
+    /// Functions, methods and external callees, sorted by name
+    pub fn nodes(&self) -> impl Iterator<Item = &GraphNode> {
+        self.nodes.values()
+    }
+
+    /// Deduplicated caller → callee edges, sorted
+    pub fn edges(&self) -> impl Iterator<Item = &GraphEdge> {
+        self.edges.iter()
+    }
+
+    /// Render the nodes and edges as a Graphviz DOT digraph.
+    /// External nodes are drawn as dashed boxes.
+    pub fn to_dot(&self) -> String {
+        let mut output = String::from("digraph calls {\n");
+
+        for node in self.nodes.values() {
+            let label = match &node.receiver {
+                Some(receiver) => format!("{}.{}", receiver, node.name),
+                None => node.name.clone(),
+            };
+            let style = if node.external {
+                ", shape=box, style=dashed"
+            } else {
+                ""
+            };
+            output.push_str(&format!(
+                "    {} [label={}{}];\n",
+                dot_quote(&node.name),
+                dot_quote(&label),
+                style
+            ));
+        }
+
+        for edge in &self.edges {
+            output.push_str(&format!(
+                "    {} -> {};\n",
+                dot_quote(&edge.caller),
+                dot_quote(&edge.callee)
+            ));
+        }
+
+        output.push_str("}\n");
+        output
+    }
+
     pub fn find_incoming_chains(&self, symbol: &str, max_depth: u32) -> Vec<CallChain> {
         if max_depth == 0 {
             return vec![];
@@ -202,6 +329,10 @@ impl CallGraph {
     }
 }
 
+pub(super) fn dot_quote(s: &str) -> String {
+    format!("\"{}\"", s.replace('\\', "\\\\").replace('"', "\\\""))
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -214,7 +345,7 @@ mod tests {
             .map(|(i, name)| FunctionInfo {
                 name: name.to_string(),
                 line: i + 1,
-                params: vec![],
+                ..Default::default()
             })
             .collect();
 
@@ -224,6 +355,8 @@ mod tests {
             .map(|(i, (caller, callee))| CallInfo {
                 caller_name: Some(caller.to_string()),
                 callee_name: callee.to_string(),
+                qualifier: None,
+                is_reference: false,
                 line: i + 10,
                 column: 0,
                 context: String::new(),
@@ -241,6 +374,14 @@ mod tests {
             calls: call_infos,
             references: vec![],
             main_line: None,
+            referenced_names: HashSet::new(),
+            error: None,
+            code_lines: 0,
+            shadowed: vec![],
+            comments: vec![],
+            field_accesses: vec![],
+            empty_interfaces: vec![],
+            package: None,
         }
     }
 
@@ -325,10 +466,82 @@ mod tests {
             name: "MyStruct".into(),
             line: 5,
             methods: vec![],
+            fields: vec![],
+            exported: true,
+            interface: None,
         });
         result.class_count = 1;
         let results = vec![(PathBuf::from("test.rs"), result)];
         let graph = CallGraph::build_from_results(&results);
         assert!(graph.definitions.contains_key("MyStruct"));
     }
+
+    #[test]
+    fn edges_connect_defined_functions_across_files() {
+        let results = vec![
+            (
+                PathBuf::from("a.go"),
+                make_result(&["main"], &[("main", "helper")]),
+            ),
+            (PathBuf::from("b.go"), make_result(&["helper"], &[])),
+        ];
+        let graph = CallGraph::build_from_results(&results);
+
+        let edges: Vec<_> = graph.edges().collect();
+        assert_eq!(
+            edges,
+            vec![&GraphEdge {
+                caller: "main".into(),
+                callee: "helper".into(),
+            }]
+        );
+        assert!(graph.nodes().all(|node| !node.external));
+    }
+
+    #[test]
+    fn undefined_callees_become_external_nodes() {
+        let mut result = make_result(&["Greet"], &[("Greet", "Sprintf")]);
+        result.calls[0].qualifier = Some("fmt".into());
+        let results = vec![(PathBuf::from("a.go"), result)];
+        let graph = CallGraph::build_from_results(&results);
+
+        let external: Vec<_> = graph.nodes().filter(|node| node.external).collect();
+        assert_eq!(external.len(), 1);
+        assert_eq!(external[0].name, "fmt.Sprintf");
+        assert!(external[0].location.is_none());
+    }
+
+    #[test]
+    fn identifier_references_are_not_edges() {
+        let mut result = make_result(&["main"], &[("main", "msg")]);
+        result.calls[0].is_reference = true;
+        let results = vec![(PathBuf::from("a.go"), result)];
+        let graph = CallGraph::build_from_results(&results);
+        assert_eq!(graph.edges().count(), 0);
+        assert_eq!(graph.nodes().count(), 1);
+    }
+
+    #[test]
+    fn to_dot_renders_nodes_and_edges() {
+        let mut result = make_result(
+            &["main", "Greet"],
+            &[("main", "Greet"), ("Greet", "Sprintf")],
+        );
+        result.functions[1].receiver = Some("*Greeter".into());
+        result.calls[1].qualifier = Some("fmt".into());
+        let results = vec![(PathBuf::from("a.go"), result)];
+        let dot = CallGraph::build_from_results(&results).to_dot();
+
+        assert!(dot.starts_with("digraph calls {\n"));
+        assert!(dot.contains("\"Greet\" [label=\"*Greeter.Greet\"];"));
+        assert!(dot.contains("\"fmt.Sprintf\" [label=\"fmt.Sprintf\", shape=box, style=dashed];"));
+        assert!(dot.contains("\"main\" -> \"Greet\";"));
+        assert!(dot.contains("\"Greet\" -> \"fmt.Sprintf\";"));
+        assert!(dot.ends_with("}\n"));
+    }
+
+    #[test]
+    fn dot_quote_escapes_quotes() {
+        assert_eq!(dot_quote(r#"a"b"#), r#""a\"b""#);
+    }
 }
@@ -0,0 +1,364 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::{BTreeMap, BTreeSet, HashMap};
+use std::path::{Path, PathBuf};
+
+use super::api::receiver_type_name;
+use super::imports::{find_go_module, package_of_dir};
+use super::types::{AnalysisResult, FunctionInfo, InterfaceInfo, ParamInfo};
+use crate::lang;
+
+/// Method as compared for interface satisfaction: name, parameter types and
+/// result types
+type Signature = (String, Vec<String>, Vec<String>);
+
+/// Type declared in a package: the package name and the type name
+type TypeName<'a> = (String, &'a str);
+
+/// Map each interface to the concrete types whose method sets satisfy it.
+///
+/// Each directory of Go files under `root` is one package, named as in the
+/// import graph, and types are told apart by package: both are written
+/// `package.Name`, except in the package of a `root` without a `go.mod`,
+/// whose names are left bare. A type is listed as `T` when its value methods
+/// suffice and as `*T` when pointer methods are needed. Without a type
+/// checker, signatures are compared by the type text as written, embedded
+/// structs do not promote methods, and interfaces that embed something not
+/// declared in their own package (`io.Reader`, a type union) are left out,
+/// as are empty interfaces, which every type satisfies.
+pub fn find_implementations(
+    root: &Path,
+    results: &[(PathBuf, AnalysisResult)],
+) -> BTreeMap<String, Vec<String>> {
+    let base = if root.is_file() {
+        root.parent().unwrap_or(root)
+    } else {
+        root
+    };
+    let module = find_go_module(base);
+    let files: Vec<(String, &AnalysisResult)> = results
+        .iter()
+        .filter(|(path, _)| lang::get_language_identifier(path) == "go")
+        .map(|(path, result)| {
+            let dir = path.parent().unwrap_or(path);
+            (package_of_dir(base, module.as_ref(), dir), result)
+        })
+        .collect();
+
+    let mut interfaces: HashMap<TypeName, &InterfaceInfo> = HashMap::new();
+    let mut concrete: BTreeSet<TypeName> = BTreeSet::new();
+
+    for (package, result) in &files {
+        for class in &result.classes {
+            let name = (package.clone(), class.name.as_str());
+            match &class.interface {
+                Some(interface) => {
+                    interfaces.insert(name, interface);
+                }
+                None => {
+                    concrete.insert(name);
+                }
+            }
+        }
+    }
+
+    let mut value_methods: HashMap<TypeName, BTreeSet<Signature>> = HashMap::new();
+    let mut pointer_methods: HashMap<TypeName, BTreeSet<Signature>> = HashMap::new();
+
+    for (package, result) in &files {
+        for func in &result.functions {
+            let Some(receiver) = &func.receiver else {
+                continue;
+            };
+            let methods = if receiver.trim_start().starts_with('*') {
+                &mut pointer_methods
+            } else {
+                &mut value_methods
+            };
+            methods
+                .entry((package.clone(), receiver_type_name(receiver)))
+                .or_default()
+                .insert(signature(func));
+        }
+    }
+
+    let mut implementations = BTreeMap::new();
+
+    for name in interfaces.keys() {
+        let Some(required) = method_set(name, &interfaces, &mut vec![]) else {
+            continue;
+        };
+        if required.is_empty() {
+            continue;
+        }
+
+        let mut implementors = Vec::new();
+        for type_name in &concrete {
+            let values = value_methods.get(type_name);
+            let pointers = pointer_methods.get(type_name);
+            let has = |method: &Signature, sets: &[Option<&BTreeSet<Signature>>]| {
+                sets.iter().flatten().any(|set| set.contains(method))
+            };
+
+            if required.iter().all(|m| has(m, &[values])) {
+                implementors.push(qualified(type_name));
+            } else if required.iter().all(|m| has(m, &[values, pointers])) {
+                implementors.push(format!("*{}", qualified(type_name)));
+            }
+        }
+
+        if !implementors.is_empty() {
+            implementations.insert(qualified(name), implementors);
+        }
+    }
+
+    implementations
+}
+
+/// `package.Name`, or just `Name` in the `.` package of a directory without
+/// a module
+fn qualified((package, name): &TypeName) -> String {
+    if package == "." {
+        name.to_string()
+    } else {
+        format!("{}.{}", package, name)
+    }
+}
+
+/// Full method set of an interface including embedded ones, or `None` if any
+/// embedded element cannot be resolved within the interface's package
+fn method_set<'a>(
+    name: &TypeName<'a>,
+    interfaces: &HashMap<TypeName<'a>, &'a InterfaceInfo>,
+    visiting: &mut Vec<TypeName<'a>>,
+) -> Option<BTreeSet<Signature>> {
+    if visiting.contains(name) {
+        return None;
+    }
+    let interface = interfaces.get(name)?;
+    visiting.push(name.clone());
+
+    let mut methods: BTreeSet<Signature> = interface.methods.iter().map(signature).collect();
+    for embedded in &interface.embedded {
+        let key = (name.0.clone(), embedded.trim());
+        let (embedded_name, _) = interfaces.get_key_value(&key)?;
+        methods.extend(method_set(embedded_name, interfaces, visiting)?);
+    }
+
+    visiting.pop();
+    Some(methods)
+}
+
+fn signature(func: &FunctionInfo) -> Signature {
+    let types = |params: &[ParamInfo]| {
+        params
+            .iter()
+            .map(|p| p.type_name.clone().unwrap_or_default())
+            .collect()
+    };
+    (func.name.clone(), types(&func.params), types(&func.returns))
+}
+
+/// Format implementations as an `IMPLEMENTATIONS:` section
+pub fn format_implementations(implementations: &BTreeMap<String, Vec<String>>) -> String {
+    if implementations.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nIMPLEMENTATIONS:\n");
+    for (interface, types) in implementations {
+        output.push_str(&format!("  {}: {}\n", interface, types.join(", ")));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::ClassInfo;
+
+    fn class(name: &str, interface: Option<InterfaceInfo>) -> ClassInfo {
+        ClassInfo {
+            name: name.into(),
+            line: 1,
+            methods: vec![],
+            fields: vec![],
+            exported: true,
+            interface,
+        }
+    }
+
+    /// `"x int"` becomes a named parameter, `"int"` an unnamed one
+    fn param(text: &str) -> ParamInfo {
+        match text.split_once(' ') {
+            Some((name, type_name)) => ParamInfo::named(name, Some(type_name)),
+            None => ParamInfo::unnamed(text),
+        }
+    }
+
+    fn method(
+        name: &str,
+        receiver: Option<&str>,
+        params: &[&str],
+        returns: &[&str],
+    ) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            receiver: receiver.map(|r| r.to_string()),
+            params: params.iter().map(|p| param(p)).collect(),
+            returns: returns.iter().map(|r| param(r)).collect(),
+            ..Default::default()
+        }
+    }
+
+    fn interface(methods: Vec<FunctionInfo>, embedded: &[&str]) -> Option<InterfaceInfo> {
+        Some(InterfaceInfo {
+            methods,
+            embedded: embedded.iter().map(|e| e.to_string()).collect(),
+        })
+    }
+
+    fn results(
+        classes: Vec<ClassInfo>,
+        functions: Vec<FunctionInfo>,
+    ) -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(10);
+        result.classes = classes;
+        result.functions = functions;
+        vec![(PathBuf::from("/p/a.go"), result)]
+    }
+
+    #[test]
+    fn pointer_receiver_methods_satisfy_through_pointer() {
+        let results = results(
+            vec![
+                class(
+                    "Speaker",
+                    interface(vec![method("Greet", None, &[], &["string"])], &[]),
+                ),
+                class("Greeter", None),
+            ],
+            vec![method("Greet", Some("*Greeter"), &[], &["string"])],
+        );
+        let implementations = find_implementations(Path::new("/p"), &results);
+        assert_eq!(implementations["Speaker"], vec!["*Greeter"]);
+    }
+
+    #[test]
+    fn value_receiver_methods_satisfy_directly() {
+        let results = results(
+            vec![
+                class(
+                    "Speaker",
+                    interface(vec![method("Greet", None, &[], &["string"])], &[]),
+                ),
+                class("Robot", None),
+            ],
+            vec![method("Greet", Some("Robot"), &[], &["string"])],
+        );
+        assert_eq!(
+            find_implementations(Path::new("/p"), &results)["Speaker"],
+            vec!["Robot"]
+        );
+    }
+
+    #[test]
+    fn signatures_must_match() {
+        let results = results(
+            vec![
+                class(
+                    "Namer",
+                    interface(vec![method("Name", None, &["id int"], &["string"])], &[]),
+                ),
+                class("Wrong", None),
+                class("Right", None),
+            ],
+            vec![
+                method("Name", Some("Wrong"), &["id string"], &["string"]),
+                method("Name", Some("Right"), &["n int"], &["string"]),
+            ],
+        );
+        assert_eq!(
+            find_implementations(Path::new("/p"), &results)["Namer"],
+            vec!["Right"]
+        );
+    }
+
+    #[test]
+    fn embedded_interfaces_add_their_methods() {
+        let results = results(
+            vec![
+                class(
+                    "Reader",
+                    interface(vec![method("Read", None, &[], &[])], &[]),
+                ),
+                class(
+                    "ReadCloser",
+                    interface(vec![method("Close", None, &[], &[])], &["Reader"]),
+                ),
+                class("File", None),
+                class("Stream", None),
+            ],
+            vec![
+                method("Read", Some("File"), &[], &[]),
+                method("Close", Some("File"), &[], &[]),
+                method("Read", Some("Stream"), &[], &[]),
+            ],
+        );
+        let implementations = find_implementations(Path::new("/p"), &results);
+        assert_eq!(implementations["ReadCloser"], vec!["File"]);
+        assert_eq!(implementations["Reader"], vec!["File", "Stream"]);
+    }
+
+    #[test]
+    fn unresolved_embeds_and_empty_interfaces_are_skipped() {
+        let results = results(
+            vec![
+                class(
+                    "ReadCloser",
+                    interface(vec![method("Close", None, &[], &[])], &["io.Reader"]),
+                ),
+                class("Any", interface(vec![], &[])),
+                class("File", None),
+            ],
+            vec![method("Close", Some("File"), &[], &[])],
+        );
+        assert!(find_implementations(Path::new("/p"), &results).is_empty());
+    }
+
+    #[test]
+    fn types_are_told_apart_by_package() {
+        let greet = || method("Greet", Some("Robot"), &[], &["string"]);
+        let speaker = || {
+            class(
+                "Speaker",
+                interface(vec![method("Greet", None, &[], &["string"])], &[]),
+            )
+        };
+        let mut root = results(vec![speaker(), class("Robot", None)], vec![]).remove(0);
+        root.0 = PathBuf::from("/p/robot.go");
+        let mut toy = results(vec![class("Robot", None)], vec![greet()]).remove(0);
+        toy.0 = PathBuf::from("/p/toy/robot.go");
+        let mut script = results(vec![speaker(), class("Robot", None)], vec![greet()]).remove(0);
+        script.0 = PathBuf::from("/p/robot.py");
+
+        let implementations = find_implementations(Path::new("/p"), &[root, toy, script]);
+        assert_eq!(implementations.len(), 1, "{:?}", implementations);
+        assert_eq!(implementations["Speaker"], vec!["toy.Robot"]);
+    }
+
+    #[test]
+    fn format_lists_interfaces_with_implementors() {
+        let mut implementations = BTreeMap::new();
+        implementations.insert(
+            "Speaker".to_string(),
+            vec!["*Greeter".to_string(), "Robot".to_string()],
+        );
+        assert_eq!(
+            format_implementations(&implementations),
+            "\nIMPLEMENTATIONS:\n  Speaker: *Greeter, Robot\n"
+        );
+        assert!(format_implementations(&BTreeMap::new()).is_empty());
+    }
+}
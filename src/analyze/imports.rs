@@ -0,0 +1,671 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Package-level import graph of the analyzed Go files.
+//!
+//! Each directory is one package. When a `go.mod` is found in the analyzed
+//! directory or above it, packages are named by import path and imports under
+//! the module path resolve to them; otherwise packages are named by directory
+//! relative to the analyzed root and an import resolves to one whose directory
+//! it ends with, e.g. `myapp/store` to `store`.
+
+use std::collections::{BTreeMap, BTreeSet, VecDeque};
+use std::path::{Path, PathBuf};
+
+use super::graph::dot_quote;
+use super::types::AnalysisResult;
+use crate::lang;
+
+/// How an imported package is bound in the importing file
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub enum ImportStyle {
+    /// `import "fmt"`
+    Regular,
+    /// `import f "fmt"`
+    Alias(String),
+    /// `import _ "embed"`, for side effects only
+    Blank,
+    /// `import . "math"`, merging the package's names into the file scope
+    Dot,
+}
+
+impl ImportStyle {
+    fn from_name(name: Option<String>) -> Self {
+        match name.as_deref() {
+            None => ImportStyle::Regular,
+            Some("_") => ImportStyle::Blank,
+            Some(".") => ImportStyle::Dot,
+            Some(_) => ImportStyle::Alias(name.unwrap_or_default()),
+        }
+    }
+
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            ImportStyle::Regular => "regular",
+            ImportStyle::Alias(_) => "alias",
+            ImportStyle::Blank => "blank",
+            ImportStyle::Dot => "dot",
+        }
+    }
+
+    /// Name the package is imported as, `_` or `.`, as written before the path
+    pub fn name(&self) -> Option<&str> {
+        match self {
+            ImportStyle::Regular => None,
+            ImportStyle::Alias(alias) => Some(alias),
+            ImportStyle::Blank => Some("_"),
+            ImportStyle::Dot => Some("."),
+        }
+    }
+}
+
+/// Where an imported package comes from
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub enum DependencyKind {
+    /// One of the analyzed packages
+    Local,
+    /// Go standard library: the first path element has no dot, e.g. `net/http`
+    Stdlib,
+    /// Any other module, e.g. `github.com/lib/pq`
+    ThirdParty,
+}
+
+impl DependencyKind {
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            DependencyKind::Local => "local",
+            DependencyKind::Stdlib => "stdlib",
+            DependencyKind::ThirdParty => "third-party",
+        }
+    }
+}
+
+/// A package → imported package edge
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub struct ImportEdge {
+    /// Importing package
+    pub package: String,
+    /// Import path as written
+    pub path: String,
+    /// Imported package: the analyzed package it resolves to, or `path`
+    pub target: String,
+    pub kind: DependencyKind,
+    pub style: ImportStyle,
+}
+
+/// `module` directive of a `go.mod` and the directory holding it
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(super) struct GoModule {
+    pub(super) dir: PathBuf,
+    pub(super) path: String,
+}
+
+#[derive(Debug, Clone, Default)]
+pub struct ImportGraph {
+    packages: BTreeSet<String>,
+    edges: BTreeSet<ImportEdge>,
+}
+
+impl ImportGraph {
+    /// Aggregate the imports of the Go files under `root` by package
+    pub fn build_from_results(root: &Path, results: &[(PathBuf, AnalysisResult)]) -> Self {
+        let base = if root.is_file() {
+            root.parent().unwrap_or(root)
+        } else {
+            root
+        };
+        let module = find_go_module(base);
+        Self::build(base, module.as_ref(), results)
+    }
+
+    fn build(
+        base: &Path,
+        module: Option<&GoModule>,
+        results: &[(PathBuf, AnalysisResult)],
+    ) -> Self {
+        let files: Vec<(String, &AnalysisResult)> = results
+            .iter()
+            .filter(|(path, _)| lang::get_language_identifier(path) == "go")
+            .map(|(path, result)| (package_of(base, module, path), result))
+            .collect();
+
+        let mut graph = Self {
+            packages: files.iter().map(|(package, _)| package.clone()).collect(),
+            edges: BTreeSet::new(),
+        };
+
+        for (package, result) in &files {
+            for declaration in &result.imports {
+                for (name, path) in parse_go_import_specs(declaration) {
+                    let (target, kind) = match graph.resolve_local(module, &path) {
+                        Some(target) => (target, DependencyKind::Local),
+                        None if is_standard_library(&path) => {
+                            (path.clone(), DependencyKind::Stdlib)
+                        }
+                        None => (path.clone(), DependencyKind::ThirdParty),
+                    };
+                    graph.edges.insert(ImportEdge {
+                        package: package.clone(),
+                        path,
+                        target,
+                        kind,
+                        style: ImportStyle::from_name(name),
+                    });
+                }
+            }
+        }
+
+        graph
+    }
+
+    /// Analyzed package an import path refers to
+    fn resolve_local(&self, module: Option<&GoModule>, path: &str) -> Option<String> {
+        match module {
+            Some(module) => {
+                let inside = path == module.path
+                    || path
+                        .strip_prefix(&module.path)
+                        .is_some_and(|rest| rest.starts_with('/'));
+                inside.then(|| path.to_string())
+            }
+            None => self
+                .packages
+                .iter()
+                .filter(|package| {
+                    package.as_str() != "." && path.ends_with(&format!("/{}", package))
+                })
+                .max_by_key(|package| package.len())
+                .cloned(),
+        }
+    }
+
+    /// Analyzed packages, sorted
+    pub fn packages(&self) -> impl Iterator<Item = &String> {
+        self.packages.iter()
+    }
+
+    /// Deduplicated import edges, sorted by importing package then path
+    pub fn edges(&self) -> impl Iterator<Item = &ImportEdge> {
+        self.edges.iter()
+    }
+
+    /// Import cycles between analyzed packages, one per strongly connected
+    /// group. Each cycle starts at its smallest package and lists the
+    /// packages in import order; the last one imports the first.
+    pub fn find_cycles(&self) -> Vec<Vec<String>> {
+        let mut imports: BTreeMap<&str, BTreeSet<&str>> = BTreeMap::new();
+        for edge in &self.edges {
+            // An external `_test` package importing the package in its own directory
+            if edge.kind == DependencyKind::Local && edge.package != edge.target {
+                imports
+                    .entry(edge.package.as_str())
+                    .or_default()
+                    .insert(edge.target.as_str());
+            }
+        }
+
+        let reachable: BTreeMap<&str, BTreeSet<&str>> = imports
+            .keys()
+            .map(|&package| (package, reachable_from(&imports, package)))
+            .collect();
+
+        let mut seen = BTreeSet::new();
+        let mut cycles = Vec::new();
+        for (&package, from_package) in &reachable {
+            if seen.contains(package) || !from_package.contains(package) {
+                continue;
+            }
+            let group: BTreeSet<&str> = from_package
+                .iter()
+                .copied()
+                .filter(|other| reachable.get(other).is_some_and(|r| r.contains(package)))
+                .collect();
+            seen.extend(group.iter().copied());
+            cycles.push(shortest_cycle(&imports, &group, package));
+        }
+        cycles
+    }
+
+    /// Render packages and imports as a Graphviz DOT digraph. Standard
+    /// library packages are dashed boxes and third-party ones solid boxes;
+    /// aliased, blank and dot imports carry their name as edge label.
+    pub fn to_dot(&self) -> String {
+        let mut output = String::from("digraph imports {\n");
+
+        for package in &self.packages {
+            output.push_str(&format!("    {};\n", dot_quote(package)));
+        }
+
+        let external: BTreeMap<&str, DependencyKind> = self
+            .edges
+            .iter()
+            .filter(|edge| edge.kind != DependencyKind::Local)
+            .map(|edge| (edge.target.as_str(), edge.kind))
+            .collect();
+        for (path, kind) in external {
+            let style = match kind {
+                DependencyKind::Stdlib => "shape=box, style=dashed",
+                _ => "shape=box",
+            };
+            output.push_str(&format!("    {} [{}];\n", dot_quote(path), style));
+        }
+
+        for edge in &self.edges {
+            let attributes = match &edge.style {
+                ImportStyle::Regular => String::new(),
+                ImportStyle::Alias(alias) => format!(" [label={}]", dot_quote(alias)),
+                ImportStyle::Blank => " [label=\"_\", style=dotted]".to_string(),
+                ImportStyle::Dot => " [label=\".\", style=bold]".to_string(),
+            };
+            output.push_str(&format!(
+                "    {} -> {}{};\n",
+                dot_quote(&edge.package),
+                dot_quote(&edge.target),
+                attributes
+            ));
+        }
+
+        output.push_str("}\n");
+        output
+    }
+}
+
+/// Package of a Go file: its directory as import path or relative path
+fn package_of(base: &Path, module: Option<&GoModule>, file: &Path) -> String {
+    package_of_dir(base, module, file.parent().unwrap_or(file))
+}
+
+/// Name of the package in `dir`: its import path under `module`, or else
+/// the directory relative to `base`
+pub(super) fn package_of_dir(base: &Path, module: Option<&GoModule>, dir: &Path) -> String {
+    let (root, prefix) = match module {
+        Some(module) => (module.dir.as_path(), Some(module.path.as_str())),
+        None => (base, None),
+    };
+    let relative = dir
+        .strip_prefix(root)
+        .unwrap_or(dir)
+        .components()
+        .map(|component| component.as_os_str().to_string_lossy())
+        .collect::<Vec<_>>()
+        .join("/");
+
+    match (prefix, relative.is_empty()) {
+        (Some(prefix), true) => prefix.to_string(),
+        (Some(prefix), false) => format!("{}/{}", prefix, relative),
+        (None, true) => ".".to_string(),
+        (None, false) => relative,
+    }
+}
+
+/// Nearest `go.mod` in `dir` or one of its parents
+pub(super) fn find_go_module(dir: &Path) -> Option<GoModule> {
+    dir.ancestors().find_map(|ancestor| {
+        let content = std::fs::read_to_string(ancestor.join("go.mod")).ok()?;
+        let path = content.lines().find_map(|line| {
+            let rest = line.trim().strip_prefix("module")?;
+            rest.starts_with(char::is_whitespace)
+                .then(|| rest.trim().trim_matches(['"', '`']).to_string())
+        })?;
+        Some(GoModule {
+            dir: ancestor.to_path_buf(),
+            path,
+        })
+    })
+}
+
+/// Go's own rule, as used by the `go` command: standard library paths have
+/// no dot in their first element
+fn is_standard_library(path: &str) -> bool {
+    !path.split('/').next().unwrap_or_default().contains('.')
+}
+
+/// The `(name, path)` specs of a Go import declaration such as
+/// `import (\n\t"fmt"\n\t_ "embed"\n)`
+pub(super) fn parse_go_import_specs(declaration: &str) -> Vec<(Option<String>, String)> {
+    let text = declaration.trim_start();
+    let text = text.strip_prefix("import").unwrap_or(text);
+
+    let mut specs = Vec::new();
+    let mut name = None;
+    let mut chars = text.char_indices().peekable();
+
+    while let Some((_, c)) = chars.next() {
+        match c {
+            '/' if chars.peek().is_some_and(|&(_, next)| next == '/') => {
+                while chars.next_if(|&(_, c)| c != '\n').is_some() {}
+            }
+            '/' if chars.peek().is_some_and(|&(_, next)| next == '*') => {
+                chars.next();
+                let mut previous = ' ';
+                for (_, c) in chars.by_ref() {
+                    if previous == '*' && c == '/' {
+                        break;
+                    }
+                    previous = c;
+                }
+            }
+            quote @ ('"' | '`') => {
+                let mut path = String::new();
+                while let Some((_, c)) = chars.next() {
+                    match c {
+                        '\\' if quote == '"' => {
+                            if let Some((_, escaped)) = chars.next() {
+                                path.push(escaped);
+                            }
+                        }
+                        c if c == quote => break,
+                        c => path.push(c),
+                    }
+                }
+                if !path.is_empty() {
+                    specs.push((name.take(), path));
+                }
+            }
+            '.' => name = Some(".".to_string()),
+            c if c == '_' || c.is_alphanumeric() => {
+                let mut word = c.to_string();
+                while let Some((_, c)) = chars.next_if(|&(_, c)| c == '_' || c.is_alphanumeric()) {
+                    word.push(c);
+                }
+                name = Some(word);
+            }
+            _ => {}
+        }
+    }
+
+    specs
+}
+
+/// Packages reachable from `start` through at least one import
+fn reachable_from<'a>(
+    imports: &BTreeMap<&'a str, BTreeSet<&'a str>>,
+    start: &str,
+) -> BTreeSet<&'a str> {
+    let mut reached = BTreeSet::new();
+    let mut stack: Vec<&str> = imports.get(start).into_iter().flatten().copied().collect();
+    while let Some(package) = stack.pop() {
+        if reached.insert(package) {
+            stack.extend(imports.get(package).into_iter().flatten().copied());
+        }
+    }
+    reached
+}
+
+/// Shortest import path from `start` back to itself within `group`
+fn shortest_cycle(
+    imports: &BTreeMap<&str, BTreeSet<&str>>,
+    group: &BTreeSet<&str>,
+    start: &str,
+) -> Vec<String> {
+    let mut previous: BTreeMap<&str, &str> = BTreeMap::new();
+    let mut queue = VecDeque::from([start]);
+
+    while let Some(package) = queue.pop_front() {
+        for &next in imports.get(package).into_iter().flatten() {
+            if !group.contains(next) || previous.contains_key(next) {
+                continue;
+            }
+            previous.insert(next, package);
+            if next == start {
+                queue.clear();
+                break;
+            }
+            queue.push_back(next);
+        }
+    }
+
+    let mut cycle = Vec::new();
+    let mut package = start;
+    while let Some(&before) = previous.get(package) {
+        cycle.push(before.to_string());
+        if before == start {
+            break;
+        }
+        package = before;
+    }
+    cycle.reverse();
+    cycle
+}
+
+/// Format the import graph as an `IMPORTS:` section with one block per
+/// package, imports written as in Go source, followed by `IMPORT CYCLES:`
+/// when there are any
+pub fn format_import_graph(graph: &ImportGraph) -> String {
+    if graph.edges.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nIMPORTS:\n");
+    let mut current = None;
+    for edge in &graph.edges {
+        if current != Some(&edge.package) {
+            output.push_str(&format!("  {}\n", edge.package));
+            current = Some(&edge.package);
+        }
+        let name = edge
+            .style
+            .name()
+            .map(|name| format!("{} ", name))
+            .unwrap_or_default();
+        output.push_str(&format!(
+            "    {}{} ({})\n",
+            name,
+            edge.path,
+            edge.kind.as_str()
+        ));
+    }
+
+    let cycles = graph.find_cycles();
+    if !cycles.is_empty() {
+        output.push_str("\nIMPORT CYCLES:\n");
+        for cycle in cycles {
+            output.push_str(&format!("  {} -> {}\n", cycle.join(" -> "), cycle[0]));
+        }
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn go_file(path: &str, imports: &[&str]) -> (PathBuf, AnalysisResult) {
+        let mut result = AnalysisResult::empty(10);
+        result.imports = imports.iter().map(|i| i.to_string()).collect();
+        result.import_count = imports.len();
+        (PathBuf::from(path), result)
+    }
+
+    fn module() -> GoModule {
+        GoModule {
+            dir: PathBuf::from("/p"),
+            path: "example.com/app".into(),
+        }
+    }
+
+    fn edges(graph: &ImportGraph) -> Vec<(&str, &str, &str, &str)> {
+        graph
+            .edges()
+            .map(|e| {
+                (
+                    e.package.as_str(),
+                    e.target.as_str(),
+                    e.kind.as_str(),
+                    e.style.as_str(),
+                )
+            })
+            .collect()
+    }
+
+    #[test]
+    fn parses_every_spec_form() {
+        let specs = parse_go_import_specs(
+            "import (\n\t\"fmt\" // printing\n\tm \"math\"\n\t_ \"embed\"\n\t. `strings`\n\t/* old */ \"os\"\n)",
+        );
+        assert_eq!(
+            specs,
+            vec![
+                (None, "fmt".to_string()),
+                (Some("m".to_string()), "math".to_string()),
+                (Some("_".to_string()), "embed".to_string()),
+                (Some(".".to_string()), "strings".to_string()),
+                (None, "os".to_string()),
+            ]
+        );
+        assert_eq!(
+            parse_go_import_specs("import \"fmt\""),
+            vec![(None, "fmt".to_string())]
+        );
+    }
+
+    #[test]
+    fn sample_depends_on_fmt_from_the_standard_library() {
+        let graph = ImportGraph::build(
+            Path::new("/p"),
+            None,
+            &[go_file("/p/sample.go", &["import \"fmt\""])],
+        );
+        assert_eq!(graph.packages().collect::<Vec<_>>(), vec!["."]);
+        assert_eq!(edges(&graph), vec![(".", "fmt", "stdlib", "regular")]);
+    }
+
+    #[test]
+    fn classifies_and_labels_dependencies() {
+        let results = vec![
+            go_file(
+                "/p/main.go",
+                &[
+                    "import (\n\t\"net/http\"\n\t_ \"github.com/lib/pq\"\n\tdb \"example.com/app/store\"\n)",
+                ],
+            ),
+            go_file("/p/store/store.go", &["import . \"example.com/app/util\""]),
+            go_file("/p/notes.md", &["import \"ignored\""]),
+        ];
+        let graph = ImportGraph::build(Path::new("/p"), Some(&module()), &results);
+        assert_eq!(
+            graph.packages().collect::<Vec<_>>(),
+            vec!["example.com/app", "example.com/app/store"]
+        );
+        assert_eq!(
+            edges(&graph),
+            vec![
+                ("example.com/app", "example.com/app/store", "local", "alias"),
+                (
+                    "example.com/app",
+                    "github.com/lib/pq",
+                    "third-party",
+                    "blank"
+                ),
+                ("example.com/app", "net/http", "stdlib", "regular"),
+                (
+                    "example.com/app/store",
+                    "example.com/app/util",
+                    "local",
+                    "dot"
+                ),
+            ]
+        );
+    }
+
+    #[test]
+    fn without_go_mod_imports_resolve_by_directory_suffix() {
+        let results = vec![
+            go_file("/p/main.go", &["import \"myapp/store\""]),
+            go_file("/p/store/store.go", &["import \"log\""]),
+        ];
+        let graph = ImportGraph::build(Path::new("/p"), None, &results);
+        assert_eq!(
+            edges(&graph),
+            vec![
+                (".", "store", "local", "regular"),
+                ("store", "log", "stdlib", "regular")
+            ]
+        );
+    }
+
+    #[test]
+    fn finds_cycles_between_packages() {
+        let results = vec![
+            go_file("/p/a/a.go", &["import \"example.com/app/b\""]),
+            go_file("/p/b/b.go", &["import \"example.com/app/c\""]),
+            go_file(
+                "/p/c/c.go",
+                &["import (\n\t\"example.com/app/a\"\n\t\"fmt\"\n)"],
+            ),
+            go_file("/p/d/d.go", &["import \"example.com/app/a\""]),
+            // External test package in the same directory
+            go_file("/p/d/d_test.go", &["import \"example.com/app/d\""]),
+        ];
+        let graph = ImportGraph::build(Path::new("/p"), Some(&module()), &results);
+        assert_eq!(
+            graph.find_cycles(),
+            vec![vec![
+                "example.com/app/a",
+                "example.com/app/b",
+                "example.com/app/c"
+            ]]
+        );
+
+        let text = format_import_graph(&graph);
+        assert!(text.contains(
+            "\nIMPORT CYCLES:\n  example.com/app/a -> example.com/app/b -> example.com/app/c -> example.com/app/a\n"
+        ), "{text}");
+    }
+
+    #[test]
+    fn format_writes_imports_as_in_source() {
+        let results = vec![go_file(
+            "/p/main.go",
+            &["import (\n\t\"fmt\"\n\t_ \"github.com/lib/pq\"\n)"],
+        )];
+        let graph = ImportGraph::build(Path::new("/p"), None, &results);
+        assert_eq!(
+            format_import_graph(&graph),
+            "\nIMPORTS:\n  .\n    fmt (stdlib)\n    _ github.com/lib/pq (third-party)\n"
+        );
+        assert!(format_import_graph(&ImportGraph::default()).is_empty());
+    }
+
+    #[test]
+    fn to_dot_styles_nodes_and_labels_edges() {
+        let results = vec![go_file(
+            "/p/main.go",
+            &["import (\n\t\"fmt\"\n\t_ \"github.com/lib/pq\"\n\t. \"math\"\n)"],
+        )];
+        let dot = ImportGraph::build(Path::new("/p"), None, &results).to_dot();
+        assert!(dot.starts_with("digraph imports {\n    \".\";\n"), "{dot}");
+        assert!(
+            dot.contains("    \"fmt\" [shape=box, style=dashed];\n"),
+            "{dot}"
+        );
+        assert!(
+            dot.contains("    \"github.com/lib/pq\" [shape=box];\n"),
+            "{dot}"
+        );
+        assert!(dot.contains("    \".\" -> \"fmt\";\n"), "{dot}");
+        assert!(
+            dot.contains("    \".\" -> \"github.com/lib/pq\" [label=\"_\", style=dotted];\n"),
+            "{dot}"
+        );
+        assert!(
+            dot.contains("    \".\" -> \"math\" [label=\".\", style=bold];\n"),
+            "{dot}"
+        );
+        assert!(dot.ends_with("}\n"));
+    }
+
+    #[test]
+    fn reads_module_path_from_go_mod() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("go.mod"),
+            "// app\nmodule example.com/app\n\ngo 1.22\n",
+        )
+        .unwrap();
+        std::fs::create_dir(dir.path().join("cmd")).unwrap();
+        let module = find_go_module(&dir.path().join("cmd")).unwrap();
+        assert_eq!(module.path, "example.com/app");
+        assert_eq!(module.dir, dir.path());
+    }
+}
@@ -2,6 +2,15 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+use std::collections::{HashMap, HashSet};
+
+use crate::analyze::api::receiver_type_name;
+use crate::analyze::types::{
+    DiscardedCall, EmptyInterface, EmptyInterfaceUsage, ErrorReturn, FieldAccess, FieldInfo,
+    FunctionInfo, InterfaceInfo, PackageClause, ParamInfo, ShadowInfo, SliceAppend, StringConcat,
+    UnreachableCode,
+};
+
 /// Tree-sitter query for extracting Go code elements
 pub const ELEMENT_QUERY: &str = r#"
     (function_declaration name: (identifier) @func)
@@ -96,3 +105,1745 @@ pub fn find_method_for_receiver(
     }
     None
 }
+
+/// Find the receiver type of a Go method declaration, e.g. `*Greeter`
+pub fn find_function_receiver(node: &tree_sitter::Node, source: &str) -> Option<String> {
+    let receiver = node.child_by_field_name("receiver")?;
+    (0..receiver.child_count() as u32)
+        .filter_map(|i| receiver.child(i))
+        .find(|child| child.kind() == "parameter_declaration")
+        .and_then(|param| param.child_by_field_name("type"))
+        .and_then(|type_node| source.get(type_node.byte_range()))
+        .map(|s| s.to_string())
+}
+
+/// Extract parameters and results from a Go function or method declaration
+pub fn extract_signature(
+    node: &tree_sitter::Node,
+    source: &str,
+) -> (Vec<ParamInfo>, Vec<ParamInfo>) {
+    let params = node
+        .child_by_field_name("parameters")
+        .map(|list| expand_parameter_list(&list, source))
+        .unwrap_or_default();
+
+    let returns = match node.child_by_field_name("result") {
+        Some(result) if result.kind() == "parameter_list" => expand_parameter_list(&result, source),
+        Some(result) => source
+            .get(result.byte_range())
+            .map(|s| vec![ParamInfo::unnamed(s)])
+            .unwrap_or_default(),
+        None => vec![],
+    };
+
+    (params, returns)
+}
+
+/// Expand a Go parameter list so that grouped declarations like `(a, b int)`
+/// produce one entry per name
+fn expand_parameter_list(list: &tree_sitter::Node, source: &str) -> Vec<ParamInfo> {
+    let mut entries = Vec::new();
+
+    for param in (0..list.child_count() as u32).filter_map(|i| list.child(i)) {
+        let variadic = match param.kind() {
+            "parameter_declaration" => false,
+            "variadic_parameter_declaration" => true,
+            _ => continue,
+        };
+
+        let type_text = param
+            .child_by_field_name("type")
+            .and_then(|type_node| source.get(type_node.byte_range()))
+            .unwrap_or("");
+        let type_text = if variadic {
+            format!("...{}", type_text)
+        } else {
+            type_text.to_string()
+        };
+
+        let names: Vec<&str> = (0..param.child_count() as u32)
+            .filter_map(|i| param.child(i))
+            .filter(|child| child.kind() == "identifier")
+            .filter_map(|child| source.get(child.byte_range()))
+            .collect();
+
+        if names.is_empty() {
+            entries.push(ParamInfo::unnamed(&type_text));
+        } else {
+            for name in names {
+                entries.push(ParamInfo::named(name, Some(&type_text)));
+            }
+        }
+    }
+
+    entries
+}
+
+/// Whether a Go declaration is visible outside its package or is an entry point
+pub fn is_exported(_node: &tree_sitter::Node, name: &str, _source: &str) -> bool {
+    name.starts_with(char::is_uppercase) || name == "main" || name == "init"
+}
+
+/// Extract the fields of a struct type spec. Embedded fields are named after
+/// their type, so `*pkg.Base` becomes `Base`.
+pub fn extract_fields(node: &tree_sitter::Node, source: &str) -> Vec<FieldInfo> {
+    let Some(list) = node
+        .child_by_field_name("type")
+        .filter(|type_node| type_node.kind() == "struct_type")
+        .and_then(|struct_type| {
+            (0..struct_type.child_count() as u32)
+                .filter_map(|i| struct_type.child(i))
+                .find(|child| child.kind() == "field_declaration_list")
+        })
+    else {
+        return vec![];
+    };
+
+    let mut fields = Vec::new();
+
+    for decl in (0..list.child_count() as u32).filter_map(|i| list.child(i)) {
+        if decl.kind() != "field_declaration" {
+            continue;
+        }
+
+        let type_text = decl
+            .child_by_field_name("type")
+            .and_then(|type_node| source.get(type_node.byte_range()));
+        let tag = decl
+            .child_by_field_name("tag")
+            .and_then(|tag| source.get(tag.byte_range()))
+            .and_then(unquote);
+
+        let mut names: Vec<&str> = (0..decl.child_count() as u32)
+            .filter_map(|i| decl.child(i))
+            .filter(|child| child.kind() == "field_identifier")
+            .filter_map(|child| source.get(child.byte_range()))
+            .collect();
+
+        let embedded = names.is_empty();
+        if embedded && let Some(type_text) = type_text {
+            let base = type_text.trim_start_matches('*');
+            let base = base.split('[').next().unwrap_or(base);
+            names.push(base.rsplit('.').next().unwrap_or(base));
+        }
+
+        for name in names {
+            fields.push(FieldInfo {
+                name: name.to_string(),
+                line: decl.start_position().row + 1,
+                type_name: type_text.map(|s| s.to_string()),
+                exported: name.starts_with(char::is_uppercase),
+                tag: tag.clone(),
+                embedded,
+            });
+        }
+    }
+
+    fields
+}
+
+/// Value of `key` in a struct tag, following `reflect.StructTag.Lookup`:
+/// space-separated `key:"value"` pairs where the value is a quoted string.
+/// Parsing stops at the first malformed pair.
+pub fn struct_tag_lookup(tag: &str, key: &str) -> Option<String> {
+    let mut rest = tag;
+
+    loop {
+        rest = rest.trim_start_matches(' ');
+        if rest.is_empty() {
+            return None;
+        }
+
+        let name_len = rest
+            .find(|c: char| c <= ' ' || c == ':' || c == '"' || c == '\x7f')
+            .unwrap_or(rest.len());
+        if name_len == 0 || !rest[name_len..].starts_with(":\"") {
+            return None;
+        }
+        let name = &rest[..name_len];
+        rest = &rest[name_len + 1..];
+
+        // Find the closing quote, skipping escaped characters
+        let bytes = rest.as_bytes();
+        let mut end = 1;
+        while end < bytes.len() && bytes[end] != b'"' {
+            if bytes[end] == b'\\' {
+                end += 1;
+            }
+            end += 1;
+        }
+        if end >= bytes.len() {
+            return None;
+        }
+        let quoted = &rest[..=end];
+        rest = &rest[end + 1..];
+
+        if name == key {
+            return unquote(quoted);
+        }
+    }
+}
+
+/// Contents of a Go string literal, either raw (backquoted) or interpreted
+/// with the common escapes; `None` if it is not a well-formed literal
+fn unquote(literal: &str) -> Option<String> {
+    if let Some(raw) = literal
+        .strip_prefix('`')
+        .and_then(|rest| rest.strip_suffix('`'))
+    {
+        return Some(raw.to_string());
+    }
+
+    let inner = literal.strip_prefix('"')?.strip_suffix('"')?;
+    let mut value = String::with_capacity(inner.len());
+    let mut chars = inner.chars();
+    while let Some(c) = chars.next() {
+        if c != '\\' {
+            value.push(c);
+            continue;
+        }
+        value.push(match chars.next()? {
+            'n' => '\n',
+            't' => '\t',
+            'r' => '\r',
+            escaped @ ('"' | '\\') => escaped,
+            _ => return None,
+        });
+    }
+    Some(value)
+}
+
+/// Extract the method set an interface type spec declares. Embedded
+/// interfaces and type constraints are kept as written so callers can
+/// resolve them.
+pub fn extract_interface(node: &tree_sitter::Node, source: &str) -> Option<InterfaceInfo> {
+    let interface = node
+        .child_by_field_name("type")
+        .filter(|type_node| type_node.kind() == "interface_type")?;
+
+    let mut info = InterfaceInfo::default();
+
+    for elem in (0..interface.child_count() as u32).filter_map(|i| interface.child(i)) {
+        match elem.kind() {
+            "method_elem" => {
+                let Some(name) = elem
+                    .child_by_field_name("name")
+                    .and_then(|name| source.get(name.byte_range()))
+                else {
+                    continue;
+                };
+                let (params, returns) = extract_signature(&elem, source);
+                info.methods.push(FunctionInfo {
+                    name: name.to_string(),
+                    line: elem.start_position().row + 1,
+                    end_line: elem.end_position().row + 1,
+                    params,
+                    returns,
+                    exported: name.starts_with(char::is_uppercase),
+                    ..Default::default()
+                });
+            }
+            "type_elem" => {
+                if let Some(text) = source.get(elem.byte_range()) {
+                    info.embedded.push(text.to_string());
+                }
+            }
+            _ => {}
+        }
+    }
+
+    Some(info)
+}
+
+/// Find the name a Go method binds its receiver to, e.g. `g` in `func (g *Greeter)`.
+/// Unnamed receivers like `func (*Greeter)` have none.
+pub fn find_receiver_name(node: &tree_sitter::Node, source: &str) -> Option<String> {
+    let receiver = node.child_by_field_name("receiver")?;
+    (0..receiver.child_count() as u32)
+        .filter_map(|i| receiver.child(i))
+        .find(|child| child.kind() == "parameter_declaration")
+        .and_then(|param| param.child_by_field_name("name"))
+        .and_then(|name| source.get(name.byte_range()))
+        .map(|s| s.to_string())
+}
+
+/// Struct field accesses of a Go file, in source order: the field of every
+/// selector such as `g.name`, resolved to the receiver type when `g` is the
+/// receiver of the enclosing method, and the keys of composite literals such
+/// as `Greeter{name: "x"}`, resolved to the literal type. An unkeyed literal
+/// of a named type sets all its fields. Selectors on any other value, which
+/// may be a package as well as a struct, are left unresolved.
+pub fn find_field_accesses(root: &tree_sitter::Node, source: &str) -> Vec<FieldAccess> {
+    let text = |node: tree_sitter::Node| source.get(node.byte_range()).unwrap_or_default();
+    let mut accesses = Vec::new();
+    // Nodes with the receiver binding and type of their enclosing method
+    let mut stack: Vec<(tree_sitter::Node, Option<(String, String)>)> = vec![(*root, None)];
+
+    while let Some((node, receiver)) = stack.pop() {
+        let receiver = if node.kind() == "method_declaration" {
+            find_receiver_name(&node, source).zip(
+                find_function_receiver(&node, source)
+                    .map(|receiver| receiver_type_name(&receiver).to_string()),
+            )
+        } else {
+            receiver
+        };
+
+        match node.kind() {
+            "selector_expression" => {
+                if let Some(field) = node.child_by_field_name("field") {
+                    let operand = node.child_by_field_name("operand");
+                    let type_name = receiver
+                        .as_ref()
+                        .filter(|(name, _)| {
+                            operand.is_some_and(|o| o.kind() == "identifier" && text(o) == name)
+                        })
+                        .map(|(_, type_name)| type_name.clone());
+                    accesses.push(FieldAccess {
+                        field: Some(text(field).to_string()),
+                        type_name,
+                        line: field.start_position().row + 1,
+                    });
+                }
+            }
+            "composite_literal" => accesses.extend(composite_literal_fields(&node, source)),
+            _ => {}
+        }
+
+        let children: Vec<_> = (0..node.child_count() as u32)
+            .filter_map(|i| node.child(i))
+            .collect();
+        stack.extend(
+            children
+                .into_iter()
+                .rev()
+                .map(|child| (child, receiver.clone())),
+        );
+    }
+
+    accesses
+}
+
+/// Fields a composite literal sets. Literals with an elided type, as in
+/// `[]Point{{1, 2}}`, only contribute their keys, unresolved.
+fn composite_literal_fields(node: &tree_sitter::Node, source: &str) -> Vec<FieldAccess> {
+    let text = |node: tree_sitter::Node| source.get(node.byte_range()).unwrap_or_default();
+    let type_name = node
+        .child_by_field_name("type")
+        .and_then(|type_node| match type_node.kind() {
+            "type_identifier" => Some(type_node),
+            "generic_type" => type_node.child_by_field_name("type"),
+            _ => None,
+        })
+        .map(|type_node| text(type_node).to_string());
+    let Some(body) = node.child_by_field_name("body") else {
+        return vec![];
+    };
+
+    let elements: Vec<_> = (0..body.named_child_count() as u32)
+        .filter_map(|i| body.named_child(i))
+        .filter(|element| element.kind() != "comment")
+        .collect();
+    let keys: Vec<FieldAccess> = elements
+        .iter()
+        .filter(|element| element.kind() == "keyed_element")
+        .filter_map(|element| {
+            let key = element.named_child(0)?;
+            let key = match key.kind() {
+                "literal_element" => key.named_child(0)?,
+                _ => key,
+            };
+            matches!(key.kind(), "identifier" | "field_identifier").then(|| FieldAccess {
+                field: Some(text(key).to_string()),
+                type_name: type_name.clone(),
+                line: key.start_position().row + 1,
+            })
+        })
+        .collect();
+
+    if keys.is_empty() && !elements.is_empty() && type_name.is_some() {
+        return vec![FieldAccess {
+            field: None,
+            type_name,
+            line: node.start_position().row + 1,
+        }];
+    }
+    keys
+}
+
+/// Name and line of the `package` clause of a Go file given its root node
+pub fn find_package_clause(root: &tree_sitter::Node, source: &str) -> Option<PackageClause> {
+    let clause = named_children(root)
+        .into_iter()
+        .find(|child| child.kind() == "package_clause")?;
+    let name = named_children(&clause)
+        .into_iter()
+        .find(|child| child.kind() == "package_identifier")?;
+    Some(PackageClause {
+        name: source.get(name.byte_range())?.to_string(),
+        line: clause.start_position().row + 1,
+    })
+}
+
+/// Empty interface types, `interface{}` and `any`, of a Go file given its
+/// root node, each labelled by where it appears and the declaration around
+/// it. Type parameter lists are skipped: `[T any]` constrains nothing but
+/// keeps the code type-safe.
+pub fn find_empty_interfaces(root: &tree_sitter::Node, source: &str) -> Vec<EmptyInterface> {
+    let text = |node: tree_sitter::Node| source.get(node.byte_range()).unwrap_or_default();
+    let mut found = Vec::new();
+    // Nodes with the name of their enclosing declaration
+    let mut stack: Vec<(tree_sitter::Node, String)> = vec![(*root, String::new())];
+
+    while let Some((node, declaration)) = stack.pop() {
+        let declaration = match node.kind() {
+            "function_declaration" | "method_declaration" | "type_spec" | "type_alias" => node
+                .child_by_field_name("name")
+                .map(|name| text(name).to_string())
+                .unwrap_or(declaration),
+            "var_spec" | "const_spec" if declaration.is_empty() => node
+                .child_by_field_name("name")
+                .map(|name| text(name).to_string())
+                .unwrap_or_default(),
+            _ => declaration,
+        };
+
+        let empty = match node.kind() {
+            "type_parameter_list" => continue,
+            "type_identifier" => text(node) == "any",
+            "interface_type" => named_children(&node)
+                .iter()
+                .all(|child| child.kind() == "comment"),
+            _ => false,
+        };
+        if empty {
+            let start = node.start_position();
+            found.push(EmptyInterface {
+                usage: empty_interface_usage(&node),
+                declaration,
+                line: start.row + 1,
+                column: start.column + 1,
+            });
+            continue;
+        }
+
+        let children: Vec<_> = (0..node.child_count() as u32)
+            .filter_map(|i| node.child(i))
+            .collect();
+        stack.extend(
+            children
+                .into_iter()
+                .rev()
+                .map(|child| (child, declaration.clone())),
+        );
+    }
+
+    found
+}
+
+/// Where a type appears, judged by the node holding it
+fn empty_interface_usage(node: &tree_sitter::Node) -> EmptyInterfaceUsage {
+    let Some(parent) = node.parent() else {
+        return EmptyInterfaceUsage::Other;
+    };
+    match parent.kind() {
+        "map_type"
+        | "slice_type"
+        | "array_type"
+        | "implicit_length_array_type"
+        | "channel_type" => EmptyInterfaceUsage::Element,
+        "type_assertion_expression" | "type_case" => EmptyInterfaceUsage::Assertion,
+        "field_declaration" => EmptyInterfaceUsage::Field,
+        "parameter_declaration" | "variadic_parameter_declaration" => match parent.parent() {
+            Some(list) if is_result(&list) => EmptyInterfaceUsage::Result,
+            _ => EmptyInterfaceUsage::Parameter,
+        },
+        _ if is_result(node) => EmptyInterfaceUsage::Result,
+        _ => EmptyInterfaceUsage::Other,
+    }
+}
+
+/// Whether a node is the `result` of the signature holding it
+fn is_result(node: &tree_sitter::Node) -> bool {
+    node.parent()
+        .and_then(|parent| parent.child_by_field_name("result"))
+        .is_some_and(|result| result.byte_range() == node.byte_range())
+}
+
+/// Lines of the bare `return` statements of a Go function declaration, not
+/// counting those of function literals inside it
+pub fn find_naked_returns(node: &tree_sitter::Node) -> Vec<usize> {
+    let mut lines = Vec::new();
+    let mut stack: Vec<tree_sitter::Node> = node.child_by_field_name("body").into_iter().collect();
+
+    while let Some(current) = stack.pop() {
+        match current.kind() {
+            "func_literal" => continue,
+            "return_statement" if current.named_child_count() == 0 => {
+                lines.push(current.start_position().row + 1);
+                continue;
+            }
+            _ => {}
+        }
+        stack.extend((0..current.child_count() as u32).filter_map(|i| current.child(i)));
+    }
+
+    lines.sort_unstable();
+    lines
+}
+
+/// Calls in a Go function declaration whose results are not all kept: call
+/// statements, `defer` and `go` statements, and assignments or `var`
+/// declarations giving some results to `_`. Ordered by position; calls in
+/// function literals count as the declaration's own.
+pub fn find_discarded_calls(node: &tree_sitter::Node, source: &str) -> Vec<DiscardedCall> {
+    let mut calls = Vec::new();
+    let mut stack: Vec<tree_sitter::Node> = node.child_by_field_name("body").into_iter().collect();
+
+    while let Some(current) = stack.pop() {
+        match current.kind() {
+            "expression_statement" | "defer_statement" | "go_statement" => {
+                if let Some(call) = current
+                    .named_child(0)
+                    .filter(|child| child.kind() == "call_expression")
+                {
+                    let deferred = current.kind() == "defer_statement";
+                    calls.extend(discarded_call(&call, source, deferred, None, 0));
+                }
+            }
+            "assignment_statement" | "short_var_declaration" => {
+                let is_plain = current
+                    .child_by_field_name("operator")
+                    .is_none_or(|operator| operator.kind() == "=");
+                if let (true, Some(left), Some(right)) = (
+                    is_plain,
+                    current.child_by_field_name("left"),
+                    current.child_by_field_name("right"),
+                ) {
+                    let targets = named_children(&left);
+                    calls.extend(blank_assignments(&targets, &named_children(&right), source));
+                }
+            }
+            "var_spec" => {
+                if let Some(value) = current.child_by_field_name("value") {
+                    let targets: Vec<_> = (0..current.child_count() as u32)
+                        .filter(|&i| current.field_name_for_child(i) == Some("name"))
+                        .filter_map(|i| current.child(i))
+                        .collect();
+                    calls.extend(blank_assignments(&targets, &named_children(&value), source));
+                }
+            }
+            _ => {}
+        }
+        stack.extend((0..current.child_count() as u32).filter_map(|i| current.child(i)));
+    }
+
+    calls.sort_by_key(|call| (call.line, call.column));
+    calls
+}
+
+fn named_children<'a>(node: &tree_sitter::Node<'a>) -> Vec<tree_sitter::Node<'a>> {
+    (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i))
+        .filter(|child| child.is_named() && !child.kind().contains("comment"))
+        .collect()
+}
+
+/// Calls among `values` with a result assigned to a blank target: either one
+/// call spread over every target, or one value per target
+fn blank_assignments(
+    targets: &[tree_sitter::Node],
+    values: &[tree_sitter::Node],
+    source: &str,
+) -> Vec<DiscardedCall> {
+    let is_blank = |node: &tree_sitter::Node| source.get(node.byte_range()) == Some("_");
+
+    match values {
+        [call] if call.kind() == "call_expression" => {
+            let blanks: Vec<usize> = (0..targets.len())
+                .filter(|&i| is_blank(&targets[i]))
+                .collect();
+            if blanks.is_empty() {
+                return vec![];
+            }
+            discarded_call(call, source, false, Some(blanks), targets.len())
+                .into_iter()
+                .collect()
+        }
+        _ if values.len() == targets.len() => targets
+            .iter()
+            .zip(values)
+            .filter(|(target, value)| is_blank(target) && value.kind() == "call_expression")
+            .filter_map(|(_, call)| discarded_call(call, source, false, Some(vec![0]), 1))
+            .collect(),
+        _ => vec![],
+    }
+}
+
+fn discarded_call(
+    call: &tree_sitter::Node,
+    source: &str,
+    deferred: bool,
+    blank_results: Option<Vec<usize>>,
+    assigned: usize,
+) -> Option<DiscardedCall> {
+    let callee = source.get(call.child_by_field_name("function")?.byte_range())?;
+    let text = source.get(call.byte_range())?;
+    let call_text = match text.split_once('\n') {
+        Some((first, _)) => format!("{} ...", first.trim_end()),
+        None => text.to_string(),
+    };
+    let start = call.start_position();
+    Some(DiscardedCall {
+        callee: callee.to_string(),
+        call: call_text,
+        line: start.row + 1,
+        column: start.column + 1,
+        deferred,
+        blank_results,
+        assigned,
+    })
+}
+
+/// Node kinds that open a lexical scope for local declarations
+const SCOPE_KINDS: &[&str] = &[
+    "function_declaration",
+    "method_declaration",
+    "func_literal",
+    "block",
+    "if_statement",
+    "for_statement",
+    "expression_switch_statement",
+    "type_switch_statement",
+    "select_statement",
+    "expression_case",
+    "type_case",
+    "default_case",
+    "communication_case",
+];
+
+const FUNCTION_KINDS: &[&str] = &["function_declaration", "method_declaration", "func_literal"];
+
+enum Visit<'a> {
+    Enter(tree_sitter::Node<'a>),
+    Leave,
+}
+
+/// A local declaration: its position and whether a loop declares it
+#[derive(Clone, Copy)]
+struct Declaration {
+    point: tree_sitter::Point,
+    loop_variable: bool,
+}
+
+/// Find local declarations that shadow a variable of an enclosing scope.
+///
+/// Scopes follow the Go spec: each function (parameters, receiver and named
+/// results share the scope of its body), block, `if`, `for`, `switch` and
+/// `select` statement and case clause. A name is only visible after its
+/// declaration, and `:=` only declares the names not already in the current
+/// scope. Package-level declarations are not considered, and neither are
+/// the deliberate copies `x := x` and `switch x := x.(type)`.
+pub fn find_shadowed(root: &tree_sitter::Node, source: &str) -> Vec<ShadowInfo> {
+    let mut shadowed = Vec::new();
+    let mut scopes: Vec<HashMap<&str, Declaration>> = Vec::new();
+    let mut stack = vec![Visit::Enter(*root)];
+
+    while let Some(visit) = stack.pop() {
+        let node = match visit {
+            Visit::Enter(node) => node,
+            Visit::Leave => {
+                scopes.pop();
+                continue;
+            }
+        };
+
+        // A function body shares the scope of the parameters
+        let is_body = node.kind() == "block"
+            && node
+                .parent()
+                .is_some_and(|parent| FUNCTION_KINDS.contains(&parent.kind()));
+        let opens_scope = SCOPE_KINDS.contains(&node.kind()) && !is_body;
+        if opens_scope {
+            scopes.push(HashMap::new());
+        }
+
+        if !scopes.is_empty() {
+            let loop_variable = matches!(node.kind(), "range_clause")
+                || (node.kind() == "short_var_declaration"
+                    && node.parent().is_some_and(|p| p.kind() == "for_clause"));
+
+            let declared = if is_idiomatic_redeclaration(&node, source) {
+                vec![]
+            } else {
+                declared_identifiers(&node)
+            };
+            for ident in declared {
+                let Some(name) = source.get(ident.byte_range()) else {
+                    continue;
+                };
+                let Some((current, enclosing)) = scopes.split_last_mut() else {
+                    continue;
+                };
+                if name == "_" || current.contains_key(name) {
+                    continue;
+                }
+
+                if let Some(outer) = enclosing.iter().rev().find_map(|scope| scope.get(name)) {
+                    shadowed.push(ShadowInfo {
+                        name: name.to_string(),
+                        line: ident.start_position().row + 1,
+                        column: ident.start_position().column + 1,
+                        shadowed_line: outer.point.row + 1,
+                        shadowed_column: outer.point.column + 1,
+                        loop_variable: loop_variable || outer.loop_variable,
+                    });
+                }
+                current.insert(
+                    name,
+                    Declaration {
+                        point: ident.start_position(),
+                        loop_variable,
+                    },
+                );
+            }
+        }
+
+        if opens_scope {
+            stack.push(Visit::Leave);
+        }
+        let children: Vec<_> = (0..node.child_count() as u32)
+            .filter_map(|i| node.child(i))
+            .collect();
+        stack.extend(children.into_iter().rev().map(Visit::Enter));
+    }
+
+    shadowed.sort_by_key(|s| (s.line, s.column));
+    shadowed
+}
+
+/// Identifiers a node declares in the current scope: parameter names,
+/// `var` and `const` specs, the left side of `:=` (including `range` and
+/// `select` receives) and a type switch alias
+fn declared_identifiers<'a>(node: &tree_sitter::Node<'a>) -> Vec<tree_sitter::Node<'a>> {
+    let children = |n: tree_sitter::Node<'a>| {
+        (0..n.child_count() as u32)
+            .filter_map(move |i| n.child(i))
+            .filter(|child| child.kind() == "identifier")
+    };
+    let defines =
+        || (0..node.child_count() as u32).any(|i| node.child(i).is_some_and(|c| c.kind() == ":="));
+
+    match node.kind() {
+        // Only the function's own parameters, not those of a `func` typed parameter
+        "parameter_declaration" | "variadic_parameter_declaration" => {
+            let own = node
+                .parent()
+                .and_then(|list| list.parent())
+                .is_some_and(|parent| FUNCTION_KINDS.contains(&parent.kind()));
+            if own {
+                children(*node).collect()
+            } else {
+                vec![]
+            }
+        }
+        "var_spec" | "const_spec" => children(*node).collect(),
+        "short_var_declaration" => node
+            .child_by_field_name("left")
+            .map(|left| children(left).collect())
+            .unwrap_or_default(),
+        "range_clause" | "receive_statement" if defines() => node
+            .child_by_field_name("left")
+            .map(|left| children(left).collect())
+            .unwrap_or_default(),
+        "type_switch_statement" => node
+            .child_by_field_name("alias")
+            .map(|alias| children(alias).collect())
+            .unwrap_or_default(),
+        _ => vec![],
+    }
+}
+
+/// Whether a declaration redeclares its own right-hand side, as in `x := x`,
+/// `a, b := a, b` or `switch v := v.(type)`, the usual way to copy a value
+/// into an inner scope
+fn is_idiomatic_redeclaration(node: &tree_sitter::Node, source: &str) -> bool {
+    let text = |n: Option<tree_sitter::Node>| {
+        n.and_then(|n| source.get(n.byte_range()))
+            .map(|s| s.split_whitespace().collect::<String>())
+    };
+
+    match node.kind() {
+        "short_var_declaration" => {
+            let left = text(node.child_by_field_name("left"));
+            left.is_some() && left == text(node.child_by_field_name("right"))
+        }
+        "type_switch_statement" => {
+            let alias = text(node.child_by_field_name("alias"));
+            alias.is_some() && alias == text(node.child_by_field_name("value"))
+        }
+        _ => false,
+    }
+}
+
+/// Calls known to return a string, as written at the call site
+const STRING_FUNCTIONS: &[&str] = &[
+    "string",
+    "fmt.Sprint",
+    "fmt.Sprintf",
+    "fmt.Sprintln",
+    "strconv.Itoa",
+    "strconv.Quote",
+    "strconv.FormatBool",
+    "strconv.FormatFloat",
+    "strconv.FormatInt",
+    "strconv.FormatUint",
+    "strings.Join",
+    "strings.Repeat",
+    "strings.Replace",
+    "strings.ReplaceAll",
+    "strings.ToLower",
+    "strings.ToUpper",
+    "strings.Trim",
+    "strings.TrimPrefix",
+    "strings.TrimSpace",
+    "strings.TrimSuffix",
+];
+
+/// A local variable: whether it holds a string and where it is declared
+#[derive(Clone, Copy)]
+struct Local {
+    string: bool,
+    start: usize,
+}
+
+/// Find assignments in a function declaration node that grow a string
+/// inside a `for` loop, `s += x` or `s = s + x`, where `s` is declared
+/// before the innermost loop around the assignment. A string declared in
+/// the loop body starts over on each iteration and is not reported.
+///
+/// Without type information a variable counts as a string when it is
+/// declared with type `string` or initialized with a string literal, a
+/// string concatenation or a call such as `fmt.Sprintf` known to return
+/// one, and an assignment counts when the variable is one or the
+/// appended value evidently is. Scopes follow [`find_shadowed`]; variables
+/// declared outside the function are always outside the loop.
+pub fn find_string_concats(node: &tree_sitter::Node, source: &str) -> Vec<StringConcat> {
+    let mut concats = Vec::new();
+    let mut scopes: Vec<HashMap<&str, Local>> = Vec::new();
+    let mut stack = vec![Visit::Enter(*node)];
+
+    while let Some(visit) = stack.pop() {
+        let node = match visit {
+            Visit::Enter(node) => node,
+            Visit::Leave => {
+                scopes.pop();
+                continue;
+            }
+        };
+
+        let is_body = node.kind() == "block"
+            && node
+                .parent()
+                .is_some_and(|parent| FUNCTION_KINDS.contains(&parent.kind()));
+        let opens_scope = SCOPE_KINDS.contains(&node.kind()) && !is_body;
+        if opens_scope {
+            scopes.push(HashMap::new());
+        }
+
+        let lookup = |name: &str| {
+            scopes
+                .iter()
+                .rev()
+                .find_map(|scope| scope.get(name).copied())
+        };
+        let is_string_var = |name: &str| lookup(name).is_some_and(|local| local.string);
+
+        if node.kind() == "assignment_statement" {
+            concats.extend(string_concat(&node, source, &lookup, &is_string_var));
+        }
+
+        let declared = declared_identifiers(&node);
+        let locals: Vec<(&str, Local)> = declared
+            .iter()
+            .enumerate()
+            .filter_map(|(index, ident)| {
+                let name = source.get(ident.byte_range())?;
+                let string = declares_string(&node, index, declared.len(), source, &is_string_var);
+                Some((
+                    name,
+                    Local {
+                        string,
+                        start: ident.start_byte(),
+                    },
+                ))
+            })
+            .collect();
+        if let Some(current) = scopes.last_mut() {
+            current.extend(locals.into_iter().filter(|(name, _)| *name != "_"));
+        }
+
+        if opens_scope {
+            stack.push(Visit::Leave);
+        }
+        let children: Vec<_> = (0..node.child_count() as u32)
+            .filter_map(|i| node.child(i))
+            .collect();
+        stack.extend(children.into_iter().rev().map(Visit::Enter));
+    }
+
+    concats
+}
+
+/// The string an assignment statement grows inside a loop, if it is one
+fn string_concat(
+    assignment: &tree_sitter::Node,
+    source: &str,
+    lookup: &dyn Fn(&str) -> Option<Local>,
+    is_string_var: &dyn Fn(&str) -> bool,
+) -> Option<StringConcat> {
+    let targets = named_children(&assignment.child_by_field_name("left")?);
+    let values = named_children(&assignment.child_by_field_name("right")?);
+    let ([target], [value]) = (targets.as_slice(), values.as_slice()) else {
+        return None;
+    };
+    if target.kind() != "identifier" {
+        return None;
+    }
+    let name = source.get(target.byte_range())?;
+
+    let is_string = || is_string_var(name) || is_string_expression(value, source, is_string_var);
+    let appends = match assignment.child_by_field_name("operator")?.kind() {
+        "+=" => is_string(),
+        "=" => {
+            let mut operands = Vec::new();
+            concat_operands(*value, &mut operands);
+            operands.len() > 1
+                && operands
+                    .iter()
+                    .any(|operand| source.get(operand.byte_range()) == Some(name))
+                && is_string()
+        }
+        _ => false,
+    };
+    if !appends {
+        return None;
+    }
+
+    let enclosing_loop = std::iter::successors(assignment.parent(), |node| node.parent())
+        .take_while(|node| !FUNCTION_KINDS.contains(&node.kind()))
+        .find(|node| node.kind() == "for_statement")?;
+    let declared_in_loop =
+        lookup(name).is_some_and(|local| enclosing_loop.byte_range().contains(&local.start));
+    if declared_in_loop {
+        return None;
+    }
+
+    let start = assignment.start_position();
+    Some(StringConcat {
+        variable: name.to_string(),
+        line: start.row + 1,
+        column: start.column + 1,
+    })
+}
+
+/// Operands of a chain of `+`, such as `s`, `a` and `b` in `s + a + b`
+fn concat_operands<'a>(node: tree_sitter::Node<'a>, operands: &mut Vec<tree_sitter::Node<'a>>) {
+    let is_plus = node.kind() == "binary_expression"
+        && node
+            .child_by_field_name("operator")
+            .is_some_and(|operator| operator.kind() == "+");
+    match (
+        is_plus,
+        node.child_by_field_name("left"),
+        node.child_by_field_name("right"),
+    ) {
+        (true, Some(left), Some(right)) => {
+            concat_operands(left, operands);
+            concat_operands(right, operands);
+        }
+        _ => operands.push(node),
+    }
+}
+
+/// Whether an expression evidently evaluates to a string
+fn is_string_expression(
+    node: &tree_sitter::Node,
+    source: &str,
+    is_string_var: &dyn Fn(&str) -> bool,
+) -> bool {
+    match node.kind() {
+        "interpreted_string_literal" | "raw_string_literal" => true,
+        "identifier" => source.get(node.byte_range()).is_some_and(is_string_var),
+        "parenthesized_expression" => node
+            .named_child(0)
+            .is_some_and(|inner| is_string_expression(&inner, source, is_string_var)),
+        "binary_expression" => {
+            let mut operands = Vec::new();
+            concat_operands(*node, &mut operands);
+            operands.len() > 1
+                && operands
+                    .iter()
+                    .any(|operand| is_string_expression(operand, source, is_string_var))
+        }
+        "call_expression" => {
+            let Some(function) = node.child_by_field_name("function") else {
+                return false;
+            };
+            let callee: String = source
+                .get(function.byte_range())
+                .unwrap_or_default()
+                .split_whitespace()
+                .collect();
+            // A `String()` method, as of a `fmt.Stringer`
+            let no_arguments = node
+                .child_by_field_name("arguments")
+                .is_some_and(|arguments| named_children(&arguments).is_empty());
+            STRING_FUNCTIONS.contains(&callee.as_str())
+                || (callee.ends_with(".String") && no_arguments)
+        }
+        _ => false,
+    }
+}
+
+/// Whether the `index`-th of the `count` identifiers a node declares holds
+/// a string, by its declared type or its initial value
+fn declares_string(
+    node: &tree_sitter::Node,
+    index: usize,
+    count: usize,
+    source: &str,
+    is_string_var: &dyn Fn(&str) -> bool,
+) -> bool {
+    let text = |n: Option<tree_sitter::Node>| n.and_then(|n| source.get(n.byte_range()));
+    // One value per name; a call spreading several results is not followed
+    let value = |list: Option<tree_sitter::Node>| {
+        let values = list.map(|list| named_children(&list)).unwrap_or_default();
+        values.len() == count
+            && values
+                .get(index)
+                .is_some_and(|value| is_string_expression(value, source, is_string_var))
+    };
+
+    match node.kind() {
+        "parameter_declaration" => text(node.child_by_field_name("type")) == Some("string"),
+        "var_spec" => match node.child_by_field_name("type") {
+            Some(declared) => text(Some(declared)) == Some("string"),
+            None => value(node.child_by_field_name("value")),
+        },
+        "short_var_declaration" => value(node.child_by_field_name("right")),
+        _ => false,
+    }
+}
+
+/// A local variable as [`find_slice_appends`] follows it
+#[derive(Clone, Default)]
+struct SliceLocal {
+    start: usize,
+    /// Declared slice type, e.g. `[]string`; empty when unknown
+    slice_type: String,
+    /// Whether the slice was last given no capacity
+    empty: bool,
+    /// Whether the variable is a channel, whose length is not known up front
+    channel: bool,
+}
+
+/// Find `append` calls in a function declaration node that grow a slice
+/// inside a `for` loop, `s = append(s, x)`, where `s` is declared before the
+/// innermost loop around the call and holds no capacity: it is declared as
+/// `var s []T` or assigned `nil`, `[]T{}`, `make([]T, 0)` or a
+/// zero-capacity slice expression such as `x[:0:0]`. Appending to such an
+/// expression, `s = append(s[:0:0], x)`, is reported whatever `s` holds.
+/// When the loop ranges over a collection or an integer, the capacity it
+/// needs is recorded, so a report can suggest `make([]T, 0, len(items))`;
+/// without type information any variable but a channel counts as one.
+///
+/// Other assignments are followed in source order, so a slice given its
+/// capacity with `s = make([]T, 0, n)` before the loop is not reported.
+/// Scopes follow [`find_shadowed`]; variables declared outside the function
+/// are only reported for zero-capacity appends.
+pub fn find_slice_appends(node: &tree_sitter::Node, source: &str) -> Vec<SliceAppend> {
+    let mut appends = Vec::new();
+    let mut scopes: Vec<HashMap<&str, SliceLocal>> = Vec::new();
+    let mut stack = vec![Visit::Enter(*node)];
+
+    while let Some(visit) = stack.pop() {
+        let node = match visit {
+            Visit::Enter(node) => node,
+            Visit::Leave => {
+                scopes.pop();
+                continue;
+            }
+        };
+
+        let is_body = node.kind() == "block"
+            && node
+                .parent()
+                .is_some_and(|parent| FUNCTION_KINDS.contains(&parent.kind()));
+        let opens_scope = SCOPE_KINDS.contains(&node.kind()) && !is_body;
+        if opens_scope {
+            scopes.push(HashMap::new());
+        }
+
+        if node.kind() == "assignment_statement" {
+            let lookup = |name: &str| {
+                scopes
+                    .iter()
+                    .rev()
+                    .find_map(|scope| scope.get(name).cloned())
+            };
+            appends.extend(slice_appends(&node, source, &lookup));
+            reassign_slices(&node, source, &mut scopes);
+        }
+
+        let declared = declared_identifiers(&node);
+        let locals: Vec<(&str, SliceLocal)> = declared
+            .iter()
+            .enumerate()
+            .filter_map(|(index, ident)| {
+                let name = source.get(ident.byte_range())?;
+                let local = SliceLocal {
+                    start: ident.start_byte(),
+                    ..slice_declaration(&node, index, declared.len(), source)
+                };
+                Some((name, local))
+            })
+            .collect();
+        if let Some(current) = scopes.last_mut() {
+            current.extend(locals.into_iter().filter(|(name, _)| *name != "_"));
+        }
+
+        if opens_scope {
+            stack.push(Visit::Leave);
+        }
+        let children: Vec<_> = (0..node.child_count() as u32)
+            .filter_map(|i| node.child(i))
+            .collect();
+        stack.extend(children.into_iter().rev().map(Visit::Enter));
+    }
+
+    appends
+}
+
+/// The slices an assignment statement grows inside a loop with `append`
+/// while they hold no capacity
+fn slice_appends(
+    assignment: &tree_sitter::Node,
+    source: &str,
+    lookup: &dyn Fn(&str) -> Option<SliceLocal>,
+) -> Vec<SliceAppend> {
+    let is_plain = assignment
+        .child_by_field_name("operator")
+        .is_some_and(|operator| operator.kind() == "=");
+    let (Some(left), Some(right)) = (
+        assignment.child_by_field_name("left"),
+        assignment.child_by_field_name("right"),
+    ) else {
+        return vec![];
+    };
+    let (targets, values) = (named_children(&left), named_children(&right));
+    if !is_plain || targets.len() != values.len() {
+        return vec![];
+    }
+    let Some(enclosing_loop) = std::iter::successors(assignment.parent(), |node| node.parent())
+        .take_while(|node| !FUNCTION_KINDS.contains(&node.kind()))
+        .find(|node| node.kind() == "for_statement")
+    else {
+        return vec![];
+    };
+
+    targets
+        .iter()
+        .zip(&values)
+        .filter_map(|(target, value)| {
+            if target.kind() != "identifier" || !is_append(value, source) {
+                return None;
+            }
+            let name = source.get(target.byte_range())?;
+            let grown = value
+                .child_by_field_name("arguments")
+                .and_then(|arguments| named_children(&arguments).first().copied())?;
+
+            let local = lookup(name);
+            let declared_in_loop = local
+                .as_ref()
+                .is_some_and(|local| enclosing_loop.byte_range().contains(&local.start));
+            if declared_in_loop {
+                return None;
+            }
+            let zero_capacity = is_zero_capacity(&grown, source);
+            let grows_empty = source.get(grown.byte_range()) == Some(name)
+                && local.as_ref().is_some_and(|local| local.empty);
+            if !zero_capacity && !grows_empty {
+                return None;
+            }
+
+            let start = value.start_position();
+            Some(SliceAppend {
+                variable: name.to_string(),
+                line: start.row + 1,
+                column: start.column + 1,
+                loop_line: enclosing_loop.start_position().row + 1,
+                slice_type: local.map(|local| local.slice_type).unwrap_or_default(),
+                capacity: loop_capacity(&enclosing_loop, source, lookup),
+                zero_capacity,
+            })
+        })
+        .collect()
+}
+
+/// Record what plain assignments other than `append` calls leave in the
+/// variables they assign, as `s = nil` or `s = make([]T, 0, n)`
+fn reassign_slices<'a>(
+    assignment: &tree_sitter::Node,
+    source: &'a str,
+    scopes: &mut [HashMap<&'a str, SliceLocal>],
+) {
+    let is_plain = assignment
+        .child_by_field_name("operator")
+        .is_some_and(|operator| operator.kind() == "=");
+    let (Some(left), Some(right)) = (
+        assignment.child_by_field_name("left"),
+        assignment.child_by_field_name("right"),
+    ) else {
+        return;
+    };
+    let (targets, values) = (named_children(&left), named_children(&right));
+    if !is_plain || targets.len() != values.len() {
+        return;
+    }
+
+    for (target, value) in targets.iter().zip(&values) {
+        if target.kind() != "identifier" || is_append(value, source) {
+            continue;
+        }
+        let Some(name) = source.get(target.byte_range()) else {
+            continue;
+        };
+        let Some(local) = scopes
+            .iter_mut()
+            .rev()
+            .find_map(|scope| scope.get_mut(name))
+        else {
+            continue;
+        };
+        if value.kind() == "nil" {
+            local.empty = true;
+            continue;
+        }
+        let assigned = slice_value(value, source);
+        local.empty = assigned.empty;
+        local.channel = assigned.channel;
+        if !assigned.slice_type.is_empty() {
+            local.slice_type = assigned.slice_type;
+        }
+    }
+}
+
+/// What the `index`-th of the `count` identifiers a node declares holds, by
+/// its declared type or its initial value
+fn slice_declaration<'a>(
+    node: &tree_sitter::Node<'a>,
+    index: usize,
+    count: usize,
+    source: &str,
+) -> SliceLocal {
+    // One value per name; a call spreading several results is not followed
+    let value = |list: Option<tree_sitter::Node<'a>>| {
+        let values = list.map(|list| named_children(&list)).unwrap_or_default();
+        (values.len() == count)
+            .then(|| values.get(index).copied())
+            .flatten()
+    };
+    let declared = node.child_by_field_name("type");
+    let declared_kind = declared.map(|declared| declared.kind());
+
+    match node.kind() {
+        "parameter_declaration" => SliceLocal {
+            channel: declared_kind == Some("channel_type"),
+            ..SliceLocal::default()
+        },
+        "var_spec" => {
+            let mut local = match value(node.child_by_field_name("value")) {
+                Some(value) if value.kind() != "nil" => slice_value(&value, source),
+                _ => SliceLocal {
+                    empty: declared_kind == Some("slice_type"),
+                    ..SliceLocal::default()
+                },
+            };
+            match (declared, declared_kind) {
+                (Some(declared), Some("slice_type")) => {
+                    local.slice_type = source
+                        .get(declared.byte_range())
+                        .unwrap_or_default()
+                        .to_string();
+                }
+                (_, Some("channel_type")) => local.channel = true,
+                _ => {}
+            }
+            local
+        }
+        "short_var_declaration" => value(node.child_by_field_name("right"))
+            .map(|value| slice_value(&value, source))
+            .unwrap_or_default(),
+        _ => SliceLocal::default(),
+    }
+}
+
+/// What a value leaves in a variable: a slice type and whether it has no
+/// capacity for `[]T{}`, `make([]T, 0)` or `x[:0:0]`, or a channel for
+/// `make(chan T)`
+fn slice_value(value: &tree_sitter::Node, source: &str) -> SliceLocal {
+    let text = |node: &tree_sitter::Node| {
+        source
+            .get(node.byte_range())
+            .unwrap_or_default()
+            .to_string()
+    };
+
+    match value.kind() {
+        "composite_literal" => match value.child_by_field_name("type") {
+            Some(literal_type) if literal_type.kind() == "slice_type" => SliceLocal {
+                slice_type: text(&literal_type),
+                empty: value
+                    .child_by_field_name("body")
+                    .is_some_and(|body| named_children(&body).is_empty()),
+                ..SliceLocal::default()
+            },
+            _ => SliceLocal::default(),
+        },
+        "call_expression"
+            if value
+                .child_by_field_name("function")
+                .is_some_and(|function| text(&function) == "make") =>
+        {
+            let arguments = value
+                .child_by_field_name("arguments")
+                .map(|arguments| named_children(&arguments))
+                .unwrap_or_default();
+            match arguments.as_slice() {
+                [made, ..] if made.kind() == "channel_type" => SliceLocal {
+                    channel: true,
+                    ..SliceLocal::default()
+                },
+                [made, rest @ ..] if made.kind() == "slice_type" => SliceLocal {
+                    slice_type: text(made),
+                    empty: matches!(rest, [length] if text(length) == "0"),
+                    ..SliceLocal::default()
+                },
+                _ => SliceLocal::default(),
+            }
+        }
+        _ => SliceLocal {
+            empty: is_zero_capacity(value, source),
+            ..SliceLocal::default()
+        },
+    }
+}
+
+/// Whether a node is a call of the `append` builtin
+fn is_append(node: &tree_sitter::Node, source: &str) -> bool {
+    node.kind() == "call_expression"
+        && node
+            .child_by_field_name("function")
+            .is_some_and(|function| source.get(function.byte_range()) == Some("append"))
+}
+
+/// Whether a node is a slice expression with a capacity of 0, as `s[:0:0]`
+fn is_zero_capacity(node: &tree_sitter::Node, source: &str) -> bool {
+    node.kind() == "slice_expression"
+        && node
+            .child_by_field_name("capacity")
+            .is_some_and(|capacity| source.get(capacity.byte_range()) == Some("0"))
+}
+
+/// Capacity a `for` loop needs when it ranges over a collection of known
+/// length, `len(items)`, or over an integer literal. Ranges over channels
+/// and calls, such as function iterators, give none.
+fn loop_capacity(
+    for_statement: &tree_sitter::Node,
+    source: &str,
+    lookup: &dyn Fn(&str) -> Option<SliceLocal>,
+) -> String {
+    let Some(collection) = named_children(for_statement)
+        .into_iter()
+        .find(|child| child.kind() == "range_clause")
+        .and_then(|range| range.child_by_field_name("right"))
+    else {
+        return String::new();
+    };
+    let text = source.get(collection.byte_range()).unwrap_or_default();
+
+    match collection.kind() {
+        "int_literal" => text.to_string(),
+        "identifier" if lookup(text).is_some_and(|local| local.channel) => String::new(),
+        "identifier" | "selector_expression" | "index_expression" | "slice_expression" => {
+            format!("len({})", text)
+        }
+        _ => String::new(),
+    }
+}
+
+/// Call a variable was last assigned from
+#[derive(Clone)]
+struct Origin {
+    callee: String,
+    line: usize,
+}
+
+/// Find `return` statements in a function declaration node whose last
+/// value is a variable last assigned from a call, such as `return err` or
+/// `return nil, err` after `f, err := os.Open(name)`, in functions (or
+/// function literals) whose last result is an `error`.
+///
+/// Assignments are followed in source order within the scopes of
+/// [`find_shadowed`], so after `if` branches the one written last wins.
+/// Errors made by `fmt.Errorf` or an `errors` function already carry their
+/// own message and are not reported, and neither are parameters or
+/// variables assigned anything but a call.
+pub fn find_error_returns(node: &tree_sitter::Node, source: &str) -> Vec<ErrorReturn> {
+    let mut returns = Vec::new();
+    let mut scopes: Vec<HashMap<&str, Option<Origin>>> = Vec::new();
+    let mut stack = vec![Visit::Enter(*node)];
+
+    while let Some(visit) = stack.pop() {
+        let node = match visit {
+            Visit::Enter(node) => node,
+            Visit::Leave => {
+                scopes.pop();
+                continue;
+            }
+        };
+
+        let is_body = node.kind() == "block"
+            && node
+                .parent()
+                .is_some_and(|parent| FUNCTION_KINDS.contains(&parent.kind()));
+        let opens_scope = SCOPE_KINDS.contains(&node.kind()) && !is_body;
+        if opens_scope {
+            scopes.push(HashMap::new());
+        }
+
+        match node.kind() {
+            "return_statement" => returns.extend(error_return(&node, source, &scopes)),
+            "assignment_statement" => {
+                let is_plain = node
+                    .child_by_field_name("operator")
+                    .is_some_and(|operator| operator.kind() == "=");
+                if let (true, Some(left), Some(right)) = (
+                    is_plain,
+                    node.child_by_field_name("left"),
+                    node.child_by_field_name("right"),
+                ) {
+                    let targets = named_children(&left);
+                    let origins = call_origins(targets.len(), &named_children(&right), source);
+                    for (target, origin) in targets.iter().zip(origins) {
+                        let Some(name) = source.get(target.byte_range()) else {
+                            continue;
+                        };
+                        if let Some(slot) = scopes.iter_mut().rev().find_map(|s| s.get_mut(name)) {
+                            *slot = origin;
+                        }
+                    }
+                }
+            }
+            _ => {}
+        }
+
+        let declared = declared_identifiers(&node);
+        let values = match node.kind() {
+            "short_var_declaration" => node.child_by_field_name("right"),
+            "var_spec" => node.child_by_field_name("value"),
+            _ => None,
+        };
+        let origins = match values {
+            Some(values) => call_origins(declared.len(), &named_children(&values), source),
+            None => vec![None; declared.len()],
+        };
+        if let Some(current) = scopes.last_mut() {
+            for (ident, origin) in declared.iter().zip(origins) {
+                if let Some(name) = source.get(ident.byte_range()).filter(|name| *name != "_") {
+                    current.insert(name, origin);
+                }
+            }
+        }
+
+        if opens_scope {
+            stack.push(Visit::Leave);
+        }
+        let children: Vec<_> = (0..node.child_count() as u32)
+            .filter_map(|i| node.child(i))
+            .collect();
+        stack.extend(children.into_iter().rev().map(Visit::Enter));
+    }
+
+    returns
+}
+
+/// The call each of `count` targets is assigned from: one call spread over
+/// every target, or one value per target
+fn call_origins(count: usize, values: &[tree_sitter::Node], source: &str) -> Vec<Option<Origin>> {
+    let origin = |value: &tree_sitter::Node| {
+        if value.kind() != "call_expression" {
+            return None;
+        }
+        let callee = source.get(value.child_by_field_name("function")?.byte_range())?;
+        if callee == "fmt.Errorf" || callee.starts_with("errors.") {
+            return None;
+        }
+        Some(Origin {
+            callee: callee.to_string(),
+            line: value.start_position().row + 1,
+        })
+    };
+
+    match values {
+        [call] => vec![origin(call); count],
+        _ if values.len() == count => values.iter().map(origin).collect(),
+        _ => vec![None; count],
+    }
+}
+
+/// The error a `return` statement passes on unchanged, if it does
+fn error_return(
+    statement: &tree_sitter::Node,
+    source: &str,
+    scopes: &[HashMap<&str, Option<Origin>>],
+) -> Option<ErrorReturn> {
+    let values = named_children(&statement.named_child(0)?);
+    let last = values.last().filter(|value| value.kind() == "identifier")?;
+    let name = source.get(last.byte_range())?;
+
+    let function = std::iter::successors(statement.parent(), |node| node.parent())
+        .find(|node| FUNCTION_KINDS.contains(&node.kind()))?;
+    if !returns_error(&function, source) {
+        return None;
+    }
+
+    let origin = scopes
+        .iter()
+        .rev()
+        .find_map(|scope| scope.get(name))?
+        .as_ref()?;
+    let start = statement.start_position();
+    Some(ErrorReturn {
+        variable: name.to_string(),
+        line: start.row + 1,
+        column: start.column + 1,
+        callee: origin.callee.clone(),
+        call_line: origin.line,
+    })
+}
+
+/// Whether the last result of a function is declared `error`
+fn returns_error(function: &tree_sitter::Node, source: &str) -> bool {
+    let Some(result) = function.child_by_field_name("result") else {
+        return false;
+    };
+    let last_type = if result.kind() == "parameter_list" {
+        named_children(&result)
+            .last()
+            .and_then(|parameter| parameter.child_by_field_name("type"))
+    } else {
+        Some(result)
+    };
+    last_type.and_then(|t| source.get(t.byte_range())) == Some("error")
+}
+
+/// Calls that never return besides the `panic` builtin: they end the process
+/// or panic themselves
+const EXIT_CALLS: &[&str] = &[
+    "os.Exit",
+    "log.Fatal",
+    "log.Fatalf",
+    "log.Fatalln",
+    "log.Panic",
+    "log.Panicf",
+    "log.Panicln",
+];
+
+/// Statements a bare `break` inside them refers to
+const BREAKABLE_KINDS: &[&str] = &[
+    "for_statement",
+    "expression_switch_statement",
+    "type_switch_statement",
+    "select_statement",
+];
+
+/// Whether the statements of a block can still run
+enum Flow<'a> {
+    Reachable,
+    /// Left by the statement described, on the given line
+    Left(&'a str, usize),
+    /// Left, and the first unreachable statement already reported
+    Unreachable,
+}
+
+/// Find the statements of a function declaration node that follow, in the
+/// same block, a statement always leaving it: `return`, `goto`, `break`,
+/// `continue`, a call to `panic`, `os.Exit` or a `log.Fatal`/`log.Panic`
+/// function, or a statement terminating by the Go spec's rules, such as an
+/// `if` whose branches both leave or a `for` without condition or `break`.
+/// Only the first statement of each unreachable run is reported. A labeled
+/// statement that a `goto` in the function names makes the block reachable
+/// again.
+pub fn find_unreachable_code(node: &tree_sitter::Node, source: &str) -> Vec<UnreachableCode> {
+    let mut goto_targets = HashSet::new();
+    let mut statement_lists = Vec::new();
+    let mut stack = vec![*node];
+    while let Some(node) = stack.pop() {
+        match node.kind() {
+            "goto_statement" => goto_targets.extend(label_name(&node, source)),
+            "statement_list" => statement_lists.push(node),
+            _ => {}
+        }
+        stack.extend(named_children(&node));
+    }
+
+    let mut unreachable = Vec::new();
+    for list in statement_lists {
+        let mut flow = Flow::Reachable;
+        for statement in named_children(&list) {
+            let is_goto_target = statement.kind() == "labeled_statement"
+                && label_name(&statement, source).is_some_and(|label| goto_targets.contains(label));
+            match flow {
+                Flow::Left(after, after_line) if !is_goto_target => {
+                    let start = statement.start_position();
+                    unreachable.push(UnreachableCode {
+                        line: start.row + 1,
+                        column: start.column + 1,
+                        after: after.to_string(),
+                        after_line,
+                    });
+                    flow = Flow::Unreachable;
+                    continue;
+                }
+                Flow::Unreachable if !is_goto_target => continue,
+                _ => {}
+            }
+            flow = match leaving_statement(&statement, None, source) {
+                Some(after) => Flow::Left(after, statement.start_position().row + 1),
+                None => Flow::Reachable,
+            };
+        }
+    }
+
+    unreachable.sort_by_key(|u| (u.line, u.column));
+    unreachable
+}
+
+/// What makes `statement` always leave its block, if it does. `label` is
+/// the label of an enclosing labeled statement, which `break` may name.
+fn leaving_statement<'a>(
+    statement: &tree_sitter::Node,
+    label: Option<&str>,
+    source: &'a str,
+) -> Option<&'a str> {
+    match statement.kind() {
+        "return_statement" => Some("return"),
+        "goto_statement" => Some("goto"),
+        "break_statement" => Some("break"),
+        "continue_statement" => Some("continue"),
+        "expression_statement" => {
+            let call = named_children(statement)
+                .into_iter()
+                .find(|child| child.kind() == "call_expression")?;
+            let function = source.get(call.child_by_field_name("function")?.byte_range())?;
+            (function == "panic" || EXIT_CALLS.contains(&function)).then_some(function)
+        }
+        "block" => leaving_statement(&last_statement(statement)?, None, source),
+        "labeled_statement" => {
+            let inner = named_children(statement)
+                .into_iter()
+                .rfind(|child| child.kind() != "label_name")?;
+            leaving_statement(&inner, label_name(statement, source), source)
+        }
+        "if_statement" => {
+            let alternative = statement.child_by_field_name("alternative")?;
+            leaving_statement(&statement.child_by_field_name("consequence")?, None, source)?;
+            leaving_statement(&alternative, None, source)?;
+            Some("if")
+        }
+        "for_statement" => {
+            let endless = named_children(statement)
+                .iter()
+                .all(|child| match child.kind() {
+                    "block" => true,
+                    "for_clause" => child.child_by_field_name("condition").is_none(),
+                    _ => false,
+                });
+            (endless && !breaks_out(statement, label, source)).then_some("for")
+        }
+        "expression_switch_statement" | "type_switch_statement" | "select_statement" => {
+            let cases: Vec<_> = named_children(statement)
+                .into_iter()
+                .filter(|child| child.kind().ends_with("_case"))
+                .collect();
+            let is_select = statement.kind() == "select_statement";
+            // A switch without `default` may match no case at all
+            if !is_select && !cases.iter().any(|case| case.kind() == "default_case") {
+                return None;
+            }
+            let every_case_leaves = cases.iter().all(|case| {
+                last_statement(case).is_some_and(|last| {
+                    last.kind() == "fallthrough_statement"
+                        || leaving_statement(&last, None, source).is_some()
+                })
+            });
+            (every_case_leaves && !breaks_out(statement, label, source)).then_some(if is_select {
+                "select"
+            } else {
+                "switch"
+            })
+        }
+        _ => None,
+    }
+}
+
+/// Last statement of a block or case clause
+fn last_statement<'a>(node: &tree_sitter::Node<'a>) -> Option<tree_sitter::Node<'a>> {
+    let list = named_children(node)
+        .into_iter()
+        .find(|child| child.kind() == "statement_list")?;
+    named_children(&list).pop()
+}
+
+/// Whether a `break` inside `statement` leaves it: an unlabeled one outside
+/// any nested loop, `switch` or `select`, or one naming `label`. Function
+/// literals are not searched, since their statements leave only the literal.
+fn breaks_out(statement: &tree_sitter::Node, label: Option<&str>, source: &str) -> bool {
+    let mut stack: Vec<_> = named_children(statement)
+        .into_iter()
+        .map(|child| (child, false))
+        .collect();
+    while let Some((node, nested)) = stack.pop() {
+        match node.kind() {
+            "func_literal" => continue,
+            "break_statement" => {
+                let refers = match label_name(&node, source) {
+                    Some(target) => label == Some(target),
+                    None => !nested,
+                };
+                if refers {
+                    return true;
+                }
+            }
+            _ => {}
+        }
+        let nested = nested || BREAKABLE_KINDS.contains(&node.kind());
+        stack.extend(
+            named_children(&node)
+                .into_iter()
+                .map(|child| (child, nested)),
+        );
+    }
+    false
+}
+
+/// Label a labeled statement declares, or a `goto` or `break` names
+fn label_name<'a>(node: &tree_sitter::Node, source: &'a str) -> Option<&'a str> {
+    named_children(node)
+        .into_iter()
+        .find(|child| child.kind() == "label_name")
+        .and_then(|label| source.get(label.byte_range()))
+}
@@ -2,6 +2,8 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+use crate::analyze::types::{FieldInfo, ParamInfo};
+
 /// Tree-sitter query for extracting Java code elements
 pub const ELEMENT_QUERY: &str = r#"
     (method_declaration name: (identifier) @func)
@@ -19,3 +21,103 @@ pub const CALL_QUERY: &str = r#"
     (object_creation_expression
       type: (type_identifier) @constructor.call)
 "#;
+
+/// Extract parameters and the return type from a Java method declaration.
+/// Varargs keep their declared form, e.g. `String...`.
+pub fn extract_signature(
+    node: &tree_sitter::Node,
+    source: &str,
+) -> (Vec<ParamInfo>, Vec<ParamInfo>) {
+    let params = node
+        .child_by_field_name("parameters")
+        .map(|list| {
+            (0..list.child_count() as u32)
+                .filter_map(|i| list.child(i))
+                .filter(|child| child.is_named() && !child.kind().contains("comment"))
+                .map(|param| match param.kind() {
+                    "spread_parameter" => spread_param_info(&param, source),
+                    _ => super::param_info(&param, source),
+                })
+                .collect()
+        })
+        .unwrap_or_default();
+
+    let returns = node
+        .child_by_field_name("type")
+        .filter(|type_node| type_node.kind() != "void_type")
+        .and_then(|type_node| source.get(type_node.byte_range()))
+        .map(|s| vec![ParamInfo::unnamed(s)])
+        .unwrap_or_default();
+
+    (params, returns)
+}
+
+/// `String... args` has no fields; the name sits in a trailing declarator
+fn spread_param_info(node: &tree_sitter::Node, source: &str) -> ParamInfo {
+    let children: Vec<_> = (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i))
+        .collect();
+    let name = children
+        .iter()
+        .find(|child| child.kind() == "variable_declarator")
+        .and_then(|declarator| declarator.child_by_field_name("name"))
+        .and_then(|name| source.get(name.byte_range()));
+    let type_name = children
+        .iter()
+        .take_while(|child| child.kind() != "...")
+        .filter(|child| child.is_named() && child.kind() != "modifiers")
+        .last()
+        .and_then(|type_node| source.get(type_node.byte_range()))
+        .map(|type_text| format!("{}...", type_text));
+
+    ParamInfo {
+        name: name.map(|s| s.to_string()),
+        type_name,
+    }
+}
+
+/// Whether a declaration is visible outside its file (anything not `private`)
+pub fn is_exported(node: &tree_sitter::Node, _name: &str, source: &str) -> bool {
+    !super::has_modifier(node, source, &["private"])
+}
+
+/// Extract the fields of a class declaration, one per declared variable
+pub fn extract_fields(node: &tree_sitter::Node, source: &str) -> Vec<FieldInfo> {
+    let Some(body) = node.child_by_field_name("body") else {
+        return vec![];
+    };
+
+    let mut fields = Vec::new();
+
+    for decl in (0..body.child_count() as u32).filter_map(|i| body.child(i)) {
+        if decl.kind() != "field_declaration" {
+            continue;
+        }
+
+        let type_text = decl
+            .child_by_field_name("type")
+            .and_then(|type_node| source.get(type_node.byte_range()));
+        let exported = is_exported(&decl, "", source);
+
+        for declarator in (0..decl.child_count() as u32).filter_map(|i| decl.child(i)) {
+            if declarator.kind() != "variable_declarator" {
+                continue;
+            }
+            if let Some(name) = declarator
+                .child_by_field_name("name")
+                .and_then(|name| source.get(name.byte_range()))
+            {
+                fields.push(FieldInfo {
+                    name: name.to_string(),
+                    line: declarator.start_position().row + 1,
+                    type_name: type_text.map(|s| s.to_string()),
+                    exported,
+                    tag: None,
+                    embedded: false,
+                });
+            }
+        }
+    }
+
+    fields
+}
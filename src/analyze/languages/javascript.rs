@@ -2,6 +2,8 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+use crate::analyze::types::FieldInfo;
+
 /// Tree-sitter query for extracting JavaScript/TypeScript code elements
 pub const ELEMENT_QUERY: &str = r#"
     (function_declaration name: (identifier) @func)
@@ -24,3 +26,32 @@ pub const CALL_QUERY: &str = r#"
     (new_expression
       constructor: (identifier) @constructor.call)
 "#;
+
+/// Whether a JavaScript declaration is part of an `export` statement
+pub fn is_exported(node: &tree_sitter::Node, _name: &str, _source: &str) -> bool {
+    node.parent()
+        .is_some_and(|parent| parent.kind() == "export_statement")
+}
+
+/// Extract the field definitions of a class; `#private` fields are not exported
+pub fn extract_fields(node: &tree_sitter::Node, source: &str) -> Vec<FieldInfo> {
+    let Some(body) = node.child_by_field_name("body") else {
+        return vec![];
+    };
+
+    (0..body.child_count() as u32)
+        .filter_map(|i| body.child(i))
+        .filter(|decl| decl.kind() == "field_definition")
+        .filter_map(|decl| {
+            let property = decl.child_by_field_name("property")?;
+            Some(FieldInfo {
+                name: source.get(property.byte_range())?.to_string(),
+                line: decl.start_position().row + 1,
+                type_name: None,
+                exported: property.kind() != "private_property_identifier",
+                tag: None,
+                embedded: false,
+            })
+        })
+        .collect()
+}
@@ -2,6 +2,8 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+use crate::analyze::types::ParamInfo;
+
 /// Tree-sitter query for extracting Kotlin code elements
 pub const ELEMENT_QUERY: &str = r#"
     ; Functions
@@ -28,3 +30,49 @@ pub const CALL_QUERY: &str = r#"
       (navigation_expression
         (identifier) @method.call))
 "#;
+
+/// Node kinds that can appear as the declared return type of a Kotlin function
+const RETURN_TYPE_KINDS: &[&str] = &[
+    "user_type",
+    "nullable_type",
+    "function_type",
+    "parenthesized_type",
+];
+
+/// Extract parameters and the return type from a Kotlin function declaration
+pub fn extract_signature(
+    node: &tree_sitter::Node,
+    source: &str,
+) -> (Vec<ParamInfo>, Vec<ParamInfo>) {
+    let mut params = Vec::new();
+    let mut returns = Vec::new();
+    let mut after_params = false;
+
+    for child in (0..node.child_count() as u32).filter_map(|i| node.child(i)) {
+        match child.kind() {
+            "function_value_parameters" => {
+                params = (0..child.child_count() as u32)
+                    .filter_map(|i| child.child(i))
+                    .filter(|param| param.kind() == "parameter")
+                    .map(|param| super::param_info(&param, source))
+                    .collect();
+                after_params = true;
+            }
+            "function_body" => break,
+            kind if after_params && RETURN_TYPE_KINDS.contains(&kind) => {
+                if let Some(text) = source.get(child.byte_range()) {
+                    returns.push(ParamInfo::unnamed(text));
+                }
+                break;
+            }
+            _ => {}
+        }
+    }
+
+    (params, returns)
+}
+
+/// Whether a declaration is visible outside its file (anything not `private`)
+pub fn is_exported(node: &tree_sitter::Node, _name: &str, source: &str) -> bool {
+    !super::has_modifier(node, source, &["private"])
+}
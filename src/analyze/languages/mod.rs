@@ -11,6 +11,11 @@ pub mod ruby;
 pub mod rust;
 pub mod swift;
 
+use super::types::{
+    DiscardedCall, EmptyInterface, ErrorReturn, FieldAccess, FieldInfo, InterfaceInfo,
+    PackageClause, ParamInfo, ShadowInfo, SliceAppend, StringConcat, UnreachableCode,
+};
+
 /// Handler for extracting function names from special node kinds
 type ExtractFunctionNameHandler = fn(&tree_sitter::Node, &str, &str) -> Option<String>;
 
@@ -20,6 +25,55 @@ type FindMethodForReceiverHandler = fn(&tree_sitter::Node, &str, Option<usize>)
 /// Handler for finding the receiver type from a receiver node
 type FindReceiverTypeHandler = fn(&tree_sitter::Node, &str) -> Option<String>;
 
+/// Handler for finding the type a function declaration node is declared on
+type FindFunctionReceiverHandler = fn(&tree_sitter::Node, &str) -> Option<String>;
+
+/// Handler for extracting parameter and return type texts from a function declaration node
+type ExtractSignatureHandler = fn(&tree_sitter::Node, &str) -> (Vec<ParamInfo>, Vec<ParamInfo>);
+
+/// Handler for deciding whether a declaration node with the given name is exported
+type IsExportedHandler = fn(&tree_sitter::Node, &str, &str) -> bool;
+
+/// Handler for extracting the fields declared by a class or struct declaration node
+type ExtractFieldsHandler = fn(&tree_sitter::Node, &str) -> Vec<FieldInfo>;
+
+/// Handler for finding the identifier a method declaration binds its receiver to
+type FindReceiverNameHandler = fn(&tree_sitter::Node, &str) -> Option<String>;
+
+/// Handler for extracting the required method set of an interface declaration node;
+/// returns `None` for declarations that are not interfaces
+type ExtractInterfaceHandler = fn(&tree_sitter::Node, &str) -> Option<InterfaceInfo>;
+
+/// Handler for finding local declarations that shadow an enclosing one, given the root node
+type FindShadowedHandler = fn(&tree_sitter::Node, &str) -> Vec<ShadowInfo>;
+
+/// Handler for finding the struct fields a file reads or writes, given the root node
+type FindFieldAccessesHandler = fn(&tree_sitter::Node, &str) -> Vec<FieldAccess>;
+
+/// Handler for finding the empty interface types of a file, given the root node
+type FindEmptyInterfacesHandler = fn(&tree_sitter::Node, &str) -> Vec<EmptyInterface>;
+
+/// Handler for finding the `package` clause of a file, given the root node
+type FindPackageClauseHandler = fn(&tree_sitter::Node, &str) -> Option<PackageClause>;
+
+/// Handler for finding the lines of bare `return` statements in a function declaration node
+type FindNakedReturnsHandler = fn(&tree_sitter::Node) -> Vec<usize>;
+
+/// Handler for finding the calls whose results a function declaration node drops
+type FindDiscardedCallsHandler = fn(&tree_sitter::Node, &str) -> Vec<DiscardedCall>;
+
+/// Handler for finding the strings a function declaration node builds up inside loops
+type FindStringConcatsHandler = fn(&tree_sitter::Node, &str) -> Vec<StringConcat>;
+
+/// Handler for finding the returns of a function declaration node that pass on a call's error unchanged
+type FindErrorReturnsHandler = fn(&tree_sitter::Node, &str) -> Vec<ErrorReturn>;
+
+/// Handler for finding the statements of a function declaration node that can never run
+type FindUnreachableCodeHandler = fn(&tree_sitter::Node, &str) -> Vec<UnreachableCode>;
+
+/// Handler for finding the `append` calls of a function declaration node that grow a slice without capacity in a loop
+type FindSliceAppendsHandler = fn(&tree_sitter::Node, &str) -> Vec<SliceAppend>;
+
 /// Language configuration containing all language-specific information
 #[derive(Copy, Clone)]
 pub struct LanguageInfo {
@@ -31,6 +85,95 @@ pub struct LanguageInfo {
     pub extract_function_name_handler: Option<ExtractFunctionNameHandler>,
     pub find_method_for_receiver_handler: Option<FindMethodForReceiverHandler>,
     pub find_receiver_type_handler: Option<FindReceiverTypeHandler>,
+    pub class_node_kinds: &'static [&'static str],
+    pub find_function_receiver_handler: Option<FindFunctionReceiverHandler>,
+    pub extract_signature_handler: Option<ExtractSignatureHandler>,
+    /// Node kinds (including operator tokens) that add a branch to cyclomatic complexity
+    pub decision_node_kinds: &'static [&'static str],
+    /// Control flow structures that add to cognitive complexity and nest their contents
+    pub nesting_node_kinds: &'static [&'static str],
+    /// Nodes whose named children run in sequence, compared by clone detection
+    pub statement_block_kinds: &'static [&'static str],
+    /// Numeric literal nodes, checked by magic number detection
+    pub number_literal_kinds: &'static [&'static str],
+    /// Declarations whose literals are named constants; a declaration opening
+    /// with a `const` keyword always is one
+    pub constant_declaration_kinds: &'static [&'static str],
+    /// Index and subscript expressions; their first named child is the indexed value
+    pub index_expression_kinds: &'static [&'static str],
+    /// Decides visibility; languages without one treat every declaration as exported
+    pub is_exported_handler: Option<IsExportedHandler>,
+    pub extract_fields_handler: Option<ExtractFieldsHandler>,
+    /// Receiver binding of a method (`g` in `func (g *Greeter)`, `self`); `None` when
+    /// the receiver is implicit, as with `this`
+    pub find_receiver_name_handler: Option<FindReceiverNameHandler>,
+    pub extract_interface_handler: Option<ExtractInterfaceHandler>,
+    pub find_shadowed_handler: Option<FindShadowedHandler>,
+    pub find_field_accesses_handler: Option<FindFieldAccessesHandler>,
+    pub find_empty_interfaces_handler: Option<FindEmptyInterfacesHandler>,
+    pub find_package_clause_handler: Option<FindPackageClauseHandler>,
+    /// Only consulted for functions that name their results
+    pub find_naked_returns_handler: Option<FindNakedReturnsHandler>,
+    pub find_discarded_calls_handler: Option<FindDiscardedCallsHandler>,
+    pub find_string_concats_handler: Option<FindStringConcatsHandler>,
+    pub find_error_returns_handler: Option<FindErrorReturnsHandler>,
+    pub find_unreachable_code_handler: Option<FindUnreachableCodeHandler>,
+    pub find_slice_appends_handler: Option<FindSliceAppendsHandler>,
+}
+
+/// Split a parameter node into its name and declared type. Uses the `name`,
+/// `pattern` and `type` fields where the grammar has them, otherwise the
+/// first identifier and the type following a `:`. Anything else, such as a
+/// destructuring pattern, is kept whole as the name.
+pub fn param_info(node: &tree_sitter::Node, source: &str) -> ParamInfo {
+    let text = |n: tree_sitter::Node| source.get(n.byte_range()).map(|s| s.to_string());
+
+    let is_name = |kind: &str| kind.ends_with("identifier") && !kind.starts_with("type");
+
+    if is_name(node.kind()) {
+        return ParamInfo {
+            name: text(*node),
+            type_name: None,
+        };
+    }
+
+    let children: Vec<_> = (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i))
+        .collect();
+
+    let name = node
+        .child_by_field_name("name")
+        .or_else(|| node.child_by_field_name("pattern"))
+        .or_else(|| children.iter().find(|child| is_name(child.kind())).copied())
+        .and_then(text);
+
+    let type_name = node
+        .child_by_field_name("type")
+        .or_else(|| {
+            children
+                .iter()
+                .skip_while(|child| child.kind() != ":")
+                .find(|child| child.is_named())
+                .copied()
+        })
+        .and_then(text);
+
+    match (name, type_name) {
+        (None, None) => ParamInfo {
+            name: text(*node),
+            type_name: None,
+        },
+        (name, type_name) => ParamInfo { name, type_name },
+    }
+}
+
+/// Whether a declaration's `modifiers` child contains any of the given keywords
+pub fn has_modifier(node: &tree_sitter::Node, source: &str, keywords: &[&str]) -> bool {
+    (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i))
+        .filter(|child| child.kind() == "modifiers")
+        .filter_map(|child| source.get(child.byte_range()))
+        .any(|text| text.split_whitespace().any(|word| keywords.contains(&word)))
 }
 
 /// Get language configuration for a given language
@@ -45,6 +188,48 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: None,
             find_method_for_receiver_handler: None,
             find_receiver_type_handler: None,
+            class_node_kinds: &["class_definition"],
+            find_function_receiver_handler: None,
+            extract_signature_handler: None,
+            decision_node_kinds: &[
+                "if_statement",
+                "elif_clause",
+                "for_statement",
+                "while_statement",
+                "except_clause",
+                "case_clause",
+                "conditional_expression",
+                "for_in_clause",
+                "if_clause",
+                "and",
+                "or",
+            ],
+            nesting_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "while_statement",
+                "except_clause",
+                "match_statement",
+                "conditional_expression",
+            ],
+            statement_block_kinds: &["block"],
+            number_literal_kinds: &["integer", "float"],
+            constant_declaration_kinds: &[],
+            index_expression_kinds: &["subscript"],
+            is_exported_handler: Some(python::is_exported),
+            extract_fields_handler: None,
+            find_receiver_name_handler: Some(python::find_receiver_name),
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         "rust" => Some(LanguageInfo {
             element_query: rust::ELEMENT_QUERY,
@@ -55,6 +240,42 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: Some(rust::extract_function_name_for_kind),
             find_method_for_receiver_handler: Some(rust::find_method_for_receiver),
             find_receiver_type_handler: Some(rust::find_receiver_type),
+            class_node_kinds: &["impl_item", "trait_item"],
+            find_function_receiver_handler: None,
+            extract_signature_handler: None,
+            decision_node_kinds: &[
+                "if_expression",
+                "while_expression",
+                "for_expression",
+                "match_arm",
+                "&&",
+                "||",
+            ],
+            nesting_node_kinds: &[
+                "if_expression",
+                "match_expression",
+                "for_expression",
+                "while_expression",
+                "loop_expression",
+            ],
+            statement_block_kinds: &["block"],
+            number_literal_kinds: &["integer_literal", "float_literal"],
+            constant_declaration_kinds: &["const_item", "static_item"],
+            index_expression_kinds: &["index_expression"],
+            is_exported_handler: Some(rust::is_exported),
+            extract_fields_handler: Some(rust::extract_fields),
+            find_receiver_name_handler: Some(rust::find_receiver_name),
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         "javascript" | "typescript" => Some(LanguageInfo {
             element_query: javascript::ELEMENT_QUERY,
@@ -69,6 +290,50 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: None,
             find_method_for_receiver_handler: None,
             find_receiver_type_handler: None,
+            class_node_kinds: &["class_declaration"],
+            find_function_receiver_handler: None,
+            extract_signature_handler: None,
+            decision_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "for_in_statement",
+                "while_statement",
+                "do_statement",
+                "switch_case",
+                "catch_clause",
+                "ternary_expression",
+                "&&",
+                "||",
+                "??",
+            ],
+            nesting_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "for_in_statement",
+                "while_statement",
+                "do_statement",
+                "switch_statement",
+                "catch_clause",
+                "ternary_expression",
+            ],
+            statement_block_kinds: &["statement_block"],
+            number_literal_kinds: &["number"],
+            constant_declaration_kinds: &[],
+            index_expression_kinds: &["subscript_expression"],
+            is_exported_handler: Some(javascript::is_exported),
+            extract_fields_handler: Some(javascript::extract_fields),
+            find_receiver_name_handler: None,
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         "go" => Some(LanguageInfo {
             element_query: go::ELEMENT_QUERY,
@@ -79,6 +344,43 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: None,
             find_method_for_receiver_handler: Some(go::find_method_for_receiver),
             find_receiver_type_handler: None,
+            class_node_kinds: &[],
+            find_function_receiver_handler: Some(go::find_function_receiver),
+            extract_signature_handler: Some(go::extract_signature),
+            decision_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "expression_case",
+                "type_case",
+                "communication_case",
+                "&&",
+                "||",
+            ],
+            nesting_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "expression_switch_statement",
+                "type_switch_statement",
+                "select_statement",
+            ],
+            statement_block_kinds: &["statement_list"],
+            number_literal_kinds: &["int_literal", "float_literal", "imaginary_literal"],
+            constant_declaration_kinds: &["const_declaration"],
+            index_expression_kinds: &["index_expression"],
+            is_exported_handler: Some(go::is_exported),
+            extract_fields_handler: Some(go::extract_fields),
+            find_receiver_name_handler: Some(go::find_receiver_name),
+            extract_interface_handler: Some(go::extract_interface),
+            find_shadowed_handler: Some(go::find_shadowed),
+            find_field_accesses_handler: Some(go::find_field_accesses),
+            find_empty_interfaces_handler: Some(go::find_empty_interfaces),
+            find_package_clause_handler: Some(go::find_package_clause),
+            find_naked_returns_handler: Some(go::find_naked_returns),
+            find_discarded_calls_handler: Some(go::find_discarded_calls),
+            find_string_concats_handler: Some(go::find_string_concats),
+            find_error_returns_handler: Some(go::find_error_returns),
+            find_unreachable_code_handler: Some(go::find_unreachable_code),
+            find_slice_appends_handler: Some(go::find_slice_appends),
         }),
         "java" => Some(LanguageInfo {
             element_query: java::ELEMENT_QUERY,
@@ -89,6 +391,60 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: None,
             find_method_for_receiver_handler: None,
             find_receiver_type_handler: None,
+            class_node_kinds: &[
+                "class_declaration",
+                "interface_declaration",
+                "enum_declaration",
+            ],
+            find_function_receiver_handler: None,
+            extract_signature_handler: Some(java::extract_signature),
+            decision_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "enhanced_for_statement",
+                "while_statement",
+                "do_statement",
+                "switch_label",
+                "catch_clause",
+                "ternary_expression",
+                "&&",
+                "||",
+            ],
+            nesting_node_kinds: &[
+                "if_statement",
+                "for_statement",
+                "enhanced_for_statement",
+                "while_statement",
+                "do_statement",
+                "switch_expression",
+                "catch_clause",
+                "ternary_expression",
+            ],
+            statement_block_kinds: &["block"],
+            number_literal_kinds: &[
+                "decimal_integer_literal",
+                "hex_integer_literal",
+                "octal_integer_literal",
+                "binary_integer_literal",
+                "decimal_floating_point_literal",
+                "hex_floating_point_literal",
+            ],
+            constant_declaration_kinds: &[],
+            index_expression_kinds: &["array_access"],
+            is_exported_handler: Some(java::is_exported),
+            extract_fields_handler: Some(java::extract_fields),
+            find_receiver_name_handler: None,
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         "kotlin" => Some(LanguageInfo {
             element_query: kotlin::ELEMENT_QUERY,
@@ -99,6 +455,52 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: None,
             find_method_for_receiver_handler: None,
             find_receiver_type_handler: None,
+            class_node_kinds: &["class_declaration", "object_declaration"],
+            find_function_receiver_handler: None,
+            extract_signature_handler: Some(kotlin::extract_signature),
+            decision_node_kinds: &[
+                "if_expression",
+                "for_statement",
+                "while_statement",
+                "do_while_statement",
+                "when_entry",
+                "catch_block",
+                "&&",
+                "||",
+            ],
+            nesting_node_kinds: &[
+                "if_expression",
+                "for_statement",
+                "while_statement",
+                "do_while_statement",
+                "when_expression",
+                "catch_block",
+            ],
+            statement_block_kinds: &["statements"],
+            number_literal_kinds: &[
+                "integer_literal",
+                "long_literal",
+                "hex_literal",
+                "bin_literal",
+                "unsigned_literal",
+                "real_literal",
+            ],
+            constant_declaration_kinds: &[],
+            index_expression_kinds: &["index_expression"],
+            is_exported_handler: Some(kotlin::is_exported),
+            extract_fields_handler: None,
+            find_receiver_name_handler: None,
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         "swift" => Some(LanguageInfo {
             element_query: swift::ELEMENT_QUERY,
@@ -114,6 +516,55 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: Some(swift::extract_function_name_for_kind),
             find_method_for_receiver_handler: None,
             find_receiver_type_handler: None,
+            class_node_kinds: &["class_declaration", "protocol_declaration"],
+            find_function_receiver_handler: None,
+            extract_signature_handler: Some(swift::extract_signature),
+            decision_node_kinds: &[
+                "if_statement",
+                "guard_statement",
+                "for_statement",
+                "while_statement",
+                "repeat_while_statement",
+                "switch_entry",
+                "catch_block",
+                "ternary_expression",
+                "&&",
+                "||",
+            ],
+            nesting_node_kinds: &[
+                "if_statement",
+                "guard_statement",
+                "for_statement",
+                "while_statement",
+                "repeat_while_statement",
+                "switch_statement",
+                "catch_block",
+                "ternary_expression",
+            ],
+            statement_block_kinds: &["statements"],
+            number_literal_kinds: &[
+                "integer_literal",
+                "real_literal",
+                "hex_literal",
+                "oct_literal",
+                "bin_literal",
+            ],
+            constant_declaration_kinds: &[],
+            index_expression_kinds: &[],
+            is_exported_handler: Some(swift::is_exported),
+            extract_fields_handler: None,
+            find_receiver_name_handler: None,
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         "ruby" => Some(LanguageInfo {
             element_query: ruby::ELEMENT_QUERY,
@@ -124,6 +575,60 @@ pub fn get_language_info(language: &str) -> Option<LanguageInfo> {
             extract_function_name_handler: None,
             find_method_for_receiver_handler: Some(ruby::find_method_for_receiver),
             find_receiver_type_handler: None,
+            class_node_kinds: &["class", "module"],
+            find_function_receiver_handler: None,
+            extract_signature_handler: None,
+            decision_node_kinds: &[
+                "if",
+                "unless",
+                "elsif",
+                "while",
+                "until",
+                "for",
+                "when",
+                "rescue",
+                "conditional",
+                "if_modifier",
+                "unless_modifier",
+                "while_modifier",
+                "until_modifier",
+                "&&",
+                "||",
+                "and",
+                "or",
+            ],
+            nesting_node_kinds: &[
+                "if",
+                "unless",
+                "while",
+                "until",
+                "for",
+                "case",
+                "rescue",
+                "conditional",
+                "if_modifier",
+                "unless_modifier",
+                "while_modifier",
+                "until_modifier",
+            ],
+            statement_block_kinds: &["body_statement", "then", "else", "do"],
+            number_literal_kinds: &["integer", "float"],
+            constant_declaration_kinds: &[],
+            index_expression_kinds: &["element_reference"],
+            is_exported_handler: None,
+            extract_fields_handler: None,
+            find_receiver_name_handler: None,
+            extract_interface_handler: None,
+            find_shadowed_handler: None,
+            find_field_accesses_handler: None,
+            find_empty_interfaces_handler: None,
+            find_package_clause_handler: None,
+            find_naked_returns_handler: None,
+            find_discarded_calls_handler: None,
+            find_string_concats_handler: None,
+            find_error_returns_handler: None,
+            find_unreachable_code_handler: None,
+            find_slice_appends_handler: None,
         }),
         _ => None,
     }
@@ -27,3 +27,47 @@ pub const CALL_QUERY: &str = r#"
     (decorator (identifier) @function.call)
     (decorator (attribute attribute: (identifier) @method.call))
 "#;
+
+/// Whether a Python name is public by convention (no leading underscore)
+pub fn is_exported(_node: &tree_sitter::Node, name: &str, _source: &str) -> bool {
+    !name.starts_with('_')
+}
+
+/// Find the explicit receiver parameter (usually `self` or `cls`) of a method
+/// defined directly in a class body. Static methods have none.
+pub fn find_receiver_name(node: &tree_sitter::Node, source: &str) -> Option<String> {
+    let mut outer = node.parent()?;
+    if outer.kind() == "decorated_definition" {
+        let is_static = (0..outer.child_count() as u32)
+            .filter_map(|i| outer.child(i))
+            .filter(|child| child.kind() == "decorator")
+            .filter_map(|child| source.get(child.byte_range()))
+            .any(|text| text.trim() == "@staticmethod");
+        if is_static {
+            return None;
+        }
+        outer = outer.parent()?;
+    }
+
+    let in_class_body = outer.kind() == "block"
+        && outer
+            .parent()
+            .is_some_and(|parent| parent.kind() == "class_definition");
+    if !in_class_body {
+        return None;
+    }
+
+    let params = node.child_by_field_name("parameters")?;
+    let first = (0..params.named_child_count() as u32)
+        .filter_map(|i| params.named_child(i))
+        .find(|child| !child.kind().contains("comment"))?;
+    let name = match first.kind() {
+        "identifier" => first,
+        "typed_parameter" => (0..first.named_child_count() as u32)
+            .filter_map(|i| first.named_child(i))
+            .find(|child| child.kind() == "identifier")?,
+        "default_parameter" | "typed_default_parameter" => first.child_by_field_name("name")?,
+        _ => return None,
+    };
+    source.get(name.byte_range()).map(|s| s.to_string())
+}
@@ -2,6 +2,8 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+use crate::analyze::types::FieldInfo;
+
 /// Tree-sitter query for extracting Rust code elements
 pub const ELEMENT_QUERY: &str = r#"
     (function_item name: (identifier) @func)
@@ -132,3 +134,76 @@ pub fn find_receiver_type(node: &tree_sitter::Node, source: &str) -> Option<Stri
     }
     None
 }
+
+/// Attributes that mark a function as invoked by the toolchain rather than by code
+const ENTRY_POINT_ATTRIBUTES: &[&str] = &["test", "bench", "main", "no_mangle"];
+
+/// Whether a Rust item is public or an entry point (`main`, tests, `#[no_mangle]`)
+pub fn is_exported(node: &tree_sitter::Node, name: &str, source: &str) -> bool {
+    let is_public = (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i))
+        .any(|child| child.kind() == "visibility_modifier");
+    if is_public || name == "main" {
+        return true;
+    }
+
+    let mut sibling = node.prev_sibling();
+    while let Some(prev) = sibling {
+        match prev.kind() {
+            "attribute_item" => {
+                let text = source.get(prev.byte_range()).unwrap_or("");
+                if ENTRY_POINT_ATTRIBUTES
+                    .iter()
+                    .any(|attr| text.contains(attr))
+                {
+                    return true;
+                }
+            }
+            "line_comment" | "block_comment" => {}
+            _ => break,
+        }
+        sibling = prev.prev_sibling();
+    }
+
+    false
+}
+
+/// Extract the named fields of a struct item
+pub fn extract_fields(node: &tree_sitter::Node, source: &str) -> Vec<FieldInfo> {
+    let Some(list) = node
+        .child_by_field_name("body")
+        .filter(|body| body.kind() == "field_declaration_list")
+    else {
+        return vec![];
+    };
+
+    (0..list.child_count() as u32)
+        .filter_map(|i| list.child(i))
+        .filter(|decl| decl.kind() == "field_declaration")
+        .filter_map(|decl| {
+            let name = decl.child_by_field_name("name")?;
+            Some(FieldInfo {
+                name: source.get(name.byte_range())?.to_string(),
+                line: decl.start_position().row + 1,
+                type_name: decl
+                    .child_by_field_name("type")
+                    .and_then(|type_node| source.get(type_node.byte_range()))
+                    .map(|s| s.to_string()),
+                exported: (0..decl.child_count() as u32)
+                    .filter_map(|i| decl.child(i))
+                    .any(|child| child.kind() == "visibility_modifier"),
+                tag: None,
+                embedded: false,
+            })
+        })
+        .collect()
+}
+
+/// Methods taking `self` in any form bind their receiver to `self`
+pub fn find_receiver_name(node: &tree_sitter::Node, _source: &str) -> Option<String> {
+    let params = node.child_by_field_name("parameters")?;
+    (0..params.child_count() as u32)
+        .filter_map(|i| params.child(i))
+        .any(|child| child.kind() == "self_parameter")
+        .then(|| "self".to_string())
+}
@@ -2,6 +2,8 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+use crate::analyze::types::ParamInfo;
+
 /// Tree-sitter query for extracting Swift code elements
 pub const ELEMENT_QUERY: &str = r#"
     ; Functions
@@ -71,3 +73,28 @@ pub fn extract_function_name_for_kind(
         _ => None,
     }
 }
+
+/// Extract parameters and the return type from a Swift function declaration
+pub fn extract_signature(
+    node: &tree_sitter::Node,
+    source: &str,
+) -> (Vec<ParamInfo>, Vec<ParamInfo>) {
+    let params = (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i))
+        .filter(|child| child.kind() == "parameter")
+        .map(|child| super::param_info(&child, source))
+        .collect();
+
+    let returns = node
+        .child_by_field_name("return_type")
+        .and_then(|type_node| source.get(type_node.byte_range()))
+        .map(|s| vec![ParamInfo::unnamed(s)])
+        .unwrap_or_default();
+
+    (params, returns)
+}
+
+/// Whether a declaration is visible outside its file (anything not `private` or `fileprivate`)
+pub fn is_exported(node: &tree_sitter::Node, _name: &str, source: &str) -> bool {
+    !super::has_modifier(node, source, &["private", "fileprivate"])
+}
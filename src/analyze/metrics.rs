@@ -0,0 +1,944 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::collections::HashSet;
+use std::path::{Path, PathBuf};
+use std::str::FromStr;
+
+use super::checks::ignore::{CHECK_COMPLEXITY, CHECK_FUNCTION_LOC, CHECK_NESTING, CHECK_PARAMS};
+use super::languages::LanguageInfo;
+use super::types::{AnalysisResult, FunctionInfo};
+
+/// A function whose cyclomatic complexity exceeds the configured maximum
+#[derive(Debug, Clone)]
+pub struct ComplexityViolation {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+    pub max: usize,
+}
+
+/// A function with more lines of code than the configured maximum
+#[derive(Debug, Clone)]
+pub struct LengthViolation {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+    pub max: usize,
+}
+
+/// A function declaring more parameters than the configured maximum
+#[derive(Debug, Clone)]
+pub struct ParamCountViolation {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+    pub max: usize,
+}
+
+/// A function nesting control flow more deeply than the configured maximum
+#[derive(Debug, Clone)]
+pub struct NestingViolation {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+    pub max: usize,
+}
+
+/// Upper bounds of the default function length buckets: 1–10, 11–25, 26–50
+/// and 51+ lines of code
+pub const DEFAULT_LENGTH_BUCKETS: &[usize] = &[10, 25, 50];
+
+/// Number of functions whose lines of code fall within `min..=max`
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct LengthBucket {
+    pub min: usize,
+    /// `None` for the last bucket, which has no upper bound
+    pub max: Option<usize>,
+    pub functions: usize,
+}
+
+impl LengthBucket {
+    /// `1–10`, or `51+` for the last bucket
+    pub fn label(&self) -> String {
+        match self.max {
+            Some(max) => format!("{}–{}", self.min, max),
+            None => format!("{}+", self.min),
+        }
+    }
+}
+
+/// Weights of the metrics summed into a function's hotspot score:
+///
+/// `complexity × (cyclomatic − 1) + cognitive × cognitive complexity +
+/// nesting × max nesting depth + loc × lines of code`
+///
+/// Cyclomatic complexity starts at 1, so straight-line code only scores for
+/// its length. The defaults count a level of nesting as two flow breaks and
+/// ten lines of code as one.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct HotspotWeights {
+    pub complexity: f64,
+    pub cognitive: f64,
+    pub nesting: f64,
+    pub loc: f64,
+}
+
+impl Default for HotspotWeights {
+    fn default() -> Self {
+        Self {
+            complexity: 1.0,
+            cognitive: 1.0,
+            nesting: 2.0,
+            loc: 0.1,
+        }
+    }
+}
+
+impl HotspotWeights {
+    /// Score of `function`, rounded to two decimals
+    pub fn score(&self, function: &FunctionInfo) -> f64 {
+        let score = self.complexity * function.complexity.saturating_sub(1) as f64
+            + self.cognitive * function.cognitive_complexity as f64
+            + self.nesting * function.max_nesting_depth as f64
+            + self.loc * function.lines_of_code as f64;
+        (score * 100.0).round() / 100.0
+    }
+}
+
+impl FromStr for HotspotWeights {
+    type Err = String;
+
+    /// Parse `name=weight` pairs separated by commas, such as
+    /// `complexity=2,loc=0`; metrics left out keep their default weight
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let mut weights = Self::default();
+        for pair in s.split(',').filter(|pair| !pair.trim().is_empty()) {
+            let (name, value) = pair.split_once('=').ok_or_else(|| {
+                format!("invalid hotspot weight '{}' (expected name=weight)", pair)
+            })?;
+            let value: f64 = value
+                .trim()
+                .parse()
+                .ok()
+                .filter(|value: &f64| value.is_finite() && *value >= 0.0)
+                .ok_or_else(|| format!("invalid hotspot weight '{}' for {}", value, name))?;
+            match name.trim() {
+                "complexity" => weights.complexity = value,
+                "cognitive" => weights.cognitive = value,
+                "nesting" => weights.nesting = value,
+                "loc" => weights.loc = value,
+                other => {
+                    return Err(format!(
+                        "unknown hotspot metric '{}' (expected complexity, cognitive, nesting or loc)",
+                        other
+                    ));
+                }
+            }
+        }
+        Ok(weights)
+    }
+}
+
+/// A function ranked by its hotspot score
+#[derive(Debug, Clone)]
+pub struct Hotspot {
+    pub path: PathBuf,
+    pub function: FunctionInfo,
+}
+
+/// Compute the cyclomatic complexity of a declaration node.
+///
+/// Starts at 1 and adds one for every decision point listed in the
+/// language's `decision_node_kinds`. Operator tokens such as `&&` are
+/// anonymous nodes whose kind is the operator itself, so they are matched
+/// the same way.
+pub fn cyclomatic_complexity(node: &tree_sitter::Node, info: &LanguageInfo) -> usize {
+    let mut complexity = 1;
+    let mut stack = vec![*node];
+
+    while let Some(current) = stack.pop() {
+        if info.decision_node_kinds.contains(&current.kind()) {
+            complexity += 1;
+        }
+        stack.extend((0..current.child_count() as u32).filter_map(|i| current.child(i)));
+    }
+
+    complexity
+}
+
+/// Anonymous functions across the supported grammars; like nested
+/// declarations they deepen the nesting without adding to the score
+const CLOSURE_NODE_KINDS: &[&str] = &[
+    "func_literal",
+    "closure_expression",
+    "lambda",
+    "arrow_function",
+    "function_expression",
+    "lambda_expression",
+    "lambda_literal",
+    "anonymous_function",
+    "do_block",
+];
+
+/// Named nodes that are the `else` part of the structure containing them
+const ELSE_NODE_KINDS: &[&str] = &["else_clause", "elif_clause", "elsif", "else"];
+
+/// Structures whose `else` keyword does not start a branch: Python's
+/// `a if c else b` ternary and Swift's `guard`
+const NON_BRANCH_ELSE_KINDS: &[&str] = &["conditional_expression", "guard_statement"];
+
+/// Boolean operator tokens whose sequences add to cognitive complexity
+const BOOLEAN_OPERATORS: &[&str] = &["&&", "||", "and", "or"];
+
+/// Compute the cognitive complexity of a declaration node, following the
+/// SonarSource rules.
+///
+/// Every structure in the language's `nesting_node_kinds` adds one plus the
+/// depth it is nested at, and nests whatever it contains. `else` and
+/// `else if` branches add one without a nesting penalty. Each run of the
+/// same boolean operator adds one, so `a && b && c` adds one and
+/// `a && b || c` adds two. Closures and nested functions only deepen the
+/// nesting. Recursion and labeled jumps are not counted.
+pub fn cognitive_complexity(node: &tree_sitter::Node, info: &LanguageInfo) -> usize {
+    let mut complexity = 0;
+    // (node, nesting depth, whether the node is an else branch)
+    let mut stack: Vec<(tree_sitter::Node, usize, bool)> =
+        children(node).map(|child| (child, 0, false)).collect();
+
+    while let Some((current, nesting, is_else)) = stack.pop() {
+        let kind = current.kind();
+
+        if is_else {
+            complexity += 1;
+            match else_if_target(&current, info) {
+                Some(target) => push_branches(&mut stack, &target, nesting, true),
+                None => push_branches(&mut stack, &current, nesting, false),
+            }
+        } else if info.nesting_node_kinds.contains(&kind) {
+            complexity += 1 + nesting;
+            push_branches(&mut stack, &current, nesting, true);
+        } else if CLOSURE_NODE_KINDS.contains(&kind) || info.function_node_kinds.contains(&kind) {
+            stack.extend(children(&current).map(|child| (child, nesting + 1, false)));
+        } else {
+            if let Some(operator) = boolean_operator(&current) {
+                let continues_sequence = current
+                    .named_child(0)
+                    .is_some_and(|left| boolean_operator(&left) == Some(operator));
+                if !continues_sequence {
+                    complexity += 1;
+                }
+            }
+            stack.extend(children(&current).map(|child| (child, nesting, false)));
+        }
+    }
+
+    complexity
+}
+
+/// Compute the deepest nesting of the structures in the language's
+/// `nesting_node_kinds` within a declaration node.
+///
+/// A structure at the top of the body is at depth 1. Plain blocks, closures
+/// and nested functions do not add a level, and an `else if` continues the
+/// level of the structure it chains to, so a long chain counts once.
+pub fn max_nesting_depth(node: &tree_sitter::Node, info: &LanguageInfo) -> usize {
+    let mut max_depth = 0;
+    // (node, structures enclosing it, whether the node is an else branch)
+    let mut stack: Vec<(tree_sitter::Node, usize, bool)> =
+        children(node).map(|child| (child, 0, false)).collect();
+
+    while let Some((current, depth, is_else)) = stack.pop() {
+        let structure = if is_else {
+            else_if_target(&current, info)
+        } else {
+            info.nesting_node_kinds
+                .contains(&current.kind())
+                .then_some(current)
+        };
+
+        match structure {
+            Some(structure) => {
+                max_depth = max_depth.max(depth + 1);
+                push_branches(&mut stack, &structure, depth, true);
+            }
+            // A plain else branch is inside the structure it belongs to
+            None if is_else => push_branches(&mut stack, &current, depth, false),
+            None => stack.extend(children(&current).map(|child| (child, depth, false))),
+        }
+    }
+
+    max_depth
+}
+
+fn children<'a>(node: &tree_sitter::Node<'a>) -> impl Iterator<Item = tree_sitter::Node<'a>> {
+    (0..node.child_count() as u32).filter_map(|i| node.child(i))
+}
+
+/// The structure an else branch chains to: the branch itself for `else if`
+/// in grammars without a wrapper, or the sole structure inside an
+/// `else_clause`
+fn else_if_target<'a>(
+    node: &tree_sitter::Node<'a>,
+    info: &LanguageInfo,
+) -> Option<tree_sitter::Node<'a>> {
+    if info.nesting_node_kinds.contains(&node.kind()) {
+        return Some(*node);
+    }
+    if !node.kind().ends_with("else_clause") {
+        return None;
+    }
+
+    let mut named =
+        children(node).filter(|child| child.is_named() && !child.kind().contains("comment"));
+    match (named.next(), named.next()) {
+        (Some(only), None) if info.nesting_node_kinds.contains(&only.kind()) => Some(only),
+        _ => None,
+    }
+}
+
+/// Push the children of a structure: else branches stay at its depth, the
+/// rest is nested one level deeper. `after_else_token` also treats whatever
+/// follows a bare `else` keyword as a branch, which grammars without an
+/// else node use; it is off for else clauses that hold their own keyword.
+fn push_branches<'a>(
+    stack: &mut Vec<(tree_sitter::Node<'a>, usize, bool)>,
+    node: &tree_sitter::Node<'a>,
+    nesting: usize,
+    after_else_token: bool,
+) {
+    let after_else_token = after_else_token && !NON_BRANCH_ELSE_KINDS.contains(&node.kind());
+    let mut previous: Option<tree_sitter::Node> = None;
+
+    for child in children(node) {
+        let is_else = (child.is_named() && ELSE_NODE_KINDS.contains(&child.kind()))
+            || (after_else_token && previous.is_some_and(|p| !p.is_named() && p.kind() == "else"));
+        if is_else {
+            stack.push((child, nesting, true));
+        } else {
+            stack.push((child, nesting + 1, false));
+        }
+        previous = Some(child);
+    }
+}
+
+/// The boolean operator token directly under a binary expression node
+fn boolean_operator(node: &tree_sitter::Node) -> Option<&'static str> {
+    children(node)
+        .filter(|child| !child.is_named())
+        .find_map(|child| {
+            BOOLEAN_OPERATORS
+                .iter()
+                .copied()
+                .find(|op| *op == child.kind())
+        })
+}
+
+/// Count the lines of `node` that hold at least one non-comment token.
+///
+/// Works on token positions rather than raw text: comment nodes are skipped
+/// as whole subtrees, so a block comment starting after code only leaves that
+/// code line behind, and a line with code plus a trailing comment still
+/// counts. Tokens spanning several lines, such as raw strings, count every
+/// line they cover. Anonymous delimiters that open or close `node` (the braces
+/// of a block) are not counted.
+pub fn lines_of_code(node: &tree_sitter::Node) -> usize {
+    let last = node.child_count().saturating_sub(1) as u32;
+    let mut stack: Vec<tree_sitter::Node> = (0..node.child_count() as u32)
+        .filter_map(|i| node.child(i).map(|child| (i, child)))
+        .filter(|(i, child)| child.is_named() || (*i != 0 && *i != last))
+        .map(|(_, child)| child)
+        .collect();
+    let mut lines = HashSet::new();
+
+    while let Some(current) = stack.pop() {
+        if current.kind().contains("comment") {
+            continue;
+        }
+
+        if current.child_count() == 0 {
+            if current.start_byte() < current.end_byte() {
+                lines.extend(current.start_position().row..=current.end_position().row);
+            }
+            continue;
+        }
+
+        stack.extend((0..current.child_count() as u32).filter_map(|i| current.child(i)));
+    }
+
+    lines.len()
+}
+
+/// Lines of code in a function body, falling back to the whole declaration
+/// for grammars without a `body` field
+pub fn function_lines_of_code(decl: &tree_sitter::Node) -> usize {
+    let body = decl.child_by_field_name("body").or_else(|| {
+        (0..decl.named_child_count() as u32)
+            .filter_map(|i| decl.named_child(i))
+            .find(|child| child.kind() == "function_body")
+    });
+
+    match body {
+        Some(body) => lines_of_code(&body),
+        None => lines_of_code(decl),
+    }
+}
+
+/// Collect functions whose complexity is above `max`, ordered by path and
+/// line; functions with an `analyzer:ignore complexity` comment are skipped
+pub fn complexity_violations(
+    results: &[(PathBuf, AnalysisResult)],
+    max: usize,
+) -> Vec<ComplexityViolation> {
+    let mut violations: Vec<ComplexityViolation> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(move |f| f.complexity > max && !f.is_ignored(CHECK_COMPLEXITY))
+                .map(move |f| ComplexityViolation {
+                    path: path.clone(),
+                    function: f.clone(),
+                    max,
+                })
+        })
+        .collect();
+
+    violations.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    violations
+}
+
+/// Collect functions with more than `max` lines of code, ordered by path and
+/// line; functions with an `analyzer:ignore function-loc` comment are skipped
+pub fn length_violations(
+    results: &[(PathBuf, AnalysisResult)],
+    max: usize,
+) -> Vec<LengthViolation> {
+    let mut violations: Vec<LengthViolation> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(move |f| f.lines_of_code > max && !f.is_ignored(CHECK_FUNCTION_LOC))
+                .map(move |f| LengthViolation {
+                    path: path.clone(),
+                    function: f.clone(),
+                    max,
+                })
+        })
+        .collect();
+
+    violations.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    violations
+}
+
+/// Collect functions with more than `max` parameters, ordered by path and
+/// line. Each name of a grouped declaration such as Go's `(a, b int)` counts,
+/// a variadic counts once and receivers do not; functions with an
+/// `analyzer:ignore params` comment are skipped.
+pub fn param_count_violations(
+    results: &[(PathBuf, AnalysisResult)],
+    max: usize,
+) -> Vec<ParamCountViolation> {
+    let mut violations: Vec<ParamCountViolation> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(move |f| f.params.len() > max && !f.is_ignored(CHECK_PARAMS))
+                .map(move |f| ParamCountViolation {
+                    path: path.clone(),
+                    function: f.clone(),
+                    max,
+                })
+        })
+        .collect();
+
+    violations.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    violations
+}
+
+/// Collect functions nesting control flow deeper than `max`, ordered by path
+/// and line; functions with an `analyzer:ignore nesting` comment are skipped
+pub fn nesting_violations(
+    results: &[(PathBuf, AnalysisResult)],
+    max: usize,
+) -> Vec<NestingViolation> {
+    let mut violations: Vec<NestingViolation> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result
+                .functions
+                .iter()
+                .filter(move |f| f.max_nesting_depth > max && !f.is_ignored(CHECK_NESTING))
+                .map(move |f| NestingViolation {
+                    path: path.clone(),
+                    function: f.clone(),
+                    max,
+                })
+        })
+        .collect();
+
+    violations.sort_by(|a, b| {
+        a.path
+            .cmp(&b.path)
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    violations
+}
+
+/// Count the functions in each length bucket. `bounds` are the inclusive
+/// upper bounds of every bucket but the last, which holds the longer
+/// functions; they are sorted and deduplicated, and 0 is dropped. Functions
+/// without any line of code count in the first bucket. Every function is
+/// counted, including those under an ignore comment.
+pub fn length_distribution(
+    results: &[(PathBuf, AnalysisResult)],
+    bounds: &[usize],
+) -> Vec<LengthBucket> {
+    let mut bounds: Vec<usize> = bounds.iter().copied().filter(|&bound| bound > 0).collect();
+    bounds.sort_unstable();
+    bounds.dedup();
+
+    let mut buckets: Vec<LengthBucket> = bounds
+        .iter()
+        .map(Some)
+        .chain([None])
+        .scan(1, |min, max| {
+            let bucket = LengthBucket {
+                min: *min,
+                max: max.copied(),
+                functions: 0,
+            };
+            *min = max.map_or(*min, |max| max + 1);
+            Some(bucket)
+        })
+        .collect();
+
+    for function in results.iter().flat_map(|(_, result)| &result.functions) {
+        let index = bounds.partition_point(|&bound| bound < function.lines_of_code);
+        buckets[index].functions += 1;
+    }
+    buckets
+}
+
+/// Set the hotspot score of every function from `weights`
+pub fn score_hotspots(functions: &mut [FunctionInfo], weights: &HotspotWeights) {
+    for function in functions {
+        function.hotspot_score = weights.score(function);
+    }
+}
+
+/// The `n` functions with the highest hotspot score, highest first; ties are
+/// ordered by path and line. Every function is ranked, including those
+/// under an ignore comment.
+pub fn hotspots(results: &[(PathBuf, AnalysisResult)], n: usize) -> Vec<Hotspot> {
+    let mut hotspots: Vec<Hotspot> = results
+        .iter()
+        .flat_map(|(path, result)| {
+            result.functions.iter().map(move |f| Hotspot {
+                path: path.clone(),
+                function: f.clone(),
+            })
+        })
+        .collect();
+
+    hotspots.sort_by(|a, b| {
+        b.function
+            .hotspot_score
+            .total_cmp(&a.function.hotspot_score)
+            .then_with(|| a.path.cmp(&b.path))
+            .then_with(|| a.function.line.cmp(&b.function.line))
+    });
+    hotspots.truncate(n);
+    hotspots
+}
+
+/// Format hotspots as a `HOTSPOTS:` section with paths relative to `base`
+pub fn format_hotspots(base: &Path, hotspots: &[Hotspot]) -> String {
+    if hotspots.is_empty() {
+        return String::new();
+    }
+
+    let mut output = String::from("\nHOTSPOTS:\n");
+    for hotspot in hotspots {
+        let path = hotspot.path.strip_prefix(base).unwrap_or(&hotspot.path);
+        let function = &hotspot.function;
+        output.push_str(&format!(
+            "  {}:{} {} score {:.2} (complexity {}, cognitive {}, nesting {}, loc {})\n",
+            path.display(),
+            function.line,
+            function.name,
+            function.hotspot_score,
+            function.complexity,
+            function.cognitive_complexity,
+            function.max_nesting_depth,
+            function.lines_of_code
+        ));
+    }
+    output
+}
+
+/// Format complexity violations, one per line, relative to `base`
+pub fn format_complexity_violations(base: &Path, violations: &[ComplexityViolation]) -> String {
+    let mut output = String::new();
+    for violation in violations {
+        let path = violation.path.strip_prefix(base).unwrap_or(&violation.path);
+        output.push_str(&format!(
+            "{}:{}: {} has cyclomatic complexity {} (max {})\n",
+            path.display(),
+            violation.function.line,
+            violation.function.name,
+            violation.function.complexity,
+            violation.max
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::languages;
+    use crate::analyze::parser::{ElementExtractor, ParserManager};
+
+    fn complexity_of(code: &str, language: &str) -> usize {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, language).unwrap();
+        let info = languages::get_language_info(language).unwrap();
+        cyclomatic_complexity(&tree.root_node(), &info)
+    }
+
+    fn function_loc(code: &str, language: &str) -> usize {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, language).unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, language).unwrap();
+        result.functions[0].lines_of_code
+    }
+
+    fn cognitive_of(code: &str, language: &str) -> usize {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, language).unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, language).unwrap();
+        result.functions[0].cognitive_complexity
+    }
+
+    fn nesting_of(code: &str, language: &str) -> usize {
+        let pm = ParserManager::new();
+        let tree = pm.parse(code, language).unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, language).unwrap();
+        result.functions[0].max_nesting_depth
+    }
+
+    fn result_with(functions: &[(&str, usize)]) -> AnalysisResult {
+        let mut result = AnalysisResult::empty(10);
+        result.functions = functions
+            .iter()
+            .enumerate()
+            .map(|(i, (name, complexity))| FunctionInfo {
+                name: name.to_string(),
+                line: i + 1,
+                complexity: *complexity,
+                ..Default::default()
+            })
+            .collect();
+        result
+    }
+
+    #[test]
+    fn straight_line_code_has_complexity_one() {
+        assert_eq!(complexity_of("package main\nfunc f() { g() }\n", "go"), 1);
+    }
+
+    #[test]
+    fn go_branches_and_operators_count() {
+        let code = "package main\nfunc f(a, b bool, xs []int) {\n\tif a && b {\n\t}\n\tfor range xs {\n\t}\n\tswitch {\n\tcase a:\n\tcase b || a:\n\tdefault:\n\t}\n}\n";
+        // 1 + if + && + for + 2 cases + ||
+        assert_eq!(complexity_of(code, "go"), 7);
+    }
+
+    #[test]
+    fn python_boolean_operators_count() {
+        let code =
+            "def f(a, b):\n    if a and b:\n        return 1\n    elif a or b:\n        return 2\n";
+        assert_eq!(complexity_of(code, "python"), 5);
+    }
+
+    #[test]
+    fn straight_line_code_has_cognitive_complexity_zero() {
+        assert_eq!(cognitive_of("package main\nfunc f() { g() }\n", "go"), 0);
+    }
+
+    #[test]
+    fn nesting_scores_higher_than_flat_code() {
+        let flat = "package main\nfunc f(a, b bool) {\n\tif a {\n\t}\n\tif b {\n\t}\n}\n";
+        let nested = "package main\nfunc f(a, b bool) {\n\tif a {\n\t\tif b {\n\t\t}\n\t}\n}\n";
+        assert_eq!(complexity_of(flat, "go"), complexity_of(nested, "go"));
+        assert_eq!(cognitive_of(flat, "go"), 2);
+        // 1 for the outer if, 1 plus one level of nesting for the inner one
+        assert_eq!(cognitive_of(nested, "go"), 3);
+    }
+
+    #[test]
+    fn nested_loops_add_their_depth() {
+        let code = "package main\nfunc f(xs []int) {\n\tfor range xs {\n\t\tfor range xs {\n\t\t\tif true {\n\t\t\t}\n\t\t}\n\t}\n}\n";
+        assert_eq!(cognitive_of(code, "go"), 1 + 2 + 3);
+    }
+
+    #[test]
+    fn else_branches_add_one_without_nesting() {
+        let code =
+            "package main\nfunc f(a, b bool) {\n\tif a {\n\t} else if b {\n\t} else {\n\t}\n}\n";
+        assert_eq!(cognitive_of(code, "go"), 3);
+        let code =
+            "fn f(a: bool, b: bool) {\n    if a {\n    } else if b {\n    } else {\n    }\n}\n";
+        assert_eq!(cognitive_of(code, "rust"), 3);
+    }
+
+    #[test]
+    fn boolean_operator_runs_count_once_each() {
+        let same = "package main\nfunc f(a, b, c bool) {\n\tif a && b && c {\n\t}\n}\n";
+        let mixed = "package main\nfunc f(a, b, c bool) {\n\tif a && b || c {\n\t}\n}\n";
+        assert_eq!(cognitive_of(same, "go"), 2);
+        assert_eq!(cognitive_of(mixed, "go"), 3);
+    }
+
+    #[test]
+    fn closures_deepen_nesting() {
+        let code =
+            "package main\nfunc f(a bool) {\n\tg := func() {\n\t\tif a {\n\t\t}\n\t}\n\tg()\n}\n";
+        assert_eq!(cognitive_of(code, "go"), 2);
+    }
+
+    #[test]
+    fn python_elif_and_ternary() {
+        let code = "def f(a, b):\n    if a and b:\n        return 1 if a else 2\n    elif b:\n        return 3\n    else:\n        return 4\n";
+        // if + and + nested ternary (1 + 1) + elif + else
+        assert_eq!(cognitive_of(code, "python"), 6);
+    }
+
+    #[test]
+    fn nesting_depth_counts_structures_not_blocks() {
+        assert_eq!(nesting_of("package main\nfunc f() { g() }\n", "go"), 0);
+        let code = "package main\nfunc f(xs []int) {\n\t{\n\t\tfor _, x := range xs {\n\t\t\tif x > 0 {\n\t\t\t\tswitch x {\n\t\t\t\tcase 1:\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t}\n\tif len(xs) == 0 {\n\t}\n}\n";
+        assert_eq!(nesting_of(code, "go"), 3);
+        let closure = "package main\nfunc f(a bool) {\n\tif a {\n\t\tgo func() {\n\t\t\tfor {\n\t\t\t}\n\t\t}()\n\t}\n}\n";
+        assert_eq!(nesting_of(closure, "go"), 2);
+    }
+
+    #[test]
+    fn else_if_chains_stay_at_one_level() {
+        let chain = "package main\nfunc f(a, b, c bool) {\n\tif a {\n\t} else if b {\n\t} else if c {\n\t} else {\n\t}\n}\n";
+        assert_eq!(nesting_of(chain, "go"), 1);
+        let nested =
+            "package main\nfunc f(a, b bool) {\n\tif a {\n\t} else {\n\t\tif b {\n\t\t}\n\t}\n}\n";
+        assert_eq!(nesting_of(nested, "go"), 2);
+        let python = "def f(a, b):\n    if a:\n        pass\n    elif b:\n        while b:\n            pass\n    else:\n        pass\n";
+        assert_eq!(nesting_of(python, "python"), 2);
+    }
+
+    #[test]
+    fn nesting_violations_skip_ignored_functions() {
+        let mut result = result_with(&[("flat", 1), ("deep", 1), ("generated", 1)]);
+        result.functions[1].max_nesting_depth = 5;
+        result.functions[2].max_nesting_depth = 6;
+        result.functions[2].ignored_checks = vec![CHECK_NESTING.into()];
+        let violations = nesting_violations(&[(PathBuf::from("/p/a.go"), result)], 4);
+        let names: Vec<&str> = violations
+            .iter()
+            .map(|v| v.function.name.as_str())
+            .collect();
+        assert_eq!(names, vec!["deep"]);
+    }
+
+    #[test]
+    fn lines_of_code_skips_blank_lines_and_comments() {
+        let code = "package main\n\nfunc f() {\n\ta := 1 /* starts here\n\tstill comment */\n\t// full line\n\n\tb := a // trailing\n\t_ = b\n}\n";
+        assert_eq!(function_loc(code, "go"), 3);
+    }
+
+    #[test]
+    fn lines_of_code_excludes_body_braces() {
+        let code = "fn f() {\n    let x = 1;\n    println!(\"{}\", x);\n}\n";
+        assert_eq!(function_loc(code, "rust"), 2);
+    }
+
+    #[test]
+    fn lines_of_code_counts_multiline_strings() {
+        let code = "def f():\n    \"\"\"Doc\n    string\"\"\"\n    # comment\n    return 1\n";
+        assert_eq!(function_loc(code, "python"), 3);
+    }
+
+    #[test]
+    fn violations_only_include_functions_above_max() {
+        let results = vec![(
+            PathBuf::from("/p/a.go"),
+            result_with(&[("simple", 1), ("branchy", 12)]),
+        )];
+        let violations = complexity_violations(&results, 10);
+        assert_eq!(violations.len(), 1);
+        assert_eq!(violations[0].function.name, "branchy");
+    }
+
+    #[test]
+    fn violations_skip_functions_ignoring_complexity() {
+        let mut result = result_with(&[("parser", 40), ("generated", 30), ("other", 20)]);
+        result.functions[0].ignored_checks = vec!["complexity".into()];
+        result.functions[1].ignored_checks = vec!["all".into()];
+        result.functions[2].ignored_checks = vec!["unused".into()];
+        let violations = complexity_violations(&[(PathBuf::from("/p/a.go"), result)], 10);
+        assert_eq!(violations.len(), 1);
+        assert_eq!(violations[0].function.name, "other");
+    }
+
+    #[test]
+    fn length_distribution_counts_functions_per_bucket() {
+        let mut result = AnalysisResult::empty(200);
+        result.functions = [0, 10, 11, 25, 26, 51, 120]
+            .iter()
+            .map(|&lines_of_code| FunctionInfo {
+                lines_of_code,
+                ..Default::default()
+            })
+            .collect();
+        let results = vec![(PathBuf::from("/p/a.go"), result)];
+
+        let buckets = length_distribution(&results, DEFAULT_LENGTH_BUCKETS);
+        let counts: Vec<(String, usize)> = buckets
+            .iter()
+            .map(|bucket| (bucket.label(), bucket.functions))
+            .collect();
+        assert_eq!(
+            counts,
+            vec![
+                ("1–10".to_string(), 2),
+                ("11–25".to_string(), 2),
+                ("26–50".to_string(), 1),
+                ("51+".to_string(), 2),
+            ]
+        );
+
+        // Bounds are normalized; none leaves a single open bucket
+        let buckets = length_distribution(&results, &[100, 0, 20, 20]);
+        assert_eq!(
+            buckets.iter().map(|b| b.functions).collect::<Vec<_>>(),
+            vec![3, 3, 1]
+        );
+        assert_eq!(buckets[2].label(), "101+");
+        assert_eq!(
+            length_distribution(&results, &[]),
+            vec![LengthBucket {
+                min: 1,
+                max: None,
+                functions: 7
+            }]
+        );
+    }
+
+    #[test]
+    fn hotspot_score_weighs_each_metric() {
+        let function = FunctionInfo {
+            complexity: 8,
+            cognitive_complexity: 10,
+            max_nesting_depth: 3,
+            lines_of_code: 45,
+            ..Default::default()
+        };
+        assert_eq!(HotspotWeights::default().score(&function), 27.5);
+
+        let weights: HotspotWeights = "complexity=2, loc=0".parse().unwrap();
+        assert_eq!(
+            weights,
+            HotspotWeights {
+                complexity: 2.0,
+                loc: 0.0,
+                ..HotspotWeights::default()
+            }
+        );
+        assert_eq!(weights.score(&function), 30.0);
+
+        // Straight-line code only scores for its length
+        let trivial = FunctionInfo {
+            complexity: 1,
+            lines_of_code: 2,
+            ..Default::default()
+        };
+        assert_eq!(HotspotWeights::default().score(&trivial), 0.2);
+
+        assert!("size=1".parse::<HotspotWeights>().is_err());
+        assert!("loc".parse::<HotspotWeights>().is_err());
+        assert!("loc=-1".parse::<HotspotWeights>().is_err());
+    }
+
+    #[test]
+    fn hotspots_rank_the_highest_scores_first() {
+        let mut a = result_with(&[("flat", 1), ("branchy", 9), ("tied", 3)]);
+        let mut b = result_with(&[("other", 3)]);
+        score_hotspots(&mut a.functions, &HotspotWeights::default());
+        score_hotspots(&mut b.functions, &HotspotWeights::default());
+        let results = vec![(PathBuf::from("/p/b.go"), b), (PathBuf::from("/p/a.go"), a)];
+
+        let names: Vec<String> = hotspots(&results, 3)
+            .iter()
+            .map(|h| format!("{}:{}", h.path.display(), h.function.name))
+            .collect();
+        assert_eq!(
+            names,
+            vec!["/p/a.go:branchy", "/p/a.go:tied", "/p/b.go:other"]
+        );
+
+        assert_eq!(
+            format_hotspots(Path::new("/p"), &hotspots(&results, 1)),
+            "\nHOTSPOTS:\n  a.go:2 branchy score 8.00 (complexity 9, cognitive 0, nesting 0, loc 0)\n"
+        );
+        assert!(format_hotspots(Path::new("/p"), &[]).is_empty());
+    }
+
+    #[test]
+    fn length_violations_use_lines_of_code() {
+        let mut result = result_with(&[("short", 1), ("long", 1), ("generated", 1)]);
+        result.functions[0].lines_of_code = 40;
+        result.functions[1].lines_of_code = 61;
+        result.functions[2].lines_of_code = 500;
+        result.functions[2].ignored_checks = vec!["function-loc".into()];
+        let violations = length_violations(&[(PathBuf::from("/p/a.go"), result)], 60);
+        assert_eq!(violations.len(), 1);
+        assert_eq!(violations[0].function.name, "long");
+    }
+
+    #[test]
+    fn param_count_violations_count_each_parameter() {
+        let pm = ParserManager::new();
+        let code = "package main\n\nfunc helper(x int) int {\n\treturn x\n}\n\nfunc (s *Server) draw(x, y, w, h int, color string, opts ...Option) {\n}\n\n//analyzer:ignore params\nfunc legacy(a, b, c, d, e, f int) {\n}\n";
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+        let violations = param_count_violations(&[(PathBuf::from("/p/a.go"), result)], 5);
+        let found: Vec<(&str, usize)> = violations
+            .iter()
+            .map(|v| (v.function.name.as_str(), v.function.params.len()))
+            .collect();
+        assert_eq!(found, vec![("draw", 6)]);
+    }
+
+    #[test]
+    fn violations_format_relative_paths() {
+        let results = vec![(PathBuf::from("/p/a.go"), result_with(&[("branchy", 12)]))];
+        let violations = complexity_violations(&results, 10);
+        let out = format_complexity_violations(Path::new("/p"), &violations);
+        assert_eq!(
+            out,
+            "a.go:1: branchy has cyclomatic complexity 12 (max 10)\n"
+        );
+    }
+}
@@ -2,22 +2,76 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
+pub mod api;
+pub mod build;
 pub mod cache;
+pub mod cancel;
+pub mod checks;
+pub mod compare;
+pub mod diff;
+pub mod filter;
 pub mod formatter;
 pub mod graph;
+pub mod implementations;
+pub mod imports;
 pub mod languages;
+pub mod metrics;
+pub mod output;
+pub mod packages;
 pub mod parser;
+pub mod policy;
+pub mod stats;
 pub mod traversal;
 pub mod types;
 
+use std::collections::BTreeMap;
+use std::io::Write;
 use std::path::{Path, PathBuf};
+use std::sync::{Arc, Mutex};
+use std::time::Instant;
 
-use self::cache::AnalysisCache;
+use self::api::ApiSurface;
+use self::build::{BuildContext, SkippedFile};
+use self::cache::{AnalysisCache, DiskCache};
+use self::cancel::CancelToken;
+use self::checks::any::{self, EmptyInterfaceType};
+use self::checks::append::{self, SliceAppendInLoop};
+use self::checks::clones::{self, CloneGroup};
+use self::checks::concat::{self, StringConcatInLoop};
+use self::checks::custom::{self, Check, ParsedFile, ParsedFiles};
+use self::checks::docs::{self, MissingDoc};
+use self::checks::errors::{self, IgnoredError};
+use self::checks::fields::{self, UnusedField};
+use self::checks::magic::{self, MagicNumber};
+use self::checks::naked::{self, NakedReturn};
+use self::checks::panics::{self, PanicCall};
+use self::checks::receiver::{self, UnusedReceiver};
+use self::checks::receiver_kinds::{self, MixedReceivers};
+use self::checks::shadow::{self, ShadowedVariable};
+use self::checks::tags::{self, DuplicateJsonTag};
+use self::checks::todo::{self, TodoComment};
+use self::checks::unreachable::{self, UnreachableStatement};
+use self::checks::unused::{self, UnusedFunction};
+use self::checks::wrapping::{self, UnwrappedError};
+use self::checks::{Finding, builtin};
+use self::compare::MetricsDiff;
+use self::diff::ChangedLines;
+use self::filter::PathFilter;
 use self::formatter::Formatter;
 use self::graph::CallGraph;
+use self::imports::ImportGraph;
+use self::metrics::{
+    ComplexityViolation, Hotspot, HotspotWeights, LengthBucket, LengthViolation, NestingViolation,
+    ParamCountViolation,
+};
+use self::output::json::{JsonFile, JsonReport};
+use self::output::{GroupBy, OutputFormat, SortOrder};
+use self::packages::PackageReport;
 use self::parser::{ElementExtractor, ParserManager};
+use self::policy::{Policy, Violation};
+use self::stats::Stats;
 use self::traversal::FileTraverser;
-use self::types::{AnalysisMode, AnalysisResult, FocusedAnalysisData};
+use self::types::{AnalysisMode, AnalysisResult, EntryType, FocusedAnalysisData, FunctionInfo};
 
 use crate::lang;
 
@@ -41,6 +95,7 @@ where
 pub struct CodeAnalyzer {
     parser_manager: ParserManager,
     cache: AnalysisCache,
+    disk_cache: Option<DiskCache>,
 }
 
 impl Default for CodeAnalyzer {
@@ -54,9 +109,17 @@ impl CodeAnalyzer {
         Self {
             parser_manager: ParserManager::new(),
             cache: AnalysisCache::new(100),
+            disk_cache: None,
         }
     }
 
+    /// Persist parse results under `dir`, keyed by file content hash, so
+    /// unchanged files are not re-parsed by later runs
+    pub fn with_disk_cache(mut self, dir: impl Into<PathBuf>) -> Self {
+        self.disk_cache = Some(DiskCache::new(dir));
+        self
+    }
+
     fn determine_mode(&self, focus: &Option<String>, path: &Path) -> AnalysisMode {
         if focus.is_some() {
             return AnalysisMode::Focused;
@@ -90,18 +153,90 @@ impl CodeAnalyzer {
             return Ok(cached);
         }
 
-        let content = match std::fs::read_to_string(path) {
+        let content = match read_source(path) {
             Ok(content) => content,
-            Err(_) => {
-                return Ok(AnalysisResult::empty(0));
-            }
+            Err(e) => return Ok(AnalysisResult::failed(e)),
         };
 
+        let result = self.analyze_content(path, &content, mode, ast_recursion_limit)?;
+        self.cache
+            .put(path.to_path_buf(), modified, mode, result.clone());
+        Ok(result)
+    }
+
+    /// Analyze `path` as `analyze_file` does, also returning the source and
+    /// syntax tree of the file for the checks. The in-memory cache keeps no
+    /// tree and is only written here; a result from the disk cache comes
+    /// with a parse of its own.
+    fn analyze_file_with_source(
+        &self,
+        path: &Path,
+        mode: &AnalysisMode,
+        ast_recursion_limit: Option<usize>,
+    ) -> Result<(AnalysisResult, Option<ParsedFile>), String> {
+        let source = match read_source(path) {
+            Ok(source) => source,
+            Err(e) => return Ok((AnalysisResult::failed(e), None)),
+        };
+        let (result, tree) = self.parse_content(path, &source, mode, ast_recursion_limit)?;
+        if let Ok(modified) = std::fs::metadata(path).and_then(|m| m.modified()) {
+            self.cache
+                .put(path.to_path_buf(), modified, mode, result.clone());
+        }
+        Ok((result, self.parsed_file(path, source, tree)))
+    }
+
+    /// `source` of `path` with its syntax tree, parsed here unless `tree`
+    /// already is; `None` for a language without a parser
+    fn parsed_file(
+        &self,
+        path: &Path,
+        source: String,
+        tree: Option<tree_sitter::Tree>,
+    ) -> Option<ParsedFile> {
+        let language = lang::get_language_identifier(path);
+        if language.is_empty() {
+            return None;
+        }
+        let tree = match tree {
+            Some(tree) => tree,
+            None => self.parser_manager.parse(&source, language).ok()?,
+        };
+        Some(ParsedFile {
+            language,
+            source,
+            tree,
+        })
+    }
+
+    /// Analyze source text as if it were the contents of `path`, which only
+    /// decides the language. Persisted to the disk cache, if any, but not to
+    /// the in-memory cache, which is keyed by modification time.
+    fn analyze_content(
+        &self,
+        path: &Path,
+        content: &str,
+        mode: &AnalysisMode,
+        ast_recursion_limit: Option<usize>,
+    ) -> Result<AnalysisResult, String> {
+        self.parse_content(path, content, mode, ast_recursion_limit)
+            .map(|(result, _)| result)
+    }
+
+    /// `analyze_content`, also returning the syntax tree when the content
+    /// was parsed rather than found in the disk cache
+    fn parse_content(
+        &self,
+        path: &Path,
+        content: &str,
+        mode: &AnalysisMode,
+        ast_recursion_limit: Option<usize>,
+    ) -> Result<(AnalysisResult, Option<tree_sitter::Tree>), String> {
         let line_count = content.lines().count();
 
         let language = lang::get_language_identifier(path);
         if language.is_empty() {
-            return Ok(AnalysisResult::empty(line_count));
+            return Ok((AnalysisResult::empty(line_count), None));
         }
 
         let language_supported = languages::get_language_info(language)
@@ -109,15 +244,26 @@ impl CodeAnalyzer {
             .unwrap_or(false);
 
         if !language_supported {
-            return Ok(AnalysisResult::empty(line_count));
+            return Ok((AnalysisResult::empty(line_count), None));
+        }
+
+        let disk_entry = self.disk_cache.as_ref().map(|disk_cache| {
+            let key = DiskCache::key(content, language, mode, ast_recursion_limit);
+            (disk_cache, key)
+        });
+        if let Some(cached) = disk_entry
+            .as_ref()
+            .and_then(|(disk_cache, key)| disk_cache.get(key))
+        {
+            return Ok((cached, None));
         }
 
-        let tree = self.parser_manager.parse(&content, language)?;
+        let tree = self.parser_manager.parse(content, language)?;
 
         let depth = mode.as_str();
         let mut result = ElementExtractor::extract_with_depth(
             &tree,
-            &content,
+            content,
             language,
             depth,
             ast_recursion_limit,
@@ -125,100 +271,410 @@ impl CodeAnalyzer {
 
         result.line_count = line_count;
 
-        self.cache
-            .put(path.to_path_buf(), modified, mode, result.clone());
+        if let Some((disk_cache, key)) = &disk_entry {
+            disk_cache.put(key, &result);
+        }
 
-        Ok(result)
+        Ok((result, Some(tree)))
     }
 
-    fn analyze_directory(
+    /// Analyze a file or every file in a directory in `mode`, and with
+    /// `parsed` keep the source and syntax tree of each file
+    fn collect_results(
         &self,
         path: &Path,
+        mode: AnalysisMode,
         max_depth: u32,
         ast_recursion_limit: Option<usize>,
         traverser: &FileTraverser,
-        mode: &AnalysisMode,
-    ) -> Result<String, String> {
-        let mode = *mode;
-
-        let results = traverser.collect_directory_results(path, max_depth, |file_path| {
-            self.analyze_file(file_path, &mode, ast_recursion_limit)
-        })?;
+        parsed: Option<&Mutex<ParsedFiles>>,
+    ) -> Result<Vec<(PathBuf, AnalysisResult)>, String> {
+        let analyze = |file_path: &Path| match parsed {
+            Some(parsed) => {
+                let (result, file) =
+                    self.analyze_file_with_source(file_path, &mode, ast_recursion_limit)?;
+                if let Some(file) = file {
+                    lock_or_recover(parsed, |_| {}).insert(file_path.to_path_buf(), file);
+                }
+                Ok(result)
+            }
+            None => self.analyze_file(file_path, &mode, ast_recursion_limit),
+        };
 
-        Ok(Formatter::format_directory_structure(
-            path, &results, max_depth,
-        ))
-    }
+        if path.is_file() {
+            let result = analyze(path)?;
+            return Ok(vec![(path.to_path_buf(), result)]);
+        }
 
-    fn analyze_focused(
-        &self,
-        path: &Path,
-        focus: &str,
-        follow_depth: u32,
-        max_depth: u32,
-        ast_recursion_limit: Option<usize>,
-        traverser: &FileTraverser,
-    ) -> Result<String, String> {
-        let files_to_analyze = if path.is_file() {
-            vec![path.to_path_buf()]
-        } else {
-            traverser.collect_files_for_focused(path, max_depth)?
-        };
+        let results = traverser.collect_directory_results(path, max_depth, analyze)?;
 
-        use rayon::prelude::*;
-        let all_results: Result<Vec<_>, _> = files_to_analyze
-            .par_iter()
-            .map(|file_path| {
-                self.analyze_file(file_path, &AnalysisMode::Semantic, ast_recursion_limit)
-                    .map(|result| (file_path.clone(), result))
+        Ok(results
+            .into_iter()
+            .map(|(file_path, entry)| {
+                let EntryType::File(result) = entry;
+                (file_path, result)
             })
-            .collect();
-        let all_results = all_results?;
+            .collect())
+    }
+}
 
-        let graph = CallGraph::build_from_results(&all_results);
+/// Contents of the source file `path`, which must be UTF-8
+fn read_source(path: &Path) -> Result<String, String> {
+    std::fs::read_to_string(path).map_err(|e| format!("Failed to read '{}': {}", path.display(), e))
+}
 
-        let incoming_chains = if follow_depth > 0 {
-            graph.find_incoming_chains(focus, follow_depth)
-        } else {
-            vec![]
-        };
+/// Simplified public API for the analyze tool
+use std::sync::OnceLock;
 
-        let outgoing_chains = if follow_depth > 0 {
-            graph.find_outgoing_chains(focus, follow_depth)
-        } else {
-            vec![]
-        };
+static ANALYZER: OnceLock<CodeAnalyzer> = OnceLock::new();
 
-        let definitions = graph.definitions.get(focus).cloned().unwrap_or_default();
+fn get_analyzer() -> &'static CodeAnalyzer {
+    ANALYZER.get_or_init(CodeAnalyzer::new)
+}
 
-        let focus_data = FocusedAnalysisData {
-            focus_symbol: focus,
-            follow_depth,
-            files_analyzed: &files_to_analyze,
-            definitions: &definitions,
-            incoming_chains: &incoming_chains,
-            outgoing_chains: &outgoing_chains,
-        };
+/// Options controlling a single analysis run
+#[derive(Debug, Clone)]
+pub struct AnalyzeOptions {
+    /// Symbol name to focus on
+    pub focus: Option<String>,
+    /// Call graph depth for focused analysis
+    pub follow_depth: u32,
+    /// Directory recursion limit, 0 means unlimited
+    pub max_depth: u32,
+    /// Maximum depth for recursive AST traversal
+    pub ast_recursion_limit: Option<usize>,
+    /// Output format for file and directory analysis
+    pub format: OutputFormat,
+    /// Order of functions within each file in text and JSON reports
+    pub sort: SortOrder,
+    /// Whether text and Markdown reports list findings per check or per file
+    pub group_by: GroupBy,
+    /// Report functions whose cyclomatic complexity exceeds this value
+    pub max_complexity: Option<usize>,
+    /// Report functions with more lines of code than this value
+    pub max_function_loc: Option<usize>,
+    /// Report functions declaring more parameters than this value
+    pub max_params: Option<usize>,
+    /// Report functions nesting control flow more deeply than this value
+    pub max_nesting: Option<usize>,
+    /// Upper bounds of the function length buckets in JSON and Markdown
+    /// reports; the last bucket holds the longer functions
+    pub length_buckets: Vec<usize>,
+    /// Rank the functions by hotspot score and report the top N
+    pub hotspots: Option<usize>,
+    /// Weights of the hotspot score, also used by `SortOrder::Hotspot`
+    pub hotspot_weights: HotspotWeights,
+    /// Fail the run when an unused function is found
+    pub fail_on_unused: bool,
+    /// Report unexported functions that are never referenced
+    pub find_unused: bool,
+    /// Report methods whose body never uses the receiver
+    pub find_unused_receivers: bool,
+    /// Report struct fields that encode to the same JSON key
+    pub find_duplicate_tags: bool,
+    /// Report local variables shadowing a declaration of an enclosing scope
+    pub find_shadowed: bool,
+    /// Leave `err` and single-letter loop variables out of the shadowing report
+    pub shadow_skip_common: bool,
+    /// Report bare returns in functions with named results
+    pub find_naked_returns: bool,
+    /// Functions with at most this many lines of code may use naked returns
+    pub naked_return_max_loc: usize,
+    /// Report comments holding one of `todo_markers`
+    pub find_todos: bool,
+    /// Words that mark deferred work in comments, matched case-insensitively
+    pub todo_markers: Vec<String>,
+    /// Report statement sequences duplicated within or across functions
+    pub find_clones: bool,
+    /// Shortest duplicated sequence reported, in statements
+    pub clone_min_statements: usize,
+    /// Report Go calls whose `error` result is dropped or assigned to `_`
+    pub find_ignored_errors: bool,
+    /// Leave deferred calls, such as `defer f.Close()`, out of that report
+    pub ignored_errors_skip_deferred: bool,
+    /// Report numeric literals used in function bodies instead of named constants
+    pub find_magic_numbers: bool,
+    /// Values besides 0, 1 and -1 that are not magic numbers
+    pub magic_numbers_allowed: Vec<String>,
+    /// Leave literals used as an array or map index out of that report
+    pub magic_numbers_ignore_indices: bool,
+    /// Report Go calls to `panic` outside `init`, `main` and test files
+    pub find_panics: bool,
+    /// Also report `log.Fatal`, `log.Fatalf` and `log.Fatalln` calls
+    pub panics_include_fatal: bool,
+    /// Also report calls in `init`, `main` and test files
+    pub panics_include_acceptable: bool,
+    /// Report Go types declaring methods on both value and pointer receivers
+    pub find_mixed_receivers: bool,
+    /// Report Go struct fields that their package never reads or writes
+    pub find_unused_fields: bool,
+    /// Also report exported fields, for packages not used from outside
+    pub unused_fields_include_exported: bool,
+    /// Report Go strings built with `+=` or `+` inside loops
+    pub find_string_concats: bool,
+    /// Report Go `return err` statements passing on a call's error without context
+    pub find_unwrapped_errors: bool,
+    /// Report Go `interface{}` and `any` types outside type parameter constraints
+    pub find_empty_interfaces: bool,
+    /// Report exported Go declarations, and packages, without a doc comment
+    pub find_missing_docs: bool,
+    /// Accept any doc comment, not only one starting with the declared name
+    pub missing_docs_any_text: bool,
+    /// Report Go statements after a `return`, `panic` or other statement leaving their block
+    pub find_unreachable: bool,
+    /// Report Go slices grown by `append` in a loop without preallocated capacity
+    pub find_slice_appends: bool,
+    /// Also descend into hidden, vendor, testdata and build output directories
+    pub include_skipped_dirs: bool,
+    /// Skip Go files that a build for this platform and these tags would
+    /// not compile; `None` analyzes every file
+    pub build_context: Option<BuildContext>,
+    /// Glob patterns, relative to the analyzed directory, of the files to
+    /// analyze; empty analyzes every file
+    pub include: Vec<String>,
+    /// Glob patterns of files and directories to leave out, even when they
+    /// match `include`
+    pub exclude: Vec<String>,
+    /// List only the exported API instead of the regular overview
+    pub api: bool,
+    /// Report which types satisfy which interfaces
+    pub find_implementations: bool,
+    /// Report package imports and import cycles; replaces the call graph in the `dot` format
+    pub import_graph: bool,
+    /// Number of worker threads parsing files; `None` or 0 uses one per CPU
+    pub jobs: Option<usize>,
+    /// Directory for the on-disk parse cache, relative to `cwd`; `None` disables it
+    pub cache_dir: Option<PathBuf>,
+    /// Report only functions and findings overlapping these lines, with paths relative to `cwd`
+    pub changed_lines: Option<ChangedLines>,
+    /// Earlier run of the same path to compare function metrics with
+    pub baseline: Option<JsonReport>,
+    /// Custom checks, run in order after the built-in ones
+    pub checks: Vec<Arc<dyn Check>>,
+    /// Stops the run once cancelled; no further file is analyzed and the
+    /// output is an error unless `keep_partial_results` is set
+    pub cancel: Option<CancelToken>,
+    /// On cancellation, report the files analyzed so far
+    pub keep_partial_results: bool,
+    /// Measure the run's throughput into `AnalysisOutput::stats`
+    pub stats: bool,
+}
 
-        let mut output = Formatter::format_focused_output(&focus_data);
+impl AnalyzeOptions {
+    /// Limits that fail the run, taken from the threshold options
+    pub fn policy(&self) -> Policy {
+        Policy {
+            max_complexity: self.max_complexity,
+            max_function_loc: self.max_function_loc,
+            max_params: self.max_params,
+            max_nesting: self.max_nesting,
+            fail_on_unused: self.fail_on_unused,
+        }
+    }
 
-        if path.is_file() {
-            let hint = "NOTE: Focus mode works best with directory paths. \
-                        Use a parent directory in the path for cross-file analysis.\n\n";
-            output = format!("{}{}", hint, output);
+    /// Run `check` after the built-in checks, and after checks registered
+    /// before it. Fails, leaving the checks as they were, when its name is
+    /// taken by a built-in check or one registered before.
+    pub fn register_check(&mut self, check: impl Check + 'static) -> Result<(), String> {
+        self.checks.push(Arc::new(check));
+        let valid = custom::validate_checks(&self.checks);
+        if valid.is_err() {
+            self.checks.pop();
         }
+        valid
+    }
+
+    /// Run the enabled built-in checks, then the custom ones, into an output
+    /// holding their typed results and findings, those on `changes` only
+    /// when given
+    fn run_checks(
+        &self,
+        results: &[(PathBuf, AnalysisResult)],
+        parsed: &ParsedFiles,
+        changes: Option<&ChangedLines>,
+    ) -> AnalysisOutput {
+        let mut checked = AnalysisOutput::default();
+        let builtin = builtin::builtin_checks(self);
+        let mut findings = custom::run_checks(&builtin, results, parsed, changes, &mut checked);
+        checked.check_findings =
+            custom::run_checks(&self.checks, results, parsed, changes, &mut checked);
+        findings.extend(checked.check_findings.iter().cloned());
+        checked.findings = findings;
+        checked
+    }
 
-        Ok(output)
+    /// Score `functions` with the hotspot weights, then put them in `sort` order
+    fn order_functions(&self, functions: &mut [FunctionInfo]) {
+        metrics::score_hotspots(functions, &self.hotspot_weights);
+        self.sort.sort_functions(functions);
     }
 }
 
-/// Simplified public API for the analyze tool
-use std::sync::OnceLock;
+impl Default for AnalyzeOptions {
+    fn default() -> Self {
+        Self {
+            focus: None,
+            follow_depth: 2,
+            max_depth: 3,
+            ast_recursion_limit: None,
+            format: OutputFormat::Text,
+            sort: SortOrder::Line,
+            group_by: GroupBy::Check,
+            max_complexity: None,
+            max_function_loc: None,
+            max_params: None,
+            max_nesting: None,
+            length_buckets: metrics::DEFAULT_LENGTH_BUCKETS.to_vec(),
+            hotspots: None,
+            hotspot_weights: HotspotWeights::default(),
+            fail_on_unused: false,
+            find_unused: false,
+            find_unused_receivers: false,
+            find_duplicate_tags: false,
+            find_shadowed: false,
+            shadow_skip_common: false,
+            find_naked_returns: false,
+            naked_return_max_loc: naked::DEFAULT_NAKED_RETURN_MAX_LOC,
+            find_todos: false,
+            todo_markers: todo::DEFAULT_TODO_MARKERS
+                .iter()
+                .map(|marker| marker.to_string())
+                .collect(),
+            find_clones: false,
+            clone_min_statements: clones::DEFAULT_CLONE_MIN_STATEMENTS,
+            find_ignored_errors: false,
+            ignored_errors_skip_deferred: false,
+            find_magic_numbers: false,
+            magic_numbers_allowed: vec![],
+            magic_numbers_ignore_indices: false,
+            find_panics: false,
+            panics_include_fatal: false,
+            panics_include_acceptable: false,
+            find_mixed_receivers: false,
+            find_unused_fields: false,
+            unused_fields_include_exported: false,
+            find_string_concats: false,
+            find_unwrapped_errors: false,
+            find_empty_interfaces: false,
+            find_missing_docs: false,
+            missing_docs_any_text: false,
+            find_unreachable: false,
+            find_slice_appends: false,
+            include_skipped_dirs: false,
+            build_context: None,
+            include: vec![],
+            exclude: vec![],
+            api: false,
+            find_implementations: false,
+            import_graph: false,
+            jobs: None,
+            cache_dir: None,
+            changed_lines: None,
+            baseline: None,
+            checks: vec![],
+            cancel: None,
+            keep_partial_results: false,
+            stats: false,
+        }
+    }
+}
 
-static ANALYZER: OnceLock<CodeAnalyzer> = OnceLock::new();
+/// Rendered output of an analysis run together with any threshold violations
+#[derive(Debug, Clone, Default)]
+pub struct AnalysisOutput {
+    pub output: String,
+    pub complexity_violations: Vec<ComplexityViolation>,
+    /// Functions above the line limit (with `max_function_loc`)
+    pub length_violations: Vec<LengthViolation>,
+    /// Functions above the parameter limit (with `max_params`)
+    pub param_violations: Vec<ParamCountViolation>,
+    /// Functions above the nesting limit (with `max_nesting`)
+    pub nesting_violations: Vec<NestingViolation>,
+    /// Functions per length bucket of `AnalyzeOptions::length_buckets`
+    pub length_distribution: Vec<LengthBucket>,
+    /// Functions with the highest hotspot score, highest first (with `hotspots`)
+    pub hotspots: Vec<Hotspot>,
+    /// Findings that fail the run under the options' [`Policy`]
+    pub violations: Vec<Violation>,
+    /// Unexported functions never referenced in the analyzed files (with `find_unused`)
+    pub unused_functions: Vec<UnusedFunction>,
+    /// Methods that never use their receiver (with `find_unused_receivers`)
+    pub unused_receivers: Vec<UnusedReceiver>,
+    /// Struct fields sharing a JSON key (with `find_duplicate_tags`)
+    pub duplicate_tags: Vec<DuplicateJsonTag>,
+    /// Local variables hiding an enclosing declaration (with `find_shadowed`)
+    pub shadowed: Vec<ShadowedVariable>,
+    /// Bare returns in long functions with named results (with `find_naked_returns`)
+    pub naked_returns: Vec<NakedReturn>,
+    /// Comments marking deferred work (with `find_todos`)
+    pub todos: Vec<TodoComment>,
+    /// Duplicated statement sequences (with `find_clones`)
+    pub clones: Vec<CloneGroup>,
+    /// Calls dropping an `error` result (with `find_ignored_errors`)
+    pub ignored_errors: Vec<IgnoredError>,
+    /// Numeric literals that should be named constants (with `find_magic_numbers`)
+    pub magic_numbers: Vec<MagicNumber>,
+    /// Calls that panic or exit the process (with `find_panics`)
+    pub panics: Vec<PanicCall>,
+    /// Types mixing value and pointer receivers (with `find_mixed_receivers`)
+    pub mixed_receivers: Vec<MixedReceivers>,
+    /// Struct fields never read or written (with `find_unused_fields`)
+    pub unused_fields: Vec<UnusedField>,
+    /// Strings concatenated inside loops (with `find_string_concats`)
+    pub string_concats: Vec<StringConcatInLoop>,
+    /// Errors returned without added context (with `find_unwrapped_errors`)
+    pub unwrapped_errors: Vec<UnwrappedError>,
+    /// Empty interface types (with `find_empty_interfaces`)
+    pub empty_interfaces: Vec<EmptyInterfaceType>,
+    /// Declarations and packages lacking a doc comment (with `find_missing_docs`)
+    pub missing_docs: Vec<MissingDoc>,
+    /// Statements that can never run (with `find_unreachable`)
+    pub unreachable_code: Vec<UnreachableStatement>,
+    /// Slices grown in loops without capacity (with `find_slice_appends`)
+    pub slice_appends: Vec<SliceAppendInLoop>,
+    /// Functions added, removed and changed since `AnalyzeOptions::baseline`
+    pub metrics_diff: Option<MetricsDiff>,
+    /// Go files left out by build constraints (with `build_context`)
+    pub skipped_files: Vec<SkippedFile>,
+    /// Findings of the custom checks in `AnalyzeOptions::checks`
+    pub check_findings: Vec<Finding>,
+    /// Findings of every check run, see [`AnalysisOutput::findings`]
+    findings: Vec<Finding>,
+    /// Call graph behind the rendered output (with the `dot` format or in
+    /// focused mode)
+    pub call_graph: Option<CallGraph>,
+    /// Exported identifiers of the analyzed files (with `api`)
+    pub api: Option<ApiSurface>,
+    /// Interface name to the types satisfying it (with `find_implementations`)
+    pub implementations: BTreeMap<String, Vec<String>>,
+    /// Packages and what they import (with `import_graph`)
+    pub import_graph: Option<ImportGraph>,
+    /// Files, bytes and functions analyzed and the time it took (with `stats`)
+    pub stats: Option<Stats>,
+    /// Whether `AnalyzeOptions::cancel` was cancelled during the run; the
+    /// output is then an error, or with `keep_partial_results` leaves out the
+    /// files not analyzed in time
+    pub cancelled: bool,
+}
 
-fn get_analyzer() -> &'static CodeAnalyzer {
-    ANALYZER.get_or_init(CodeAnalyzer::new)
+impl AnalysisOutput {
+    fn text(output: String) -> Self {
+        Self {
+            output,
+            ..Self::default()
+        }
+    }
+
+    /// Every finding of the enabled checks, ordered by check then path and
+    /// line: the built-in checks first, then the custom ones
+    pub fn findings(&self) -> Vec<Finding> {
+        self.findings.clone()
+    }
+
+    /// Whether every configured threshold was respected
+    pub fn passed(&self) -> bool {
+        self.violations.is_empty()
+    }
 }
 
 pub fn analyze(
@@ -229,83 +685,767 @@ pub fn analyze(
     ast_recursion_limit: Option<usize>,
     cwd: &str,
 ) -> String {
+    let options = AnalyzeOptions {
+        focus: focus.map(|s| s.to_string()),
+        follow_depth,
+        max_depth,
+        ast_recursion_limit,
+        ..AnalyzeOptions::default()
+    };
+    analyze_with_options(path, &options, cwd).output
+}
+
+/// Analyze source that need not be on disk, such as an unsaved editor
+/// buffer, as if it were the contents of `filename`. The extension of
+/// `filename` selects the language; the file itself is never read. The
+/// result has the full semantic detail of a file analyzed from disk and can
+/// be passed, paired with `filename`, wherever per-file results are taken,
+/// e.g. `JsonReport::from_results`. Files of an unsupported language give
+/// an empty result, as they do on disk.
+pub fn analyze_source(filename: &str, source: &str) -> Result<AnalysisResult, String> {
+    get_analyzer().analyze_content(Path::new(filename), source, &AnalysisMode::Semantic, None)
+}
+
+/// Run the checks `options` enables, built-in and custom, over source that
+/// need not be on disk, analyzed as `analyze_source` does. Custom checks
+/// get the buffer and its syntax tree from `CheckContext::parsed`. Returns
+/// the findings, ordered as `AnalysisOutput::findings` orders them. Fails
+/// when two custom checks share a name or one takes a built-in name.
+pub fn check_source(
+    filename: &str,
+    source: &str,
+    options: &AnalyzeOptions,
+) -> Result<Vec<Finding>, String> {
+    custom::validate_checks(&options.checks)?;
+    let analyzer = get_analyzer();
+    let path = PathBuf::from(filename);
+    let (mut result, tree) =
+        analyzer.parse_content(&path, source, &AnalysisMode::Semantic, None)?;
+    options.order_functions(&mut result.functions);
+
+    let mut parsed = ParsedFiles::new();
+    if let Some(file) = analyzer.parsed_file(&path, source.to_string(), tree) {
+        parsed.insert(path.clone(), file);
+    }
+    Ok(options
+        .run_checks(&[(path, result)], &parsed, None)
+        .findings)
+}
+
+pub fn analyze_with_options(path: &str, options: &AnalyzeOptions, cwd: &str) -> AnalysisOutput {
+    let mut output = match thread_pool(options) {
+        Ok(pool) => pool.install(|| run_analysis(path, options, cwd)),
+        Err(e) => AnalysisOutput::text(format!("Analysis error: {}", e)),
+    };
+    output.cancelled = options
+        .cancel
+        .as_ref()
+        .is_some_and(CancelToken::is_cancelled);
+    output
+}
+
+/// What [`write_json_lines`] wrote and found
+#[derive(Debug, Clone, Default)]
+pub struct JsonLinesSummary {
+    /// Lines written, one per analyzed file
+    pub lines: usize,
+    /// Functions over the options' per-function limits, ordered by rule then
+    /// path and line
+    pub violations: Vec<Violation>,
+    /// Files, bytes and functions analyzed and the time it took (with `stats`)
+    pub stats: Option<Stats>,
+}
+
+impl JsonLinesSummary {
+    /// Whether every configured limit was respected
+    pub fn passed(&self) -> bool {
+        self.violations.is_empty()
+    }
+}
+
+/// Write one JSON object per analyzed file to `writer`, as `--format jsonl`
+/// does, each line as soon as its file is analyzed rather than once the
+/// whole run is done, so memory use does not grow with the number of files.
+/// Lines come in the order workers finish, not in path order; each is
+/// written whole under a lock, so lines of concurrent workers never mix.
+///
+/// The file selection options (`max_depth`, `include`, `exclude`,
+/// `build_context`, ...), `sort`, `cache_dir`, `jobs` and `cancel` apply.
+/// The per-function limits (`max_complexity`, `max_function_loc`,
+/// `max_params`, `max_nesting`) and `stats` are evaluated file by file into
+/// the returned summary. Options that need every file at once, the checks,
+/// `fail_on_unused`, `changed_lines`, `baseline` and the whole-tree reports,
+/// are rejected with an error before anything is written.
+pub fn write_json_lines(
+    path: &str,
+    options: &AnalyzeOptions,
+    cwd: &str,
+    writer: &mut (dyn Write + Send),
+) -> Result<JsonLinesSummary, String> {
+    thread_pool(options)?.install(|| stream_json_lines(path, options, cwd, writer))
+}
+
+/// Analyze the Go packages matching import path `patterns` such as `./...`,
+/// `./store` or `example.com/app/store/...`, resolved against the module of
+/// the nearest `go.mod` in `cwd` or above it. Returns one report per
+/// package, each listed once, with its files analyzed as a whole-directory
+/// package the way `go build` would see it: `build_context` decides which
+/// files are compiled, `_test.go` files and files whose name starts with `.`
+/// or `_` are left out, and subdirectories are packages of their own.
+///
+/// A pattern that matches nothing, a package that cannot be read and a
+/// package whose files are all excluded by build constraints have `error`
+/// set on their report; files that fail to parse have it set on their
+/// result. Neither fails the run. Combining the `files` of every report
+/// gives the cross-package view that `JsonReport::from_results` and the
+/// checks take.
+///
+/// `cache_dir`, `jobs`, `sort`, `hotspot_weights`, `cancel` and
+/// `keep_partial_results` apply; `include` and `exclude` do not, since the
+/// patterns select the files. Fails without a `go.mod`, or when cancelled
+/// without `keep_partial_results`.
+pub fn analyze_packages(
+    patterns: &[&str],
+    options: &AnalyzeOptions,
+    cwd: &str,
+) -> Result<Vec<PackageReport>, String> {
+    thread_pool(options)?.install(|| load_packages(patterns, options, cwd))
+}
+
+fn load_packages(
+    patterns: &[&str],
+    options: &AnalyzeOptions,
+    cwd: &str,
+) -> Result<Vec<PackageReport>, String> {
+    let module = imports::find_go_module(Path::new(cwd))
+        .ok_or_else(|| format!("No go.mod found in '{}' or any parent directory", cwd))?;
+
+    let cached_analyzer;
+    let analyzer = match &options.cache_dir {
+        Some(dir) => {
+            cached_analyzer = CodeAnalyzer::new().with_disk_cache(Path::new(cwd).join(dir));
+            &cached_analyzer
+        }
+        None => get_analyzer(),
+    };
+    let traverser = FileTraverser::new()
+        .build_context(options.build_context.clone())
+        .cancel_token(options.cancel.clone())
+        .keep_partial_results(options.keep_partial_results);
+
+    let mut reports = packages::match_packages(&module, Path::new(cwd), patterns);
+    let mode = AnalysisMode::Semantic;
+    for report in reports.iter_mut().filter(|report| report.error.is_none()) {
+        // A depth of 1 keeps to the package directory itself
+        let results = traverser.collect_directory_results(&report.dir, 1, |file| {
+            analyzer.analyze_file(file, &mode, options.ast_recursion_limit)
+        });
+        let results = match results {
+            Ok(results) => results,
+            Err(e) if traverser.is_cancelled() => return Err(e),
+            Err(e) => {
+                report.error = Some(e);
+                continue;
+            }
+        };
+        report.files = results
+            .into_iter()
+            .filter(|(file, _)| packages::is_package_file(file))
+            .map(|(file, entry)| {
+                let EntryType::File(mut result) = entry;
+                options.order_functions(&mut result.functions);
+                (file, result)
+            })
+            .collect();
+
+        report.skipped_files = traverser.skipped_files();
+        report
+            .skipped_files
+            .retain(|file| packages::is_package_file(&file.path));
+        if report.files.is_empty() && !report.skipped_files.is_empty() {
+            report.error = Some(format!(
+                "build constraints exclude all Go files in {}",
+                report.dir.display()
+            ));
+        }
+    }
+    Ok(reports)
+}
+
+/// Worker pool of `options.jobs` threads, one per CPU by default
+fn thread_pool(options: &AnalyzeOptions) -> Result<rayon::ThreadPool, String> {
+    let jobs = options.jobs.filter(|&jobs| jobs > 0).unwrap_or_else(|| {
+        std::thread::available_parallelism()
+            .map(|n| n.get())
+            .unwrap_or(1)
+    });
+
+    rayon::ThreadPoolBuilder::new()
+        .num_threads(jobs)
+        .build()
+        .map_err(|e| format!("Failed to start {} worker threads: {}", jobs, e))
+}
+
+/// The first option `write_json_lines` cannot honor, since it needs every
+/// file at once
+fn whole_run_option(options: &AnalyzeOptions) -> Option<&'static str> {
+    // The limits are built-in checks too, but judged one function at a time
+    let limits = [
+        options.max_complexity,
+        options.max_function_loc,
+        options.max_params,
+        options.max_nesting,
+    ]
+    .iter()
+    .flatten()
+    .count();
+    let unsupported = [
+        (options.fail_on_unused, "failing on unused functions"),
+        (options.changed_lines.is_some(), "diff filtering"),
+        (options.baseline.is_some(), "baseline comparison"),
+        (
+            builtin::builtin_checks(options).len() > limits || !options.checks.is_empty(),
+            "checks",
+        ),
+        (options.hotspots.is_some(), "hotspots"),
+        (options.find_implementations, "implementations"),
+        (options.import_graph, "the import graph"),
+        (options.api, "API listing"),
+    ];
+    unsupported
+        .into_iter()
+        .find_map(|(enabled, name)| enabled.then_some(name))
+}
+
+fn stream_json_lines(
+    path: &str,
+    options: &AnalyzeOptions,
+    cwd: &str,
+    writer: &mut (dyn Write + Send),
+) -> Result<JsonLinesSummary, String> {
+    let started = Instant::now();
     let abs_path = if Path::new(path).is_absolute() {
         PathBuf::from(path)
     } else {
         PathBuf::from(cwd).join(path)
     };
 
-    let analyzer = get_analyzer();
-    let traverser = FileTraverser::new();
+    if options.focus.is_some() {
+        return Err("JSONL output is not supported in focused mode".to_string());
+    }
+    if let Some(option) = whole_run_option(options) {
+        return Err(format!(
+            "JSONL output does not support {}, which needs every file at once",
+            option
+        ));
+    }
+
+    let cached_analyzer;
+    let analyzer = match &options.cache_dir {
+        Some(dir) => {
+            cached_analyzer = CodeAnalyzer::new().with_disk_cache(Path::new(cwd).join(dir));
+            &cached_analyzer
+        }
+        None => get_analyzer(),
+    };
+    let traverser = file_traverser(options)?;
+    traverser.validate_path(&abs_path)?;
+
+    let writer = Mutex::new(writer);
+    let policy = options.policy();
+    let summary = Mutex::new(JsonLinesSummary {
+        stats: options.stats.then(Stats::default),
+        ..JsonLinesSummary::default()
+    });
+    let write_line = |file: &Path, mut result: AnalysisResult| {
+        options.order_functions(&mut result.functions);
+        let line = JsonFile::render_line(&abs_path, file, &result)?;
+        lock_or_recover(&writer, |_| {})
+            .write_all(line.as_bytes())
+            .map_err(|e| format!("Failed to write JSON line: {}", e))?;
+
+        let analyzed = [(file.to_path_buf(), result)];
+        let violations = policy.evaluate(&analyzed);
+        let mut summary = lock_or_recover(&summary, |_| {});
+        summary.violations.extend(violations);
+        if let Some(stats) = &mut summary.stats {
+            let file_stats = Stats::from_results(&analyzed);
+            stats.files += file_stats.files;
+            stats.bytes += file_stats.bytes;
+            stats.functions += file_stats.functions;
+        }
+        Ok(())
+    };
+
+    let mode = AnalysisMode::Semantic;
+    let written = if abs_path.is_file() {
+        let result = analyzer.analyze_file(&abs_path, &mode, options.ast_recursion_limit)?;
+        write_line(&abs_path, result)?;
+        1
+    } else {
+        traverser.for_each_directory_result(
+            &abs_path,
+            options.max_depth,
+            |file| analyzer.analyze_file(file, &mode, options.ast_recursion_limit),
+            write_line,
+        )?
+    };
+
+    lock_or_recover(&writer, |_| {})
+        .flush()
+        .map_err(|e| format!("Failed to write JSON line: {}", e))?;
+
+    let mut summary = lock_or_recover(&summary, |_| {}).clone();
+    summary.lines = written;
+    // Files finish in any order; evaluating them all at once sorts this way
+    let rule_order = |rule_id: &str| checks::RULES.iter().position(|(rule, _)| *rule == rule_id);
+    summary.violations.sort_by(|a, b| {
+        (rule_order(a.rule_id), &a.path, a.start_line).cmp(&(
+            rule_order(b.rule_id),
+            &b.path,
+            b.start_line,
+        ))
+    });
+    if let Some(stats) = &mut summary.stats {
+        stats.elapsed = started.elapsed();
+    }
+    Ok(summary)
+}
+
+/// File traverser selecting the files `options` asks for
+fn file_traverser(options: &AnalyzeOptions) -> Result<FileTraverser, String> {
+    let path_filter = PathFilter::new(&options.include, &options.exclude)?;
+    Ok(FileTraverser::new()
+        .include_skipped_dirs(options.include_skipped_dirs)
+        .build_context(options.build_context.clone())
+        .path_filter(path_filter)
+        .cancel_token(options.cancel.clone())
+        .keep_partial_results(options.keep_partial_results))
+}
+
+fn run_analysis(path: &str, options: &AnalyzeOptions, cwd: &str) -> AnalysisOutput {
+    let started = Instant::now();
+    let abs_path = if Path::new(path).is_absolute() {
+        PathBuf::from(path)
+    } else {
+        PathBuf::from(cwd).join(path)
+    };
+
+    let cached_analyzer;
+    let analyzer = match &options.cache_dir {
+        Some(dir) => {
+            cached_analyzer = CodeAnalyzer::new().with_disk_cache(Path::new(cwd).join(dir));
+            &cached_analyzer
+        }
+        None => get_analyzer(),
+    };
+    let traverser = match file_traverser(options) {
+        Ok(traverser) => traverser,
+        Err(e) => return AnalysisOutput::text(format!("Analysis error: {}", e)),
+    };
 
     if let Err(e) = traverser.validate_path(&abs_path) {
-        return e;
+        return AnalysisOutput::text(e);
     }
 
-    let focus_owned = focus.map(|s| s.to_string());
-    let mode = analyzer.determine_mode(&focus_owned, &abs_path);
+    if let Err(e) = custom::validate_checks(&options.checks) {
+        return AnalysisOutput::text(format!("Analysis error: {}", e));
+    }
 
-    let mut output = match mode {
-        AnalysisMode::Focused => {
-            match analyzer.analyze_focused(
-                &abs_path,
-                focus.unwrap_or(""),
-                follow_depth,
-                max_depth,
-                ast_recursion_limit,
-                &traverser,
-            ) {
-                Ok(output) => output,
-                Err(e) => return format!("Analysis error: {}", e),
+    let focus = options.focus.as_deref();
+    let follow_depth = options.follow_depth;
+    let max_depth = options.max_depth;
+    let ast_recursion_limit = options.ast_recursion_limit;
+    let mode = analyzer.determine_mode(&options.focus, &abs_path);
+
+    if options.api && mode == AnalysisMode::Focused {
+        return AnalysisOutput::text(
+            "Analysis error: API listing is not supported in focused mode".to_string(),
+        );
+    }
+
+    if options.changed_lines.is_some() && mode == AnalysisMode::Focused {
+        return AnalysisOutput::text(
+            "Analysis error: Diff filtering is not supported in focused mode".to_string(),
+        );
+    }
+
+    if options.format != OutputFormat::Text && mode == AnalysisMode::Focused {
+        return AnalysisOutput::text(format!(
+            "Analysis error: {} output is not supported in focused mode",
+            options.format.as_str().to_uppercase()
+        ));
+    }
+
+    let needs_semantic = options.format != OutputFormat::Text
+        || !options.policy().is_empty()
+        || options.find_unused
+        || options.find_unused_receivers
+        || options.find_duplicate_tags
+        || options.find_shadowed
+        || options.find_naked_returns
+        || options.find_todos
+        || options.find_clones
+        || options.find_ignored_errors
+        || options.find_magic_numbers
+        || options.find_panics
+        || options.find_mixed_receivers
+        || options.find_unused_fields
+        || options.find_string_concats
+        || options.find_unwrapped_errors
+        || options.find_empty_interfaces
+        || options.find_missing_docs
+        || options.find_unreachable
+        || options.find_slice_appends
+        || options.hotspots.is_some()
+        || !options.checks.is_empty()
+        || options.find_implementations
+        || options.import_graph
+        || options.api
+        || options.changed_lines.is_some()
+        || options.baseline.is_some()
+        || options.stats;
+    // Only custom checks look at syntax trees, so only they keep them
+    let parsed = Mutex::new(ParsedFiles::new());
+    let keep_parsed = (!options.checks.is_empty()).then_some(&parsed);
+    // The text report of a plain run needs no more than its own mode, so
+    // only other outputs and the checks pay for semantic details
+    let collect_mode = if needs_semantic || mode == AnalysisMode::Focused {
+        AnalysisMode::Semantic
+    } else {
+        mode
+    };
+    let mut results = match analyzer.collect_results(
+        &abs_path,
+        collect_mode,
+        max_depth,
+        ast_recursion_limit,
+        &traverser,
+        keep_parsed,
+    ) {
+        Ok(results) => results,
+        Err(e) => return AnalysisOutput::text(format!("Analysis error: {}", e)),
+    };
+
+    let parsed = lock_or_recover(&parsed, |_| {});
+    for (_, result) in &mut results {
+        options.order_functions(&mut result.functions);
+    }
+    let mut stats = options.stats.then(|| Stats::from_results(&results));
+    let skipped_files = traverser.skipped_files();
+
+    // Compared before a diff narrows the results, which would report every
+    // function outside the change as removed
+    let metrics_diff = options.baseline.as_ref().map(|baseline| {
+        let current = JsonReport::from_results(&abs_path, &results);
+        compare::compare_runs(&baseline.files, &current.files)
+    });
+
+    // Checks see every file and function whole, since unchanged code decides
+    // whether changed code is used, and report only on the changed lines
+    let changes = options
+        .changed_lines
+        .as_ref()
+        .map(|changes| changes.resolve(Path::new(cwd)));
+    let checked = options.run_checks(&results, &parsed, changes.as_ref());
+    if let Some(changes) = &changes {
+        changes.retain_changed(&mut results);
+    }
+
+    let length_distribution = metrics::length_distribution(&results, &options.length_buckets);
+
+    let hotspots = options
+        .hotspots
+        .map(|n| metrics::hotspots(&results, n))
+        .unwrap_or_default();
+
+    let violations = options.policy().evaluate(&results);
+
+    let implementations = if options.find_implementations {
+        implementations::find_implementations(&abs_path, &results)
+    } else {
+        BTreeMap::new()
+    };
+
+    let import_graph = options
+        .import_graph
+        .then(|| ImportGraph::build_from_results(&abs_path, &results));
+
+    let api = options.api.then(|| api::exported_api(&results));
+
+    let call_graph =
+        (mode == AnalysisMode::Focused).then(|| CallGraph::build_from_results(&results));
+
+    // Taken before any output is rendered, to time the analysis alone
+    if let Some(stats) = &mut stats {
+        stats.elapsed = started.elapsed();
+    }
+
+    // Only touched files are listed
+    if let Some(changes) = &changes {
+        results.retain(|(path, _)| changes.contains_file(path));
+    }
+
+    let mut analysis = AnalysisOutput {
+        length_distribution,
+        hotspots,
+        violations,
+        metrics_diff,
+        skipped_files,
+        api,
+        implementations,
+        import_graph,
+        call_graph,
+        stats,
+        ..checked
+    };
+
+    analysis.output = match options.format {
+        OutputFormat::Json => {
+            let mut report = JsonReport::from_results(&abs_path, &results)
+                .with_unused_functions(&abs_path, &analysis.unused_functions)
+                .with_unused_receivers(&abs_path, &analysis.unused_receivers)
+                .with_length_distribution(&analysis.length_distribution)
+                .with_hotspots(&abs_path, &analysis.hotspots)
+                .with_duplicate_tags(&abs_path, &analysis.duplicate_tags)
+                .with_shadowed(&abs_path, &analysis.shadowed)
+                .with_naked_returns(&abs_path, &analysis.naked_returns)
+                .with_todos(&abs_path, &analysis.todos)
+                .with_clones(&abs_path, &analysis.clones)
+                .with_ignored_errors(&abs_path, &analysis.ignored_errors)
+                .with_magic_numbers(&abs_path, &analysis.magic_numbers)
+                .with_panics(&abs_path, &analysis.panics)
+                .with_mixed_receivers(&abs_path, &analysis.mixed_receivers)
+                .with_unused_fields(&abs_path, &analysis.unused_fields)
+                .with_string_concats(&abs_path, &analysis.string_concats)
+                .with_unwrapped_errors(&abs_path, &analysis.unwrapped_errors)
+                .with_empty_interfaces(&abs_path, &analysis.empty_interfaces)
+                .with_missing_docs(&abs_path, &analysis.missing_docs)
+                .with_unreachable_code(&abs_path, &analysis.unreachable_code)
+                .with_slice_appends(&abs_path, &analysis.slice_appends)
+                .with_skipped_files(&abs_path, &analysis.skipped_files)
+                .with_check_findings(&abs_path, &analysis.check_findings)
+                .with_implementations(&analysis.implementations);
+            if let Some(api) = &analysis.api {
+                report = report.with_api(&abs_path, api);
             }
+            if let Some(graph) = &analysis.import_graph {
+                report = report.with_import_graph(graph);
+            }
+            if let Some(diff) = &analysis.metrics_diff {
+                report = report.with_metrics_diff(diff);
+            }
+            report
+                .render()
+                .unwrap_or_else(|e| format!("Analysis error: {}", e))
         }
-        AnalysisMode::Semantic => {
-            if abs_path.is_file() {
-                match analyzer.analyze_file(&abs_path, &mode, ast_recursion_limit) {
-                    Ok(result) => Formatter::format_analysis_result(&abs_path, &result, &mode),
-                    Err(e) => return format!("Analysis error: {}", e),
-                }
-            } else {
-                match analyzer.analyze_directory(
-                    &abs_path,
-                    max_depth,
-                    ast_recursion_limit,
-                    &traverser,
-                    &mode,
-                ) {
-                    Ok(output) => output,
-                    Err(e) => return format!("Analysis error: {}", e),
-                }
+        OutputFormat::Markdown => {
+            let mut report = output::markdown::MarkdownReport::from_results(&abs_path, &results)
+                .with_length_distribution(&analysis.length_distribution);
+            if options.find_unused {
+                report = report.with_unused_functions(&abs_path, &analysis.unused_functions);
+            }
+            if let Some(diff) = &analysis.metrics_diff {
+                report = report.with_metrics_diff(diff);
+            }
+            if options.group_by == GroupBy::File {
+                report = report.with_findings_by_file(&abs_path, &analysis.findings());
+            }
+            report
+                .render()
+                .unwrap_or_else(|e| format!("Analysis error: {}", e))
+        }
+        OutputFormat::Html => output::html::HtmlReport::from_results(&abs_path, &results)
+            .render()
+            .unwrap_or_else(|e| format!("Analysis error: {}", e)),
+        OutputFormat::Csv => output::csv::CsvReport::from_results(&abs_path, &results)
+            .render()
+            .unwrap_or_else(|e| format!("Analysis error: {}", e)),
+        // Buffered here; `write_json_lines` streams the same lines as files finish
+        OutputFormat::JsonLines => results
+            .iter()
+            .map(|(path, result)| JsonFile::render_line(&abs_path, path, result))
+            .collect::<Result<String, String>>()
+            .unwrap_or_else(|e| format!("Analysis error: {}", e)),
+        OutputFormat::Dot => match &analysis.import_graph {
+            Some(graph) => graph.to_dot(),
+            None => {
+                let graph = CallGraph::build_from_results(&results);
+                let output = graph.to_dot();
+                analysis.call_graph = Some(graph);
+                output
             }
+        },
+        OutputFormat::Sarif => {
+            let rules: Vec<(&'static str, &'static str)> = options
+                .checks
+                .iter()
+                .map(|check| (check.name(), check.description()))
+                .collect();
+            output::sarif::SarifLog::from_findings_with_rules(
+                &abs_path,
+                &analysis.findings(),
+                &rules,
+            )
+            .render()
+            .unwrap_or_else(|e| format!("Analysis error: {}", e))
         }
-        AnalysisMode::Structure => {
-            if abs_path.is_file() {
-                match analyzer.analyze_file(&abs_path, &mode, ast_recursion_limit) {
-                    Ok(result) => Formatter::format_analysis_result(&abs_path, &result, &mode),
-                    Err(e) => return format!("Analysis error: {}", e),
+        OutputFormat::Text => match &analysis.api {
+            Some(api) => {
+                let base = if abs_path.is_file() {
+                    abs_path.parent().unwrap_or(&abs_path)
+                } else {
+                    &abs_path
+                };
+                api::format_api_surface(base, api)
+            }
+            None => {
+                let mut output = match mode {
+                    AnalysisMode::Focused => match &analysis.call_graph {
+                        Some(graph) => format_focused(
+                            &abs_path,
+                            focus.unwrap_or(""),
+                            follow_depth,
+                            graph,
+                            &results,
+                        ),
+                        None => String::new(),
+                    },
+                    _ if abs_path.is_file() => {
+                        let empty = AnalysisResult::empty(0);
+                        let result = results.first().map(|(_, result)| result).unwrap_or(&empty);
+                        Formatter::format_analysis_result(&abs_path, result, &mode)
+                    }
+                    _ => {
+                        let entries: Vec<(PathBuf, EntryType)> = results
+                            .iter()
+                            .map(|(path, result)| (path.clone(), EntryType::File(result.clone())))
+                            .collect();
+                        Formatter::format_directory_structure(&abs_path, &entries, max_depth)
+                    }
+                };
+
+                // If focus is specified with non-focused mode, filter results
+                if let Some(focus_str) = focus
+                    && mode != AnalysisMode::Focused
+                {
+                    output = Formatter::filter_by_focus(&output, focus_str);
                 }
-            } else {
-                match analyzer.analyze_directory(
-                    &abs_path,
-                    max_depth,
-                    ast_recursion_limit,
-                    &traverser,
-                    &mode,
-                ) {
-                    Ok(output) => output,
-                    Err(e) => return format!("Analysis error: {}", e),
+
+                let base = if abs_path.is_file() {
+                    abs_path.parent().unwrap_or(&abs_path)
+                } else {
+                    &abs_path
+                };
+                output.push_str(&build::format_skipped_files(base, &analysis.skipped_files));
+                output.push_str(&metrics::format_hotspots(base, &analysis.hotspots));
+                output.push_str(&match options.group_by {
+                    GroupBy::Check => format_findings_by_check(base, &analysis),
+                    GroupBy::File => checks::format_findings_by_file(base, &analysis.findings()),
+                });
+                output.push_str(&implementations::format_implementations(
+                    &analysis.implementations,
+                ));
+                if let Some(graph) = &analysis.import_graph {
+                    output.push_str(&imports::format_import_graph(graph));
+                }
+                if let Some(diff) = &analysis.metrics_diff {
+                    output.push_str(&compare::format_metrics_diff(diff));
                 }
+                output
             }
-        }
+        },
     };
+    analysis
+}
 
-    // If focus is specified with non-focused mode, filter results
-    if let Some(focus_str) = focus
-        && mode != AnalysisMode::Focused
-    {
-        output = Formatter::filter_by_focus(&output, focus_str);
+/// Text report of focused mode: where `focus` is defined and, with a
+/// `follow_depth`, the call chains into and out of it across `results`
+fn format_focused(
+    path: &Path,
+    focus: &str,
+    follow_depth: u32,
+    graph: &CallGraph,
+    results: &[(PathBuf, AnalysisResult)],
+) -> String {
+    let (incoming_chains, outgoing_chains) = if follow_depth > 0 {
+        (
+            graph.find_incoming_chains(focus, follow_depth),
+            graph.find_outgoing_chains(focus, follow_depth),
+        )
+    } else {
+        (vec![], vec![])
+    };
+    let definitions = graph.definitions.get(focus).cloned().unwrap_or_default();
+    let files_analyzed: Vec<PathBuf> = results.iter().map(|(path, _)| path.clone()).collect();
+
+    let focus_data = FocusedAnalysisData {
+        focus_symbol: focus,
+        follow_depth,
+        files_analyzed: &files_analyzed,
+        definitions: &definitions,
+        incoming_chains: &incoming_chains,
+        outgoing_chains: &outgoing_chains,
+    };
+    let output = Formatter::format_focused_output(&focus_data);
+
+    if path.is_file() {
+        let hint = "NOTE: Focus mode works best with directory paths. \
+                    Use a parent directory in the path for cross-file analysis.\n\n";
+        return format!("{}{}", hint, output);
     }
+    output
+}
 
+/// Text sections of the enabled checks, one per check in a fixed order
+fn format_findings_by_check(base: &Path, analysis: &AnalysisOutput) -> String {
+    let mut output = String::new();
+    output.push_str(&unused::format_unused_functions(
+        base,
+        &analysis.unused_functions,
+    ));
+    output.push_str(&receiver::format_unused_receivers(
+        base,
+        &analysis.unused_receivers,
+    ));
+    output.push_str(&tags::format_duplicate_json_tags(
+        base,
+        &analysis.duplicate_tags,
+    ));
+    output.push_str(&shadow::format_shadowed_variables(base, &analysis.shadowed));
+    output.push_str(&naked::format_naked_returns(base, &analysis.naked_returns));
+    output.push_str(&todo::format_todo_comments(base, &analysis.todos));
+    output.push_str(&clones::format_clones(base, &analysis.clones));
+    output.push_str(&errors::format_ignored_errors(
+        base,
+        &analysis.ignored_errors,
+    ));
+    output.push_str(&magic::format_magic_numbers(base, &analysis.magic_numbers));
+    output.push_str(&panics::format_panics(base, &analysis.panics));
+    output.push_str(&receiver_kinds::format_mixed_receivers(
+        base,
+        &analysis.mixed_receivers,
+    ));
+    output.push_str(&fields::format_unused_fields(base, &analysis.unused_fields));
+    output.push_str(&concat::format_string_concats(
+        base,
+        &analysis.string_concats,
+    ));
+    output.push_str(&wrapping::format_unwrapped_errors(
+        base,
+        &analysis.unwrapped_errors,
+    ));
+    output.push_str(&any::format_empty_interfaces(
+        base,
+        &analysis.empty_interfaces,
+    ));
+    output.push_str(&docs::format_missing_docs(base, &analysis.missing_docs));
+    output.push_str(&unreachable::format_unreachable_code(
+        base,
+        &analysis.unreachable_code,
+    ));
+    output.push_str(&append::format_slice_appends(base, &analysis.slice_appends));
+    output.push_str(&custom::format_findings(base, &analysis.check_findings));
     output
 }
 
@@ -377,6 +1517,56 @@ mod tests {
         assert_eq!(r.function_count, 0);
     }
 
+    #[test]
+    fn analyze_file_fails_on_invalid_utf8() {
+        let dir = tempfile::tempdir().unwrap();
+        let file = dir.path().join("latin1.go");
+        std::fs::write(&file, b"package main\n\n// caf\xe9\n").unwrap();
+
+        let a = CodeAnalyzer::new();
+        for mode in [AnalysisMode::Semantic, AnalysisMode::Structure] {
+            let r = a.analyze_file(&file, &mode, None).unwrap();
+            let error = r.error.unwrap();
+            assert!(error.starts_with("Failed to read '"), "{}", error);
+            assert!(error.contains("UTF-8"), "{}", error);
+        }
+        let (r, parsed) = a
+            .analyze_file_with_source(&file, &AnalysisMode::Semantic, None)
+            .unwrap();
+        assert!(r.error.is_some());
+        assert!(parsed.is_none());
+    }
+
+    #[test]
+    fn analyze_source_matches_the_file_on_disk() {
+        let file = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/fixtures/sample.rs");
+        let from_disk = CodeAnalyzer::new()
+            .analyze_file(&file, &AnalysisMode::Semantic, None)
+            .unwrap();
+        let source = std::fs::read_to_string(&file).unwrap();
+        let in_memory = analyze_source("unsaved/sample.rs", &source).unwrap();
+
+        assert_eq!(
+            format!("{:?}", in_memory.functions),
+            format!("{:?}", from_disk.functions)
+        );
+        assert_eq!(
+            format!("{:?}", in_memory.classes),
+            format!("{:?}", from_disk.classes)
+        );
+        assert_eq!(in_memory.imports, from_disk.imports);
+        assert_eq!(in_memory.referenced_names, from_disk.referenced_names);
+        assert_eq!(in_memory.line_count, from_disk.line_count);
+        assert_eq!(in_memory.code_lines, from_disk.code_lines);
+    }
+
+    #[test]
+    fn analyze_source_unsupported_extension() {
+        let r = analyze_source("notes.txt", "just text\nand more").unwrap();
+        assert_eq!(r.function_count, 0);
+        assert_eq!(r.line_count, 2);
+    }
+
     #[test]
     fn analyze_public_api_basic() {
         let cwd = PathBuf::from(env!("CARGO_MANIFEST_DIR"))
@@ -0,0 +1,149 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Per-function metrics as CSV, for spreadsheets and dashboards.
+//!
+//! The header row is part of the output contract: columns may be added at
+//! the end, but existing ones keep their name and position.
+
+use std::io::Write;
+use std::path::{Path, PathBuf};
+
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// Column names of the header row
+pub const CSV_HEADER: &[&str] = &[
+    "file",
+    "function",
+    "receiver",
+    "line",
+    "complexity",
+    "cognitive_complexity",
+    "lines_of_code",
+    "param_count",
+    "max_nesting_depth",
+];
+
+/// One row per function, files ordered by path and functions keeping their
+/// order within each file
+#[derive(Debug, Clone)]
+pub struct CsvReport {
+    rows: Vec<(String, FunctionInfo)>,
+}
+
+impl CsvReport {
+    /// Build a report from per-file results, with paths relative to `root`
+    pub fn from_results(root: &Path, results: &[(PathBuf, AnalysisResult)]) -> Self {
+        let base = if root.is_file() {
+            root.parent().unwrap_or(root)
+        } else {
+            root
+        };
+
+        let mut files: Vec<(String, &AnalysisResult)> = results
+            .iter()
+            .map(|(path, result)| {
+                let relative = path.strip_prefix(base).unwrap_or(path);
+                (relative.display().to_string(), result)
+            })
+            .collect();
+        files.sort_by(|a, b| a.0.cmp(&b.0));
+
+        let rows = files
+            .into_iter()
+            .flat_map(|(path, result)| {
+                result
+                    .functions
+                    .iter()
+                    .map(move |function| (path.clone(), function.clone()))
+            })
+            .collect();
+        Self { rows }
+    }
+
+    /// Write the header and one line per function to `writer`
+    pub fn write_to<W: Write>(&self, mut writer: W) -> std::io::Result<()> {
+        writeln!(writer, "{}", CSV_HEADER.join(","))?;
+        for (path, function) in &self.rows {
+            let fields = [
+                quote(path),
+                quote(&function.name),
+                quote(function.receiver.as_deref().unwrap_or_default()),
+                function.line.to_string(),
+                function.complexity.to_string(),
+                function.cognitive_complexity.to_string(),
+                function.lines_of_code.to_string(),
+                function.params.len().to_string(),
+                function.max_nesting_depth.to_string(),
+            ];
+            writeln!(writer, "{}", fields.join(","))?;
+        }
+        Ok(())
+    }
+
+    /// Render the CSV document as a string
+    pub fn render(&self) -> Result<String, String> {
+        let mut buffer = Vec::new();
+        self.write_to(&mut buffer)
+            .map_err(|e| format!("Failed to render CSV: {}", e))?;
+        String::from_utf8(buffer).map_err(|e| format!("Failed to render CSV: {}", e))
+    }
+}
+
+/// Quote a field as RFC 4180 asks when it holds a comma, quote or line
+/// break, doubling any quotes inside
+fn quote(field: &str) -> String {
+    if field.contains([',', '"', '\r', '\n']) {
+        format!("\"{}\"", field.replace('"', "\"\""))
+    } else {
+        field.to_string()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::ParamInfo;
+
+    fn function(name: &str, line: usize, receiver: Option<&str>) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            receiver: receiver.map(|r| r.to_string()),
+            complexity: 2,
+            cognitive_complexity: 1,
+            lines_of_code: 4,
+            params: vec![ParamInfo::unnamed("int")],
+            max_nesting_depth: 1,
+            ..Default::default()
+        }
+    }
+
+    #[test]
+    fn csv_has_stable_header_and_one_row_per_function() {
+        let mut greeter = AnalysisResult::empty(20);
+        greeter.functions = vec![function("Greet", 9, Some("*Greeter"))];
+        let mut helper = AnalysisResult::empty(10);
+        helper.functions = vec![function("helper", 3, None)];
+        let results = vec![
+            (PathBuf::from("/proj/z.go"), greeter),
+            (PathBuf::from("/proj/pkg/a.go"), helper),
+        ];
+        let out = CsvReport::from_results(Path::new("/proj"), &results)
+            .render()
+            .unwrap();
+        assert_eq!(
+            out,
+            "file,function,receiver,line,complexity,cognitive_complexity,lines_of_code,param_count,max_nesting_depth\n\
+             pkg/a.go,helper,,3,2,1,4,1,1\n\
+             z.go,Greet,*Greeter,9,2,1,4,1,1\n"
+        );
+    }
+
+    #[test]
+    fn fields_with_commas_and_quotes_are_quoted() {
+        assert_eq!(quote("HashMap<K, V>"), "\"HashMap<K, V>\"");
+        assert_eq!(quote("say \"hi\""), "\"say \"\"hi\"\"\"");
+        assert_eq!(quote("plain"), "plain");
+    }
+}
@@ -0,0 +1,373 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Self-contained HTML report: a sortable function table whose rows open the
+//! function's source. Styles and script are inline so the file works offline.
+
+use std::collections::HashMap;
+use std::io::Write;
+use std::path::{Path, PathBuf};
+
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// Lines of context shown around a function in its source snippet
+const SNIPPET_CONTEXT: usize = 2;
+
+const STYLE: &str = r#"body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3rem 0.75rem; border-bottom: 1px solid #d0d7de; text-align: left; }
+td.num, th.num { text-align: right; }
+thead th { cursor: pointer; user-select: none; background: #f6f8fa; }
+th[aria-sort="ascending"]::after { content: " \25B2"; }
+th[aria-sort="descending"]::after { content: " \25BC"; }
+tbody tr { cursor: pointer; }
+tbody tr:hover, tbody tr.selected { background: #ddf4ff; }
+pre { background: #f6f8fa; padding: 0.5rem 0; overflow-x: auto; }
+.line { display: block; padding-right: 1rem; }
+.line.hl { background: #fff8c5; }
+.ln { display: inline-block; width: 4em; padding-right: 1em; text-align: right; color: #6e7781; user-select: none; }
+"#;
+
+const SCRIPT: &str = r#"const table = document.getElementById("functions");
+const body = table.tBodies[0];
+const headers = Array.from(table.tHead.rows[0].cells);
+headers.forEach((th, column) => {
+  th.addEventListener("click", () => {
+    const ascending = th.getAttribute("aria-sort") !== "ascending";
+    headers.forEach((other) => other.removeAttribute("aria-sort"));
+    th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+    const numeric = th.classList.contains("num");
+    const rows = Array.from(body.rows);
+    rows.sort((a, b) => {
+      const x = a.cells[column].textContent;
+      const y = b.cells[column].textContent;
+      const order = numeric ? Number(x) - Number(y) : x.localeCompare(y);
+      return ascending ? order : -order;
+    });
+    rows.forEach((row) => body.appendChild(row));
+  });
+});
+body.addEventListener("click", (event) => {
+  const row = event.target.closest("tr");
+  const snippet = row && document.getElementById(row.dataset.snippet);
+  if (!snippet) return;
+  body.querySelectorAll("tr.selected").forEach((r) => r.classList.remove("selected"));
+  row.classList.add("selected");
+  document.getElementById("source-title").textContent = snippet.dataset.title;
+  document.getElementById("source-code").replaceChildren(snippet.content.cloneNode(true));
+  const source = document.getElementById("source");
+  source.hidden = false;
+  source.scrollIntoView({ block: "nearest" });
+});
+"#;
+
+/// A function together with the file declaring it and its source snippet
+#[derive(Debug, Clone)]
+struct Row {
+    /// Path relative to the analyzed directory
+    path: String,
+    function: FunctionInfo,
+    /// `(line number, text)` of the function and its context, empty when the
+    /// file could not be read
+    snippet: Vec<(usize, String)>,
+}
+
+/// HTML document with a totals line, a sortable function table and the
+/// source of each function
+#[derive(Debug, Clone)]
+pub struct HtmlReport {
+    /// Name of the analyzed file or directory
+    title: String,
+    files: usize,
+    lines_of_code: usize,
+    functions: Vec<Row>,
+}
+
+impl HtmlReport {
+    /// Build a report from per-file results, reading each file again for the
+    /// source snippets; files are sorted by path and functions keep their
+    /// order within each file
+    pub fn from_results(root: &Path, results: &[(PathBuf, AnalysisResult)]) -> Self {
+        let base = base_dir(root);
+
+        let mut files: Vec<(String, &PathBuf, &AnalysisResult)> = results
+            .iter()
+            .map(|(path, result)| (relative_path(base, path), path, result))
+            .collect();
+        files.sort_by(|a, b| a.0.cmp(&b.0));
+
+        let mut sources: HashMap<&PathBuf, Vec<String>> = HashMap::new();
+        let mut functions = Vec::new();
+        for (relative, path, result) in &files {
+            if result.functions.is_empty() {
+                continue;
+            }
+            let lines = sources.entry(path).or_insert_with(|| {
+                std::fs::read_to_string(path)
+                    .map(|content| content.lines().map(str::to_string).collect())
+                    .unwrap_or_default()
+            });
+            for function in &result.functions {
+                functions.push(Row {
+                    path: relative.clone(),
+                    function: function.clone(),
+                    snippet: snippet(lines, function),
+                });
+            }
+        }
+
+        Self {
+            title: root
+                .file_name()
+                .map(|name| name.to_string_lossy().to_string())
+                .unwrap_or_else(|| root.display().to_string()),
+            files: files.len(),
+            lines_of_code: files.iter().map(|(_, _, result)| result.code_lines).sum(),
+            functions,
+        }
+    }
+
+    /// Write the HTML document to `writer`
+    pub fn write_to<W: Write>(&self, mut writer: W) -> std::io::Result<()> {
+        let title = escape_html(&self.title);
+        writeln!(writer, "<!DOCTYPE html>")?;
+        writeln!(writer, "<html lang=\"en\">")?;
+        writeln!(writer, "<head>")?;
+        writeln!(writer, "<meta charset=\"utf-8\">")?;
+        writeln!(writer, "<title>Code analysis: {}</title>", title)?;
+        writeln!(writer, "<style>\n{}</style>", STYLE)?;
+        writeln!(writer, "</head>")?;
+        writeln!(writer, "<body>")?;
+        writeln!(writer, "<h1>Code analysis: {}</h1>", title)?;
+        writeln!(
+            writer,
+            "<p>{} files, {} functions, {} lines of code</p>",
+            self.files,
+            self.functions.len(),
+            self.lines_of_code
+        )?;
+
+        if self.functions.is_empty() {
+            writeln!(writer, "<p>No functions found.</p>")?;
+        } else {
+            writeln!(writer, "<table id=\"functions\">")?;
+            writeln!(
+                writer,
+                "<thead><tr><th>File</th><th>Function</th><th>Receiver</th>\
+                 <th class=\"num\">Line</th><th class=\"num\">Complexity</th>\
+                 <th class=\"num\">Cognitive</th><th class=\"num\">LOC</th></tr></thead>"
+            )?;
+            writeln!(writer, "<tbody>")?;
+            for (index, Row { path, function, .. }) in self.functions.iter().enumerate() {
+                writeln!(
+                    writer,
+                    "<tr data-snippet=\"snippet-{}\"><td>{}</td><td><code>{}</code></td>\
+                     <td><code>{}</code></td><td class=\"num\">{}</td><td class=\"num\">{}</td>\
+                     <td class=\"num\">{}</td><td class=\"num\">{}</td></tr>",
+                    index,
+                    escape_html(path),
+                    escape_html(&function.name),
+                    escape_html(function.receiver.as_deref().unwrap_or_default()),
+                    function.line,
+                    function.complexity,
+                    function.cognitive_complexity,
+                    function.lines_of_code
+                )?;
+            }
+            writeln!(writer, "</tbody>")?;
+            writeln!(writer, "</table>")?;
+        }
+
+        writeln!(writer, "<section id=\"source\" hidden>")?;
+        writeln!(writer, "<h2 id=\"source-title\"></h2>")?;
+        writeln!(writer, "<pre><code id=\"source-code\"></code></pre>")?;
+        writeln!(writer, "</section>")?;
+
+        for (
+            index,
+            Row {
+                path,
+                function,
+                snippet,
+            },
+        ) in self.functions.iter().enumerate()
+        {
+            write!(
+                writer,
+                "<template id=\"snippet-{}\" data-title=\"{}:{} {}\">",
+                index,
+                escape_html(path),
+                function.line,
+                escape_html(&function.name)
+            )?;
+            if snippet.is_empty() {
+                write!(writer, "Source not available.")?;
+            }
+            let end_line = function.end_line.max(function.line);
+            for (number, text) in snippet {
+                let class = if (function.line..=end_line).contains(number) {
+                    "line hl"
+                } else {
+                    "line"
+                };
+                write!(
+                    writer,
+                    "<span class=\"{}\"><span class=\"ln\">{}</span>{}</span>",
+                    class,
+                    number,
+                    escape_html(text)
+                )?;
+            }
+            writeln!(writer, "</template>")?;
+        }
+
+        if !self.functions.is_empty() {
+            writeln!(writer, "<script>\n{}</script>", SCRIPT)?;
+        }
+        writeln!(writer, "</body>")?;
+        writeln!(writer, "</html>")?;
+        Ok(())
+    }
+
+    /// Render the HTML document as a string
+    pub fn render(&self) -> Result<String, String> {
+        let mut buffer = Vec::new();
+        self.write_to(&mut buffer)
+            .map_err(|e| format!("Failed to render HTML: {}", e))?;
+        String::from_utf8(buffer).map_err(|e| format!("Failed to render HTML: {}", e))
+    }
+}
+
+/// Numbered lines of `function` with `SNIPPET_CONTEXT` lines around it
+fn snippet(lines: &[String], function: &FunctionInfo) -> Vec<(usize, String)> {
+    if function.line == 0 || function.line > lines.len() {
+        return vec![];
+    }
+    let first = function.line.saturating_sub(SNIPPET_CONTEXT).max(1);
+    let last = (function.end_line.max(function.line) + SNIPPET_CONTEXT).min(lines.len());
+    (first..=last)
+        .map(|number| (number, lines[number - 1].clone()))
+        .collect()
+}
+
+fn base_dir(root: &Path) -> &Path {
+    if root.is_file() {
+        root.parent().unwrap_or(root)
+    } else {
+        root
+    }
+}
+
+fn relative_path(base: &Path, path: &Path) -> String {
+    path.strip_prefix(base)
+        .unwrap_or(path)
+        .display()
+        .to_string()
+}
+
+/// Escape text for use in HTML content and quoted attribute values
+fn escape_html(text: &str) -> String {
+    let mut escaped = String::with_capacity(text.len());
+    for c in text.chars() {
+        match c {
+            '&' => escaped.push_str("&amp;"),
+            '<' => escaped.push_str("&lt;"),
+            '>' => escaped.push_str("&gt;"),
+            '"' => escaped.push_str("&quot;"),
+            '\'' => escaped.push_str("&#39;"),
+            _ => escaped.push(c),
+        }
+    }
+    escaped
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn function(name: &str, line: usize, end_line: usize, receiver: Option<&str>) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            end_line,
+            receiver: receiver.map(|r| r.to_string()),
+            complexity: 1,
+            lines_of_code: 1,
+            ..Default::default()
+        }
+    }
+
+    fn sample_dir() -> tempfile::TempDir {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("main.go"),
+            "package main\n\ntype Box[T any] struct{}\n\nfunc (b *Box[T]) Less(a, c T) bool {\n\treturn a < c && true\n}\n",
+        )
+        .unwrap();
+        dir
+    }
+
+    #[test]
+    fn html_is_standalone_with_sortable_table() {
+        let dir = sample_dir();
+        let mut result = AnalysisResult::empty(7);
+        result.code_lines = 5;
+        result.functions = vec![function("Less", 5, 7, Some("*Box[T]"))];
+        let out = HtmlReport::from_results(dir.path(), &[(dir.path().join("main.go"), result)])
+            .render()
+            .unwrap();
+        assert!(out.starts_with("<!DOCTYPE html>\n"), "{out}");
+        assert!(out.contains("<style>") && out.contains("<script>"), "{out}");
+        assert!(!out.contains("src=") && !out.contains("href="), "{out}");
+        assert!(
+            out.contains("<p>1 files, 1 functions, 5 lines of code</p>"),
+            "{out}"
+        );
+        assert!(
+            out.contains(
+                "<tr data-snippet=\"snippet-0\"><td>main.go</td><td><code>Less</code></td>"
+            ),
+            "{out}"
+        );
+        assert!(out.contains("<th class=\"num\">Complexity</th>"), "{out}");
+    }
+
+    #[test]
+    fn snippet_highlights_function_lines_and_escapes_source() {
+        let dir = sample_dir();
+        let mut result = AnalysisResult::empty(7);
+        result.functions = vec![function("Less", 5, 7, Some("*Box[T]"))];
+        let out = HtmlReport::from_results(dir.path(), &[(dir.path().join("main.go"), result)])
+            .render()
+            .unwrap();
+        assert!(
+            out.contains(
+                "<span class=\"line\"><span class=\"ln\">3</span>type Box[T any] struct{}</span>"
+            ),
+            "{out}"
+        );
+        assert!(
+            out.contains(
+                "<span class=\"line hl\"><span class=\"ln\">6</span>\treturn a &lt; c &amp;&amp; true</span>"
+            ),
+            "{out}"
+        );
+        assert!(!out.contains("<span class=\"ln\">2</span>"), "{out}");
+    }
+
+    #[test]
+    fn names_and_paths_are_escaped() {
+        let mut result = AnalysisResult::empty(3);
+        result.functions = vec![function("<script>", 1, 1, Some("Map<K, V>"))];
+        let out = HtmlReport::from_results(
+            Path::new("/proj"),
+            &[(PathBuf::from("/proj/a&b.rs"), result)],
+        )
+        .render()
+        .unwrap();
+        assert!(out.contains("<td>a&amp;b.rs</td>"), "{out}");
+        assert!(out.contains("<code>&lt;script&gt;</code>"), "{out}");
+        assert!(out.contains("<code>Map&lt;K, V&gt;</code>"), "{out}");
+        assert!(out.contains("Source not available."), "{out}");
+    }
+}
@@ -0,0 +1,1969 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Stable JSON representation of analysis results.
+//!
+//! Field names in this module are part of the public output contract and
+//! must not be renamed without bumping [`SCHEMA_VERSION`].
+
+use serde::{Deserialize, Serialize};
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use crate::analyze::api::{ApiFunction, ApiSurface, ApiType};
+use crate::analyze::build::SkippedFile;
+use crate::analyze::checks::Finding;
+use crate::analyze::checks::any::{self, EmptyInterfaceType};
+use crate::analyze::checks::append::SliceAppendInLoop;
+use crate::analyze::checks::clones::CloneGroup;
+use crate::analyze::checks::concat::StringConcatInLoop;
+use crate::analyze::checks::docs::MissingDoc;
+use crate::analyze::checks::errors::IgnoredError;
+use crate::analyze::checks::fields::UnusedField;
+use crate::analyze::checks::magic::MagicNumber;
+use crate::analyze::checks::naked::NakedReturn;
+use crate::analyze::checks::panics::PanicCall;
+use crate::analyze::checks::receiver::UnusedReceiver;
+use crate::analyze::checks::receiver_kinds::MixedReceivers;
+use crate::analyze::checks::shadow::ShadowedVariable;
+use crate::analyze::checks::tags::DuplicateJsonTag;
+use crate::analyze::checks::todo::TodoComment;
+use crate::analyze::checks::unreachable::UnreachableStatement;
+use crate::analyze::checks::unused::UnusedFunction;
+use crate::analyze::checks::wrapping::UnwrappedError;
+use crate::analyze::compare::{FunctionMetrics, MetricsChange, MetricsDiff};
+use crate::analyze::imports::{ImportGraph, ImportStyle};
+use crate::analyze::metrics::{Hotspot, LengthBucket};
+use crate::analyze::types::{AnalysisResult, ClassInfo, FieldInfo, FunctionInfo, ParamInfo};
+use crate::lang;
+
+/// Version of the JSON schema, bumped on incompatible changes
+pub const SCHEMA_VERSION: u32 = 1;
+
+/// Top-level JSON document
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonReport {
+    /// Schema version of this document
+    pub version: u32,
+    /// Path that was analyzed, made absolute against the working directory
+    pub root: String,
+    /// Non-blank, non-comment lines across all files
+    #[serde(default)]
+    pub lines_of_code: usize,
+    /// One entry per analyzed file, sorted by path
+    pub files: Vec<JsonFile>,
+    /// Number of functions per lines-of-code bucket, shortest first
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub length_distribution: Vec<JsonLengthBucket>,
+    /// Functions with the highest hotspot score, highest first; only present with `--hotspots`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub hotspots: Vec<JsonHotspot>,
+    /// Unexported functions that are never referenced; only present with `--unused`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub unused_functions: Vec<JsonLocation>,
+    /// Methods that never use their receiver; only present with `--unused-receivers`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub unused_receivers: Vec<JsonUnusedReceiver>,
+    /// Struct fields that encode to the same JSON key; only present with `--duplicate-tags`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub duplicate_tags: Vec<JsonDuplicateTag>,
+    /// Local variables hiding an enclosing declaration; only present with `--shadow`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub shadowed: Vec<JsonShadowed>,
+    /// Bare returns in long functions with named results; only present with `--naked-returns`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub naked_returns: Vec<JsonNakedReturn>,
+    /// Comments marking deferred work; only present with `--todos`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub todos: Vec<JsonTodo>,
+    /// Duplicated statement sequences; only present with `--clones`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub clones: Vec<JsonClone>,
+    /// Calls dropping an `error` result; only present with `--ignored-errors`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub ignored_errors: Vec<JsonIgnoredError>,
+    /// Numeric literals that should be named constants; only present with `--magic-numbers`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub magic_numbers: Vec<JsonMagicNumber>,
+    /// Calls that panic or exit the process; only present with `--panics`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub panics: Vec<JsonPanic>,
+    /// Types mixing value and pointer receivers; only present with `--mixed-receivers`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub mixed_receivers: Vec<JsonMixedReceivers>,
+    /// Struct fields never read or written; only present with `--unused-fields`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub unused_fields: Vec<JsonUnusedField>,
+    /// Strings concatenated inside loops; only present with `--string-concat`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub string_concats: Vec<JsonStringConcat>,
+    /// Errors returned without added context; only present with `--unwrapped-errors`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub unwrapped_errors: Vec<JsonUnwrappedError>,
+    /// `interface{}` and `any` types; only present with `--empty-interfaces`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub empty_interfaces: Vec<JsonEmptyInterface>,
+    /// Totals of `empty_interfaces`; only present when there are some
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub empty_interface_counts: Option<JsonEmptyInterfaceCounts>,
+    /// Declarations and packages without a proper doc comment; only present with `--missing-docs`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub missing_docs: Vec<JsonMissingDoc>,
+    /// Statements that can never run; only present with `--unreachable`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub unreachable_code: Vec<JsonUnreachableCode>,
+    /// Slices grown in loops without capacity; only present with `--slice-appends`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub slice_appends: Vec<JsonSliceAppend>,
+    /// Go files left out by build constraints; only present with `--goos`, `--goarch` or `--tags`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub skipped_files: Vec<JsonSkippedFile>,
+    /// Findings of custom checks registered through the library API
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub checks: Vec<JsonCheckFinding>,
+    /// Exported identifiers grouped by type; only present with `--api`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub api: Option<JsonApi>,
+    /// Interface name to the types satisfying it; only present with `--implementations`
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub implementations: BTreeMap<String, Vec<String>>,
+    /// Packages, their imports and import cycles; only present with `--imports`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub import_graph: Option<JsonImportGraph>,
+    /// Functions added, removed and changed since a baseline; only present with `--compare`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub metrics_diff: Option<JsonMetricsDiff>,
+}
+
+/// Package-level import graph
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonImportGraph {
+    /// Analyzed packages, by import path or by directory relative to the root
+    pub packages: Vec<String>,
+    pub imports: Vec<JsonImport>,
+    /// Each cycle lists packages in import order; the last imports the first
+    pub cycles: Vec<Vec<String>>,
+}
+
+/// One package importing another
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonImport {
+    /// Importing package
+    pub package: String,
+    /// Import path as written
+    pub path: String,
+    /// Analyzed package the path resolves to, or the path itself
+    pub target: String,
+    /// `local`, `stdlib` or `third-party`
+    pub kind: String,
+    /// `regular`, `alias`, `blank` or `dot`
+    pub style: String,
+    /// Name the package is imported as; only present for aliased imports
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub alias: Option<String>,
+}
+
+/// A method whose receiver is never used
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonUnusedReceiver {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub name: String,
+    pub line: usize,
+    /// Identifier the receiver is bound to, e.g. `g`
+    pub receiver: String,
+    /// Receiver type, e.g. `*Greeter`
+    pub receiver_type: String,
+}
+
+/// Fields of one struct sharing a JSON key
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonDuplicateTag {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Struct declaring the fields
+    #[serde(rename = "type")]
+    pub type_name: String,
+    pub key: String,
+    /// Line of the first conflicting field
+    pub line: usize,
+    /// Names of the conflicting fields in declaration order
+    pub fields: Vec<String>,
+}
+
+/// A local variable hiding a declaration of an enclosing scope
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonShadowed {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub name: String,
+    /// 1-based position of the shadowing identifier
+    pub line: usize,
+    pub column: usize,
+    /// 1-based position of the hidden declaration
+    pub shadowed_line: usize,
+    pub shadowed_column: usize,
+}
+
+/// A bare `return` in a function with named results
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonNakedReturn {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the return
+    pub name: String,
+    /// Line of the `return` statement
+    pub line: usize,
+    /// Line of the function declaration
+    pub function_line: usize,
+    pub lines_of_code: usize,
+}
+
+/// A comment marking deferred work
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonTodo {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub line: usize,
+    /// Marker as configured, e.g. `TODO`
+    pub marker: String,
+    /// Text following the marker, empty if there is none
+    pub text: String,
+}
+
+/// A statement sequence found in several places
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonClone {
+    /// Length of the duplicated sequence in statements
+    pub statements: usize,
+    pub locations: Vec<JsonCloneLocation>,
+}
+
+/// One copy of a duplicated statement sequence
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonCloneLocation {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the copy
+    pub name: String,
+    pub start_line: usize,
+    pub end_line: usize,
+}
+
+/// A call whose `error` result is never checked
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonIgnoredError {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the call
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// Call expression as written, up to the end of its first line
+    pub call: String,
+    /// `unchecked`, `deferred` or `blank` (assigned to `_`)
+    pub kind: String,
+}
+
+/// A numeric literal that should be a named constant
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonMagicNumber {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the literal
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// Literal as written, with a leading `-` for a negated one
+    pub value: String,
+}
+
+/// A call to `panic` or a `log.Fatal` function
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonPanic {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the call
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// `panic` or e.g. `log.Fatalf`
+    pub call: String,
+    /// `exported`, `unexported`, `init`, `main` or `test`
+    pub context: String,
+}
+
+/// A Go type with methods on both value and pointer receivers
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonMixedReceivers {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Type name
+    pub name: String,
+    /// Line of the type declaration
+    pub line: usize,
+    pub value_methods: Vec<String>,
+    pub pointer_methods: Vec<String>,
+}
+
+/// A struct field that its package never reads or writes
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonUnusedField {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Struct declaring the field
+    #[serde(rename = "type")]
+    pub type_name: String,
+    pub name: String,
+    pub line: usize,
+}
+
+/// A string built up by concatenation inside a loop
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonStringConcat {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the assignment
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// Variable being built
+    pub variable: String,
+}
+
+/// A `return` passing on a call's error without added context
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonUnwrappedError {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the `return`
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// Returned variable
+    pub variable: String,
+    /// Called function the error came from, e.g. `os.Open`
+    pub call: String,
+    pub call_line: usize,
+}
+
+/// An `interface{}` or `any` type
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonEmptyInterface {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub line: usize,
+    pub column: usize,
+    /// `parameter`, `result`, `field`, `element`, `assertion` or `other`
+    pub usage: String,
+    /// Enclosing function, type or package-level variable; empty when there is none
+    pub declaration: String,
+}
+
+/// Number of empty interface types, in total and per usage
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonEmptyInterfaceCounts {
+    pub total: usize,
+    /// Usage name to count, leaving out usages without any
+    #[serde(flatten)]
+    pub usages: BTreeMap<String, usize>,
+}
+
+/// An exported declaration or package without a proper doc comment
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonMissingDoc {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// `package`, `type`, `function` or `method`
+    pub kind: String,
+    /// Declared name; `Type.Method` for methods
+    pub name: String,
+    pub line: usize,
+    /// First line of a comment not starting with the name; `null` when there is none
+    pub comment: Option<String>,
+}
+
+/// A statement that can never run
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonUnreachableCode {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the statement
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// What leaves the block first, e.g. `return`, `panic` or `if`
+    pub after: String,
+    pub after_line: usize,
+}
+
+/// A slice grown by `append` inside a loop without preallocated capacity
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonSliceAppend {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Function containing the call
+    pub name: String,
+    pub line: usize,
+    pub column: usize,
+    /// Slice being grown
+    pub variable: String,
+    /// Line of the innermost loop around the call
+    pub loop_line: usize,
+    /// Declared slice type, e.g. `[]int`; empty when unknown
+    pub slice_type: String,
+    /// Capacity the loop needs, e.g. `len(items)`; empty when unknown
+    pub capacity: String,
+    /// Whether the call appends to a zero-capacity slice such as `s[:0:0]`
+    pub zero_capacity: bool,
+}
+
+/// A function ranked by its hotspot score, with the metrics behind it
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonHotspot {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub name: String,
+    pub line: usize,
+    pub score: f64,
+    pub complexity: usize,
+    pub cognitive_complexity: usize,
+    pub max_nesting_depth: usize,
+    pub lines_of_code: usize,
+}
+
+/// Number of functions within a range of lines of code
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonLengthBucket {
+    pub min: usize,
+    /// `null` for the last bucket, which has no upper bound
+    pub max: Option<usize>,
+    pub functions: usize,
+}
+
+/// A Go file left out by build constraints
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonSkippedFile {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    /// Failed constraint, e.g. `//go:build windows` or `file name requires windows`
+    pub reason: String,
+}
+
+/// A finding reported by a custom check
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonCheckFinding {
+    /// Name of the check that reported it
+    pub rule: String,
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub start_line: usize,
+    pub end_line: usize,
+    pub message: String,
+}
+
+/// Function metrics compared with a baseline run
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonMetricsDiff {
+    pub added: Vec<JsonFunctionMetrics>,
+    pub removed: Vec<JsonFunctionMetrics>,
+    pub changed: Vec<JsonMetricsChange>,
+}
+
+/// A function present in only one of the compared runs
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonFunctionMetrics {
+    /// Package, receiver and name, e.g. `pkg/store.(*Cache).Get`
+    pub name: String,
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub line: usize,
+    pub complexity: usize,
+    pub lines_of_code: usize,
+}
+
+/// A function whose complexity or length changed since the baseline
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonMetricsChange {
+    /// Package, receiver and name, e.g. `pkg/store.(*Cache).Get`
+    pub name: String,
+    /// Path relative to the analyzed directory, in the new run
+    pub path: String,
+    pub line: usize,
+    pub old_complexity: usize,
+    pub new_complexity: usize,
+    pub complexity_delta: i64,
+    pub old_lines_of_code: usize,
+    pub new_lines_of_code: usize,
+    pub lines_of_code_delta: i64,
+}
+
+/// Exported API surface of the analyzed files
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonApi {
+    pub types: Vec<JsonApiType>,
+    pub functions: Vec<JsonApiFunction>,
+}
+
+/// An exported type with its exported fields and methods
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonApiType {
+    pub name: String,
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub line: usize,
+    pub fields: Vec<JsonApiField>,
+    pub methods: Vec<JsonApiFunction>,
+}
+
+/// An exported field; `type` is `null` when the language does not declare one
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonApiField {
+    pub name: String,
+    #[serde(rename = "type")]
+    pub type_name: Option<String>,
+    /// Go struct tag, e.g. `json:"name"`; omitted when there is none
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub tag: Option<String>,
+}
+
+/// An exported function or method signature
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonApiFunction {
+    pub name: String,
+    pub line: usize,
+    /// Parameters as `name type`, e.g. `x int`
+    pub params: Vec<String>,
+    /// Return types, with names if the language declares them
+    pub returns: Vec<String>,
+}
+
+/// A named declaration at a specific place in a file
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonLocation {
+    /// Path relative to the analyzed directory
+    pub path: String,
+    pub name: String,
+    /// 1-based line of the declaration
+    pub line: usize,
+}
+
+/// Analysis results for a single file
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonFile {
+    /// Path relative to the analyzed directory (or the file name for a single file)
+    pub path: String,
+    /// Language identifier, e.g. `go` or `rust`
+    pub language: String,
+    /// Total number of lines in the file
+    pub line_count: usize,
+    /// Lines holding at least one non-comment token
+    #[serde(default)]
+    pub code_lines: usize,
+    pub functions: Vec<JsonFunction>,
+    pub classes: Vec<JsonClass>,
+    /// Import statements as written in the source
+    pub imports: Vec<String>,
+    /// Why the file could not be analyzed; omitted on success
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub error: Option<String>,
+}
+
+/// A function or method declaration
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonFunction {
+    pub name: String,
+    /// Type the function is declared on, e.g. `*Greeter`; `null` for free functions
+    pub receiver: Option<String>,
+    /// Number of declared parameters, excluding the receiver
+    pub param_count: usize,
+    /// Number of declared return values
+    pub return_count: usize,
+    /// 1-based line of the function name
+    pub start_line: usize,
+    /// 1-based line where the declaration ends
+    pub end_line: usize,
+    /// Cyclomatic complexity (1 for straight-line code)
+    #[serde(default)]
+    pub complexity: usize,
+    /// Cognitive complexity (0 for straight-line code)
+    #[serde(default)]
+    pub cognitive_complexity: usize,
+    /// Deepest nesting of control flow structures (0 for straight-line code)
+    #[serde(default)]
+    pub max_nesting_depth: usize,
+    /// Non-blank, non-comment lines in the function body
+    #[serde(default)]
+    pub lines_of_code: usize,
+    /// Weighted sum of the metrics above (`--hotspot-weights`)
+    #[serde(default)]
+    pub hotspot_score: f64,
+    /// Declared parameters, excluding the receiver
+    #[serde(default)]
+    pub params: Vec<JsonParam>,
+    /// Declared return values
+    #[serde(default)]
+    pub returns: Vec<JsonParam>,
+}
+
+/// A parameter or return value; `name` is `null` when unnamed, `type` when
+/// the language does not declare one
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonParam {
+    pub name: Option<String>,
+    #[serde(rename = "type")]
+    pub type_name: Option<String>,
+}
+
+/// A class, struct, or other type declaration
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JsonClass {
+    pub name: String,
+    /// 1-based line of the type name
+    pub line: usize,
+}
+
+impl JsonReport {
+    /// Build a JSON report from per-file analysis results
+    pub fn from_results(root: &Path, results: &[(PathBuf, AnalysisResult)]) -> Self {
+        let base = base_dir(root);
+
+        let mut files: Vec<JsonFile> = results
+            .iter()
+            .map(|(path, result)| JsonFile::from_result(base, path, result))
+            .collect();
+        files.sort_by(|a, b| a.path.cmp(&b.path));
+
+        Self {
+            version: SCHEMA_VERSION,
+            root: root.display().to_string(),
+            lines_of_code: files.iter().map(|f| f.code_lines).sum(),
+            files,
+            length_distribution: vec![],
+            hotspots: vec![],
+            unused_functions: vec![],
+            unused_receivers: vec![],
+            duplicate_tags: vec![],
+            shadowed: vec![],
+            naked_returns: vec![],
+            todos: vec![],
+            clones: vec![],
+            ignored_errors: vec![],
+            magic_numbers: vec![],
+            panics: vec![],
+            mixed_receivers: vec![],
+            unused_fields: vec![],
+            string_concats: vec![],
+            unwrapped_errors: vec![],
+            empty_interfaces: vec![],
+            empty_interface_counts: None,
+            missing_docs: vec![],
+            unreachable_code: vec![],
+            slice_appends: vec![],
+            skipped_files: vec![],
+            checks: vec![],
+            api: None,
+            implementations: BTreeMap::new(),
+            import_graph: None,
+            metrics_diff: None,
+        }
+    }
+
+    /// Read a report written by an earlier `--format json` run
+    pub fn parse(json: &str) -> Result<Self, String> {
+        let report: Self = serde_json::from_str(json)
+            .map_err(|e| format!("Failed to parse JSON report: {}", e))?;
+        if report.version != SCHEMA_VERSION {
+            return Err(format!(
+                "Unsupported JSON report version {} (expected {})",
+                report.version, SCHEMA_VERSION
+            ));
+        }
+        Ok(report)
+    }
+
+    /// Attach the results of the unused receiver check
+    pub fn with_unused_receivers(mut self, root: &Path, unused: &[UnusedReceiver]) -> Self {
+        let base = base_dir(root);
+        self.unused_receivers = unused
+            .iter()
+            .map(|entry| JsonUnusedReceiver {
+                path: relative_path(base, &entry.path),
+                name: entry.function.name.clone(),
+                line: entry.function.line,
+                receiver: entry.receiver_name().to_string(),
+                receiver_type: entry.receiver_type().to_string(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the results of the duplicate JSON tag check
+    pub fn with_duplicate_tags(mut self, root: &Path, duplicates: &[DuplicateJsonTag]) -> Self {
+        let base = base_dir(root);
+        self.duplicate_tags = duplicates
+            .iter()
+            .map(|entry| JsonDuplicateTag {
+                path: relative_path(base, &entry.path),
+                type_name: entry.type_name.clone(),
+                key: entry.key.clone(),
+                line: entry.line(),
+                fields: entry.fields.iter().map(|f| f.name.clone()).collect(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the results of the shadowed variable check
+    pub fn with_shadowed(mut self, root: &Path, shadowed: &[ShadowedVariable]) -> Self {
+        let base = base_dir(root);
+        self.shadowed = shadowed
+            .iter()
+            .map(|entry| JsonShadowed {
+                path: relative_path(base, &entry.path),
+                name: entry.shadow.name.clone(),
+                line: entry.shadow.line,
+                column: entry.shadow.column,
+                shadowed_line: entry.shadow.shadowed_line,
+                shadowed_column: entry.shadow.shadowed_column,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the results of the naked return check
+    pub fn with_naked_returns(mut self, root: &Path, naked: &[NakedReturn]) -> Self {
+        let base = base_dir(root);
+        self.naked_returns = naked
+            .iter()
+            .map(|entry| JsonNakedReturn {
+                path: relative_path(base, &entry.path),
+                name: entry.function.name.clone(),
+                line: entry.line,
+                function_line: entry.function.line,
+                lines_of_code: entry.function.lines_of_code,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the comments marking deferred work
+    pub fn with_todos(mut self, root: &Path, todos: &[TodoComment]) -> Self {
+        let base = base_dir(root);
+        self.todos = todos
+            .iter()
+            .map(|todo| JsonTodo {
+                path: relative_path(base, &todo.path),
+                line: todo.line,
+                marker: todo.marker.clone(),
+                text: todo.text.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the duplicated statement sequences
+    pub fn with_clones(mut self, root: &Path, clones: &[CloneGroup]) -> Self {
+        let base = base_dir(root);
+        self.clones = clones
+            .iter()
+            .map(|group| JsonClone {
+                statements: group.statements,
+                locations: group
+                    .locations
+                    .iter()
+                    .map(|location| JsonCloneLocation {
+                        path: relative_path(base, &location.path),
+                        name: location.function.clone(),
+                        start_line: location.start_line,
+                        end_line: location.end_line,
+                    })
+                    .collect(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the calls dropping an `error` result
+    pub fn with_ignored_errors(mut self, root: &Path, ignored: &[IgnoredError]) -> Self {
+        let base = base_dir(root);
+        self.ignored_errors = ignored
+            .iter()
+            .map(|entry| JsonIgnoredError {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.call.line,
+                column: entry.call.column,
+                call: entry.call.call.clone(),
+                kind: entry.kind.as_str().to_string(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach numeric literals that should be named constants
+    pub fn with_magic_numbers(mut self, root: &Path, magic: &[MagicNumber]) -> Self {
+        let base = base_dir(root);
+        self.magic_numbers = magic
+            .iter()
+            .map(|entry| JsonMagicNumber {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.literal.line,
+                column: entry.literal.column,
+                value: entry.literal.value.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach calls that panic or exit the process
+    pub fn with_panics(mut self, root: &Path, panics: &[PanicCall]) -> Self {
+        let base = base_dir(root);
+        self.panics = panics
+            .iter()
+            .map(|entry| JsonPanic {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.line,
+                column: entry.column,
+                call: entry.callee.clone(),
+                context: entry.context.as_str().to_string(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the types mixing value and pointer receivers
+    pub fn with_mixed_receivers(mut self, root: &Path, mixed: &[MixedReceivers]) -> Self {
+        let base = base_dir(root);
+        self.mixed_receivers = mixed
+            .iter()
+            .map(|entry| JsonMixedReceivers {
+                path: relative_path(base, &entry.path),
+                name: entry.type_name.clone(),
+                line: entry.line,
+                value_methods: entry.value_methods.clone(),
+                pointer_methods: entry.pointer_methods.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the number of functions per length bucket
+    pub fn with_length_distribution(mut self, buckets: &[LengthBucket]) -> Self {
+        self.length_distribution = buckets
+            .iter()
+            .map(|bucket| JsonLengthBucket {
+                min: bucket.min,
+                max: bucket.max,
+                functions: bucket.functions,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the functions ranked by hotspot score
+    pub fn with_hotspots(mut self, root: &Path, hotspots: &[Hotspot]) -> Self {
+        let base = base_dir(root);
+        self.hotspots = hotspots
+            .iter()
+            .map(|hotspot| JsonHotspot {
+                path: relative_path(base, &hotspot.path),
+                name: hotspot.function.name.clone(),
+                line: hotspot.function.line,
+                score: hotspot.function.hotspot_score,
+                complexity: hotspot.function.complexity,
+                cognitive_complexity: hotspot.function.cognitive_complexity,
+                max_nesting_depth: hotspot.function.max_nesting_depth,
+                lines_of_code: hotspot.function.lines_of_code,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the struct fields never read or written
+    pub fn with_unused_fields(mut self, root: &Path, unused: &[UnusedField]) -> Self {
+        let base = base_dir(root);
+        self.unused_fields = unused
+            .iter()
+            .map(|entry| JsonUnusedField {
+                path: relative_path(base, &entry.path),
+                type_name: entry.type_name.clone(),
+                name: entry.field.name.clone(),
+                line: entry.field.line,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the strings concatenated inside loops
+    pub fn with_string_concats(mut self, root: &Path, concats: &[StringConcatInLoop]) -> Self {
+        let base = base_dir(root);
+        self.string_concats = concats
+            .iter()
+            .map(|entry| JsonStringConcat {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.concat.line,
+                column: entry.concat.column,
+                variable: entry.concat.variable.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the errors returned without added context
+    pub fn with_unwrapped_errors(mut self, root: &Path, unwrapped: &[UnwrappedError]) -> Self {
+        let base = base_dir(root);
+        self.unwrapped_errors = unwrapped
+            .iter()
+            .map(|entry| JsonUnwrappedError {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.error_return.line,
+                column: entry.error_return.column,
+                variable: entry.error_return.variable.clone(),
+                call: entry.error_return.callee.clone(),
+                call_line: entry.error_return.call_line,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the empty interface types and their counts
+    pub fn with_empty_interfaces(mut self, root: &Path, found: &[EmptyInterfaceType]) -> Self {
+        let base = base_dir(root);
+        self.empty_interfaces = found
+            .iter()
+            .map(|entry| JsonEmptyInterface {
+                path: relative_path(base, &entry.path),
+                line: entry.empty_interface.line,
+                column: entry.empty_interface.column,
+                usage: entry.empty_interface.usage.as_str().to_string(),
+                declaration: entry.empty_interface.declaration.clone(),
+            })
+            .collect();
+        self.empty_interface_counts = (!found.is_empty()).then(|| JsonEmptyInterfaceCounts {
+            total: found.len(),
+            usages: any::count_by_usage(found)
+                .into_iter()
+                .map(|(usage, count)| (usage.as_str().to_string(), count))
+                .collect(),
+        });
+        self
+    }
+
+    /// Attach the declarations and packages lacking a doc comment
+    pub fn with_missing_docs(mut self, root: &Path, missing: &[MissingDoc]) -> Self {
+        let base = base_dir(root);
+        self.missing_docs = missing
+            .iter()
+            .map(|entry| JsonMissingDoc {
+                path: relative_path(base, &entry.path),
+                kind: entry.target.as_str().to_string(),
+                name: entry.name.clone(),
+                line: entry.line,
+                comment: entry.comment.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the statements that can never run
+    pub fn with_unreachable_code(mut self, root: &Path, found: &[UnreachableStatement]) -> Self {
+        let base = base_dir(root);
+        self.unreachable_code = found
+            .iter()
+            .map(|entry| JsonUnreachableCode {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.unreachable.line,
+                column: entry.unreachable.column,
+                after: entry.unreachable.after.clone(),
+                after_line: entry.unreachable.after_line,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the slices grown in loops without capacity
+    pub fn with_slice_appends(mut self, root: &Path, appends: &[SliceAppendInLoop]) -> Self {
+        let base = base_dir(root);
+        self.slice_appends = appends
+            .iter()
+            .map(|entry| JsonSliceAppend {
+                path: relative_path(base, &entry.path),
+                name: entry.function.clone(),
+                line: entry.append.line,
+                column: entry.append.column,
+                variable: entry.append.variable.clone(),
+                loop_line: entry.append.loop_line,
+                slice_type: entry.append.slice_type.clone(),
+                capacity: entry.append.capacity.clone(),
+                zero_capacity: entry.append.zero_capacity,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the Go files left out by build constraints
+    pub fn with_skipped_files(mut self, root: &Path, skipped: &[SkippedFile]) -> Self {
+        let base = base_dir(root);
+        self.skipped_files = skipped
+            .iter()
+            .map(|entry| JsonSkippedFile {
+                path: relative_path(base, &entry.path),
+                reason: entry.reason.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the findings of custom checks
+    pub fn with_check_findings(mut self, root: &Path, findings: &[Finding]) -> Self {
+        let base = base_dir(root);
+        self.checks = findings
+            .iter()
+            .map(|finding| JsonCheckFinding {
+                rule: finding.rule_id.to_string(),
+                path: relative_path(base, &finding.path),
+                start_line: finding.start_line,
+                end_line: finding.end_line,
+                message: finding.message.clone(),
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the interface implementations found in the analyzed files
+    pub fn with_implementations(mut self, implementations: &BTreeMap<String, Vec<String>>) -> Self {
+        self.implementations = implementations.clone();
+        self
+    }
+
+    /// Attach the import graph of the analyzed packages
+    pub fn with_import_graph(mut self, graph: &ImportGraph) -> Self {
+        self.import_graph = Some(JsonImportGraph {
+            packages: graph.packages().cloned().collect(),
+            imports: graph
+                .edges()
+                .map(|edge| JsonImport {
+                    package: edge.package.clone(),
+                    path: edge.path.clone(),
+                    target: edge.target.clone(),
+                    kind: edge.kind.as_str().to_string(),
+                    style: edge.style.as_str().to_string(),
+                    alias: match &edge.style {
+                        ImportStyle::Alias(alias) => Some(alias.clone()),
+                        _ => None,
+                    },
+                })
+                .collect(),
+            cycles: graph.find_cycles(),
+        });
+        self
+    }
+
+    /// Attach the exported API surface
+    pub fn with_api(mut self, root: &Path, api: &ApiSurface) -> Self {
+        let base = base_dir(root);
+        self.api = Some(JsonApi {
+            types: api
+                .types
+                .iter()
+                .map(|api_type| JsonApiType::from_api_type(base, api_type))
+                .collect(),
+            functions: api
+                .functions
+                .iter()
+                .map(|ApiFunction { function, .. }| JsonApiFunction::from(function))
+                .collect(),
+        });
+        self
+    }
+
+    /// Attach the results of the unused function check
+    pub fn with_unused_functions(mut self, root: &Path, unused: &[UnusedFunction]) -> Self {
+        let base = base_dir(root);
+        self.unused_functions = unused
+            .iter()
+            .map(|entry| JsonLocation {
+                path: relative_path(base, &entry.path),
+                name: entry.function.name.clone(),
+                line: entry.function.line,
+            })
+            .collect();
+        self
+    }
+
+    /// Attach the comparison with a baseline run
+    pub fn with_metrics_diff(mut self, diff: &MetricsDiff) -> Self {
+        let metrics = |function: &FunctionMetrics| JsonFunctionMetrics {
+            name: function.name.clone(),
+            path: function.path.clone(),
+            line: function.line,
+            complexity: function.complexity,
+            lines_of_code: function.lines_of_code,
+        };
+        self.metrics_diff = Some(JsonMetricsDiff {
+            added: diff.added.iter().map(metrics).collect(),
+            removed: diff.removed.iter().map(metrics).collect(),
+            changed: diff.changed.iter().map(JsonMetricsChange::from).collect(),
+        });
+        self
+    }
+
+    /// Serialize as a pretty-printed JSON document
+    pub fn render(&self) -> Result<String, String> {
+        serde_json::to_string_pretty(self)
+            .map(|json| json + "\n")
+            .map_err(|e| format!("Failed to serialize JSON: {}", e))
+    }
+}
+
+/// Directory that reported paths are relative to
+fn base_dir(root: &Path) -> &Path {
+    if root.is_file() {
+        root.parent().unwrap_or(root)
+    } else {
+        root
+    }
+}
+
+fn relative_path(base: &Path, path: &Path) -> String {
+    path.strip_prefix(base)
+        .unwrap_or(path)
+        .display()
+        .to_string()
+}
+
+impl JsonFile {
+    fn from_result(base: &Path, path: &Path, result: &AnalysisResult) -> Self {
+        Self {
+            path: relative_path(base, path),
+            language: lang::get_language_identifier(path).to_string(),
+            line_count: result.line_count,
+            code_lines: result.code_lines,
+            functions: result.functions.iter().map(JsonFunction::from).collect(),
+            classes: result.classes.iter().map(JsonClass::from).collect(),
+            imports: result.imports.clone(),
+            error: result.error.clone(),
+        }
+    }
+
+    /// One line of `--format jsonl` output: the file's entry of a JSON
+    /// report, on a single line ending in a newline
+    pub fn render_line(
+        root: &Path,
+        path: &Path,
+        result: &AnalysisResult,
+    ) -> Result<String, String> {
+        serde_json::to_string(&Self::from_result(base_dir(root), path, result))
+            .map(|json| json + "\n")
+            .map_err(|e| format!("Failed to serialize JSON: {}", e))
+    }
+}
+
+impl From<&MetricsChange> for JsonMetricsChange {
+    fn from(change: &MetricsChange) -> Self {
+        Self {
+            name: change.name.clone(),
+            path: change.path.clone(),
+            line: change.line,
+            old_complexity: change.old_complexity,
+            new_complexity: change.new_complexity,
+            complexity_delta: change.complexity_delta(),
+            old_lines_of_code: change.old_lines_of_code,
+            new_lines_of_code: change.new_lines_of_code,
+            lines_of_code_delta: change.lines_of_code_delta(),
+        }
+    }
+}
+
+impl JsonApiType {
+    fn from_api_type(base: &Path, api_type: &ApiType) -> Self {
+        Self {
+            name: api_type.name.clone(),
+            path: relative_path(base, &api_type.path),
+            line: api_type.line,
+            fields: api_type.fields.iter().map(JsonApiField::from).collect(),
+            methods: api_type.methods.iter().map(JsonApiFunction::from).collect(),
+        }
+    }
+}
+
+impl From<&FieldInfo> for JsonApiField {
+    fn from(field: &FieldInfo) -> Self {
+        Self {
+            name: field.name.clone(),
+            type_name: field.type_name.clone(),
+            tag: field.tag.clone(),
+        }
+    }
+}
+
+impl From<&FunctionInfo> for JsonApiFunction {
+    fn from(func: &FunctionInfo) -> Self {
+        Self {
+            name: func.name.clone(),
+            line: func.line,
+            params: func.params.iter().map(ToString::to_string).collect(),
+            returns: func.returns.iter().map(ToString::to_string).collect(),
+        }
+    }
+}
+
+impl From<&FunctionInfo> for JsonFunction {
+    fn from(func: &FunctionInfo) -> Self {
+        Self {
+            name: func.name.clone(),
+            receiver: func.receiver.clone(),
+            param_count: func.params.len(),
+            return_count: func.returns.len(),
+            start_line: func.line,
+            end_line: func.end_line,
+            complexity: func.complexity,
+            cognitive_complexity: func.cognitive_complexity,
+            max_nesting_depth: func.max_nesting_depth,
+            lines_of_code: func.lines_of_code,
+            hotspot_score: func.hotspot_score,
+            params: func.params.iter().map(JsonParam::from).collect(),
+            returns: func.returns.iter().map(JsonParam::from).collect(),
+        }
+    }
+}
+
+impl From<&ParamInfo> for JsonParam {
+    fn from(param: &ParamInfo) -> Self {
+        Self {
+            name: param.name.clone(),
+            type_name: param.type_name.clone(),
+        }
+    }
+}
+
+impl From<&ClassInfo> for JsonClass {
+    fn from(class: &ClassInfo) -> Self {
+        Self {
+            name: class.name.clone(),
+            line: class.line,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::checks::clones::CloneLocation;
+
+    fn sample_result() -> AnalysisResult {
+        let mut result = AnalysisResult::empty(24);
+        result.functions = vec![FunctionInfo {
+            name: "Greet".into(),
+            line: 9,
+            end_line: 11,
+            receiver: Some("*Greeter".into()),
+            receiver_name: Some("g".into()),
+            receiver_used: true,
+            params: vec![],
+            returns: vec![ParamInfo::unnamed("string")],
+            complexity: 1,
+            cognitive_complexity: 0,
+            max_nesting_depth: 0,
+            lines_of_code: 1,
+            hotspot_score: 0.1,
+            exported: true,
+            ignored_checks: vec![],
+            naked_returns: vec![],
+            statement_blocks: vec![],
+            discarded_calls: vec![],
+            number_literals: vec![],
+            string_concats: vec![],
+            error_returns: vec![],
+            unreachable_code: vec![],
+            slice_appends: vec![],
+        }];
+        result.function_count = 1;
+        result
+    }
+
+    #[test]
+    fn json_report_has_stable_fields() {
+        let results = vec![(PathBuf::from("/proj/sample.go"), sample_result())];
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+
+        assert_eq!(value["version"], SCHEMA_VERSION);
+        let file = &value["files"][0];
+        assert_eq!(file["path"], "sample.go");
+        assert_eq!(file["language"], "go");
+        let func = &file["functions"][0];
+        assert_eq!(func["name"], "Greet");
+        assert_eq!(func["receiver"], "*Greeter");
+        assert_eq!(func["param_count"], 0);
+        assert_eq!(func["return_count"], 1);
+        assert_eq!(func["start_line"], 9);
+        assert_eq!(func["end_line"], 11);
+        assert_eq!(func["complexity"], 1);
+        assert_eq!(func["lines_of_code"], 1);
+    }
+
+    #[test]
+    fn json_functions_list_params_and_returns() {
+        let mut result = sample_result();
+        result.functions[0].params = vec![
+            ParamInfo::named("name", Some("string")),
+            ParamInfo::named("rest", Some("...int")),
+        ];
+        let results = vec![(PathBuf::from("/proj/sample.go"), result)];
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+
+        let func = &value["files"][0]["functions"][0];
+        assert_eq!(func["params"][0]["name"], "name");
+        assert_eq!(func["params"][0]["type"], "string");
+        assert_eq!(func["params"][1]["type"], "...int");
+        assert!(func["returns"][0]["name"].is_null());
+        assert_eq!(func["returns"][0]["type"], "string");
+    }
+
+    #[test]
+    fn json_report_totals_code_lines() {
+        let mut a = AnalysisResult::empty(10);
+        a.code_lines = 7;
+        let mut b = AnalysisResult::empty(5);
+        b.code_lines = 3;
+        let results = vec![
+            (PathBuf::from("/proj/a.go"), a),
+            (PathBuf::from("/proj/b.go"), b),
+        ];
+        let report = JsonReport::from_results(Path::new("/proj"), &results);
+        assert_eq!(report.lines_of_code, 10);
+        assert_eq!(report.files[0].code_lines, 7);
+    }
+
+    #[test]
+    fn json_report_includes_file_errors() {
+        let results = vec![
+            (PathBuf::from("/proj/a.go"), AnalysisResult::empty(1)),
+            (
+                PathBuf::from("/proj/b.go"),
+                AnalysisResult::failed("boom".into()),
+            ),
+        ];
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert!(value["files"][0].get("error").is_none());
+        assert_eq!(value["files"][1]["error"], "boom");
+    }
+
+    #[test]
+    fn json_report_includes_api_when_requested() {
+        let mut result = sample_result();
+        result.classes = vec![ClassInfo {
+            name: "Greeter".into(),
+            line: 5,
+            methods: vec![],
+            fields: vec![FieldInfo {
+                name: "Name".into(),
+                line: 6,
+                type_name: Some("string".into()),
+                exported: true,
+                tag: Some("json:\"name\"".into()),
+                embedded: false,
+            }],
+            exported: true,
+            interface: None,
+        }];
+        let results = vec![(PathBuf::from("/proj/sample.go"), result)];
+        let api = crate::analyze::api::exported_api(&results);
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .with_api(Path::new("/proj"), &api)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+
+        let greeter = &value["api"]["types"][0];
+        assert_eq!(greeter["name"], "Greeter");
+        assert_eq!(greeter["path"], "sample.go");
+        assert_eq!(greeter["fields"][0]["type"], "string");
+        assert_eq!(greeter["fields"][0]["tag"], "json:\"name\"");
+        assert_eq!(greeter["methods"][0]["name"], "Greet");
+        assert_eq!(greeter["methods"][0]["returns"][0], "string");
+    }
+
+    #[test]
+    fn json_report_sorts_files() {
+        let results = vec![
+            (PathBuf::from("/proj/b.go"), AnalysisResult::empty(1)),
+            (PathBuf::from("/proj/a.go"), AnalysisResult::empty(1)),
+        ];
+        let report = JsonReport::from_results(Path::new("/proj"), &results);
+        let paths: Vec<&str> = report.files.iter().map(|f| f.path.as_str()).collect();
+        assert_eq!(paths, vec!["a.go", "b.go"]);
+    }
+
+    #[test]
+    fn json_report_round_trips() {
+        let results = vec![(PathBuf::from("/proj/sample.go"), sample_result())];
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .render()
+            .unwrap();
+        let report: JsonReport = serde_json::from_str(&json).unwrap();
+        assert_eq!(report.files[0].functions[0].name, "Greet");
+    }
+
+    #[test]
+    fn json_report_omits_unused_functions_when_empty() {
+        let results = vec![(PathBuf::from("/proj/sample.go"), sample_result())];
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .render()
+            .unwrap();
+        assert!(!json.contains("unused_functions"));
+        assert!(!json.contains("unused_receivers"));
+    }
+
+    #[test]
+    fn json_report_lists_unused_functions() {
+        let unused = vec![UnusedFunction {
+            path: PathBuf::from("/proj/sample.go"),
+            function: FunctionInfo {
+                name: "dead".into(),
+                line: 3,
+                ..Default::default()
+            },
+        }];
+        let report = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_unused_functions(Path::new("/proj"), &unused);
+        assert_eq!(report.unused_functions[0].path, "sample.go");
+        assert_eq!(report.unused_functions[0].name, "dead");
+        assert_eq!(report.unused_functions[0].line, 3);
+    }
+
+    #[test]
+    fn json_report_lists_unused_receivers() {
+        let unused = vec![UnusedReceiver {
+            path: PathBuf::from("/proj/sample.go"),
+            function: FunctionInfo {
+                name: "Greet".into(),
+                line: 9,
+                receiver: Some("*Greeter".into()),
+                receiver_name: Some("g".into()),
+                ..Default::default()
+            },
+        }];
+        let report = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_unused_receivers(Path::new("/proj"), &unused);
+        let entry = &report.unused_receivers[0];
+        assert_eq!(entry.path, "sample.go");
+        assert_eq!(entry.receiver, "g");
+        assert_eq!(entry.receiver_type, "*Greeter");
+    }
+
+    #[test]
+    fn json_report_lists_shadowed_variables() {
+        let shadowed = vec![ShadowedVariable {
+            path: PathBuf::from("/proj/main.go"),
+            shadow: crate::analyze::types::ShadowInfo {
+                name: "err".into(),
+                line: 7,
+                column: 6,
+                shadowed_line: 4,
+                shadowed_column: 2,
+                loop_variable: false,
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_shadowed(Path::new("/proj"), &shadowed)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        let entry = &value["shadowed"][0];
+        assert_eq!(entry["path"], "main.go");
+        assert_eq!(entry["name"], "err");
+        assert_eq!(
+            (entry["line"].as_u64(), entry["column"].as_u64()),
+            (Some(7), Some(6))
+        );
+        assert_eq!(entry["shadowed_line"], 4);
+    }
+
+    #[test]
+    fn json_report_lists_todos_only_when_present() {
+        let todos = vec![TodoComment {
+            path: PathBuf::from("/proj/main.go"),
+            line: 3,
+            marker: "FIXME".into(),
+            text: "flaky".into(),
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_todos(Path::new("/proj"), &todos)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["todos"][0],
+            serde_json::json!({"path": "main.go", "line": 3, "marker": "FIXME", "text": "flaky"})
+        );
+
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .render()
+            .unwrap();
+        assert!(!json.contains("\"todos\""), "{json}");
+    }
+
+    #[test]
+    fn json_report_lists_ignored_errors() {
+        let ignored = vec![IgnoredError {
+            path: PathBuf::from("/proj/main.go"),
+            function: "main".into(),
+            call: crate::analyze::types::DiscardedCall {
+                callee: "os.Remove".into(),
+                call: "os.Remove(tmp)".into(),
+                line: 12,
+                column: 2,
+                deferred: false,
+                blank_results: None,
+                assigned: 0,
+            },
+            kind: crate::analyze::checks::errors::IgnoredErrorKind::Unchecked,
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_ignored_errors(Path::new("/proj"), &ignored)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["ignored_errors"][0],
+            serde_json::json!({"path": "main.go", "name": "main", "line": 12, "column": 2, "call": "os.Remove(tmp)", "kind": "unchecked"})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_magic_numbers() {
+        let magic = vec![MagicNumber {
+            path: PathBuf::from("/proj/main.go"),
+            function: "helper".into(),
+            literal: crate::analyze::types::NumberLiteral {
+                value: "2".into(),
+                line: 14,
+                column: 13,
+                index: false,
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_magic_numbers(Path::new("/proj"), &magic)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["magic_numbers"][0],
+            serde_json::json!({"path": "main.go", "name": "helper", "line": 14, "column": 13, "value": "2"})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_panics() {
+        let panics = vec![PanicCall {
+            path: PathBuf::from("/proj/lib.go"),
+            function: "Parse".into(),
+            callee: "panic".into(),
+            line: 7,
+            column: 3,
+            context: crate::analyze::checks::panics::PanicContext::Exported,
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_panics(Path::new("/proj"), &panics)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["panics"][0],
+            serde_json::json!({"path": "lib.go", "name": "Parse", "line": 7, "column": 3, "call": "panic", "context": "exported"})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_mixed_receivers() {
+        let mixed = vec![MixedReceivers {
+            path: PathBuf::from("/proj/pkg/greeter.go"),
+            type_name: "Greeter".into(),
+            line: 3,
+            value_methods: vec!["Name".into()],
+            pointer_methods: vec!["SetName".into()],
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_mixed_receivers(Path::new("/proj"), &mixed)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["mixed_receivers"][0],
+            serde_json::json!({"path": "pkg/greeter.go", "name": "Greeter", "line": 3, "value_methods": ["Name"], "pointer_methods": ["SetName"]})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_length_distribution() {
+        let buckets = [
+            LengthBucket {
+                min: 1,
+                max: Some(10),
+                functions: 3,
+            },
+            LengthBucket {
+                min: 11,
+                max: None,
+                functions: 1,
+            },
+        ];
+        let report = JsonReport::from_results(Path::new("/proj"), &[]);
+        let json = report.clone().render().unwrap();
+        assert!(!json.contains("length_distribution"), "{json}");
+
+        let json = report.with_length_distribution(&buckets).render().unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["length_distribution"],
+            serde_json::json!([
+                {"min": 1, "max": 10, "functions": 3},
+                {"min": 11, "max": null, "functions": 1}
+            ])
+        );
+    }
+
+    #[test]
+    fn json_report_lists_hotspots() {
+        let mut result = sample_result();
+        result.functions[0].complexity = 6;
+        result.functions[0].hotspot_score = 5.1;
+        let function = result.functions[0].clone();
+        let results = vec![(PathBuf::from("/proj/sample.go"), result)];
+        let hotspots = vec![Hotspot {
+            path: PathBuf::from("/proj/sample.go"),
+            function,
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .with_hotspots(Path::new("/proj"), &hotspots)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(value["files"][0]["functions"][0]["hotspot_score"], 5.1);
+        assert_eq!(
+            value["hotspots"][0],
+            serde_json::json!({"path": "sample.go", "name": "Greet", "line": 9, "score": 5.1, "complexity": 6, "cognitive_complexity": 0, "max_nesting_depth": 0, "lines_of_code": 1})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_unused_fields() {
+        let unused = vec![UnusedField {
+            path: PathBuf::from("/proj/pkg/greeter.go"),
+            type_name: "Greeter".into(),
+            field: FieldInfo {
+                name: "count".into(),
+                line: 5,
+                ..Default::default()
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_unused_fields(Path::new("/proj"), &unused)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["unused_fields"][0],
+            serde_json::json!({"path": "pkg/greeter.go", "type": "Greeter", "name": "count", "line": 5})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_string_concats() {
+        let concats = vec![StringConcatInLoop {
+            path: PathBuf::from("/proj/text.go"),
+            function: "join".into(),
+            concat: crate::analyze::types::StringConcat {
+                variable: "s".into(),
+                line: 7,
+                column: 3,
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_string_concats(Path::new("/proj"), &concats)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["string_concats"][0],
+            serde_json::json!({"path": "text.go", "name": "join", "line": 7, "column": 3, "variable": "s"})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_unwrapped_errors() {
+        let unwrapped = vec![UnwrappedError {
+            path: PathBuf::from("/proj/config.go"),
+            function: "Load".into(),
+            error_return: crate::analyze::types::ErrorReturn {
+                variable: "err".into(),
+                line: 6,
+                column: 3,
+                callee: "os.ReadFile".into(),
+                call_line: 4,
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_unwrapped_errors(Path::new("/proj"), &unwrapped)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["unwrapped_errors"][0],
+            serde_json::json!({"path": "config.go", "name": "Load", "line": 6, "column": 3, "variable": "err", "call": "os.ReadFile", "call_line": 4})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_empty_interfaces_with_counts() {
+        use crate::analyze::types::{EmptyInterface, EmptyInterfaceUsage};
+
+        let empty_interface = |usage, line| EmptyInterfaceType {
+            path: PathBuf::from("/proj/codec.go"),
+            empty_interface: EmptyInterface {
+                usage,
+                declaration: "Decode".into(),
+                line,
+                column: 28,
+            },
+        };
+        let found = vec![
+            empty_interface(EmptyInterfaceUsage::Parameter, 8),
+            empty_interface(EmptyInterfaceUsage::Result, 8),
+            empty_interface(EmptyInterfaceUsage::Parameter, 12),
+        ];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_empty_interfaces(Path::new("/proj"), &found)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["empty_interfaces"][0],
+            serde_json::json!({"path": "codec.go", "line": 8, "column": 28, "usage": "parameter", "declaration": "Decode"})
+        );
+        assert_eq!(
+            value["empty_interface_counts"],
+            serde_json::json!({"total": 3, "parameter": 2, "result": 1})
+        );
+
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_empty_interfaces(Path::new("/proj"), &[])
+            .render()
+            .unwrap();
+        assert!(!json.contains("empty_interface"));
+    }
+
+    #[test]
+    fn json_report_lists_missing_docs() {
+        use crate::analyze::checks::docs::DocTarget;
+
+        let missing = vec![MissingDoc {
+            path: PathBuf::from("/proj/greet.go"),
+            target: DocTarget::Function,
+            name: "Hello".into(),
+            line: 15,
+            comment: Some("hello returns a greeting.".into()),
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_missing_docs(Path::new("/proj"), &missing)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["missing_docs"][0],
+            serde_json::json!({"path": "greet.go", "kind": "function", "name": "Hello", "line": 15, "comment": "hello returns a greeting."})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_unreachable_code() {
+        let found = vec![UnreachableStatement {
+            path: PathBuf::from("/proj/main.go"),
+            function: "run".into(),
+            unreachable: crate::analyze::types::UnreachableCode {
+                line: 6,
+                column: 2,
+                after: "return".into(),
+                after_line: 5,
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_unreachable_code(Path::new("/proj"), &found)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["unreachable_code"][0],
+            serde_json::json!({"path": "main.go", "name": "run", "line": 6, "column": 2, "after": "return", "after_line": 5})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_slice_appends() {
+        let appends = vec![SliceAppendInLoop {
+            path: PathBuf::from("/proj/list.go"),
+            function: "collect".into(),
+            append: crate::analyze::types::SliceAppend {
+                variable: "ids".into(),
+                line: 8,
+                column: 9,
+                loop_line: 7,
+                slice_type: "[]int".into(),
+                capacity: "len(items)".into(),
+                zero_capacity: false,
+            },
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_slice_appends(Path::new("/proj"), &appends)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["slice_appends"][0],
+            serde_json::json!({"path": "list.go", "name": "collect", "line": 8, "column": 9, "variable": "ids", "loop_line": 7, "slice_type": "[]int", "capacity": "len(items)", "zero_capacity": false})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_skipped_files() {
+        let skipped = vec![SkippedFile {
+            path: PathBuf::from("/proj/fs_windows.go"),
+            reason: "file name requires windows".into(),
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_skipped_files(Path::new("/proj"), &skipped)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["skipped_files"][0],
+            serde_json::json!({"path": "fs_windows.go", "reason": "file name requires windows"})
+        );
+    }
+
+    #[test]
+    fn json_report_round_trips_and_lists_metrics_changes() {
+        let mut result = AnalysisResult::empty(10);
+        result.functions = vec![FunctionInfo {
+            name: "helper".into(),
+            line: 3,
+            complexity: 2,
+            lines_of_code: 4,
+            ..Default::default()
+        }];
+        let json =
+            JsonReport::from_results(Path::new("/proj"), &[(PathBuf::from("/proj/a.go"), result)])
+                .render()
+                .unwrap();
+        let baseline = JsonReport::parse(&json).unwrap();
+        assert_eq!(baseline.files[0].functions[0].name, "helper");
+
+        let diff = MetricsDiff {
+            changed: vec![MetricsChange {
+                name: "helper".into(),
+                path: "a.go".into(),
+                line: 3,
+                old_complexity: 2,
+                new_complexity: 1,
+                old_lines_of_code: 4,
+                new_lines_of_code: 4,
+            }],
+            ..Default::default()
+        };
+        let json = baseline.with_metrics_diff(&diff).render().unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(value["metrics_diff"]["added"], serde_json::json!([]));
+        assert_eq!(value["metrics_diff"]["changed"][0]["complexity_delta"], -1);
+        assert_eq!(
+            value["metrics_diff"]["changed"][0]["lines_of_code_delta"],
+            0
+        );
+
+        let err = JsonReport::parse("{\"version\": 99, \"root\": \".\", \"files\": []}")
+            .err()
+            .unwrap();
+        assert!(err.contains("version 99"), "{err}");
+    }
+
+    #[test]
+    fn json_report_lists_check_findings() {
+        let findings = vec![Finding {
+            rule_id: "no-sleep",
+            message: "time.Sleep in handler".into(),
+            path: PathBuf::from("/proj/main.go"),
+            start_line: 7,
+            end_line: 7,
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_check_findings(Path::new("/proj"), &findings)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(
+            value["checks"][0],
+            serde_json::json!({"rule": "no-sleep", "path": "main.go", "start_line": 7, "end_line": 7, "message": "time.Sleep in handler"})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_clone_locations() {
+        let location = |function: &str, start_line, end_line| CloneLocation {
+            path: PathBuf::from("/proj/main.go"),
+            function: function.into(),
+            start_line,
+            end_line,
+        };
+        let clones = vec![CloneGroup {
+            statements: 5,
+            locations: vec![location("parse", 4, 8), location("parseAll", 20, 24)],
+        }];
+        let json = JsonReport::from_results(Path::new("/proj"), &[])
+            .with_clones(Path::new("/proj"), &clones)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(value["clones"][0]["statements"], 5);
+        assert_eq!(
+            value["clones"][0]["locations"][1],
+            serde_json::json!({"path": "main.go", "name": "parseAll", "start_line": 20, "end_line": 24})
+        );
+    }
+
+    #[test]
+    fn json_report_lists_import_graph() {
+        let mut result = AnalysisResult::empty(5);
+        result.imports = vec!["import (\n\t\"fmt\"\n\tpq \"github.com/lib/pq\"\n)".into()];
+        let results = vec![(PathBuf::from("/proj/main.go"), result)];
+        let graph = ImportGraph::build_from_results(Path::new("/proj"), &results);
+        let json = JsonReport::from_results(Path::new("/proj"), &results)
+            .with_import_graph(&graph)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        let graph = &value["import_graph"];
+        assert_eq!(graph["packages"], serde_json::json!(["."]));
+        assert_eq!(graph["imports"][0]["path"], "fmt");
+        assert_eq!(graph["imports"][0]["kind"], "stdlib");
+        assert!(graph["imports"][0].get("alias").is_none());
+        assert_eq!(graph["imports"][1]["kind"], "third-party");
+        assert_eq!(graph["imports"][1]["style"], "alias");
+        assert_eq!(graph["imports"][1]["alias"], "pq");
+        assert_eq!(graph["cycles"], serde_json::json!([]));
+    }
+}
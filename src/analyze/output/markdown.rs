@@ -0,0 +1,511 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! GitHub-flavored Markdown summary of an analysis, for pull request
+//! comments and job summaries.
+
+use std::io::Write;
+use std::path::{Path, PathBuf};
+
+use crate::analyze::checks::unused::UnusedFunction;
+use crate::analyze::checks::{self, Finding};
+use crate::analyze::compare::{MetricsDiff, format_change};
+use crate::analyze::metrics::LengthBucket;
+use crate::analyze::types::{AnalysisResult, FunctionInfo};
+
+/// A function together with the file declaring it
+#[derive(Debug, Clone)]
+struct Row {
+    /// Path relative to the analyzed directory
+    path: String,
+    function: FunctionInfo,
+}
+
+/// Markdown document with a totals header, the function length distribution,
+/// a function table, the list of unused functions when that check ran, or
+/// the findings of every check grouped by file when attached, and the
+/// changes since a baseline run when there is one
+#[derive(Debug, Clone)]
+pub struct MarkdownReport {
+    /// Name of the analyzed file or directory
+    title: String,
+    files: usize,
+    lines_of_code: usize,
+    functions: Vec<Row>,
+    /// Empty unless attached, which leaves the section out
+    length_distribution: Vec<LengthBucket>,
+    /// `None` unless the unused function check ran
+    unused: Option<Vec<Row>>,
+    /// Relative path and findings of each file, in place of the unused
+    /// function list; `None` unless attached
+    findings_by_file: Option<Vec<(String, Vec<Finding>)>>,
+    /// `None` unless compared with a baseline run
+    metrics_diff: Option<MetricsDiff>,
+}
+
+impl MarkdownReport {
+    /// Build a report from per-file results; files are sorted by path and
+    /// functions keep their order within each file
+    pub fn from_results(root: &Path, results: &[(PathBuf, AnalysisResult)]) -> Self {
+        let base = base_dir(root);
+
+        let mut files: Vec<(String, &AnalysisResult)> = results
+            .iter()
+            .map(|(path, result)| (relative_path(base, path), result))
+            .collect();
+        files.sort_by(|a, b| a.0.cmp(&b.0));
+
+        let functions = files
+            .iter()
+            .flat_map(|(path, result)| {
+                result.functions.iter().map(|function| Row {
+                    path: path.clone(),
+                    function: function.clone(),
+                })
+            })
+            .collect();
+
+        Self {
+            title: root
+                .file_name()
+                .map(|name| name.to_string_lossy().to_string())
+                .unwrap_or_else(|| root.display().to_string()),
+            files: files.len(),
+            lines_of_code: files.iter().map(|(_, result)| result.code_lines).sum(),
+            functions,
+            length_distribution: vec![],
+            unused: None,
+            findings_by_file: None,
+            metrics_diff: None,
+        }
+    }
+
+    /// Attach the number of functions per length bucket
+    pub fn with_length_distribution(mut self, buckets: &[LengthBucket]) -> Self {
+        self.length_distribution = buckets.to_vec();
+        self
+    }
+
+    /// Attach the results of the unused function check
+    pub fn with_unused_functions(mut self, root: &Path, unused: &[UnusedFunction]) -> Self {
+        let base = base_dir(root);
+        self.unused = Some(
+            unused
+                .iter()
+                .map(|entry| Row {
+                    path: relative_path(base, &entry.path),
+                    function: entry.function.clone(),
+                })
+                .collect(),
+        );
+        self
+    }
+
+    /// Attach the findings of every enabled check, grouped by file
+    pub fn with_findings_by_file(mut self, root: &Path, findings: &[Finding]) -> Self {
+        let base = base_dir(root);
+        self.findings_by_file = Some(
+            checks::findings_by_file(findings)
+                .into_iter()
+                .map(|(path, file_findings)| {
+                    (
+                        relative_path(base, path),
+                        file_findings.into_iter().cloned().collect(),
+                    )
+                })
+                .collect(),
+        );
+        self
+    }
+
+    /// Attach the comparison with a baseline run
+    pub fn with_metrics_diff(mut self, diff: &MetricsDiff) -> Self {
+        self.metrics_diff = Some(diff.clone());
+        self
+    }
+
+    /// Write the Markdown document to `writer`
+    pub fn write_to<W: Write>(&self, mut writer: W) -> std::io::Result<()> {
+        writeln!(writer, "# Code analysis: {}", escape_cell(&self.title))?;
+        writeln!(writer)?;
+
+        match &self.unused {
+            Some(unused) => {
+                writeln!(writer, "| Files | Functions | Lines of code | Unused |")?;
+                writeln!(writer, "|------:|----------:|--------------:|-------:|")?;
+                writeln!(
+                    writer,
+                    "| {} | {} | {} | {} |",
+                    self.files,
+                    self.functions.len(),
+                    self.lines_of_code,
+                    unused.len()
+                )?;
+            }
+            None => {
+                writeln!(writer, "| Files | Functions | Lines of code |")?;
+                writeln!(writer, "|------:|----------:|--------------:|")?;
+                writeln!(
+                    writer,
+                    "| {} | {} | {} |",
+                    self.files,
+                    self.functions.len(),
+                    self.lines_of_code
+                )?;
+            }
+        }
+
+        if !self.length_distribution.is_empty() {
+            writeln!(writer)?;
+            writeln!(writer, "## Function length")?;
+            writeln!(writer)?;
+            writeln!(writer, "| Lines of code | Functions |")?;
+            writeln!(writer, "|--------------:|----------:|")?;
+            for bucket in &self.length_distribution {
+                writeln!(writer, "| {} | {} |", bucket.label(), bucket.functions)?;
+            }
+        }
+
+        writeln!(writer)?;
+        writeln!(writer, "## Functions")?;
+        writeln!(writer)?;
+        if self.functions.is_empty() {
+            writeln!(writer, "No functions found.")?;
+        } else {
+            writeln!(
+                writer,
+                "| File | Function | Receiver | Line | Complexity | Cognitive | LOC |"
+            )?;
+            writeln!(
+                writer,
+                "|------|----------|----------|-----:|-----------:|----------:|----:|"
+            )?;
+            for Row { path, function } in &self.functions {
+                writeln!(
+                    writer,
+                    "| {} | {} | {} | {} | {} | {} | {} |",
+                    escape_cell(path),
+                    code(&function.name),
+                    function.receiver.as_deref().map(code).unwrap_or_default(),
+                    function.line,
+                    function.complexity,
+                    function.cognitive_complexity,
+                    function.lines_of_code
+                )?;
+            }
+        }
+
+        if let Some(files) = &self.findings_by_file {
+            writeln!(writer)?;
+            writeln!(writer, "## Findings by file")?;
+            writeln!(writer)?;
+            if files.is_empty() {
+                writeln!(writer, "None found.")?;
+            }
+            for (index, (path, findings)) in files.iter().enumerate() {
+                if index > 0 {
+                    writeln!(writer)?;
+                }
+                writeln!(writer, "### {}", escape_cell(path))?;
+                writeln!(writer)?;
+                for finding in findings {
+                    writeln!(
+                        writer,
+                        "- Line {} {}: {}",
+                        finding.start_line,
+                        code(finding.rule_id),
+                        finding.message
+                    )?;
+                }
+            }
+        } else if let Some(unused) = &self.unused {
+            writeln!(writer)?;
+            writeln!(writer, "## Unused functions")?;
+            writeln!(writer)?;
+            if unused.is_empty() {
+                writeln!(writer, "None found.")?;
+            }
+            for Row { path, function } in unused {
+                writeln!(
+                    writer,
+                    "- {} ({}:{})",
+                    code(&function.name),
+                    escape_cell(path),
+                    function.line
+                )?;
+            }
+        }
+
+        if let Some(diff) = &self.metrics_diff {
+            writeln!(writer)?;
+            writeln!(writer, "## Changes since baseline")?;
+            writeln!(writer)?;
+            if diff.is_empty() {
+                writeln!(writer, "No function metrics changed.")?;
+            } else {
+                writeln!(
+                    writer,
+                    "| Change | Function | Location | Complexity | LOC |"
+                )?;
+                writeln!(
+                    writer,
+                    "|--------|----------|----------|-----------:|----:|"
+                )?;
+                for (label, functions) in [("added", &diff.added), ("removed", &diff.removed)] {
+                    for function in functions {
+                        writeln!(
+                            writer,
+                            "| {} | {} | {}:{} | {} | {} |",
+                            label,
+                            code(&function.name),
+                            escape_cell(&function.path),
+                            function.line,
+                            function.complexity,
+                            function.lines_of_code
+                        )?;
+                    }
+                }
+                for change in &diff.changed {
+                    writeln!(
+                        writer,
+                        "| changed | {} | {}:{} | {} | {} |",
+                        code(&change.name),
+                        escape_cell(&change.path),
+                        change.line,
+                        format_change(change.old_complexity, change.new_complexity),
+                        format_change(change.old_lines_of_code, change.new_lines_of_code)
+                    )?;
+                }
+            }
+        }
+
+        Ok(())
+    }
+
+    /// Render the Markdown document as a string
+    pub fn render(&self) -> Result<String, String> {
+        let mut buffer = Vec::new();
+        self.write_to(&mut buffer)
+            .map_err(|e| format!("Failed to render Markdown: {}", e))?;
+        String::from_utf8(buffer).map_err(|e| format!("Failed to render Markdown: {}", e))
+    }
+}
+
+fn base_dir(root: &Path) -> &Path {
+    if root.is_file() {
+        root.parent().unwrap_or(root)
+    } else {
+        root
+    }
+}
+
+fn relative_path(base: &Path, path: &Path) -> String {
+    path.strip_prefix(base)
+        .unwrap_or(path)
+        .display()
+        .to_string()
+}
+
+/// Make text safe inside a table cell: GitHub splits cells on `|` even in
+/// code spans, so pipes are escaped, and line breaks would end the row
+fn escape_cell(text: &str) -> String {
+    text.replace('|', "\\|").replace(['\r', '\n'], " ")
+}
+
+/// Cell text as an inline code span, e.g. `` `*Greeter` ``
+fn code(text: &str) -> String {
+    format!("`{}`", escape_cell(text))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn function(name: &str, line: usize, receiver: Option<&str>) -> FunctionInfo {
+        FunctionInfo {
+            name: name.into(),
+            line,
+            receiver: receiver.map(|r| r.to_string()),
+            complexity: 1,
+            lines_of_code: 2,
+            ..Default::default()
+        }
+    }
+
+    fn sample_results() -> Vec<(PathBuf, AnalysisResult)> {
+        let mut result = AnalysisResult::empty(24);
+        result.code_lines = 19;
+        result.functions = vec![
+            function("Greet", 9, Some("*Greeter")),
+            function("helper", 13, None),
+            function("main", 17, None),
+        ];
+        vec![(PathBuf::from("/proj/sample.go"), result)]
+    }
+
+    #[test]
+    fn markdown_has_totals_and_function_table() {
+        let out = MarkdownReport::from_results(Path::new("/proj"), &sample_results())
+            .render()
+            .unwrap();
+        assert!(out.starts_with("# Code analysis: proj\n"), "{out}");
+        assert!(out.contains("| 1 | 3 | 19 |\n"), "{out}");
+        assert!(
+            out.contains("| sample.go | `Greet` | `*Greeter` | 9 | 1 | 0 | 2 |\n"),
+            "{out}"
+        );
+        assert!(
+            out.contains("| sample.go | `helper` |  | 13 | 1 | 0 | 2 |\n"),
+            "{out}"
+        );
+        assert!(!out.contains("Unused"), "{out}");
+    }
+
+    #[test]
+    fn markdown_has_length_distribution_when_attached() {
+        let results = sample_results();
+        let buckets = crate::analyze::metrics::length_distribution(&results, &[2, 5]);
+        let out = MarkdownReport::from_results(Path::new("/proj"), &results)
+            .with_length_distribution(&buckets)
+            .render()
+            .unwrap();
+        assert!(
+            out.contains(
+                "## Function length\n\n\
+                 | Lines of code | Functions |\n\
+                 |--------------:|----------:|\n\
+                 | 1–2 | 3 |\n\
+                 | 3–5 | 0 |\n\
+                 | 6+ | 0 |\n\n## Functions\n"
+            ),
+            "{out}"
+        );
+    }
+
+    #[test]
+    fn markdown_lists_unused_functions_when_checked() {
+        let results = sample_results();
+        let unused = vec![UnusedFunction {
+            path: PathBuf::from("/proj/sample.go"),
+            function: function("helper", 13, None),
+        }];
+        let out = MarkdownReport::from_results(Path::new("/proj"), &results)
+            .with_unused_functions(Path::new("/proj"), &unused)
+            .render()
+            .unwrap();
+        assert!(out.contains("| 1 | 3 | 19 | 1 |\n"), "{out}");
+        assert!(
+            out.contains("## Unused functions\n\n- `helper` (sample.go:13)\n"),
+            "{out}"
+        );
+
+        let out = MarkdownReport::from_results(Path::new("/proj"), &results)
+            .with_unused_functions(Path::new("/proj"), &[])
+            .render()
+            .unwrap();
+        assert!(
+            out.contains("## Unused functions\n\nNone found.\n"),
+            "{out}"
+        );
+    }
+
+    #[test]
+    fn markdown_groups_findings_by_file_when_attached() {
+        let finding = |rule_id, path: &str, line, message: &str| Finding {
+            rule_id,
+            message: message.into(),
+            path: PathBuf::from(path),
+            start_line: line,
+            end_line: line,
+        };
+        let findings = vec![
+            finding(
+                "unused-function",
+                "/proj/sample.go",
+                13,
+                "helper is never called",
+            ),
+            finding("todo-comment", "/proj/cmd/main.go", 4, "TODO: flags"),
+            finding("todo-comment", "/proj/sample.go", 2, "FIXME: names"),
+        ];
+        let out = MarkdownReport::from_results(Path::new("/proj"), &sample_results())
+            .with_unused_functions(Path::new("/proj"), &[])
+            .with_findings_by_file(Path::new("/proj"), &findings)
+            .render()
+            .unwrap();
+        assert!(
+            out.ends_with(
+                "## Findings by file\n\n\
+                 ### cmd/main.go\n\n\
+                 - Line 4 `todo-comment`: TODO: flags\n\n\
+                 ### sample.go\n\n\
+                 - Line 2 `todo-comment`: FIXME: names\n\
+                 - Line 13 `unused-function`: helper is never called\n"
+            ),
+            "{out}"
+        );
+        assert!(!out.contains("## Unused functions"), "{out}");
+    }
+
+    #[test]
+    fn markdown_lists_changes_since_baseline() {
+        use crate::analyze::compare::{FunctionMetrics, MetricsChange};
+
+        let diff = MetricsDiff {
+            added: vec![FunctionMetrics {
+                name: "fresh".into(),
+                path: "sample.go".into(),
+                line: 30,
+                complexity: 1,
+                lines_of_code: 3,
+            }],
+            removed: vec![],
+            changed: vec![MetricsChange {
+                name: "(*Greeter).Greet".into(),
+                path: "sample.go".into(),
+                line: 9,
+                old_complexity: 1,
+                new_complexity: 3,
+                old_lines_of_code: 2,
+                new_lines_of_code: 2,
+            }],
+        };
+        let out = MarkdownReport::from_results(Path::new("/proj"), &sample_results())
+            .with_metrics_diff(&diff)
+            .render()
+            .unwrap();
+        assert!(
+            out.contains(
+                "## Changes since baseline\n\n| Change | Function | Location | Complexity | LOC |\n"
+            ),
+            "{out}"
+        );
+        assert!(
+            out.contains("| added | `fresh` | sample.go:30 | 1 | 3 |\n"),
+            "{out}"
+        );
+        assert!(
+            out.contains("| changed | `(*Greeter).Greet` | sample.go:9 | 1 -> 3 (+2) | 2 |\n"),
+            "{out}"
+        );
+
+        let out = MarkdownReport::from_results(Path::new("/proj"), &sample_results())
+            .with_metrics_diff(&MetricsDiff::default())
+            .render()
+            .unwrap();
+        assert!(out.contains("No function metrics changed.\n"), "{out}");
+    }
+
+    #[test]
+    fn pipes_in_type_strings_are_escaped() {
+        let mut result = AnalysisResult::empty(3);
+        result.functions = vec![function("apply", 1, Some("Box<dyn Fn(u8) | Send>"))];
+        let out = MarkdownReport::from_results(
+            Path::new("/proj"),
+            &[(PathBuf::from("/proj/a.rs"), result)],
+        )
+        .render()
+        .unwrap();
+        assert!(out.contains("`Box<dyn Fn(u8) \\| Send>`"), "{out}");
+    }
+}
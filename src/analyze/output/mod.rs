@@ -0,0 +1,256 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+pub mod csv;
+pub mod html;
+pub mod json;
+pub mod markdown;
+pub mod sarif;
+
+use std::fmt;
+use std::str::FromStr;
+
+use crate::analyze::types::FunctionInfo;
+
+/// Output formats supported by the CLI
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum OutputFormat {
+    #[default]
+    Text,
+    Json,
+    /// Graphviz call graph
+    Dot,
+    /// SARIF 2.1.0 log of findings
+    Sarif,
+    /// GitHub-flavored Markdown summary
+    Markdown,
+    /// Self-contained HTML page with a sortable function table
+    Html,
+    /// One CSV row of metrics per function
+    Csv,
+    /// One JSON object per file and line, written as each file finishes
+    JsonLines,
+}
+
+impl OutputFormat {
+    pub fn as_str(&self) -> &str {
+        match self {
+            OutputFormat::Text => "text",
+            OutputFormat::Json => "json",
+            OutputFormat::Dot => "dot",
+            OutputFormat::Sarif => "sarif",
+            OutputFormat::Markdown => "markdown",
+            OutputFormat::Html => "html",
+            OutputFormat::Csv => "csv",
+            OutputFormat::JsonLines => "jsonl",
+        }
+    }
+}
+
+impl fmt::Display for OutputFormat {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.as_str())
+    }
+}
+
+impl FromStr for OutputFormat {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s {
+            "text" => Ok(OutputFormat::Text),
+            "json" => Ok(OutputFormat::Json),
+            "dot" => Ok(OutputFormat::Dot),
+            "sarif" => Ok(OutputFormat::Sarif),
+            "markdown" | "md" => Ok(OutputFormat::Markdown),
+            "html" => Ok(OutputFormat::Html),
+            "csv" => Ok(OutputFormat::Csv),
+            "jsonl" | "ndjson" => Ok(OutputFormat::JsonLines),
+            _ => Err(format!(
+                "unknown output format '{}' (expected text, json, jsonl, dot, sarif, markdown, html or csv)",
+                s
+            )),
+        }
+    }
+}
+
+/// Order of functions within each file of a report
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum SortOrder {
+    /// Source order
+    #[default]
+    Line,
+    /// Highest cyclomatic complexity first
+    Complexity,
+    /// Highest cognitive complexity first
+    Cognitive,
+    /// Highest hotspot score first
+    Hotspot,
+}
+
+impl SortOrder {
+    pub fn as_str(&self) -> &str {
+        match self {
+            SortOrder::Line => "line",
+            SortOrder::Complexity => "complexity",
+            SortOrder::Cognitive => "cognitive",
+            SortOrder::Hotspot => "hotspot",
+        }
+    }
+
+    /// Reorder `functions` in place; ties keep source order
+    pub fn sort_functions(&self, functions: &mut [FunctionInfo]) {
+        match self {
+            SortOrder::Line => functions.sort_by_key(|f| f.line),
+            SortOrder::Complexity => {
+                functions.sort_by(|a, b| b.complexity.cmp(&a.complexity).then(a.line.cmp(&b.line)))
+            }
+            SortOrder::Cognitive => functions.sort_by(|a, b| {
+                b.cognitive_complexity
+                    .cmp(&a.cognitive_complexity)
+                    .then(a.line.cmp(&b.line))
+            }),
+            SortOrder::Hotspot => functions.sort_by(|a, b| {
+                b.hotspot_score
+                    .total_cmp(&a.hotspot_score)
+                    .then(a.line.cmp(&b.line))
+            }),
+        }
+    }
+}
+
+impl fmt::Display for SortOrder {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.as_str())
+    }
+}
+
+impl FromStr for SortOrder {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s {
+            "line" => Ok(SortOrder::Line),
+            "complexity" => Ok(SortOrder::Complexity),
+            "cognitive" => Ok(SortOrder::Cognitive),
+            "hotspot" => Ok(SortOrder::Hotspot),
+            _ => Err(format!(
+                "unknown sort order '{}' (expected line, complexity, cognitive or hotspot)",
+                s
+            )),
+        }
+    }
+}
+
+/// How text and Markdown reports arrange the findings of the enabled checks
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum GroupBy {
+    /// One section per check, each ordered by path and line
+    #[default]
+    Check,
+    /// One block per file in path order, each ordered by line
+    File,
+}
+
+impl GroupBy {
+    pub fn as_str(&self) -> &str {
+        match self {
+            GroupBy::Check => "check",
+            GroupBy::File => "file",
+        }
+    }
+}
+
+impl fmt::Display for GroupBy {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.as_str())
+    }
+}
+
+impl FromStr for GroupBy {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s {
+            "check" => Ok(GroupBy::Check),
+            "file" => Ok(GroupBy::File),
+            _ => Err(format!("unknown grouping '{}' (expected file or check)", s)),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn output_format_round_trips() {
+        for format in [
+            OutputFormat::Text,
+            OutputFormat::Json,
+            OutputFormat::Dot,
+            OutputFormat::Sarif,
+            OutputFormat::Markdown,
+            OutputFormat::Html,
+            OutputFormat::Csv,
+            OutputFormat::JsonLines,
+        ] {
+            assert_eq!(format.as_str().parse::<OutputFormat>(), Ok(format));
+        }
+    }
+
+    #[test]
+    fn output_format_rejects_unknown() {
+        assert!("yaml".parse::<OutputFormat>().is_err());
+    }
+
+    #[test]
+    fn output_format_defaults_to_text() {
+        assert_eq!(OutputFormat::default(), OutputFormat::Text);
+    }
+
+    #[test]
+    fn sort_order_round_trips() {
+        for order in [
+            SortOrder::Line,
+            SortOrder::Complexity,
+            SortOrder::Cognitive,
+            SortOrder::Hotspot,
+        ] {
+            assert_eq!(order.as_str().parse::<SortOrder>(), Ok(order));
+        }
+        assert!("size".parse::<SortOrder>().is_err());
+    }
+
+    #[test]
+    fn group_by_round_trips() {
+        for group_by in [GroupBy::Check, GroupBy::File] {
+            assert_eq!(group_by.as_str().parse::<GroupBy>(), Ok(group_by));
+        }
+        assert!("rule".parse::<GroupBy>().is_err());
+        assert_eq!(GroupBy::default(), GroupBy::Check);
+    }
+
+    #[test]
+    fn cognitive_sort_puts_highest_first_and_keeps_ties_in_line_order() {
+        let function = |name: &str, line: usize, cognitive: usize| FunctionInfo {
+            name: name.into(),
+            line,
+            complexity: 1,
+            cognitive_complexity: cognitive,
+            ..Default::default()
+        };
+        let mut functions = vec![
+            function("flat", 1, 1),
+            function("nested", 5, 6),
+            function("other", 9, 1),
+        ];
+
+        SortOrder::Cognitive.sort_functions(&mut functions);
+        let names: Vec<&str> = functions.iter().map(|f| f.name.as_str()).collect();
+        assert_eq!(names, vec!["nested", "flat", "other"]);
+
+        SortOrder::Line.sort_functions(&mut functions);
+        assert_eq!(functions[0].name, "flat");
+    }
+}
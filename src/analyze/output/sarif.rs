@@ -0,0 +1,279 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! SARIF 2.1.0 log of analyzer findings, for code scanning integrations
+//! such as GitHub Actions.
+
+use serde::Serialize;
+use std::io::Write;
+use std::path::Path;
+
+use crate::analyze::checks::{Finding, RULES};
+
+const SARIF_SCHEMA: &str = "https://json.schemastore.org/sarif-2.1.0.json";
+const SARIF_VERSION: &str = "2.1.0";
+const TOOL_NAME: &str = env!("CARGO_PKG_NAME");
+const TOOL_VERSION: &str = env!("CARGO_PKG_VERSION");
+
+/// Top-level SARIF log with a single run
+#[derive(Debug, Clone, Serialize)]
+pub struct SarifLog {
+    #[serde(rename = "$schema")]
+    pub schema: &'static str,
+    pub version: &'static str,
+    pub runs: Vec<SarifRun>,
+}
+
+#[derive(Debug, Clone, Serialize)]
+pub struct SarifRun {
+    pub tool: SarifTool,
+    pub results: Vec<SarifResult>,
+}
+
+#[derive(Debug, Clone, Serialize)]
+pub struct SarifTool {
+    pub driver: SarifDriver,
+}
+
+#[derive(Debug, Clone, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SarifDriver {
+    pub name: &'static str,
+    pub version: &'static str,
+    pub rules: Vec<SarifRule>,
+}
+
+#[derive(Debug, Clone, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SarifRule {
+    pub id: &'static str,
+    pub short_description: SarifMessage,
+}
+
+#[derive(Debug, Clone, Serialize)]
+pub struct SarifMessage {
+    pub text: String,
+}
+
+#[derive(Debug, Clone, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SarifResult {
+    pub rule_id: &'static str,
+    /// Index of the rule in the driver's `rules` array
+    pub rule_index: usize,
+    pub level: &'static str,
+    pub message: SarifMessage,
+    pub locations: Vec<SarifLocation>,
+}
+
+#[derive(Debug, Clone, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SarifLocation {
+    pub physical_location: SarifPhysicalLocation,
+}
+
+#[derive(Debug, Clone, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SarifPhysicalLocation {
+    pub artifact_location: SarifArtifactLocation,
+    pub region: SarifRegion,
+}
+
+#[derive(Debug, Clone, Serialize)]
+pub struct SarifArtifactLocation {
+    /// File URI relative to the analyzed directory, with `/` separators
+    pub uri: String,
+}
+
+#[derive(Debug, Clone, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SarifRegion {
+    pub start_line: usize,
+    pub end_line: usize,
+}
+
+impl SarifLog {
+    /// Build a SARIF log reporting `findings` relative to the analyzed `root`
+    pub fn from_findings(root: &Path, findings: &[Finding]) -> Self {
+        Self::from_findings_with_rules(root, findings, &[])
+    }
+
+    /// Like [`SarifLog::from_findings`], also listing `(id, description)`
+    /// rules of custom checks after the built-in ones
+    pub fn from_findings_with_rules(
+        root: &Path,
+        findings: &[Finding],
+        extra_rules: &[(&'static str, &'static str)],
+    ) -> Self {
+        let base = if root.is_file() {
+            root.parent().unwrap_or(root)
+        } else {
+            root
+        };
+
+        let all_rules: Vec<(&'static str, &'static str)> =
+            RULES.iter().chain(extra_rules).copied().collect();
+        let rules = all_rules
+            .iter()
+            .map(|&(id, description)| SarifRule {
+                id,
+                short_description: SarifMessage {
+                    text: description.to_string(),
+                },
+            })
+            .collect();
+
+        let results = findings
+            .iter()
+            .map(|finding| SarifResult {
+                rule_id: finding.rule_id,
+                rule_index: all_rules
+                    .iter()
+                    .position(|(id, _)| *id == finding.rule_id)
+                    .unwrap_or_default(),
+                level: "warning",
+                message: SarifMessage {
+                    text: finding.message.clone(),
+                },
+                locations: vec![SarifLocation {
+                    physical_location: SarifPhysicalLocation {
+                        artifact_location: SarifArtifactLocation {
+                            uri: artifact_uri(base, &finding.path),
+                        },
+                        region: SarifRegion {
+                            start_line: finding.start_line,
+                            end_line: finding.end_line,
+                        },
+                    },
+                }],
+            })
+            .collect();
+
+        Self {
+            schema: SARIF_SCHEMA,
+            version: SARIF_VERSION,
+            runs: vec![SarifRun {
+                tool: SarifTool {
+                    driver: SarifDriver {
+                        name: TOOL_NAME,
+                        version: TOOL_VERSION,
+                        rules,
+                    },
+                },
+                results,
+            }],
+        }
+    }
+
+    /// Write the pretty-printed SARIF document to `writer`
+    pub fn write_to<W: Write>(&self, mut writer: W) -> std::io::Result<()> {
+        serde_json::to_writer_pretty(&mut writer, self)?;
+        writeln!(writer)
+    }
+
+    /// Serialize as a pretty-printed SARIF document
+    pub fn render(&self) -> Result<String, String> {
+        let mut buffer = Vec::new();
+        self.write_to(&mut buffer)
+            .map_err(|e| format!("Failed to serialize SARIF: {}", e))?;
+        String::from_utf8(buffer).map_err(|e| format!("Failed to serialize SARIF: {}", e))
+    }
+}
+
+/// Relative URI reference for a file: `/` separators, spaces and `%` escaped
+fn artifact_uri(base: &Path, path: &Path) -> String {
+    let relative = path.strip_prefix(base).unwrap_or(path);
+    relative
+        .components()
+        .map(|component| component.as_os_str().to_string_lossy())
+        .filter(|part| part != "/")
+        .collect::<Vec<_>>()
+        .join("/")
+        .replace('%', "%25")
+        .replace(' ', "%20")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::checks::{RULE_COMPLEXITY, RULE_UNUSED_FUNCTION};
+    use std::path::PathBuf;
+
+    fn finding(rule_id: &'static str, path: &str, line: usize) -> Finding {
+        Finding {
+            rule_id,
+            message: "problem".into(),
+            path: PathBuf::from(path),
+            start_line: line,
+            end_line: line + 2,
+        }
+    }
+
+    #[test]
+    fn sarif_log_has_tool_metadata() {
+        let json = SarifLog::from_findings(Path::new("/proj"), &[])
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(value["version"], "2.1.0");
+        let driver = &value["runs"][0]["tool"]["driver"];
+        assert_eq!(driver["name"], "code-analyze");
+        assert_eq!(driver["version"], env!("CARGO_PKG_VERSION"));
+        assert_eq!(driver["rules"].as_array().unwrap().len(), RULES.len());
+        assert!(value["runs"][0]["results"].as_array().unwrap().is_empty());
+    }
+
+    #[test]
+    fn sarif_results_carry_rule_and_location() {
+        let findings = vec![finding(RULE_UNUSED_FUNCTION, "/proj/pkg/a.go", 3)];
+        let json = SarifLog::from_findings(Path::new("/proj"), &findings)
+            .render()
+            .unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+
+        let result = &value["runs"][0]["results"][0];
+        assert_eq!(result["ruleId"], RULE_UNUSED_FUNCTION);
+        assert_eq!(
+            RULES[result["ruleIndex"].as_u64().unwrap() as usize].0,
+            RULE_UNUSED_FUNCTION
+        );
+        assert_eq!(result["level"], "warning");
+        assert_eq!(result["message"]["text"], "problem");
+        let location = &result["locations"][0]["physicalLocation"];
+        assert_eq!(location["artifactLocation"]["uri"], "pkg/a.go");
+        assert_eq!(location["region"]["startLine"], 3);
+        assert_eq!(location["region"]["endLine"], 5);
+    }
+
+    #[test]
+    fn artifact_uri_escapes_spaces() {
+        let uri = artifact_uri(Path::new("/proj"), Path::new("/proj/my dir/a.go"));
+        assert_eq!(uri, "my%20dir/a.go");
+    }
+
+    #[test]
+    fn custom_rules_follow_the_built_in_ones() {
+        let log = SarifLog::from_findings_with_rules(
+            Path::new("/proj"),
+            &[finding("no-sleep", "/proj/a.go", 1)],
+            &[("no-sleep", "Calls time.Sleep")],
+        );
+        let driver = &log.runs[0].tool.driver;
+        assert_eq!(driver.rules.len(), RULES.len() + 1);
+        assert_eq!(log.runs[0].results[0].rule_index, RULES.len());
+        assert_eq!(
+            driver.rules[RULES.len()].short_description.text,
+            "Calls time.Sleep"
+        );
+    }
+
+    #[test]
+    fn rule_index_matches_rules_table() {
+        let log = SarifLog::from_findings(
+            Path::new("/proj"),
+            &[finding(RULE_COMPLEXITY, "/proj/a.go", 1)],
+        );
+        let result = &log.runs[0].results[0];
+        assert_eq!(RULES[result.rule_index].0, RULE_COMPLEXITY);
+    }
+}
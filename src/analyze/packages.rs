@@ -0,0 +1,380 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Go packages selected by import path patterns, as the `go` command takes
+//! them: `./...`, `./store`, `example.com/app/...` or `example.com/app/store`.
+//!
+//! Patterns resolve against the module whose `go.mod` is in the working
+//! directory or above it, so only packages of that module can be named.
+//! Each directory holding `.go` files is one package. Like the `go` command,
+//! a package leaves out `_test.go` files and files whose name starts with
+//! `.` or `_`, and a `...` wildcard leaves out nested modules, `vendor` and
+//! `testdata` directories and directories whose name starts with `.` or `_`.
+
+use std::collections::BTreeSet;
+use std::path::{Component, Path, PathBuf};
+
+use super::build::SkippedFile;
+use super::imports::{GoModule, package_of_dir};
+use super::types::AnalysisResult;
+
+/// Analysis of one Go package, or the error loading it
+#[derive(Debug, Clone, Default)]
+pub struct PackageReport {
+    /// Import path, e.g. `example.com/app/store`, or the pattern as given
+    /// when it matched no package
+    pub import_path: String,
+    /// Directory holding the package's files; empty when the pattern names
+    /// no directory of the module
+    pub dir: PathBuf,
+    /// Semantic results of the package's Go files, in path order. A file that
+    /// failed to parse has its `error` set.
+    pub files: Vec<(PathBuf, AnalysisResult)>,
+    /// Go files left out by the build context
+    pub skipped_files: Vec<SkippedFile>,
+    /// Why the package could not be loaded
+    pub error: Option<String>,
+}
+
+impl PackageReport {
+    /// The load error, if any, followed by the error of every file that
+    /// failed to parse, as `path: error`
+    pub fn errors(&self) -> Vec<String> {
+        let file_errors = self.files.iter().filter_map(|(path, result)| {
+            let error = result.error.as_ref()?;
+            Some(format!("{}: {}", path.display(), error))
+        });
+        self.error.iter().cloned().chain(file_errors).collect()
+    }
+
+    fn failed(pattern: &str, dir: PathBuf, error: String) -> Self {
+        Self {
+            import_path: pattern.to_string(),
+            dir,
+            error: Some(error),
+            ..Self::default()
+        }
+    }
+}
+
+/// One report per package matched by `patterns`, in pattern order, each
+/// package listed once however many patterns match it. A pattern naming no
+/// package of `module` gives a report carrying the error instead; the files
+/// are left for the caller to analyze.
+pub(super) fn match_packages(
+    module: &GoModule,
+    cwd: &Path,
+    patterns: &[&str],
+) -> Vec<PackageReport> {
+    let mut reports = Vec::new();
+    let mut seen = BTreeSet::new();
+
+    for pattern in patterns {
+        let (dir, recursive) = match resolve_pattern(module, cwd, pattern) {
+            Ok(resolved) => resolved,
+            Err(e) => {
+                reports.push(PackageReport::failed(pattern, PathBuf::new(), e));
+                continue;
+            }
+        };
+
+        let mut dirs = Vec::new();
+        if let Err(e) = package_dirs(&dir, recursive, &mut dirs) {
+            reports.push(PackageReport::failed(pattern, dir, e));
+            continue;
+        }
+        if dirs.is_empty() {
+            let error = if recursive {
+                format!("pattern {}: matched no packages", pattern)
+            } else if dir.is_dir() {
+                format!("no Go files in {}", dir.display())
+            } else {
+                format!("directory {} does not exist", dir.display())
+            };
+            reports.push(PackageReport::failed(pattern, dir, error));
+            continue;
+        }
+
+        for dir in dirs {
+            if seen.insert(dir.clone()) {
+                reports.push(PackageReport {
+                    import_path: package_of_dir(&module.dir, Some(module), &dir),
+                    dir,
+                    ..PackageReport::default()
+                });
+            }
+        }
+    }
+    reports
+}
+
+/// Directory a pattern names and whether it ends in the `...` wildcard.
+/// Patterns starting with `.` or `/` are directories relative to `cwd`, any
+/// other is an import path under the module path.
+fn resolve_pattern(
+    module: &GoModule,
+    cwd: &Path,
+    pattern: &str,
+) -> Result<(PathBuf, bool), String> {
+    let (base, recursive) = match pattern.strip_suffix("...") {
+        Some(rest) if rest.is_empty() || rest.ends_with('/') => (rest.trim_end_matches('/'), true),
+        _ => (pattern, false),
+    };
+    if base.contains("...") {
+        return Err(format!(
+            "pattern {}: only a trailing /... wildcard is supported",
+            pattern
+        ));
+    }
+
+    if base == "."
+        || base == ".."
+        || base.starts_with("./")
+        || base.starts_with("../")
+        || Path::new(base).is_absolute()
+    {
+        let dir = normalize(&cwd.join(base));
+        if !dir.starts_with(&module.dir) {
+            return Err(format!(
+                "directory {} is outside main module {}",
+                dir.display(),
+                module.path
+            ));
+        }
+        return Ok((dir, recursive));
+    }
+
+    let relative = if base == module.path {
+        Some("")
+    } else {
+        base.strip_prefix(&module.path)
+            .and_then(|rest| rest.strip_prefix('/'))
+    };
+    match relative {
+        Some(relative) => Ok((module.dir.join(relative), recursive)),
+        None => Err(format!(
+            "package {} is not in main module {}",
+            base, module.path
+        )),
+    }
+}
+
+/// Whether the `go` command builds `path` into its package: a `.go` file
+/// that is not a test and whose name does not start with `.` or `_`
+pub(super) fn is_package_file(path: &Path) -> bool {
+    let name = path
+        .file_name()
+        .map(|name| name.to_string_lossy())
+        .unwrap_or_default();
+    name.ends_with(".go")
+        && !name.ends_with("_test.go")
+        && !name.starts_with('.')
+        && !name.starts_with('_')
+}
+
+/// Collect `dir` if it holds package files and, when `recursive`, the package
+/// directories below it that belong to the same module, in path order
+fn package_dirs(dir: &Path, recursive: bool, found: &mut Vec<PathBuf>) -> Result<(), String> {
+    if !dir.is_dir() {
+        return Ok(());
+    }
+    let entries = std::fs::read_dir(dir)
+        .map_err(|e| format!("Failed to read directory '{}': {}", dir.display(), e))?;
+    let mut entry_paths = entries
+        .map(|entry| entry.map(|entry| entry.path()))
+        .collect::<Result<Vec<_>, _>>()
+        .map_err(|e| format!("Failed to read directory entry: {}", e))?;
+    entry_paths.sort();
+
+    if entry_paths
+        .iter()
+        .any(|path| path.is_file() && is_package_file(path))
+    {
+        found.push(dir.to_path_buf());
+    }
+    if !recursive {
+        return Ok(());
+    }
+
+    for path in entry_paths.iter().filter(|path| path.is_dir()) {
+        let name = path
+            .file_name()
+            .map(|name| name.to_string_lossy())
+            .unwrap_or_default();
+        let skipped = name.starts_with('.')
+            || name.starts_with('_')
+            || name == "vendor"
+            || name == "testdata";
+        if !skipped && !path.join("go.mod").is_file() {
+            package_dirs(path, true, found)?;
+        }
+    }
+    Ok(())
+}
+
+/// `path` with `.` and `..` components resolved lexically
+fn normalize(path: &Path) -> PathBuf {
+    let mut normalized = PathBuf::new();
+    for component in path.components() {
+        match component {
+            Component::CurDir => {}
+            Component::ParentDir => {
+                normalized.pop();
+            }
+            other => normalized.push(other),
+        }
+    }
+    normalized
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn module(dir: &Path) -> GoModule {
+        std::fs::write(dir.join("go.mod"), "module example.com/app\n").unwrap();
+        for (file, content) in [
+            ("main.go", "package main\n"),
+            ("store/store.go", "package store\n"),
+            ("store/cache/cache.go", "package cache\n"),
+            ("store/testdata/fixture.go", "package fixture\n"),
+            ("_tools/tools.go", "package tools\n"),
+            ("plugin/go.mod", "module example.com/plugin\n"),
+            ("plugin/plugin.go", "package plugin\n"),
+            ("docs/README.md", "docs\n"),
+            ("store/store_test.go", "package store\n"),
+            ("e2e/app_test.go", "package e2e\n"),
+            ("e2e/_scratch.go", "package e2e\n"),
+            ("e2e/.#app.go", "package e2e\n"),
+        ] {
+            let path = dir.join(file);
+            std::fs::create_dir_all(path.parent().unwrap()).unwrap();
+            std::fs::write(path, content).unwrap();
+        }
+        GoModule {
+            dir: dir.to_path_buf(),
+            path: "example.com/app".to_string(),
+        }
+    }
+
+    fn matched(module: &GoModule, cwd: &Path, patterns: &[&str]) -> Vec<(String, Option<String>)> {
+        match_packages(module, cwd, patterns)
+            .into_iter()
+            .map(|report| (report.import_path, report.error))
+            .collect()
+    }
+
+    #[test]
+    fn wildcards_match_the_packages_of_the_module() {
+        let dir = tempfile::tempdir().unwrap();
+        let module = module(dir.path());
+        let packages = |pattern| -> Vec<String> {
+            matched(&module, dir.path(), &[pattern])
+                .into_iter()
+                .map(|(import_path, _)| import_path)
+                .collect()
+        };
+
+        let all = vec![
+            "example.com/app".to_string(),
+            "example.com/app/store".to_string(),
+            "example.com/app/store/cache".to_string(),
+        ];
+        assert_eq!(packages("./..."), all);
+        assert_eq!(packages("example.com/app/..."), all);
+        assert_eq!(packages("example.com/app/store/..."), all[1..].to_vec());
+        assert_eq!(
+            packages("./store"),
+            vec!["example.com/app/store".to_string()]
+        );
+        assert_eq!(
+            packages("example.com/app"),
+            vec!["example.com/app".to_string()]
+        );
+    }
+
+    #[test]
+    fn tests_and_ignored_names_are_not_package_files() {
+        assert!(is_package_file(Path::new("/app/store.go")));
+        assert!(!is_package_file(Path::new("/app/store_test.go")));
+        assert!(!is_package_file(Path::new("/app/_store.go")));
+        assert!(!is_package_file(Path::new("/app/.store.go")));
+        assert!(!is_package_file(Path::new("/app/store.md")));
+
+        let dir = tempfile::tempdir().unwrap();
+        let module = module(dir.path());
+        assert!(
+            matched(&module, dir.path(), &["./e2e"])[0]
+                .1
+                .as_ref()
+                .unwrap()
+                .starts_with("no Go files in ")
+        );
+    }
+
+    #[test]
+    fn relative_patterns_resolve_from_the_working_directory() {
+        let dir = tempfile::tempdir().unwrap();
+        let module = module(dir.path());
+        let cwd = dir.path().join("store");
+
+        let reports = match_packages(&module, &cwd, &["./cache", "..", "./..."]);
+        let import_paths: Vec<&str> = reports.iter().map(|r| r.import_path.as_str()).collect();
+        assert_eq!(
+            import_paths,
+            vec![
+                "example.com/app/store/cache",
+                "example.com/app",
+                "example.com/app/store"
+            ]
+        );
+        assert_eq!(reports[0].dir, dir.path().join("store/cache"));
+    }
+
+    #[test]
+    fn unmatched_patterns_carry_an_error() {
+        let dir = tempfile::tempdir().unwrap();
+        let module = module(dir.path());
+        let error = |pattern| {
+            matched(&module, dir.path(), &[pattern])[0]
+                .1
+                .clone()
+                .unwrap()
+        };
+
+        assert_eq!(
+            error("github.com/lib/pq"),
+            "package github.com/lib/pq is not in main module example.com/app"
+        );
+        assert!(error("./docs").starts_with("no Go files in "));
+        assert!(error("./missing").contains("does not exist"));
+        assert_eq!(
+            error("./docs/..."),
+            "pattern ./docs/...: matched no packages"
+        );
+        assert_eq!(
+            error("example.com/.../cache"),
+            "pattern example.com/.../cache: only a trailing /... wildcard is supported"
+        );
+        assert!(error("../elsewhere").contains("is outside main module"));
+    }
+
+    #[test]
+    fn packages_matched_twice_are_listed_once() {
+        let dir = tempfile::tempdir().unwrap();
+        let module = module(dir.path());
+        let reports = matched(&module, dir.path(), &["./store", "./...", "nowhere/pkg"]);
+        assert_eq!(
+            reports,
+            vec![
+                ("example.com/app/store".to_string(), None),
+                ("example.com/app".to_string(), None),
+                ("example.com/app/store/cache".to_string(), None),
+                (
+                    "nowhere/pkg".to_string(),
+                    Some("package nowhere/pkg is not in main module example.com/app".to_string())
+                ),
+            ]
+        );
+    }
+}
@@ -2,19 +2,28 @@
 // Copyright 2025 utapyngo (modifications)
 // SPDX-License-Identifier: Apache-2.0
 
-use std::collections::HashMap;
+use std::collections::{HashMap, HashSet};
 use std::sync::{Arc, Mutex};
+use std::thread::ThreadId;
 use tree_sitter::{Language, Parser, StreamingIterator, Tree};
 
+use super::checks::{clones, ignore, magic};
+use super::languages::LanguageInfo;
 use super::lock_or_recover;
+use super::metrics;
 use super::types::{
-    AnalysisResult, CallInfo, ClassInfo, ElementQueryResult, FunctionInfo, ReferenceInfo,
-    ReferenceType,
+    AnalysisResult, CallInfo, ClassInfo, CommentInfo, ElementQueryResult, FunctionInfo, ParamInfo,
+    ReferenceInfo, ReferenceType,
 };
 
+type ParserCache = HashMap<(ThreadId, String), Arc<Mutex<Parser>>>;
+
+/// Tree-sitter parsers keyed by worker thread and language. A parser is
+/// stateful and cannot be shared, so each thread gets its own instead of
+/// queueing on a single parser per language.
 #[derive(Clone)]
 pub struct ParserManager {
-    parsers: Arc<Mutex<HashMap<String, Arc<Mutex<Parser>>>>>,
+    parsers: Arc<Mutex<ParserCache>>,
 }
 
 impl ParserManager {
@@ -26,8 +35,9 @@ impl ParserManager {
 
     pub fn get_or_create_parser(&self, language: &str) -> Result<Arc<Mutex<Parser>>, String> {
         let mut cache = lock_or_recover(&self.parsers, |c| c.clear());
+        let key = (std::thread::current().id(), language.to_string());
 
-        if let Some(parser) = cache.get(language) {
+        if let Some(parser) = cache.get(&key) {
             return Ok(Arc::clone(parser));
         }
 
@@ -51,7 +61,7 @@ impl ParserManager {
             .map_err(|e| format!("Failed to set language for {}: {}", language, e))?;
 
         let parser_arc = Arc::new(Mutex::new(parser));
-        cache.insert(language.to_string(), Arc::clone(&parser_arc));
+        cache.insert(key, Arc::clone(&parser_arc));
         Ok(parser_arc)
     }
 
@@ -103,6 +113,23 @@ impl ElementExtractor {
         } else if depth == "semantic" {
             let calls = Self::extract_calls(tree, source, language)?;
             result.calls = calls;
+            result.referenced_names = Self::collect_referenced_names(tree, source, language);
+            result.shadowed = languages::get_language_info(language)
+                .and_then(|info| info.find_shadowed_handler)
+                .map(|handler| handler(&tree.root_node(), source))
+                .unwrap_or_default();
+            result.field_accesses = languages::get_language_info(language)
+                .and_then(|info| info.find_field_accesses_handler)
+                .map(|handler| handler(&tree.root_node(), source))
+                .unwrap_or_default();
+            result.empty_interfaces = languages::get_language_info(language)
+                .and_then(|info| info.find_empty_interfaces_handler)
+                .map(|handler| handler(&tree.root_node(), source))
+                .unwrap_or_default();
+            result.package = languages::get_language_info(language)
+                .and_then(|info| info.find_package_clause_handler)
+                .and_then(|handler| handler(&tree.root_node(), source));
+            result.comments = Self::extract_comments(tree, source);
 
             for call in &result.calls {
                 result.references.push(ReferenceInfo {
@@ -138,9 +165,7 @@ impl ElementExtractor {
             _ => return Ok(Self::empty_analysis_result()),
         };
 
-        let query_str = info.element_query;
-
-        let (functions, classes, imports) = Self::process_element_query(tree, source, query_str)?;
+        let (functions, classes, imports) = Self::process_element_query(tree, source, &info)?;
 
         let main_line = functions.iter().find(|f| f.name == "main").map(|f| f.line);
 
@@ -155,13 +180,21 @@ impl ElementExtractor {
             references: vec![],
             line_count: 0,
             main_line,
+            referenced_names: HashSet::new(),
+            error: None,
+            code_lines: metrics::lines_of_code(&tree.root_node()),
+            shadowed: vec![],
+            comments: vec![],
+            field_accesses: vec![],
+            empty_interfaces: vec![],
+            package: None,
         })
     }
 
     fn process_element_query(
         tree: &Tree,
         source: &str,
-        query_str: &str,
+        info: &LanguageInfo,
     ) -> Result<ElementQueryResult, String> {
         use tree_sitter::{Query, QueryCursor};
 
@@ -169,7 +202,7 @@ impl ElementExtractor {
         let mut classes = Vec::new();
         let mut imports = Vec::new();
 
-        let query = Query::new(&tree.language(), query_str)
+        let query = Query::new(&tree.language(), info.element_query)
             .map_err(|e| format!("Failed to create query: {}", e))?;
 
         let mut cursor = QueryCursor::new();
@@ -188,17 +221,24 @@ impl ElementExtractor {
 
                 match query.capture_names()[capture.index as usize] {
                     "func" | "const" => {
-                        functions.push(FunctionInfo {
-                            name: text.to_string(),
-                            line,
-                            params: vec![],
-                        });
+                        functions.push(Self::build_function_info(&node, text, line, source, info));
                     }
                     "class" | "struct" => {
+                        let decl = node.parent().unwrap_or(node);
                         classes.push(ClassInfo {
                             name: text.to_string(),
                             line,
                             methods: vec![],
+                            fields: info
+                                .extract_fields_handler
+                                .map(|handler| handler(&decl, source))
+                                .unwrap_or_default(),
+                            exported: info
+                                .is_exported_handler
+                                .is_none_or(|handler| handler(&decl, text, source)),
+                            interface: info
+                                .extract_interface_handler
+                                .and_then(|handler| handler(&decl, source)),
                         });
                     }
                     "import" => {
@@ -212,6 +252,279 @@ impl ElementExtractor {
         Ok((functions, classes, imports))
     }
 
+    /// Build function details from the name node captured by the element query
+    fn build_function_info(
+        name_node: &tree_sitter::Node,
+        name: &str,
+        line: usize,
+        source: &str,
+        info: &LanguageInfo,
+    ) -> FunctionInfo {
+        let decl = name_node.parent().unwrap_or(*name_node);
+
+        let receiver = match info.find_function_receiver_handler {
+            Some(handler) => handler(&decl, source),
+            None => Self::find_enclosing_class_name(&decl, source, info.class_node_kinds),
+        };
+
+        let (params, returns) = match info.extract_signature_handler {
+            Some(handler) => handler(&decl, source),
+            None => Self::extract_signature(&decl, source, receiver.is_some()),
+        };
+
+        let naked_returns = match info.find_naked_returns_handler {
+            Some(handler) if returns.iter().any(|result| result.name.is_some()) => handler(&decl),
+            _ => vec![],
+        };
+
+        let receiver_name = match (&receiver, info.find_receiver_name_handler) {
+            (Some(_), Some(handler)) => handler(&decl, source),
+            _ => None,
+        };
+        let receiver_used = receiver_name
+            .as_deref()
+            .is_some_and(|receiver_name| Self::body_refers_to(&decl, receiver_name, source));
+
+        let mut function = FunctionInfo {
+            name: name.to_string(),
+            line,
+            end_line: decl.end_position().row + 1,
+            receiver,
+            receiver_name,
+            receiver_used,
+            params,
+            returns,
+            complexity: metrics::cyclomatic_complexity(&decl, info),
+            cognitive_complexity: metrics::cognitive_complexity(&decl, info),
+            max_nesting_depth: metrics::max_nesting_depth(&decl, info),
+            lines_of_code: metrics::function_lines_of_code(&decl),
+            exported: info
+                .is_exported_handler
+                .is_none_or(|handler| handler(&decl, name, source)),
+            ignored_checks: Self::ignored_checks(&decl, source),
+            naked_returns,
+            statement_blocks: clones::statement_blocks(&decl, source, info),
+            discarded_calls: info
+                .find_discarded_calls_handler
+                .map(|handler| handler(&decl, source))
+                .unwrap_or_default(),
+            number_literals: magic::number_literals(&decl, source, info),
+            string_concats: info
+                .find_string_concats_handler
+                .map(|handler| handler(&decl, source))
+                .unwrap_or_default(),
+            error_returns: info
+                .find_error_returns_handler
+                .map(|handler| handler(&decl, source))
+                .unwrap_or_default(),
+            unreachable_code: info
+                .find_unreachable_code_handler
+                .map(|handler| handler(&decl, source))
+                .unwrap_or_default(),
+            slice_appends: info
+                .find_slice_appends_handler
+                .map(|handler| handler(&decl, source))
+                .unwrap_or_default(),
+            // Scored from the metrics once they are all known
+            hotspot_score: 0.0,
+        };
+        function.hotspot_score = metrics::HotspotWeights::default().score(&function);
+        function
+    }
+
+    /// Comment nodes in source order. Comments are leaves for this purpose, so
+    /// a Rust doc comment inside a line comment is not listed twice, and
+    /// comment-like text in string literals is never a comment node.
+    fn extract_comments(tree: &Tree, source: &str) -> Vec<CommentInfo> {
+        let mut comments = Vec::new();
+        let mut stack = vec![tree.root_node()];
+
+        while let Some(node) = stack.pop() {
+            if node.kind().contains("comment") {
+                if let Some(text) = source.get(node.byte_range()) {
+                    comments.push(CommentInfo {
+                        line: node.start_position().row + 1,
+                        text: text.to_string(),
+                    });
+                }
+                continue;
+            }
+            stack.extend(
+                (0..node.child_count() as u32)
+                    .rev()
+                    .filter_map(|i| node.child(i)),
+            );
+        }
+
+        comments
+    }
+
+    /// Checks suppressed by `analyzer:ignore` directives in the comment group
+    /// leading a declaration: the comments between it and the previous
+    /// statement, blank lines allowed. Separator tokens and Rust attributes
+    /// may sit in between, and decorated or exported declarations are looked
+    /// up from their wrapper. A comment trailing the previous statement on its
+    /// last line belongs to that statement, not to the declaration.
+    fn ignored_checks(decl: &tree_sitter::Node, source: &str) -> Vec<String> {
+        let mut anchor = *decl;
+        while let Some(parent) = anchor.parent()
+            && matches!(parent.kind(), "decorated_definition" | "export_statement")
+        {
+            anchor = parent;
+        }
+
+        let mut comments = Vec::new();
+        let mut sibling = anchor.prev_sibling();
+        while let Some(node) = sibling {
+            if node.kind().contains("comment") {
+                comments.push(node);
+            } else if node.is_named() && node.kind() != "attribute_item" {
+                let previous_end = node.end_position().row;
+                comments.retain(|comment| comment.start_position().row != previous_end);
+                break;
+            }
+            sibling = node.prev_sibling();
+        }
+
+        let mut checks: Vec<String> = comments
+            .iter()
+            .filter_map(|comment| source.get(comment.byte_range()))
+            .filter_map(ignore::parse_ignore_directive)
+            .flatten()
+            .collect();
+        checks.sort();
+        checks.dedup();
+        checks
+    }
+
+    /// Whether the body of a declaration uses `name` as an identifier. Field
+    /// names are separate node kinds, so `x.g` does not count as using `g`,
+    /// while `g.Base.Name` through an embedded field does.
+    fn body_refers_to(decl: &tree_sitter::Node, name: &str, source: &str) -> bool {
+        let body = decl.child_by_field_name("body").unwrap_or(*decl);
+        let mut stack = vec![body];
+
+        while let Some(node) = stack.pop() {
+            if matches!(node.kind(), "identifier" | "self")
+                && source.get(node.byte_range()) == Some(name)
+            {
+                return true;
+            }
+            stack.extend((0..node.child_count() as u32).filter_map(|i| node.child(i)));
+        }
+
+        false
+    }
+
+    /// Find the name of the closest enclosing class-like declaration
+    fn find_enclosing_class_name(
+        node: &tree_sitter::Node,
+        source: &str,
+        class_kinds: &[&str],
+    ) -> Option<String> {
+        let mut current = *node;
+
+        while let Some(parent) = current.parent() {
+            if class_kinds.contains(&parent.kind()) {
+                return parent
+                    .child_by_field_name("name")
+                    .or_else(|| parent.child_by_field_name("type"))
+                    .and_then(|name_node| source.get(name_node.byte_range()))
+                    .map(|s| s.to_string());
+            }
+            current = parent;
+        }
+
+        None
+    }
+
+    /// Extract parameters and return type using the common `parameters` and
+    /// `return_type` fields shared by most grammars
+    fn extract_signature(
+        decl: &tree_sitter::Node,
+        source: &str,
+        is_method: bool,
+    ) -> (Vec<ParamInfo>, Vec<ParamInfo>) {
+        use super::languages;
+
+        let mut params = decl
+            .child_by_field_name("parameters")
+            .map(|list| {
+                (0..list.child_count() as u32)
+                    .filter_map(|i| list.child(i))
+                    .filter(|child| {
+                        child.kind() != "self_parameter" && !child.kind().ends_with("_separator")
+                    })
+                    .filter(|child| child.is_named() && !child.kind().contains("comment"))
+                    .map(|child| languages::param_info(&child, source))
+                    .collect::<Vec<_>>()
+            })
+            .unwrap_or_default();
+
+        // Python passes the receiver explicitly as the first parameter
+        if is_method
+            && params
+                .first()
+                .is_some_and(|p| matches!(p.name.as_deref(), Some("self" | "cls")))
+        {
+            params.remove(0);
+        }
+
+        let returns = decl
+            .child_by_field_name("return_type")
+            .and_then(|type_node| source.get(type_node.byte_range()))
+            .map(|s| vec![ParamInfo::unnamed(s)])
+            .unwrap_or_default();
+
+        (params, returns)
+    }
+
+    /// Collect every identifier used in the file except function declaration names,
+    /// so that functions referenced as values (callbacks) count as used
+    fn collect_referenced_names(tree: &Tree, source: &str, language: &str) -> HashSet<String> {
+        use super::languages;
+
+        let mut names = HashSet::new();
+        let Some(info) = languages::get_language_info(language) else {
+            return names;
+        };
+
+        let mut stack = vec![tree.root_node()];
+        while let Some(node) = stack.pop() {
+            if info.function_name_kinds.contains(&node.kind())
+                && !Self::is_declaration_name(&node, &info)
+                && let Some(text) = source.get(node.byte_range())
+            {
+                names.insert(text.to_string());
+            }
+            stack.extend((0..node.child_count() as u32).filter_map(|i| node.child(i)));
+        }
+
+        names
+    }
+
+    fn is_declaration_name(node: &tree_sitter::Node, info: &LanguageInfo) -> bool {
+        node.parent().is_some_and(|parent| {
+            info.function_node_kinds.contains(&parent.kind())
+                && parent.child_by_field_name("name") == Some(*node)
+        })
+    }
+
+    /// Text of the expression a method or path call selects its callee from,
+    /// e.g. `fmt` for `fmt.Sprintf` or `std::mem` for `std::mem::take`
+    fn find_call_qualifier(node: &tree_sitter::Node, callee: &str, source: &str) -> Option<String> {
+        let parent_text = node.parent()?.utf8_text(source.as_bytes()).ok()?;
+        let qualifier = parent_text
+            .strip_suffix(callee)?
+            .trim_end_matches(|c: char| c == '.' || c == ':' || c == '?' || c.is_whitespace());
+
+        if qualifier.is_empty() {
+            None
+        } else {
+            Some(qualifier.to_string())
+        }
+    }
+
     fn extract_calls(tree: &Tree, source: &str, language: &str) -> Result<Vec<CallInfo>, String> {
         use super::languages;
         use tree_sitter::{Query, QueryCursor};
@@ -256,16 +569,25 @@ impl ElementExtractor {
 
                 let caller_name = Self::find_containing_function(&node, source, language);
 
-                match query.capture_names()[capture.index as usize] {
+                let capture_name = query.capture_names()[capture.index as usize];
+                match capture_name {
                     "function.call"
                     | "method.call"
                     | "scoped.call"
                     | "macro.call"
                     | "constructor.call"
                     | "identifier.reference" => {
+                        let qualifier = match capture_name {
+                            "method.call" | "scoped.call" => {
+                                Self::find_call_qualifier(&node, text, source)
+                            }
+                            _ => None,
+                        };
                         calls.push(CallInfo {
                             caller_name,
                             callee_name: text.to_string(),
+                            qualifier,
+                            is_reference: capture_name == "identifier.reference",
                             line: start_pos.row + 1,
                             column: start_pos.column,
                             context,
@@ -449,6 +771,14 @@ impl ElementExtractor {
             line_count: 0,
             import_count: 0,
             main_line: None,
+            referenced_names: HashSet::new(),
+            error: None,
+            code_lines: 0,
+            shadowed: vec![],
+            comments: vec![],
+            field_accesses: vec![],
+            empty_interfaces: vec![],
+            package: None,
         }
     }
 }
@@ -489,6 +819,19 @@ mod tests {
         assert!(std::sync::Arc::ptr_eq(&p1, &p2));
     }
 
+    #[test]
+    fn parser_manager_gives_each_thread_its_own_parser() {
+        let pm = ParserManager::new();
+        let here = pm.get_or_create_parser("rust").unwrap();
+        let other = std::thread::scope(|scope| {
+            scope
+                .spawn(|| pm.get_or_create_parser("rust").unwrap())
+                .join()
+                .unwrap()
+        });
+        assert!(!std::sync::Arc::ptr_eq(&here, &other));
+    }
+
     #[test]
     fn parse_rust_code() {
         let pm = ParserManager::new();
@@ -532,6 +875,163 @@ mod tests {
         assert!(result.classes.iter().any(|c| c.name == "Foo"));
     }
 
+    #[test]
+    fn extract_elements_go_signature() {
+        let pm = ParserManager::new();
+        let code = "package main\n\ntype Greeter struct{}\n\nfunc (g *Greeter) Greet() string {\n\treturn \"hi\"\n}\n\nfunc pair(a, b int, rest ...string) (int, error) {\n\treturn a, nil\n}\n";
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+
+        let greet = result.functions.iter().find(|f| f.name == "Greet").unwrap();
+        assert_eq!(greet.receiver.as_deref(), Some("*Greeter"));
+        assert!(greet.params.is_empty());
+        assert_eq!(greet.returns, vec![ParamInfo::unnamed("string")]);
+        assert_eq!((greet.line, greet.end_line), (5, 7));
+
+        let pair = result.functions.iter().find(|f| f.name == "pair").unwrap();
+        assert!(pair.receiver.is_none());
+        assert_eq!(
+            pair.params,
+            vec![
+                ParamInfo::named("a", Some("int")),
+                ParamInfo::named("b", Some("int")),
+                ParamInfo::named("rest", Some("...string")),
+            ]
+        );
+        assert_eq!(
+            pair.returns,
+            vec![ParamInfo::unnamed("int"), ParamInfo::unnamed("error")]
+        );
+    }
+
+    #[test]
+    fn extract_elements_splits_param_names_and_types() {
+        let pm = ParserManager::new();
+        let code = "fn add(x: i32, label: &str) -> bool {\n    true\n}\n";
+        let tree = pm.parse(code, "rust").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "rust").unwrap();
+        let add = result.functions.iter().find(|f| f.name == "add").unwrap();
+        assert_eq!(
+            add.params,
+            vec![
+                ParamInfo::named("x", Some("i32")),
+                ParamInfo::named("label", Some("&str")),
+            ]
+        );
+        assert_eq!(add.returns, vec![ParamInfo::unnamed("bool")]);
+
+        let code = "def f(a: int, b=1):\n    pass\n";
+        let tree = pm.parse(code, "python").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "python").unwrap();
+        let f = result.functions.iter().find(|f| f.name == "f").unwrap();
+        assert_eq!(
+            f.params,
+            vec![
+                ParamInfo::named("a", Some("int")),
+                ParamInfo::named("b", None)
+            ]
+        );
+    }
+
+    #[test]
+    fn extract_elements_go_struct_fields() {
+        let pm = ParserManager::new();
+        let code = "package main\n\ntype Greeter struct {\n\tName, Title string `json:\"name\"`\n\tcount int\n\t*base.Logger\n}\n\ntype inner struct{}\n";
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+
+        let greeter = result.classes.iter().find(|c| c.name == "Greeter").unwrap();
+        assert!(greeter.exported);
+        let fields: Vec<(&str, bool)> = greeter
+            .fields
+            .iter()
+            .map(|f| (f.name.as_str(), f.exported))
+            .collect();
+        assert_eq!(
+            fields,
+            vec![
+                ("Name", true),
+                ("Title", true),
+                ("count", false),
+                ("Logger", true)
+            ]
+        );
+        assert_eq!(greeter.fields[0].type_name.as_deref(), Some("string"));
+        assert_eq!(greeter.fields[1].tag.as_deref(), Some("json:\"name\""));
+        assert!(greeter.fields[2].tag.is_none());
+
+        let inner = result.classes.iter().find(|c| c.name == "inner").unwrap();
+        assert!(!inner.exported);
+    }
+
+    #[test]
+    fn extract_elements_go_interface_methods() {
+        let pm = ParserManager::new();
+        let code = "package main\n\ntype Speaker interface {\n\tfmt.Stringer\n\tGreet(name string) (string, error)\n}\n\ntype Greeter struct{}\n";
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+
+        let speaker = result.classes.iter().find(|c| c.name == "Speaker").unwrap();
+        let interface = speaker.interface.as_ref().unwrap();
+        assert_eq!(interface.embedded, vec!["fmt.Stringer"]);
+        assert_eq!(interface.methods.len(), 1);
+        assert_eq!(interface.methods[0].name, "Greet");
+        assert_eq!(
+            interface.methods[0].params,
+            vec![ParamInfo::named("name", Some("string"))]
+        );
+        assert_eq!(
+            interface.methods[0].returns,
+            vec![ParamInfo::unnamed("string"), ParamInfo::unnamed("error")]
+        );
+
+        let greeter = result.classes.iter().find(|c| c.name == "Greeter").unwrap();
+        assert!(greeter.interface.is_none());
+    }
+
+    #[test]
+    fn extract_elements_binds_ignore_comments_to_the_next_declaration() {
+        let pm = ParserManager::new();
+        let code = "package main\n\n//analyzer:ignore complexity\n\n// parse handles every opcode\nfunc parse() {}\n\nfunc plain() {} //analyzer:ignore\n\nfunc next() {}\n\n//analyzer:ignore\n//analyzer:ignore unused\nfunc both() {}\n";
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+        let find = |name: &str| result.functions.iter().find(|f| f.name == name).unwrap();
+
+        assert_eq!(find("parse").ignored_checks, vec!["complexity"]);
+        assert!(find("parse").is_ignored("complexity"));
+        assert!(!find("parse").is_ignored("unused"));
+        // A trailing comment belongs to the line it ends
+        assert!(find("plain").ignored_checks.is_empty());
+        assert!(find("next").ignored_checks.is_empty());
+        assert!(find("both").is_ignored("complexity"));
+    }
+
+    #[test]
+    fn extract_elements_tracks_receiver_usage() {
+        let pm = ParserManager::new();
+        let code = "package main\n\ntype T struct{ Base }\n\nfunc (t *T) Uses() string {\n\treturn t.Base.Name\n}\n\nfunc (t *T) Ignores() int {\n\tx := T{}\n\treturn x.t\n}\n\nfunc (_ *T) Blank() {}\n\nfunc (*T) Unnamed() {}\n";
+        let tree = pm.parse(code, "go").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "go").unwrap();
+        let find = |name: &str| result.functions.iter().find(|f| f.name == name).unwrap();
+
+        assert_eq!(find("Uses").receiver_name.as_deref(), Some("t"));
+        assert!(find("Uses").receiver_used);
+        assert!(!find("Ignores").receiver_used);
+        assert_eq!(find("Blank").receiver_name.as_deref(), Some("_"));
+        assert!(find("Unnamed").receiver_name.is_none());
+    }
+
+    #[test]
+    fn extract_elements_python_method_receiver() {
+        let pm = ParserManager::new();
+        let code = "class Foo:\n    def bar(self, x):\n        pass\n";
+        let tree = pm.parse(code, "python").unwrap();
+        let result = ElementExtractor::extract_elements(&tree, code, "python").unwrap();
+        let bar = result.functions.iter().find(|f| f.name == "bar").unwrap();
+        assert_eq!(bar.receiver.as_deref(), Some("Foo"));
+        assert_eq!(bar.params, vec![ParamInfo::named("x", None)]);
+    }
+
     #[test]
     fn extract_with_depth_structure() {
         let pm = ParserManager::new();
@@ -0,0 +1,171 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+//! Quality gate for CI: thresholds that fail the run when exceeded.
+
+use std::path::{Path, PathBuf};
+
+use super::checks::Finding;
+use super::checks::unused;
+use super::metrics;
+use super::types::AnalysisResult;
+
+/// A finding that fails the policy
+pub type Violation = Finding;
+
+/// Limits enforced by [`Policy::evaluate`]; the default enforces nothing
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct Policy {
+    /// Highest cyclomatic complexity a function may have
+    pub max_complexity: Option<usize>,
+    /// Most lines of code a function body may have
+    pub max_function_loc: Option<usize>,
+    /// Most parameters a function may declare
+    pub max_params: Option<usize>,
+    /// Deepest nesting of control flow a function may have
+    pub max_nesting: Option<usize>,
+    /// Whether an unexported function that is never referenced is a violation
+    pub fail_on_unused: bool,
+}
+
+impl Policy {
+    /// Whether no limit is set, so every analysis passes
+    pub fn is_empty(&self) -> bool {
+        *self == Self::default()
+    }
+
+    /// Every violation of the policy, ordered by rule then path and line.
+    /// Functions with a matching `analyzer:ignore` comment are exempt.
+    pub fn evaluate(&self, results: &[(PathBuf, AnalysisResult)]) -> Vec<Violation> {
+        let mut violations = Vec::new();
+        if let Some(max) = self.max_complexity {
+            violations.extend(
+                metrics::complexity_violations(results, max)
+                    .iter()
+                    .map(Finding::from),
+            );
+        }
+        if let Some(max) = self.max_function_loc {
+            violations.extend(
+                metrics::length_violations(results, max)
+                    .iter()
+                    .map(Finding::from),
+            );
+        }
+        if let Some(max) = self.max_params {
+            violations.extend(
+                metrics::param_count_violations(results, max)
+                    .iter()
+                    .map(Finding::from),
+            );
+        }
+        if let Some(max) = self.max_nesting {
+            violations.extend(
+                metrics::nesting_violations(results, max)
+                    .iter()
+                    .map(Finding::from),
+            );
+        }
+        if self.fail_on_unused {
+            violations.extend(
+                unused::find_unused_functions(results)
+                    .iter()
+                    .map(Finding::from),
+            );
+        }
+        violations
+    }
+}
+
+/// Format violations as `path:line: message` lines with paths relative to `base`
+pub fn format_violations(base: &Path, violations: &[Violation]) -> String {
+    let mut output = String::new();
+    for violation in violations {
+        let path = violation.path.strip_prefix(base).unwrap_or(&violation.path);
+        output.push_str(&format!(
+            "{}:{}: {}\n",
+            path.display(),
+            violation.start_line,
+            violation.message
+        ));
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::checks::{
+        RULE_COMPLEXITY, RULE_FUNCTION_LENGTH, RULE_NESTING_DEPTH, RULE_TOO_MANY_PARAMS,
+        RULE_UNUSED_FUNCTION,
+    };
+    use crate::analyze::types::{FunctionInfo, ParamInfo};
+
+    fn results() -> Vec<(PathBuf, AnalysisResult)> {
+        let function = |name: &str, line, complexity, lines_of_code| FunctionInfo {
+            name: name.into(),
+            line,
+            complexity,
+            lines_of_code,
+            ..Default::default()
+        };
+        let mut result = AnalysisResult::empty(200);
+        result.functions = vec![
+            function("main", 1, 2, 10),
+            function("branchy", 20, 15, 30),
+            function("long", 60, 3, 120),
+        ];
+        result.referenced_names.insert("main".into());
+        result.referenced_names.insert("branchy".into());
+        result.functions[1].params = ["a", "b", "c", "d", "e", "f"]
+            .iter()
+            .map(|name| ParamInfo::named(name, Some("int")))
+            .collect();
+        result.functions[1].max_nesting_depth = 5;
+        vec![(PathBuf::from("/p/main.go"), result)]
+    }
+
+    #[test]
+    fn empty_policy_never_fails() {
+        let policy = Policy::default();
+        assert!(policy.is_empty());
+        assert!(policy.evaluate(&results()).is_empty());
+    }
+
+    #[test]
+    fn each_limit_reports_its_rule() {
+        let policy = Policy {
+            max_complexity: Some(10),
+            max_function_loc: Some(100),
+            max_params: Some(5),
+            max_nesting: Some(4),
+            fail_on_unused: true,
+        };
+        assert!(!policy.is_empty());
+        let violations = policy.evaluate(&results());
+        let rules: Vec<(&str, usize)> = violations
+            .iter()
+            .map(|v| (v.rule_id, v.start_line))
+            .collect();
+        assert_eq!(
+            rules,
+            vec![
+                (RULE_COMPLEXITY, 20),
+                (RULE_FUNCTION_LENGTH, 60),
+                (RULE_TOO_MANY_PARAMS, 20),
+                (RULE_NESTING_DEPTH, 20),
+                (RULE_UNUSED_FUNCTION, 60),
+            ]
+        );
+    }
+
+    #[test]
+    fn format_lists_one_violation_per_line() {
+        let policy = Policy {
+            max_function_loc: Some(100),
+            ..Default::default()
+        };
+        let out = format_violations(Path::new("/p"), &policy.evaluate(&results()));
+        assert_eq!(out, "main.go:60: long has 120 lines of code (max 100)\n");
+    }
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 utapyngo
+// SPDX-License-Identifier: Apache-2.0
+
+use std::fmt;
+use std::path::PathBuf;
+use std::time::Duration;
+
+use super::types::AnalysisResult;
+
+const BYTES_PER_MEGABYTE: f64 = 1_000_000.0;
+
+/// How much an analysis run parsed and how long it took, for judging its
+/// throughput
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct Stats {
+    /// Files analyzed without an error
+    pub files: usize,
+    /// Total size of those files on disk
+    pub bytes: u64,
+    /// Functions found in those files
+    pub functions: usize,
+    /// Time spent finding, parsing and checking the files; rendering the
+    /// output is left out
+    pub elapsed: Duration,
+}
+
+impl Stats {
+    /// Count the files, bytes and functions of `results`, leaving out files
+    /// that failed to parse. `elapsed` is left at zero for the caller to set
+    /// once the analysis is done.
+    pub fn from_results(results: &[(PathBuf, AnalysisResult)]) -> Self {
+        let parsed = results.iter().filter(|(_, result)| result.error.is_none());
+        let mut stats = Self::default();
+        for (path, result) in parsed {
+            stats.files += 1;
+            stats.bytes += std::fs::metadata(path).map(|m| m.len()).unwrap_or(0);
+            stats.functions += result.functions.len();
+        }
+        stats
+    }
+
+    pub fn files_per_second(&self) -> f64 {
+        self.per_second(self.files as f64)
+    }
+
+    /// Megabytes, of 1,000,000 bytes, analyzed per second
+    pub fn megabytes_per_second(&self) -> f64 {
+        self.per_second(self.bytes as f64 / BYTES_PER_MEGABYTE)
+    }
+
+    /// `amount` over the elapsed time, or 0 when no time was measured
+    fn per_second(&self, amount: f64) -> f64 {
+        let seconds = self.elapsed.as_secs_f64();
+        if seconds > 0.0 { amount / seconds } else { 0.0 }
+    }
+}
+
+impl fmt::Display for Stats {
+    /// `Analyzed 3 files (1.20 MB, 40 functions) in 0.250s: 12.0 files/s, 4.80 MB/s`
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "Analyzed {} files ({:.2} MB, {} functions) in {:.3}s: {:.1} files/s, {:.2} MB/s",
+            self.files,
+            self.bytes as f64 / BYTES_PER_MEGABYTE,
+            self.functions,
+            self.elapsed.as_secs_f64(),
+            self.files_per_second(),
+            self.megabytes_per_second()
+        )
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::analyze::types::FunctionInfo;
+
+    #[test]
+    fn failed_files_are_not_counted() {
+        let dir = tempfile::tempdir().unwrap();
+        let good = dir.path().join("good.go");
+        let bad = dir.path().join("bad.go");
+        std::fs::write(&good, "package main\n\nfunc main() {}\n").unwrap();
+        std::fs::write(&bad, "package main\n\nfunc {\n").unwrap();
+
+        let mut parsed = AnalysisResult::empty(3);
+        parsed.functions = vec![FunctionInfo {
+            name: "main".into(),
+            ..Default::default()
+        }];
+        let mut failed = AnalysisResult::empty(3);
+        failed.error = Some("Failed to parse file as go".into());
+
+        let stats = Stats::from_results(&[(good, parsed), (bad, failed)]);
+        assert_eq!(
+            stats,
+            Stats {
+                files: 1,
+                bytes: 29,
+                functions: 1,
+                elapsed: Duration::ZERO,
+            }
+        );
+    }
+
+    #[test]
+    fn rates_are_per_second_of_analysis() {
+        let stats = Stats {
+            files: 3,
+            bytes: 1_200_000,
+            functions: 40,
+            elapsed: Duration::from_millis(250),
+        };
+        assert_eq!(stats.files_per_second(), 12.0);
+        assert_eq!(stats.megabytes_per_second(), 4.8);
+        assert_eq!(
+            stats.to_string(),
+            "Analyzed 3 files (1.20 MB, 40 functions) in 0.250s: 12.0 files/s, 4.80 MB/s"
+        );
+        assert_eq!(Stats::default().files_per_second(), 0.0);
+    }
+}
@@ -4,22 +4,106 @@
 
 use rayon::prelude::*;
 use std::path::{Path, PathBuf};
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::{Arc, Mutex};
 
+use super::build::{BuildContext, SkippedFile};
+use super::cancel::CancelToken;
+use super::filter::PathFilter;
+use super::lock_or_recover;
 use super::types::{AnalysisResult, EntryType};
 use crate::lang;
 
-/// Handles file system traversal for analysis
-pub struct FileTraverser;
+/// Directory names skipped during traversal unless explicitly included
+const SKIPPED_DIRS: &[&str] = &[
+    "node_modules",
+    "target",
+    "__pycache__",
+    "vendor",
+    "testdata",
+];
 
-impl Default for FileTraverser {
-    fn default() -> Self {
-        Self
-    }
+/// Handles file system traversal for analysis
+#[derive(Debug, Clone, Default)]
+pub struct FileTraverser {
+    include_skipped_dirs: bool,
+    build_context: Option<BuildContext>,
+    path_filter: PathFilter,
+    cancel_token: Option<CancelToken>,
+    keep_partial_results: bool,
+    /// Files the latest walk left out by the build context, shared by clones
+    skipped: Arc<Mutex<Vec<SkippedFile>>>,
+    /// Directories below the root the latest walk could not read, with why
+    unreadable: Arc<Mutex<Vec<(PathBuf, String)>>>,
 }
 
 impl FileTraverser {
     pub fn new() -> Self {
-        Self
+        Self::default()
+    }
+
+    /// Also descend into hidden directories and the directories in `SKIPPED_DIRS`.
+    /// Hidden files are always ignored.
+    pub fn include_skipped_dirs(mut self, include: bool) -> Self {
+        self.include_skipped_dirs = include;
+        self
+    }
+
+    /// Leave out Go files whose build constraints exclude them from a build
+    /// in `context`. A file given directly as the path is never left out.
+    pub fn build_context(mut self, context: Option<BuildContext>) -> Self {
+        self.build_context = context;
+        self
+    }
+
+    /// Walk only the files `filter` selects. Like the build context, it does
+    /// not apply to a file given directly as the path.
+    pub fn path_filter(mut self, filter: PathFilter) -> Self {
+        self.path_filter = filter;
+        self
+    }
+
+    /// Stop analyzing files once `token` is cancelled. Files already being
+    /// analyzed finish; the walk then fails unless partial results are kept.
+    pub fn cancel_token(mut self, token: Option<CancelToken>) -> Self {
+        self.cancel_token = token;
+        self
+    }
+
+    /// On cancellation, return the files analyzed so far instead of an error
+    pub fn keep_partial_results(mut self, keep: bool) -> Self {
+        self.keep_partial_results = keep;
+        self
+    }
+
+    /// Whether the cancel token, if any, has been cancelled
+    pub fn is_cancelled(&self) -> bool {
+        self.cancel_token
+            .as_ref()
+            .is_some_and(CancelToken::is_cancelled)
+    }
+
+    /// Fail when cancellation left files out, unless partial results are kept
+    pub fn check_complete(&self, analyzed: usize, total: usize) -> Result<(), String> {
+        if analyzed < total && !self.keep_partial_results {
+            return Err(format!(
+                "Cancelled after analyzing {} of {} files",
+                analyzed, total
+            ));
+        }
+        Ok(())
+    }
+
+    fn should_skip(&self, path: &Path) -> bool {
+        let Some(name) = path.file_name().and_then(|n| n.to_str()) else {
+            return false;
+        };
+
+        if path.is_dir() {
+            !self.include_skipped_dirs && (name.starts_with('.') || SKIPPED_DIRS.contains(&name))
+        } else {
+            name.starts_with('.')
+        }
     }
 
     /// Validate that a path exists
@@ -30,22 +114,59 @@ impl FileTraverser {
         Ok(())
     }
 
-    /// Collect all files for focused analysis
-    pub fn collect_files_for_focused(
-        &self,
-        path: &Path,
-        max_depth: u32,
-    ) -> Result<Vec<PathBuf>, String> {
-        let files = self.collect_files_recursive(path, 0, max_depth)?;
-        Ok(files)
+    /// Files the latest walk left out by the build context, in path order.
+    /// Empty before any walk and when the walked path is a file.
+    pub fn skipped_files(&self) -> Vec<SkippedFile> {
+        lock_or_recover(&self.skipped, |_| {}).clone()
+    }
+
+    /// The files under `path` to analyze, recording the ones the build
+    /// context leaves out for [`skipped_files`] and the subdirectories that
+    /// cannot be read for [`unreadable_dirs`]. Only an unreadable `path`
+    /// itself fails the walk.
+    ///
+    /// [`skipped_files`]: Self::skipped_files
+    /// [`unreadable_dirs`]: Self::unreadable_dirs
+    fn walk(&self, path: &Path, max_depth: u32) -> Result<Vec<PathBuf>, String> {
+        let mut skipped = Vec::new();
+        let mut unreadable = Vec::new();
+        let files =
+            self.collect_files_recursive(path, path, 0, max_depth, &mut skipped, &mut unreadable);
+        *lock_or_recover(&self.skipped, |_| {}) = skipped;
+        *lock_or_recover(&self.unreadable, |_| {}) = unreadable;
+        files
+    }
+
+    /// Failed results for the directories the latest walk could not read
+    fn unreadable_dirs(&self) -> Vec<(PathBuf, AnalysisResult)> {
+        lock_or_recover(&self.unreadable, |_| {})
+            .iter()
+            .map(|(path, error)| (path.clone(), AnalysisResult::failed(error.clone())))
+            .collect()
+    }
+
+    /// Why the build context leaves out a traversed file, if it does
+    fn build_exclusion(&self, path: &Path) -> Option<String> {
+        let context = self.build_context.as_ref()?;
+        if lang::get_language_identifier(path) != "go" {
+            return None;
+        }
+        // Unreadable files are kept so their analysis reports the error
+        let source = std::fs::read_to_string(path).ok()?;
+        context.exclusion_reason(path, &source)
     }
 
-    /// Recursively collect files
+    /// Recursively collect files, recording those left out by the build
+    /// context in `skipped` and the subdirectories that cannot be read in
+    /// `unreadable`. The path filter matches paths relative to `root`.
     fn collect_files_recursive(
         &self,
         path: &Path,
+        root: &Path,
         current_depth: u32,
         max_depth: u32,
+        skipped: &mut Vec<SkippedFile>,
+        unreadable: &mut Vec<(PathBuf, String)>,
     ) -> Result<Vec<PathBuf>, String> {
         let mut files = Vec::new();
 
@@ -62,33 +183,45 @@ impl FileTraverser {
             return Ok(files);
         }
 
-        let entries = std::fs::read_dir(path)
-            .map_err(|e| format!("Failed to read directory '{}': {}", path.display(), e))?;
-
-        for entry in entries {
-            let entry = entry.map_err(|e| format!("Failed to read directory entry: {}", e))?;
-
-            let entry_path = entry.path();
+        // Sorted so results come out in path order however the workers finish
+        let mut entry_paths = match read_entries(path) {
+            Ok(entry_paths) => entry_paths,
+            Err(e) if current_depth == 0 => return Err(e),
+            Err(e) => {
+                unreadable.push((path.to_path_buf(), e));
+                return Ok(files);
+            }
+        };
+        entry_paths.sort();
 
-            // Skip hidden directories and common non-source directories
-            if let Some(name) = entry_path.file_name().and_then(|n| n.to_str())
-                && (name.starts_with('.')
-                    || name == "node_modules"
-                    || name == "target"
-                    || name == "__pycache__"
-                    || name == "vendor")
-            {
+        for entry_path in entry_paths {
+            // Skip hidden entries and common non-source directories
+            if self.should_skip(&entry_path) {
                 continue;
             }
+            let relative = entry_path.strip_prefix(root).unwrap_or(&entry_path);
 
             if entry_path.is_file() {
                 let lang_id = lang::get_language_identifier(&entry_path);
-                if !lang_id.is_empty() {
-                    files.push(entry_path);
+                if lang_id.is_empty() || !self.path_filter.includes_file(relative) {
+                    continue;
+                }
+                match self.build_exclusion(&entry_path) {
+                    Some(reason) => skipped.push(SkippedFile {
+                        path: entry_path,
+                        reason,
+                    }),
+                    None => files.push(entry_path),
                 }
-            } else if entry_path.is_dir() {
-                let mut sub_files =
-                    self.collect_files_recursive(&entry_path, current_depth + 1, max_depth)?;
+            } else if entry_path.is_dir() && !self.path_filter.excludes_dir(relative) {
+                let mut sub_files = self.collect_files_recursive(
+                    &entry_path,
+                    root,
+                    current_depth + 1,
+                    max_depth,
+                    skipped,
+                    unreadable,
+                )?;
                 files.append(&mut sub_files);
             }
         }
@@ -96,7 +229,14 @@ impl FileTraverser {
         Ok(files)
     }
 
-    /// Collect directory results for analysis with parallel processing
+    /// Collect directory results for analysis with parallel processing.
+    ///
+    /// A file that fails to analyze does not abort the walk: its entry carries
+    /// the error message instead so partial results are still returned, as
+    /// does the entry of a subdirectory that cannot be read.
+    /// Files are analyzed on the current rayon pool; results keep the sorted
+    /// path order regardless of which worker finishes first. Once the cancel
+    /// token is cancelled no further file is started.
     pub fn collect_directory_results<F>(
         &self,
         path: &Path,
@@ -106,17 +246,81 @@ impl FileTraverser {
     where
         F: Fn(&Path) -> Result<AnalysisResult, String> + Sync,
     {
-        let files_to_analyze = self.collect_files_recursive(path, 0, max_depth)?;
+        let files_to_analyze = self.walk(path, max_depth)?;
 
-        let results: Result<Vec<_>, String> = files_to_analyze
+        let mut results: Vec<(PathBuf, EntryType)> = files_to_analyze
             .par_iter()
-            .map(|file_path| {
-                analyze_file(file_path).map(|result| (file_path.clone(), EntryType::File(result)))
+            .filter_map(|file_path| {
+                if self.is_cancelled() {
+                    return None;
+                }
+                let result = analyze_file(file_path).unwrap_or_else(AnalysisResult::failed);
+                Some((file_path.clone(), EntryType::File(result)))
             })
             .collect();
 
-        results
+        self.check_complete(results.len(), files_to_analyze.len())?;
+        let unreadable = self.unreadable_dirs();
+        if !unreadable.is_empty() {
+            results.extend(
+                unreadable
+                    .into_iter()
+                    .map(|(path, result)| (path, EntryType::File(result))),
+            );
+            results.sort_by(|(a, _), (b, _)| a.cmp(b));
+        }
+        Ok(results)
     }
+
+    /// Analyze the files of a directory like [`collect_directory_results`],
+    /// but hand each result to `on_result` as soon as its worker finishes
+    /// instead of keeping them, in no particular order, and then the failed
+    /// entry of each subdirectory that cannot be read. The first error from
+    /// `on_result` stops the walk and is returned; otherwise the number of
+    /// entries handed over.
+    ///
+    /// [`collect_directory_results`]: Self::collect_directory_results
+    pub fn for_each_directory_result<F, G>(
+        &self,
+        path: &Path,
+        max_depth: u32,
+        analyze_file: F,
+        on_result: G,
+    ) -> Result<usize, String>
+    where
+        F: Fn(&Path) -> Result<AnalysisResult, String> + Sync,
+        G: Fn(&Path, AnalysisResult) -> Result<(), String> + Sync,
+    {
+        let files_to_analyze = self.walk(path, max_depth)?;
+
+        let analyzed = AtomicUsize::new(0);
+        files_to_analyze.par_iter().try_for_each(|file_path| {
+            if self.is_cancelled() {
+                return Ok(());
+            }
+            let result = analyze_file(file_path).unwrap_or_else(AnalysisResult::failed);
+            analyzed.fetch_add(1, Ordering::Relaxed);
+            on_result(file_path, result)
+        })?;
+
+        let analyzed = analyzed.into_inner();
+        self.check_complete(analyzed, files_to_analyze.len())?;
+        let unreadable = self.unreadable_dirs();
+        let handed_over = analyzed + unreadable.len();
+        for (dir, result) in unreadable {
+            on_result(&dir, result)?;
+        }
+        Ok(handed_over)
+    }
+}
+
+/// Paths of the entries of directory `path`
+fn read_entries(path: &Path) -> Result<Vec<PathBuf>, String> {
+    std::fs::read_dir(path)
+        .map_err(|e| format!("Failed to read directory '{}': {}", path.display(), e))?
+        .map(|entry| entry.map(|entry| entry.path()))
+        .collect::<Result<Vec<_>, _>>()
+        .map_err(|e| format!("Failed to read directory entry: {}", e))
 }
 
 #[cfg(test)]
@@ -144,7 +348,7 @@ mod tests {
     fn collect_files_from_fixtures() {
         let t = FileTraverser::new();
         let fixtures = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/fixtures");
-        let files = t.collect_files_for_focused(&fixtures, 3).unwrap();
+        let files = t.walk(&fixtures, 3).unwrap();
         assert!(
             files.len() >= 4,
             "expected at least 4 fixture files, got {}",
@@ -170,7 +374,7 @@ mod tests {
         std::fs::write(dir.path().join("visible.rs"), "fn visible() {}").unwrap();
 
         let t = FileTraverser::new();
-        let files = t.collect_files_for_focused(dir.path(), 3).unwrap();
+        let files = t.walk(dir.path(), 3).unwrap();
 
         let names: Vec<String> = files
             .iter()
@@ -190,7 +394,7 @@ mod tests {
 
         let t = FileTraverser::new();
         // max_depth=1 should only get top-level files
-        let files = t.collect_files_for_focused(dir.path(), 1).unwrap();
+        let files = t.walk(dir.path(), 1).unwrap();
         let names: Vec<String> = files
             .iter()
             .filter_map(|p| p.file_name().map(|n| n.to_string_lossy().to_string()))
@@ -207,7 +411,7 @@ mod tests {
         std::fs::write(deep.join("deep.rs"), "fn deep() {}").unwrap();
 
         let t = FileTraverser::new();
-        let files = t.collect_files_for_focused(dir.path(), 0).unwrap();
+        let files = t.walk(dir.path(), 0).unwrap();
         let names: Vec<String> = files
             .iter()
             .filter_map(|p| p.file_name().map(|n| n.to_string_lossy().to_string()))
@@ -222,7 +426,7 @@ mod tests {
         std::fs::write(dir.path().join("code.rs"), "fn f() {}").unwrap();
 
         let t = FileTraverser::new();
-        let files = t.collect_files_for_focused(dir.path(), 3).unwrap();
+        let files = t.walk(dir.path(), 3).unwrap();
         assert_eq!(files.len(), 1);
         assert!(files[0].to_string_lossy().contains("code.rs"));
     }
@@ -237,8 +441,280 @@ mod tests {
         assert!(results.len() >= 4);
     }
 
+    #[test]
+    fn for_each_directory_result_hands_over_every_file() {
+        let t = FileTraverser::new();
+        let fixtures = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/fixtures");
+        let analyze = |_path: &Path| Ok(AnalysisResult::empty(1));
+        let seen = std::sync::Mutex::new(Vec::new());
+        let analyzed = t
+            .for_each_directory_result(&fixtures, 3, analyze, |path, _| {
+                seen.lock().unwrap().push(path.to_path_buf());
+                Ok(())
+            })
+            .unwrap();
+
+        let mut seen = seen.into_inner().unwrap();
+        seen.sort();
+        let collected: Vec<PathBuf> = t
+            .collect_directory_results(&fixtures, 3, analyze)
+            .unwrap()
+            .into_iter()
+            .map(|(path, _)| path)
+            .collect();
+        assert_eq!(analyzed, collected.len());
+        assert_eq!(seen, collected);
+
+        let err = t
+            .for_each_directory_result(&fixtures, 3, analyze, |_, _| Err("disk full".into()))
+            .unwrap_err();
+        assert_eq!(err, "disk full");
+    }
+
+    #[test]
+    fn collect_files_skips_default_excluded_dirs() {
+        let dir = tempfile::tempdir().unwrap();
+        for name in ["vendor", "testdata", ".git"] {
+            let sub = dir.path().join(name);
+            std::fs::create_dir(&sub).unwrap();
+            std::fs::write(sub.join("skipped.go"), "package x").unwrap();
+        }
+        std::fs::write(dir.path().join("main.go"), "package main").unwrap();
+
+        let files = FileTraverser::new().walk(dir.path(), 3).unwrap();
+        assert_eq!(files.len(), 1);
+
+        let files = FileTraverser::new()
+            .include_skipped_dirs(true)
+            .walk(dir.path(), 3)
+            .unwrap();
+        assert_eq!(files.len(), 4);
+    }
+
+    #[test]
+    fn collect_files_always_skips_hidden_files() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join(".hidden.go"), "package x").unwrap();
+        std::fs::write(dir.path().join("main.go"), "package main").unwrap();
+
+        let files = FileTraverser::new()
+            .include_skipped_dirs(true)
+            .walk(dir.path(), 3)
+            .unwrap();
+        assert_eq!(files.len(), 1);
+        assert!(files[0].ends_with("main.go"));
+    }
+
+    #[test]
+    fn collect_directory_results_keeps_failed_files() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join("good.go"), "package main").unwrap();
+        std::fs::write(dir.path().join("bad.go"), "package main").unwrap();
+
+        let results = FileTraverser::new()
+            .collect_directory_results(dir.path(), 3, |path| {
+                if path.ends_with("bad.go") {
+                    Err("boom".to_string())
+                } else {
+                    Ok(AnalysisResult::empty(1))
+                }
+            })
+            .unwrap();
+
+        assert_eq!(results.len(), 2);
+        let errors: Vec<_> = results
+            .iter()
+            .filter_map(|(_, EntryType::File(r))| r.error.as_deref())
+            .collect();
+        assert_eq!(errors, vec!["boom"]);
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn unreadable_subdirectory_is_a_failed_entry() {
+        use std::os::unix::fs::PermissionsExt;
+
+        let dir = tempfile::tempdir().unwrap();
+        let locked = dir.path().join("locked");
+        std::fs::create_dir(&locked).unwrap();
+        std::fs::write(locked.join("hidden.go"), "package locked").unwrap();
+        std::fs::write(dir.path().join("main.go"), "package main").unwrap();
+        std::fs::write(dir.path().join("z.go"), "package main").unwrap();
+        std::fs::set_permissions(&locked, std::fs::Permissions::from_mode(0o000)).unwrap();
+        // Root reads the directory anyway, leaving nothing to test
+        let readable = std::fs::read_dir(&locked).is_ok();
+
+        let t = FileTraverser::new();
+        let results = t.collect_directory_results(dir.path(), 3, |_| Ok(AnalysisResult::empty(1)));
+        let handed_over = Mutex::new(Vec::new());
+        let count = t.for_each_directory_result(
+            dir.path(),
+            3,
+            |_| Ok(AnalysisResult::empty(1)),
+            |path, result| {
+                lock_or_recover(&handed_over, |_| {}).push((path.to_path_buf(), result.error));
+                Ok(())
+            },
+        );
+        std::fs::set_permissions(&locked, std::fs::Permissions::from_mode(0o755)).unwrap();
+        if readable {
+            return;
+        }
+
+        let results = results.unwrap();
+        let names: Vec<_> = results
+            .iter()
+            .map(|(path, _)| path.file_name().unwrap().to_string_lossy().to_string())
+            .collect();
+        assert_eq!(names, vec!["locked", "main.go", "z.go"]);
+        let EntryType::File(locked_result) = &results[0].1;
+        let error = locked_result.error.as_deref().unwrap();
+        assert!(error.starts_with("Failed to read directory '"), "{}", error);
+
+        assert_eq!(count.unwrap(), 3);
+        let handed_over = handed_over.into_inner().unwrap();
+        assert!(
+            handed_over
+                .iter()
+                .any(|(path, error)| *path == locked && error.is_some())
+        );
+
+        // The root itself still fails the walk
+        std::fs::set_permissions(dir.path(), std::fs::Permissions::from_mode(0o000)).unwrap();
+        let root = t.collect_directory_results(dir.path(), 3, |_| Ok(AnalysisResult::empty(1)));
+        std::fs::set_permissions(dir.path(), std::fs::Permissions::from_mode(0o755)).unwrap();
+        assert!(root.is_err());
+    }
+
+    #[test]
+    fn build_context_skips_excluded_go_files() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join("fs.go"), "package fs").unwrap();
+        std::fs::write(dir.path().join("fs_linux.go"), "package fs").unwrap();
+        std::fs::write(dir.path().join("fs_windows.go"), "package fs").unwrap();
+        std::fs::write(
+            dir.path().join("extra.go"),
+            "//go:build integration\n\npackage fs",
+        )
+        .unwrap();
+
+        let t = FileTraverser::new().build_context(Some(BuildContext {
+            goos: "linux".into(),
+            goarch: "amd64".into(),
+            tags: vec![],
+        }));
+        let names: Vec<String> = t
+            .walk(dir.path(), 3)
+            .unwrap()
+            .iter()
+            .filter_map(|p| p.file_name().map(|n| n.to_string_lossy().to_string()))
+            .collect();
+        assert_eq!(names, vec!["fs.go", "fs_linux.go"]);
+
+        let skipped: Vec<(String, String)> = t
+            .skipped_files()
+            .into_iter()
+            .map(|s| {
+                (
+                    s.path.file_name().unwrap().to_string_lossy().to_string(),
+                    s.reason,
+                )
+            })
+            .collect();
+        assert_eq!(
+            skipped,
+            vec![
+                ("extra.go".into(), "//go:build integration".into()),
+                ("fs_windows.go".into(), "file name requires windows".into()),
+            ]
+        );
+
+        // Each walk records its own skipped files
+        t.collect_directory_results(&dir.path().join("fs.go"), 3, |_| {
+            Ok(AnalysisResult::empty(1))
+        })
+        .unwrap();
+        assert!(t.skipped_files().is_empty());
+
+        // Without a build context every file is analyzed
+        let all = FileTraverser::new();
+        assert_eq!(all.walk(dir.path(), 3).unwrap().len(), 4);
+        assert!(all.skipped_files().is_empty());
+    }
+
+    #[test]
+    fn path_filter_selects_files_relative_to_the_root() {
+        let dir = tempfile::tempdir().unwrap();
+        for sub in ["pkg/store", "internal/gen"] {
+            std::fs::create_dir_all(dir.path().join(sub)).unwrap();
+        }
+        for file in [
+            "main.go",
+            "pkg/store/cache.go",
+            "pkg/store/cache_test.go",
+            "internal/gen/parser.go",
+        ] {
+            std::fs::write(dir.path().join(file), "package x").unwrap();
+        }
+
+        let filter = PathFilter::new(
+            &["**/*.go".to_string()],
+            &["**/*_test.go".to_string(), "internal/gen/**".to_string()],
+        )
+        .unwrap();
+        let files = FileTraverser::new()
+            .path_filter(filter)
+            .walk(dir.path(), 0)
+            .unwrap();
+        let relative: Vec<String> = files
+            .iter()
+            .map(|p| {
+                p.strip_prefix(dir.path())
+                    .unwrap()
+                    .to_string_lossy()
+                    .replace('\\', "/")
+            })
+            .collect();
+        assert_eq!(relative, vec!["main.go", "pkg/store/cache.go"]);
+    }
+
+    #[test]
+    fn cancelled_walk_fails_or_keeps_partial_results() {
+        let dir = tempfile::tempdir().unwrap();
+        for name in ["a.go", "b.go", "c.go"] {
+            std::fs::write(dir.path().join(name), "package main").unwrap();
+        }
+        // One worker, so files are started in path order
+        let pool = rayon::ThreadPoolBuilder::new()
+            .num_threads(1)
+            .build()
+            .unwrap();
+        let run = |keep_partial_results: bool| {
+            let token = CancelToken::new();
+            let t = FileTraverser::new()
+                .cancel_token(Some(token.clone()))
+                .keep_partial_results(keep_partial_results);
+            pool.install(|| {
+                t.collect_directory_results(dir.path(), 3, |path| {
+                    if path.ends_with("a.go") {
+                        token.cancel();
+                    }
+                    Ok(AnalysisResult::empty(1))
+                })
+            })
+        };
+
+        assert_eq!(
+            run(false).unwrap_err(),
+            "Cancelled after analyzing 1 of 3 files"
+        );
+        let results = run(true).unwrap();
+        assert_eq!(results.len(), 1);
+        assert!(results[0].0.ends_with("a.go"));
+    }
+
     #[test]
     fn default_traverser() {
-        let _t = FileTraverser;
+        let _t = FileTraverser::default();
     }
 }
@@ -3,8 +3,11 @@
 // SPDX-License-Identifier: Apache-2.0
 
 use serde::{Deserialize, Serialize};
+use std::collections::HashSet;
 use std::path::PathBuf;
 
+use super::checks::ignore::IGNORE_ALL;
+
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct AnalysisResult {
     pub functions: Vec<FunctionInfo>,
@@ -17,13 +20,330 @@ pub struct AnalysisResult {
     pub line_count: usize,
     pub import_count: usize,
     pub main_line: Option<usize>,
+    /// Identifiers used anywhere in the file other than as a declaration name
+    #[serde(default)]
+    pub referenced_names: HashSet<String>,
+    /// Why the file could not be analyzed, if it failed
+    #[serde(default)]
+    pub error: Option<String>,
+    /// Lines holding at least one non-comment token
+    #[serde(default)]
+    pub code_lines: usize,
+    /// Local variables hiding a declaration of an enclosing scope
+    #[serde(default)]
+    pub shadowed: Vec<ShadowInfo>,
+    /// Every comment in the file, in source order
+    #[serde(default)]
+    pub comments: Vec<CommentInfo>,
+    /// Struct fields read or written, for languages that record them
+    #[serde(default)]
+    pub field_accesses: Vec<FieldAccess>,
+    /// Empty interface types, for languages that record them
+    #[serde(default)]
+    pub empty_interfaces: Vec<EmptyInterface>,
+    /// `package` clause, for languages that declare one per file
+    #[serde(default)]
+    pub package: Option<PackageClause>,
 }
 
-#[derive(Debug, Clone, Serialize, Deserialize)]
+/// The `package` clause of a Go file
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct PackageClause {
+    pub name: String,
+    /// 1-based line of the `package` keyword
+    pub line: usize,
+}
+
+/// A local declaration that hides a variable of the same name declared in
+/// an enclosing scope of the same function
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ShadowInfo {
+    pub name: String,
+    /// 1-based position of the shadowing identifier
+    pub line: usize,
+    pub column: usize,
+    /// 1-based position of the declaration it hides
+    pub shadowed_line: usize,
+    pub shadowed_column: usize,
+    /// Whether either declaration is a `for` or `range` loop variable
+    pub loop_variable: bool,
+}
+
+/// A struct field named by a selector, such as `g.name`, or by a composite
+/// literal key, such as `Greeter{name: "x"}`
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct FieldAccess {
+    /// `None` for an unkeyed composite literal, which sets every field
+    pub field: Option<String>,
+    /// Struct the access resolves to, when the analyzer can tell without
+    /// type information; `None` may be any struct with such a field
+    pub type_name: Option<String>,
+    pub line: usize,
+}
+
+/// An empty interface type, `interface{}` or `any`, outside a type
+/// parameter constraint
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct EmptyInterface {
+    pub usage: EmptyInterfaceUsage,
+    /// Function, method or type declaring it, or the first name of a
+    /// package-level `var` or `const`; empty when there is none
+    pub declaration: String,
+    /// 1-based position of the type
+    pub line: usize,
+    pub column: usize,
+}
+
+/// Where an empty interface type appears
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum EmptyInterfaceUsage {
+    /// Type of a function or method parameter
+    Parameter,
+    /// Type of a function or method result
+    Result,
+    /// Type of a struct field
+    Field,
+    /// Key or element type of a map, slice, array or channel
+    Element,
+    /// Type asserted by `x.(any)` or matched by a type switch case
+    Assertion,
+    /// Anywhere else, such as a variable type or a type definition
+    Other,
+}
+
+impl EmptyInterfaceUsage {
+    pub fn as_str(&self) -> &str {
+        match self {
+            EmptyInterfaceUsage::Parameter => "parameter",
+            EmptyInterfaceUsage::Result => "result",
+            EmptyInterfaceUsage::Field => "field",
+            EmptyInterfaceUsage::Element => "element",
+            EmptyInterfaceUsage::Assertion => "assertion",
+            EmptyInterfaceUsage::Other => "other",
+        }
+    }
+}
+
+/// A comment as written, including its markers (`//`, `#`, `/* */`)
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct CommentInfo {
+    /// 1-based line the comment starts on
+    pub line: usize,
+    pub text: String,
+}
+
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
 pub struct FunctionInfo {
     pub name: String,
     pub line: usize,
-    pub params: Vec<String>,
+    pub end_line: usize,
+    /// Type the function is declared on, e.g. `*Greeter` for a Go method
+    pub receiver: Option<String>,
+    /// Identifier the receiver is bound to, e.g. `g` in `func (g *Greeter)` or `self`
+    pub receiver_name: Option<String>,
+    /// Whether the body refers to `receiver_name`
+    pub receiver_used: bool,
+    /// Declared parameters, excluding the receiver
+    pub params: Vec<ParamInfo>,
+    /// Declared results; unnamed unless the language names them
+    pub returns: Vec<ParamInfo>,
+    /// Cyclomatic complexity: 1 plus the number of decision points
+    pub complexity: usize,
+    /// Cognitive complexity: flow breaks weighted by how deeply they are nested
+    #[serde(default)]
+    pub cognitive_complexity: usize,
+    /// Deepest nesting of control flow structures; 0 for straight-line code
+    #[serde(default)]
+    pub max_nesting_depth: usize,
+    /// Non-blank, non-comment lines in the function body
+    pub lines_of_code: usize,
+    /// Weighted sum of the metrics above, see `HotspotWeights`; higher needs
+    /// attention sooner
+    #[serde(default)]
+    pub hotspot_score: f64,
+    /// Whether the function is visible outside its file or package
+    pub exported: bool,
+    /// Checks suppressed by `analyzer:ignore` comments above the declaration
+    #[serde(default)]
+    pub ignored_checks: Vec<String>,
+    /// Lines of bare `return` statements; only recorded when the results are named
+    #[serde(default)]
+    pub naked_returns: Vec<usize>,
+    /// Fingerprints of the statements in each block of the body, for clone detection
+    #[serde(default)]
+    pub statement_blocks: Vec<Vec<StatementHash>>,
+    /// Calls whose results are dropped or partly assigned to `_`
+    #[serde(default)]
+    pub discarded_calls: Vec<DiscardedCall>,
+    /// Numeric literals in the body, outside constant declarations
+    #[serde(default)]
+    pub number_literals: Vec<NumberLiteral>,
+    /// Assignments growing a string inside a loop, for languages that record them
+    #[serde(default)]
+    pub string_concats: Vec<StringConcat>,
+    /// Returns passing on an error from a call unchanged, for languages that record them
+    #[serde(default)]
+    pub error_returns: Vec<ErrorReturn>,
+    /// First statements of blocks that an earlier statement always leaves,
+    /// for languages that record them
+    #[serde(default)]
+    pub unreachable_code: Vec<UnreachableCode>,
+    /// `append` calls growing a slice without preallocated capacity inside
+    /// a loop, for languages that record them
+    #[serde(default)]
+    pub slice_appends: Vec<SliceAppend>,
+}
+
+impl FunctionInfo {
+    /// Whether an `analyzer:ignore` comment suppresses `check` for this function
+    pub fn is_ignored(&self, check: &str) -> bool {
+        self.ignored_checks
+            .iter()
+            .any(|ignored| ignored == IGNORE_ALL || ignored == check)
+    }
+}
+
+/// Fingerprint of one statement: equal for statements that differ only in
+/// identifier names
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct StatementHash {
+    pub hash: u64,
+    pub line: usize,
+    pub end_line: usize,
+}
+
+/// A call some of whose results are not kept: a call statement, a `defer` or
+/// `go` of a call, or an assignment of some of its results to `_`
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DiscardedCall {
+    /// Called function as written, e.g. `os.Remove` or `f.Close`
+    pub callee: String,
+    /// Call expression as written, up to the end of its first line
+    pub call: String,
+    /// 1-based position of the call
+    pub line: usize,
+    pub column: usize,
+    /// Whether the call is deferred
+    pub deferred: bool,
+    /// Results assigned to `_`, by position; `None` when every result is dropped
+    pub blank_results: Option<Vec<usize>>,
+    /// Number of values the call's results are assigned to, 0 when dropped
+    pub assigned: usize,
+}
+
+/// A numeric literal as written in a function body
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct NumberLiteral {
+    /// Literal text, with the sign of a unary minus in front, e.g. `-2` or `0x1F`
+    pub value: String,
+    /// 1-based position of the literal
+    pub line: usize,
+    pub column: usize,
+    /// Whether the literal is the index of an index or subscript expression
+    pub index: bool,
+}
+
+/// An assignment that appends to a string declared outside the loop
+/// running it, as in `s += x` or `s = s + x`
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct StringConcat {
+    /// Variable being built
+    pub variable: String,
+    /// 1-based position of the assignment
+    pub line: usize,
+    pub column: usize,
+}
+
+/// A `return` handing back the error of an earlier call as is, as in
+/// `return err` or `return nil, err`
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ErrorReturn {
+    /// Returned variable
+    pub variable: String,
+    /// 1-based position of the `return` statement
+    pub line: usize,
+    pub column: usize,
+    /// Called function the error came from, as written, e.g. `os.Open`
+    pub callee: String,
+    /// 1-based line of that call
+    pub call_line: usize,
+}
+
+/// A statement that never runs because the statement before it in the
+/// same block always leaves the block, as after `return` or `panic(...)`
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct UnreachableCode {
+    /// 1-based position of the unreachable statement
+    pub line: usize,
+    pub column: usize,
+    /// What leaves the block: `return`, `goto`, `break`, `continue`, the
+    /// called function, e.g. `panic` or `os.Exit`, or the kind of statement
+    /// all of whose paths leave it: `if`, `for`, `switch` or `select`
+    pub after: String,
+    /// 1-based line of the statement leaving the block
+    pub after_line: usize,
+}
+
+/// An `append` inside a loop to a slice declared outside it without
+/// capacity, as `var s []T`, `[]T{}`, `make([]T, 0)` or `x[:0:0]`, so that
+/// the slice is reallocated as it grows
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SliceAppend {
+    /// Slice being grown
+    pub variable: String,
+    /// 1-based position of the `append` call
+    pub line: usize,
+    pub column: usize,
+    /// 1-based line of the innermost loop around the call
+    pub loop_line: usize,
+    /// Slice type from the declaration, e.g. `[]string`; empty when unknown
+    pub slice_type: String,
+    /// Capacity the loop needs when it ranges over a collection of known
+    /// length, e.g. `len(items)` or `10`; empty otherwise
+    pub capacity: String,
+    /// Whether the call appends to a zero-capacity slice expression such as
+    /// `s[:0:0]`
+    pub zero_capacity: bool,
+}
+
+/// A parameter or result of a function signature
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ParamInfo {
+    /// Identifier the value is bound to, if any
+    pub name: Option<String>,
+    /// Declared type as written, e.g. `...string` for a Go variadic parameter
+    pub type_name: Option<String>,
+}
+
+impl ParamInfo {
+    /// A parameter bound to `name`
+    pub fn named(name: &str, type_name: Option<&str>) -> Self {
+        Self {
+            name: Some(name.to_string()),
+            type_name: type_name.map(|t| t.to_string()),
+        }
+    }
+
+    /// An unnamed parameter or result, e.g. the `int` result of `func() int`
+    pub fn unnamed(type_name: &str) -> Self {
+        Self {
+            name: None,
+            type_name: Some(type_name.to_string()),
+        }
+    }
+}
+
+impl std::fmt::Display for ParamInfo {
+    /// `name type`, or whichever of the two is present
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        match (&self.name, &self.type_name) {
+            (Some(name), Some(type_name)) => write!(f, "{} {}", name, type_name),
+            (Some(name), None) => f.write_str(name),
+            (None, Some(type_name)) => f.write_str(type_name),
+            (None, None) => Ok(()),
+        }
+    }
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize)]
@@ -31,12 +351,50 @@ pub struct ClassInfo {
     pub name: String,
     pub line: usize,
     pub methods: Vec<FunctionInfo>,
+    #[serde(default)]
+    pub fields: Vec<FieldInfo>,
+    /// Whether the type is visible outside its file or package
+    #[serde(default)]
+    pub exported: bool,
+    /// Method set the type requires, if it is an interface
+    #[serde(default)]
+    pub interface: Option<InterfaceInfo>,
+}
+
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct InterfaceInfo {
+    /// Methods declared directly in the interface body
+    pub methods: Vec<FunctionInfo>,
+    /// Embedded interfaces and type constraints as written, e.g. `io.Reader`
+    pub embedded: Vec<String>,
+}
+
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct FieldInfo {
+    pub name: String,
+    pub line: usize,
+    /// Declared type as written, if the language spells one out
+    pub type_name: Option<String>,
+    /// Whether the field is visible outside its file or package
+    pub exported: bool,
+    /// Go struct tag without its quotes, e.g. `json:"name,omitempty"`
+    #[serde(default)]
+    pub tag: Option<String>,
+    /// Whether the field is embedded, named after its type
+    #[serde(default)]
+    pub embedded: bool,
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct CallInfo {
     pub caller_name: Option<String>,
     pub callee_name: String,
+    /// Expression the callee was selected from, e.g. `fmt` in `fmt.Sprintf(...)`
+    #[serde(default)]
+    pub qualifier: Option<String>,
+    /// Whether this is a plain identifier reference rather than an actual call
+    #[serde(default)]
+    pub is_reference: bool,
     pub line: usize,
     pub column: usize,
     pub context: String,
@@ -115,6 +473,22 @@ impl AnalysisResult {
             line_count,
             import_count: 0,
             main_line: None,
+            referenced_names: HashSet::new(),
+            error: None,
+            code_lines: 0,
+            shadowed: vec![],
+            comments: vec![],
+            field_accesses: vec![],
+            empty_interfaces: vec![],
+            package: None,
+        }
+    }
+
+    /// Result for a file that could not be analyzed
+    pub fn failed(error: String) -> Self {
+        Self {
+            error: Some(error),
+            ..Self::empty(0)
         }
     }
 }
@@ -136,6 +510,22 @@ mod tests {
         assert_eq!(r.class_count, 0);
         assert_eq!(r.import_count, 0);
         assert!(r.main_line.is_none());
+        assert!(r.referenced_names.is_empty());
+        assert!(r.error.is_none());
+    }
+
+    #[test]
+    fn analysis_result_failed_keeps_error() {
+        let r = AnalysisResult::failed("bad".into());
+        assert_eq!(r.error.as_deref(), Some("bad"));
+        assert_eq!(r.line_count, 0);
+    }
+
+    #[test]
+    fn param_info_displays_name_and_type() {
+        assert_eq!(ParamInfo::named("x", Some("int")).to_string(), "x int");
+        assert_eq!(ParamInfo::named("x", None).to_string(), "x");
+        assert_eq!(ParamInfo::unnamed("...string").to_string(), "...string");
     }
 
     #[test]
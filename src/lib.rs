@@ -5,4 +5,55 @@
 mod analyze;
 mod lang;
 
-pub use analyze::analyze;
+pub use analyze::api::{ApiFunction, ApiSurface, ApiType};
+pub use analyze::build::{BuildContext, SkippedFile};
+pub use analyze::cancel::CancelToken;
+pub use analyze::checks::Finding;
+pub use analyze::checks::any::EmptyInterfaceType;
+pub use analyze::checks::append::SliceAppendInLoop;
+pub use analyze::checks::clones::{CloneGroup, CloneLocation};
+pub use analyze::checks::concat::StringConcatInLoop;
+pub use analyze::checks::custom::{Check, CheckContext, ParsedFile};
+pub use analyze::checks::docs::{DocTarget, MissingDoc};
+pub use analyze::checks::errors::{IgnoredError, IgnoredErrorKind};
+pub use analyze::checks::fields::UnusedField;
+pub use analyze::checks::magic::MagicNumber;
+pub use analyze::checks::naked::NakedReturn;
+pub use analyze::checks::panics::{PanicCall, PanicContext};
+pub use analyze::checks::receiver::UnusedReceiver;
+pub use analyze::checks::receiver_kinds::MixedReceivers;
+pub use analyze::checks::shadow::ShadowedVariable;
+pub use analyze::checks::tags::DuplicateJsonTag;
+pub use analyze::checks::todo::TodoComment;
+pub use analyze::checks::unreachable::UnreachableStatement;
+pub use analyze::checks::unused::UnusedFunction;
+pub use analyze::checks::wrapping::UnwrappedError;
+pub use analyze::compare::{
+    FunctionMetrics, MetricsChange, MetricsDiff, compare_runs, qualified_name,
+};
+pub use analyze::diff::ChangedLines;
+pub use analyze::filter::PathFilter;
+pub use analyze::graph::{CallGraph, GraphEdge, GraphNode};
+pub use analyze::imports::{DependencyKind, ImportEdge, ImportGraph, ImportStyle};
+pub use analyze::metrics::{
+    ComplexityViolation, DEFAULT_LENGTH_BUCKETS, Hotspot, HotspotWeights, LengthBucket,
+    LengthViolation, NestingViolation, ParamCountViolation, format_complexity_violations,
+};
+pub use analyze::output::csv::CsvReport;
+pub use analyze::output::html::HtmlReport;
+pub use analyze::output::json::{JsonFile, JsonFunction, JsonReport, JsonSkippedFile};
+pub use analyze::output::markdown::MarkdownReport;
+pub use analyze::output::sarif::SarifLog;
+pub use analyze::output::{GroupBy, OutputFormat, SortOrder};
+pub use analyze::packages::PackageReport;
+pub use analyze::policy::{Policy, Violation, format_violations};
+pub use analyze::stats::Stats;
+pub use analyze::types::{
+    AnalysisResult, ClassInfo, CommentInfo, DiscardedCall, EmptyInterface, EmptyInterfaceUsage,
+    ErrorReturn, FieldAccess, FieldInfo, FunctionInfo, NumberLiteral, PackageClause, ParamInfo,
+    SliceAppend, StringConcat, UnreachableCode,
+};
+pub use analyze::{
+    AnalysisOutput, AnalyzeOptions, JsonLinesSummary, analyze, analyze_packages, analyze_source,
+    analyze_with_options, check_source, write_json_lines,
+};
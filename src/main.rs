@@ -3,6 +3,12 @@
 // SPDX-License-Identifier: Apache-2.0
 
 use clap::Parser;
+use std::io::Read;
+
+use code_analyze::{
+    AnalyzeOptions, BuildContext, CancelToken, ChangedLines, GroupBy, HotspotWeights, JsonReport,
+    OutputFormat, SortOrder,
+};
 
 /// Analyze code structure and relationships using tree-sitter parsing.
 ///
@@ -18,7 +24,7 @@ use clap::Parser;
 #[derive(Parser)]
 #[command(
     name = "analyze",
-    override_usage = "analyze [-f SYMBOL] [-d DEPTH] [-m DEPTH] [--ast-recursion-limit N] <PATH>"
+    override_usage = "analyze [-f SYMBOL] [-d DEPTH] [-m DEPTH] [--ast-recursion-limit N] [--format FORMAT] <PATH>"
 )]
 struct Args {
     /// File or directory path to analyze
@@ -39,6 +45,273 @@ struct Args {
     /// Maximum depth for recursive AST traversal (prevents stack overflow in deeply nested code)
     #[arg(long)]
     ast_recursion_limit: Option<usize>,
+
+    /// Output format: text, json, jsonl, dot, sarif, markdown, html or csv (only text is available with --focus)
+    #[arg(long, default_value_t = OutputFormat::Text)]
+    format: OutputFormat,
+
+    /// Order functions within each file: line, complexity, cognitive or hotspot (highest first)
+    #[arg(long, default_value_t = SortOrder::Line)]
+    sort: SortOrder,
+
+    /// Group findings in text and Markdown output by check (one section per check) or by file
+    #[arg(long, default_value_t = GroupBy::Check)]
+    group_by: GroupBy,
+
+    /// Exit with status 1 if any function's cyclomatic complexity exceeds N
+    #[arg(long, value_name = "N")]
+    max_complexity: Option<usize>,
+
+    /// Exit with status 1 if any function has more than N lines of code
+    #[arg(long, value_name = "N")]
+    max_function_loc: Option<usize>,
+
+    /// Exit with status 1 if any function has more than N parameters (5 if N is omitted)
+    #[arg(
+        long,
+        value_name = "N",
+        num_args = 0..=1,
+        require_equals = true,
+        default_missing_value = "5"
+    )]
+    max_params: Option<usize>,
+
+    /// Exit with status 1 if any function nests if/for/switch/select more than N levels deep
+    #[arg(long, value_name = "N")]
+    max_nesting: Option<usize>,
+
+    /// Comma-separated upper bounds of the function length buckets in JSON and Markdown output
+    #[arg(
+        long,
+        value_name = "LIST",
+        value_delimiter = ',',
+        default_value = "10,25,50"
+    )]
+    length_buckets: Vec<usize>,
+
+    /// List the N functions with the highest hotspot score
+    #[arg(long, value_name = "N")]
+    hotspots: Option<usize>,
+
+    /// Weights of the hotspot score, as comma-separated name=weight pairs
+    #[arg(
+        long,
+        value_name = "LIST",
+        default_value = "complexity=1,cognitive=1,nesting=2,loc=0.1"
+    )]
+    hotspot_weights: HotspotWeights,
+
+    /// List unexported functions that are never referenced in the analyzed files
+    #[arg(long)]
+    unused: bool,
+
+    /// Exit with status 1 if any unexported function is never referenced
+    #[arg(long)]
+    fail_on_unused: bool,
+
+    /// List methods whose body never uses the receiver
+    #[arg(long)]
+    unused_receivers: bool,
+
+    /// List struct fields that encode to the same JSON key
+    #[arg(long)]
+    duplicate_tags: bool,
+
+    /// List local variables that shadow a declaration of an enclosing scope (Go)
+    #[arg(long)]
+    shadow: bool,
+
+    /// With --shadow, leave out `err` and single-letter loop variables
+    #[arg(long)]
+    shadow_skip_common: bool,
+
+    /// List bare returns in functions with named results (Go)
+    #[arg(long)]
+    naked_returns: bool,
+
+    /// With --naked-returns, allow them in functions of at most N lines of code
+    #[arg(long, value_name = "N", default_value_t = 30)]
+    naked_returns_max_loc: usize,
+
+    /// List comments marking deferred work (TODO, FIXME, HACK)
+    #[arg(long)]
+    todos: bool,
+
+    /// With --todos, comma-separated markers to look for (case-insensitive)
+    #[arg(
+        long,
+        value_name = "LIST",
+        value_delimiter = ',',
+        default_value = "TODO,FIXME,HACK"
+    )]
+    todo_markers: Vec<String>,
+
+    /// List statement sequences duplicated within or across functions
+    #[arg(long)]
+    clones: bool,
+
+    /// With --clones, report only sequences of at least N statements
+    #[arg(long, value_name = "N", default_value_t = 5)]
+    clones_min_statements: usize,
+
+    /// List Go calls whose error result is dropped or assigned to `_`
+    #[arg(long)]
+    ignored_errors: bool,
+
+    /// With --ignored-errors, leave out deferred calls such as `defer f.Close()`
+    #[arg(long)]
+    ignored_errors_skip_defer: bool,
+
+    /// List numeric literals in function bodies that should be named constants
+    #[arg(long)]
+    magic_numbers: bool,
+
+    /// With --magic-numbers, comma-separated values to allow besides 0, 1 and -1
+    #[arg(long, value_name = "LIST", value_delimiter = ',')]
+    magic_numbers_allow: Vec<String>,
+
+    /// With --magic-numbers, leave out literals used as an index, such as `xs[2]`
+    #[arg(long)]
+    magic_numbers_ignore_index: bool,
+
+    /// List Go `panic` calls in library code, outside init, main and tests
+    #[arg(long)]
+    panics: bool,
+
+    /// With --panics, also list log.Fatal, log.Fatalf and log.Fatalln calls
+    #[arg(long)]
+    panics_fatal: bool,
+
+    /// With --panics, also list calls in init, main and test files
+    #[arg(long)]
+    panics_all: bool,
+
+    /// List Go types with methods on both value and pointer receivers
+    #[arg(long)]
+    mixed_receivers: bool,
+
+    /// List Go struct fields that their package never reads or writes (unexported only)
+    #[arg(long)]
+    unused_fields: bool,
+
+    /// With --unused-fields, also list exported fields, for packages not imported elsewhere
+    #[arg(long)]
+    unused_fields_exported: bool,
+
+    /// List Go strings built with += or + inside loops, where strings.Builder is faster
+    #[arg(long)]
+    string_concat: bool,
+
+    /// List Go `return err` statements passing on a call's error without wrapping it
+    #[arg(long)]
+    unwrapped_errors: bool,
+
+    /// List Go interface{} and any types by usage, with their total
+    #[arg(long)]
+    empty_interfaces: bool,
+
+    /// List exported Go declarations and packages whose doc comment is missing or does not start with their name
+    #[arg(long)]
+    missing_docs: bool,
+
+    /// With --missing-docs, accept any doc comment, not only one starting with the name
+    #[arg(long)]
+    missing_docs_any_text: bool,
+
+    /// List Go statements that can never run, after a return, panic, break or similar
+    #[arg(long)]
+    unreachable: bool,
+
+    /// List Go slices grown by append in a loop without preallocated capacity
+    #[arg(long)]
+    slice_appends: bool,
+
+    /// Also descend into hidden, vendor, testdata and build output directories
+    #[arg(long)]
+    include_skipped: bool,
+
+    /// Skip Go files whose build constraints exclude OS (defaults to the host's when --goarch or --tags is given)
+    #[arg(long, value_name = "OS")]
+    goos: Option<String>,
+
+    /// Skip Go files whose build constraints exclude ARCH (defaults to the host's when --goos or --tags is given)
+    #[arg(long, value_name = "ARCH")]
+    goarch: Option<String>,
+
+    /// Comma-separated build tags satisfied by Go build constraints, as in `go build -tags`
+    #[arg(long, value_name = "LIST", value_delimiter = ',')]
+    tags: Vec<String>,
+
+    /// Analyze only files matching GLOB, relative to PATH; `**` matches any number of directories (repeatable)
+    #[arg(long, value_name = "GLOB")]
+    include: Vec<String>,
+
+    /// Leave out files and directories matching GLOB, even if included (repeatable)
+    #[arg(long, value_name = "GLOB")]
+    exclude: Vec<String>,
+
+    /// List only exported types, fields, methods and functions
+    #[arg(long)]
+    api: bool,
+
+    /// List the types that satisfy each interface
+    #[arg(long)]
+    implementations: bool,
+
+    /// Show which packages each package imports and any import cycles (Go); with --format dot, draw the import graph
+    #[arg(long)]
+    imports: bool,
+
+    /// Number of files parsed in parallel (0 or unset = one per CPU)
+    #[arg(short = 'j', long, value_name = "N")]
+    jobs: Option<usize>,
+
+    /// Cache parse results in DIR, keyed by file content, to skip unchanged files on later runs
+    #[arg(long, value_name = "DIR")]
+    cache_dir: Option<std::path::PathBuf>,
+
+    /// Report only functions and findings overlapping the changes in FILE, a
+    /// unified diff such as `git diff` prints ('-' reads standard input)
+    #[arg(long, value_name = "FILE")]
+    diff: Option<String>,
+
+    /// Compare function metrics with FILE, the `--format json` output of an
+    /// earlier run on the same path, e.g. of another commit
+    #[arg(long, value_name = "FILE")]
+    compare: Option<String>,
+
+    /// Stop analyzing after SECS seconds (fractions allowed) and exit with status 1
+    #[arg(long, value_name = "SECS")]
+    timeout: Option<f64>,
+
+    /// With --timeout, print the results of the files analyzed in time instead of an error
+    #[arg(long)]
+    partial: bool,
+
+    /// After the run, print to stderr how many files and megabytes were analyzed per second
+    #[arg(long)]
+    stats: bool,
+}
+
+/// Changed lines of the unified diff in `file`, or standard input for `-`
+fn read_diff(file: &str) -> Result<ChangedLines, String> {
+    let mut diff = String::new();
+    if file == "-" {
+        std::io::stdin()
+            .read_to_string(&mut diff)
+            .map_err(|e| format!("Failed to read diff from standard input: {}", e))?;
+    } else {
+        diff = std::fs::read_to_string(file)
+            .map_err(|e| format!("Failed to read diff '{}': {}", file, e))?;
+    }
+    ChangedLines::from_unified_diff(&diff)
+}
+
+/// Report of an earlier run saved with `--format json`
+fn read_baseline(file: &str) -> Result<JsonReport, String> {
+    let json = std::fs::read_to_string(file)
+        .map_err(|e| format!("Failed to read baseline '{}': {}", file, e))?;
+    JsonReport::parse(&json).map_err(|e| format!("Invalid baseline '{}': {}", file, e))
 }
 
 fn main() {
@@ -52,7 +325,7 @@ fn main() {
                 std::process::exit(0);
             }
             eprintln!(
-                "Usage: analyze [-f SYMBOL] [-d DEPTH] [-m DEPTH] [--ast-recursion-limit N] <PATH>"
+                "Usage: analyze [-f SYMBOL] [-d DEPTH] [-m DEPTH] [--ast-recursion-limit N] [--format FORMAT] <PATH>"
             );
             eprintln!("Try 'analyze --help' for more information.");
             std::process::exit(1);
@@ -63,14 +336,159 @@ fn main() {
         .to_string_lossy()
         .to_string();
 
-    let result = code_analyze::analyze(
-        &args.path,
-        args.focus.as_deref(),
-        args.follow_depth,
-        args.max_depth,
-        args.ast_recursion_limit,
-        &cwd,
-    );
+    let changed_lines = match args.diff.as_deref().map(read_diff).transpose() {
+        Ok(changed_lines) => changed_lines,
+        Err(e) => {
+            eprintln!("Analysis error: {}", e);
+            std::process::exit(1);
+        }
+    };
+
+    let baseline = match args.compare.as_deref().map(read_baseline).transpose() {
+        Ok(baseline) => baseline,
+        Err(e) => {
+            eprintln!("Analysis error: {}", e);
+            std::process::exit(1);
+        }
+    };
+
+    let cancel = match args.timeout.map(std::time::Duration::try_from_secs_f64) {
+        Some(Ok(timeout)) => Some(CancelToken::with_timeout(timeout)),
+        Some(Err(e)) => {
+            eprintln!("Analysis error: Invalid timeout: {}", e);
+            std::process::exit(1);
+        }
+        None => None,
+    };
+
+    // Without any of the options every file is analyzed, whatever its constraints
+    let build_context = (args.goos.is_some() || args.goarch.is_some() || !args.tags.is_empty())
+        .then(|| {
+            let host = BuildContext::host();
+            BuildContext {
+                goos: args.goos.clone().unwrap_or(host.goos),
+                goarch: args.goarch.clone().unwrap_or(host.goarch),
+                tags: args.tags.clone(),
+            }
+        });
+
+    let options = AnalyzeOptions {
+        focus: args.focus,
+        follow_depth: args.follow_depth,
+        max_depth: args.max_depth,
+        ast_recursion_limit: args.ast_recursion_limit,
+        format: args.format,
+        sort: args.sort,
+        group_by: args.group_by,
+        max_complexity: args.max_complexity,
+        max_function_loc: args.max_function_loc,
+        max_params: args.max_params,
+        max_nesting: args.max_nesting,
+        length_buckets: args.length_buckets.clone(),
+        hotspots: args.hotspots,
+        hotspot_weights: args.hotspot_weights,
+        fail_on_unused: args.fail_on_unused,
+        find_unused: args.unused,
+        find_unused_receivers: args.unused_receivers,
+        find_duplicate_tags: args.duplicate_tags,
+        find_shadowed: args.shadow,
+        shadow_skip_common: args.shadow_skip_common,
+        find_naked_returns: args.naked_returns,
+        naked_return_max_loc: args.naked_returns_max_loc,
+        find_todos: args.todos,
+        todo_markers: args.todo_markers,
+        find_clones: args.clones,
+        clone_min_statements: args.clones_min_statements,
+        find_ignored_errors: args.ignored_errors,
+        ignored_errors_skip_deferred: args.ignored_errors_skip_defer,
+        find_magic_numbers: args.magic_numbers,
+        magic_numbers_allowed: args.magic_numbers_allow,
+        magic_numbers_ignore_indices: args.magic_numbers_ignore_index,
+        find_panics: args.panics,
+        panics_include_fatal: args.panics_fatal,
+        panics_include_acceptable: args.panics_all,
+        find_mixed_receivers: args.mixed_receivers,
+        find_unused_fields: args.unused_fields,
+        unused_fields_include_exported: args.unused_fields_exported,
+        find_string_concats: args.string_concat,
+        find_unwrapped_errors: args.unwrapped_errors,
+        find_empty_interfaces: args.empty_interfaces,
+        find_missing_docs: args.missing_docs,
+        missing_docs_any_text: args.missing_docs_any_text,
+        find_unreachable: args.unreachable,
+        find_slice_appends: args.slice_appends,
+        include_skipped_dirs: args.include_skipped,
+        build_context,
+        include: args.include.clone(),
+        exclude: args.exclude.clone(),
+        api: args.api,
+        find_implementations: args.implementations,
+        import_graph: args.imports,
+        jobs: args.jobs,
+        cache_dir: args.cache_dir,
+        changed_lines,
+        baseline,
+        checks: vec![],
+        cancel,
+        keep_partial_results: args.partial,
+        stats: args.stats,
+    };
+
+    if options.format == OutputFormat::JsonLines {
+        let summary = match code_analyze::write_json_lines(
+            &args.path,
+            &options,
+            &cwd,
+            &mut std::io::stdout(),
+        ) {
+            Ok(summary) => summary,
+            Err(e) => {
+                eprintln!("Analysis error: {}", e);
+                std::process::exit(1);
+            }
+        };
+        if let Some(stats) = &summary.stats {
+            eprintln!("{}", stats);
+        }
+        if options
+            .cancel
+            .as_ref()
+            .is_some_and(CancelToken::is_cancelled)
+        {
+            eprintln!("Analysis error: Timed out; results cover only the files analyzed in time");
+            std::process::exit(1);
+        }
+        if !summary.passed() {
+            let base = std::path::Path::new(&cwd);
+            eprint!(
+                "{}",
+                code_analyze::format_violations(base, &summary.violations)
+            );
+            std::process::exit(1);
+        }
+        return;
+    }
+
+    let result = code_analyze::analyze_with_options(&args.path, &options, &cwd);
+
+    print!("{}", result.output);
+    if let Some(stats) = &result.stats {
+        eprintln!("{}", stats);
+    }
+
+    if result.cancelled {
+        if args.partial {
+            eprintln!("Analysis error: Timed out; results cover only the files analyzed in time");
+        }
+        std::process::exit(1);
+    }
 
-    print!("{}", result);
+    if !result.passed() {
+        let base = std::path::Path::new(&cwd);
+        eprint!(
+            "{}",
+            code_analyze::format_violations(base, &result.violations)
+        );
+        std::process::exit(1);
+    }
 }
@@ -72,6 +72,1761 @@ fn analyze_go_file() {
     assert!(out.contains("Greeter"), "expected 'Greeter' struct:\n{out}");
 }
 
+#[test]
+fn analyze_go_file_as_json() {
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        ..Default::default()
+    };
+    let out = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd()).output;
+    let json: serde_json::Value = serde_json::from_str(&out).expect("valid JSON");
+    let file = &json["files"][0];
+    assert_eq!(file["path"], "sample.go", "unexpected path:\n{out}");
+
+    let functions = file["functions"].as_array().expect("functions array");
+    let greet = functions
+        .iter()
+        .find(|f| f["name"] == "Greet")
+        .expect("Greet function");
+    assert_eq!(greet["receiver"], "*Greeter");
+    assert_eq!(greet["param_count"], 0);
+    assert_eq!(greet["return_count"], 1);
+    assert_eq!(greet["start_line"], 9);
+    assert_eq!(greet["end_line"], 11);
+
+    let helper = functions
+        .iter()
+        .find(|f| f["name"] == "helper")
+        .expect("helper function");
+    assert!(helper["receiver"].is_null());
+    assert_eq!(helper["param_count"], 1);
+    assert_eq!(helper["params"][0]["name"], "x");
+    assert_eq!(helper["params"][0]["type"], "int");
+    assert!(helper["returns"][0]["name"].is_null());
+    assert_eq!(helper["returns"][0]["type"], "int");
+
+    let main = functions
+        .iter()
+        .find(|f| f["name"] == "main")
+        .expect("main function");
+    assert_eq!(main["lines_of_code"], 5);
+    assert_eq!(file["code_lines"], 19);
+    assert_eq!(json["lines_of_code"], 19);
+}
+
+#[test]
+fn analyze_directory_as_json() {
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        ..Default::default()
+    };
+    let out =
+        code_analyze::analyze_with_options(&fixtures_dir().to_string_lossy(), &options, &cwd())
+            .output;
+    let json: serde_json::Value = serde_json::from_str(&out).expect("valid JSON");
+    assert_eq!(json["files"].as_array().map(|f| f.len()), Some(4));
+}
+
+#[test]
+fn max_complexity_passes_for_simple_code() {
+    let options = code_analyze::AnalyzeOptions {
+        max_complexity: Some(1),
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    assert!(
+        result.passed(),
+        "unexpected violations: {:?}",
+        result.complexity_violations
+    );
+    assert!(
+        result.output.contains("FILE:"),
+        "expected normal output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn max_complexity_reports_branchy_functions() {
+    let dir = tempfile::tempdir().unwrap();
+    let file = dir.path().join("branchy.go");
+    std::fs::write(
+        &file,
+        "package main\n\nfunc branchy(a, b bool) int {\n\tif a && b {\n\t\treturn 1\n\t}\n\treturn 0\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        max_complexity: Some(2),
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&file.to_string_lossy(), &options, &cwd());
+    assert!(!result.passed());
+    assert_eq!(result.complexity_violations.len(), 1);
+    assert_eq!(result.complexity_violations[0].function.name, "branchy");
+    assert_eq!(result.complexity_violations[0].function.complexity, 3);
+}
+
+#[test]
+fn policy_fails_on_long_and_unused_functions() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc main() {\n\ta := 1\n\tb := a + 1\n\tc := b + 1\n\tprintln(c)\n}\n\nfunc dead() {}\n",
+    )
+    .unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+
+    // Findings alone never fail the run
+    let options = code_analyze::AnalyzeOptions {
+        find_unused: true,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert_eq!(result.unused_functions.len(), 1);
+    assert!(result.passed());
+
+    let options = code_analyze::AnalyzeOptions {
+        max_function_loc: Some(3),
+        fail_on_unused: true,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(!result.passed());
+    assert_eq!(
+        code_analyze::format_violations(dir.path(), &result.violations),
+        "main.go:3: main has 4 lines of code (max 3)\nmain.go:10: dead is never referenced\n"
+    );
+}
+
+#[test]
+fn unused_reports_dead_helpers() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc main() {\n\trun(callback)\n}\n\nfunc run(f func()) { f() }\n\nfunc callback() {}\n\nfunc dead() {}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_unused: true,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let names: Vec<&str> = result
+        .unused_functions
+        .iter()
+        .map(|u| u.function.name.as_str())
+        .collect();
+    assert_eq!(names, vec!["dead"], "output:\n{}", result.output);
+    assert!(
+        result.output.contains("UNUSED:"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn analyze_go_file_as_dot_call_graph() {
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Dot,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    let graph = result.call_graph.expect("call graph");
+    let external: Vec<_> = graph
+        .nodes()
+        .filter(|node| node.external)
+        .map(|node| node.name.as_str())
+        .collect();
+    assert_eq!(external, vec!["fmt.Println", "fmt.Sprintf"]);
+
+    let dot = result.output;
+    assert!(dot.starts_with("digraph calls {"), "output:\n{}", dot);
+    assert!(dot.contains("\"main\" -> \"Greet\";"), "output:\n{}", dot);
+    assert!(dot.contains("\"main\" -> \"helper\";"), "output:\n{}", dot);
+    assert!(
+        dot.contains("\"Greet\" -> \"fmt.Sprintf\";"),
+        "output:\n{}",
+        dot
+    );
+    assert!(
+        dot.contains("\"fmt.Sprintf\" [label=\"fmt.Sprintf\", shape=box, style=dashed];"),
+        "output:\n{}",
+        dot
+    );
+}
+
+#[test]
+fn api_lists_only_exported_identifiers() {
+    let options = code_analyze::AnalyzeOptions {
+        api: true,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    assert_eq!(
+        result.output,
+        "API:\ntype Greeter\n  field Name string\n  method Greet() string\n"
+    );
+
+    let api = result.api.expect("api surface");
+    assert_eq!(api.types.len(), 1);
+    assert!(api.functions.is_empty());
+}
+
+#[test]
+fn unused_receivers_reports_methods_ignoring_receiver() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("greeter.go"),
+        "package greet\n\ntype Greeter struct{ Name string }\n\nfunc (g *Greeter) Greet() string {\n\treturn \"hi\"\n}\n\nfunc (g *Greeter) Hello() string {\n\treturn g.Name\n}\n\nfunc (_ *Greeter) Noop() {}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_unused_receivers: true,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let names: Vec<&str> = result
+        .unused_receivers
+        .iter()
+        .map(|u| u.function.name.as_str())
+        .collect();
+    assert_eq!(names, vec!["Greet"], "output:\n{}", result.output);
+    assert!(
+        result
+            .output
+            .contains("UNUSED RECEIVERS:\n  greeter.go:5 Greet (g *Greeter)"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn imports_record_sample_stdlib_dependency() {
+    let options = code_analyze::AnalyzeOptions {
+        import_graph: true,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    let graph = result.import_graph.expect("import graph");
+    let edges: Vec<_> = graph.edges().collect();
+    assert_eq!(edges.len(), 1, "output:\n{}", result.output);
+    assert_eq!(edges[0].path, "fmt");
+    assert_eq!(edges[0].kind, code_analyze::DependencyKind::Stdlib);
+    assert!(result.output.contains("IMPORTS:\n  .\n    fmt (stdlib)\n"));
+}
+
+#[test]
+fn imports_report_cycles_across_packages() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(dir.path().join("go.mod"), "module example.com/app\n").unwrap();
+    for (package, imports) in [
+        ("a", "\"example.com/app/b\""),
+        ("b", "_ \"example.com/app/a\""),
+    ] {
+        std::fs::create_dir(dir.path().join(package)).unwrap();
+        std::fs::write(
+            dir.path().join(package).join(format!("{package}.go")),
+            format!("package {package}\n\nimport {imports}\n"),
+        )
+        .unwrap();
+    }
+
+    let options = code_analyze::AnalyzeOptions {
+        import_graph: true,
+        format: code_analyze::OutputFormat::Dot,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let graph = result.import_graph.expect("import graph");
+    assert_eq!(
+        graph.find_cycles(),
+        vec![vec![
+            "example.com/app/a".to_string(),
+            "example.com/app/b".to_string()
+        ]]
+    );
+    assert!(
+        result.output.starts_with("digraph imports {\n"),
+        "output:\n{}",
+        result.output
+    );
+    assert!(
+        result.output.contains(
+            "\"example.com/app/b\" -> \"example.com/app/a\" [label=\"_\", style=dotted];"
+        )
+    );
+}
+
+#[test]
+fn implementations_map_interfaces_to_satisfying_types() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("speaker.go"),
+        "package greet\n\ntype Speaker interface {\n\tGreet() string\n}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("greeter.go"),
+        "package greet\n\ntype Greeter struct{ Name string }\n\nfunc (g *Greeter) Greet() string {\n\treturn g.Name\n}\n\ntype Mute struct{}\n\nfunc (Mute) Greet() int {\n\treturn 0\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_implementations: true,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    assert_eq!(
+        result.implementations.get("Speaker"),
+        Some(&vec!["*Greeter".to_string()]),
+        "output:\n{}",
+        result.output
+    );
+    assert!(
+        result
+            .output
+            .contains("IMPLEMENTATIONS:\n  Speaker: *Greeter\n"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn duplicate_tags_reports_fields_sharing_a_json_key() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("greeter.go"),
+        "package greet\n\ntype Greeter struct {\n\tName  string `json:\"name\"`\n\tAlias string `json:\"name,omitempty\"`\n\tSkip  string `json:\"-\"`\n\tOther string `json:\"-\"`\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_duplicate_tags: true,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    assert_eq!(result.duplicate_tags.len(), 1, "output:\n{}", result.output);
+    assert_eq!(result.duplicate_tags[0].key, "name");
+    assert!(
+        result
+            .output
+            .contains("DUPLICATE JSON TAGS:\n  greeter.go:4 Greeter \"name\": Name, Alias\n"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn shadow_reports_inner_declarations_hiding_outer_ones() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc run(items []string) error {\n\terr := check()\n\tfor i := range items {\n\t\titems := items[i:]\n\t\tif err := use(items); err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n\treturn err\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_shadowed: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let names: Vec<&str> = result
+        .shadowed
+        .iter()
+        .map(|s| s.shadow.name.as_str())
+        .collect();
+    assert_eq!(names, vec!["items", "err"], "output:\n{}", result.output);
+    assert!(
+        result
+            .output
+            .contains("SHADOWED:\n  main.go:6:3 items shadows declaration at 3:10\n"),
+        "output:\n{}",
+        result.output
+    );
+
+    let options = code_analyze::AnalyzeOptions {
+        shadow_skip_common: true,
+        ..options
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert_eq!(result.shadowed.len(), 1, "output:\n{}", result.output);
+    assert_eq!(result.shadowed[0].shadow.name, "items");
+}
+
+#[test]
+fn naked_returns_are_reported_for_long_functions_only() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("split.go"),
+        "package main\n\nfunc split(sum int) (x, y int) {\n\tx = sum * 4 / 9\n\ty = sum - x\n\treturn\n}\n\nfunc short() (n int) {\n\treturn\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_naked_returns: true,
+        naked_return_max_loc: 2,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let lines: Vec<(&str, usize)> = result
+        .naked_returns
+        .iter()
+        .map(|n| (n.function.name.as_str(), n.line))
+        .collect();
+    assert_eq!(lines, vec![("split", 6)], "output:\n{}", result.output);
+    assert!(
+        result
+            .output
+            .contains("NAKED RETURNS:\n  split.go:6 split (declared at line 3)\n"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn todo_comments_are_reported_but_not_strings() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\n// TODO: read from flags\nfunc main() {\n\tprintln(\"// FIXME: not a comment\")\n\t/* hack around\n\t   Fixme: the race */\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_todos: true,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let todos: Vec<(usize, &str, &str)> = result
+        .todos
+        .iter()
+        .map(|t| (t.line, t.marker.as_str(), t.text.as_str()))
+        .collect();
+    assert_eq!(
+        todos,
+        vec![
+            (3, "TODO", "read from flags"),
+            (6, "HACK", "around"),
+            (7, "FIXME", "the race"),
+        ],
+        "output:\n{}",
+        result.output
+    );
+    assert!(
+        result
+            .output
+            .contains("TODO:\n  main.go:3 TODO read from flags\n"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn renamed_statement_sequences_are_reported_as_clones() {
+    let dir = tempfile::tempdir().unwrap();
+    let body = |name: &str, factor: &str| {
+        format!(
+            "func {name}(xs []int) int {{\n\ttotal := 0\n\tfor _, x := range xs {{\n\t\ttotal += x\n\t}}\n\tif total > 100 {{\n\t\ttotal = 100\n\t}}\n\tlog.Println(\"result\", total)\n\treturn total * {factor}\n}}\n\n"
+        )
+    };
+    std::fs::write(
+        dir.path().join("sum.go"),
+        format!(
+            "package main\n\nimport \"log\"\n\n{}{}",
+            body("sum", "2"),
+            body("double", "2").replace("total", "acc")
+        ),
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("scale.go"),
+        format!("package main\n\nimport \"log\"\n\n{}", body("scale", "3")),
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_clones: true,
+        clone_min_statements: 4,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let groups: Vec<(usize, Vec<&str>)> = result
+        .clones
+        .iter()
+        .map(|g| {
+            let names = g.locations.iter().map(|l| l.function.as_str()).collect();
+            (g.statements, names)
+        })
+        .collect();
+    // scale differs only in its return literal, so it shares four statements
+    assert_eq!(
+        groups,
+        vec![
+            (4, vec!["scale", "sum", "double"]),
+            (5, vec!["sum", "double"])
+        ],
+        "output:\n{}",
+        result.output
+    );
+    assert!(
+        result.output.contains("DUPLICATES:\n"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn dropped_errors_are_reported_with_their_call() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nimport \"os\"\n\nfunc save(path string) error {\n\treturn os.WriteFile(path, nil, 0o644)\n}\n\nfunc main() {\n\tf, _ := os.Open(\"in.txt\")\n\tdefer f.Close()\n\tsave(\"out.txt\")\n\tif err := save(\"log.txt\"); err != nil {\n\t\tpanic(err)\n\t}\n}\n",
+    )
+    .unwrap();
+
+    let mut options = code_analyze::AnalyzeOptions {
+        find_ignored_errors: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let ignored: Vec<(usize, &str, &str)> = result
+        .ignored_errors
+        .iter()
+        .map(|e| (e.call.line, e.call.call.as_str(), e.kind.as_str()))
+        .collect();
+    assert_eq!(
+        ignored,
+        vec![
+            (10, "os.Open(\"in.txt\")", "blank"),
+            (11, "f.Close()", "deferred"),
+            (12, "save(\"out.txt\")", "unchecked"),
+        ],
+        "output:\n{}",
+        result.output
+    );
+    assert!(
+        result
+            .output
+            .contains("IGNORED ERRORS:\n  main.go:10:10 os.Open(\"in.txt\") (blank)\n"),
+        "output:\n{}",
+        result.output
+    );
+
+    options.ignored_errors_skip_deferred = true;
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert_eq!(result.ignored_errors.len(), 2, "output:\n{}", result.output);
+}
+
+#[test]
+fn magic_numbers_in_sample_are_reported() {
+    let mut options = code_analyze::AnalyzeOptions {
+        find_magic_numbers: true,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    let magic: Vec<(&str, usize, usize, &str)> = result
+        .magic_numbers
+        .iter()
+        .map(|m| {
+            (
+                m.function.as_str(),
+                m.literal.line,
+                m.literal.column,
+                m.literal.value.as_str(),
+            )
+        })
+        .collect();
+    assert_eq!(
+        magic,
+        vec![("helper", 14, 13, "2"), ("main", 21, 19, "42")],
+        "output:\n{}",
+        result.output
+    );
+    assert!(
+        result
+            .output
+            .contains("MAGIC NUMBERS:\n  sample.go:14:13 2 in helper\n"),
+        "output:\n{}",
+        result.output
+    );
+
+    options.magic_numbers_allowed = vec!["2".into(), "42".into()];
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    assert!(
+        result.magic_numbers.is_empty(),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn panics_in_library_code_are_reported() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("lib.go"),
+        "package lib\n\nimport \"log\"\n\nfunc init() {\n\tpanic(\"setup\")\n}\n\nfunc Parse(s string) int {\n\tif s == \"\" {\n\t\tpanic(\"empty\")\n\t}\n\treturn len(s)\n}\n\nfunc load() {\n\tlog.Fatalf(\"no config\")\n}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("lib_test.go"),
+        "package lib\n\nfunc mustParse() {\n\tpanic(\"bad\")\n}\n",
+    )
+    .unwrap();
+
+    let mut options = code_analyze::AnalyzeOptions {
+        find_panics: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("PANICS:\n  lib.go:11:3 panic in Parse (exported)\n"),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.panics.len(), 1, "output:\n{}", result.output);
+
+    options.panics_include_fatal = true;
+    options.panics_include_acceptable = true;
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let panics: Vec<(&str, &str, &str)> = result
+        .panics
+        .iter()
+        .map(|p| (p.function.as_str(), p.callee.as_str(), p.context.as_str()))
+        .collect();
+    assert_eq!(
+        panics,
+        vec![
+            ("init", "panic", "init"),
+            ("Parse", "panic", "exported"),
+            ("load", "log.Fatalf", "unexported"),
+            ("mustParse", "panic", "test"),
+        ],
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn mixed_receivers_are_reported_at_the_type_declaration() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("greeter.go"),
+        "package greet\n\ntype Greeter struct {\n\tname string\n}\n\nfunc (g Greeter) Name() string {\n\treturn g.name\n}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("setters.go"),
+        "package greet\n\nfunc (g *Greeter) SetName(name string) {\n\tg.name = name\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_mixed_receivers: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("MIXED RECEIVERS:\n  greeter.go:3 Greeter: value Name; pointer SetName\n"),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.mixed_receivers.len(), 1);
+}
+
+#[test]
+fn unused_fields_are_reported_per_package() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("greeter.go"),
+        "package greet\n\ntype Greeter struct {\n\tName  string\n\tcount int\n\tcache map[string]string\n}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("greet.go"),
+        "package greet\n\nfunc (g *Greeter) Greet() string {\n\tg.count++\n\treturn \"Hello, \" + g.Name\n}\n",
+    )
+    .unwrap();
+
+    let mut options = code_analyze::AnalyzeOptions {
+        find_unused_fields: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("UNUSED FIELDS:\n  greeter.go:6 Greeter.cache\n"),
+        "output:\n{}",
+        result.output
+    );
+
+    options.unused_fields_include_exported = true;
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert_eq!(result.unused_fields.len(), 1, "output:\n{}", result.output);
+}
+
+#[test]
+fn string_concatenation_in_loops_is_reported() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("text.go"),
+        "package text\n\nfunc Join(words []string) string {\n\tout := \"\"\n\tfor _, w := range words {\n\t\tout += w\n\t}\n\treturn out + \"!\"\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_string_concats: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result.output.contains(
+            "STRING CONCATENATION IN LOOPS:\n  text.go:6:3 out in Join; use strings.Builder\n"
+        ),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.findings()[0].rule_id, "string-concat");
+}
+
+#[test]
+fn unwrapped_errors_name_the_originating_call() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("config.go"),
+        "package config\n\nfunc Load(path string) ([]byte, error) {\n\tdata, err := os.ReadFile(path)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn data, nil\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_unwrapped_errors: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("UNWRAPPED ERRORS:\n  config.go:6:3 err from os.ReadFile (line 4) in Load\n"),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.findings()[0].rule_id, "unwrapped-error");
+}
+
+#[test]
+fn empty_interfaces_are_totalled_by_usage() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("codec.go"),
+        "package codec\n\nfunc Decode(v any) (interface{}, error) {\n\treturn v, nil\n}\n\nfunc Keys[K comparable, V any](m map[K]V) []K {\n\treturn nil\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_empty_interfaces: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result.output.contains(
+            "EMPTY INTERFACES: 2 (1 parameter, 1 result)\n  codec.go:3:15 parameter in Decode\n  codec.go:3:21 result in Decode\n"
+        ),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.findings()[0].rule_id, "empty-interface");
+}
+
+#[test]
+fn hotspots_rank_the_worst_functions_first() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc flat() int {\n\treturn 1\n}\n\nfunc branchy(x int) int {\n\tif x > 0 {\n\t\tif x > 10 {\n\t\t\treturn 2\n\t\t}\n\t\treturn 1\n\t}\n\treturn 0\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        hotspots: Some(1),
+        sort: code_analyze::SortOrder::Hotspot,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert_eq!(result.hotspots.len(), 1);
+    let branchy = &result.hotspots[0].function;
+    assert_eq!(branchy.name, "branchy");
+    assert!(branchy.hotspot_score > 5.0, "{}", branchy.hotspot_score);
+    assert!(
+        result
+            .output
+            .contains("HOTSPOTS:\n  main.go:7 branchy score "),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn missing_docs_name_undocumented_exports() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("greet.go"),
+        "package greet\n\ntype Greeter struct{}\n\n// Greet says hello.\nfunc (g *Greeter) Greet() string {\n\treturn \"hi\"\n}\n\n// returns a farewell\nfunc Bye() string {\n\treturn \"bye\"\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_missing_docs: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result.output.contains(
+            "MISSING DOCS:\n  greet.go:1 package greet has no package comment\n  greet.go:3 type Greeter has no doc comment\n  greet.go:11 function Bye doc comment should start with \"Bye\"\n"
+        ),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.findings()[0].rule_id, "missing-doc");
+}
+
+#[test]
+fn unreachable_code_follows_terminating_statements() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc run(ok bool) int {\n\tif ok {\n\t\treturn 1\n\t} else {\n\t\tpanic(\"no\")\n\t}\n\treturn 0\n}\n\nfunc retry() {\n\tgoto again\nagain:\n\trun(true)\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_unreachable: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("UNREACHABLE CODE:\n  main.go:9:2 after if (line 4) in run\n"),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.unreachable_code.len(), 1);
+    assert_eq!(result.findings()[0].rule_id, "unreachable-code");
+}
+
+#[test]
+fn slice_appends_suggest_the_loop_capacity() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("list.go"),
+        "package list\n\nfunc collect(items []Item) []int {\n\tvar ids []int\n\tfor _, item := range items {\n\t\tids = append(ids, item.ID)\n\t}\n\treturn ids\n}\n\nfunc sized(items []Item) []int {\n\tids := make([]int, 0, len(items))\n\tfor _, item := range items {\n\t\tids = append(ids, item.ID)\n\t}\n\treturn ids\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        find_slice_appends: true,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result.output.contains(
+            "SLICE APPENDS IN LOOPS:\n  list.go:6:9 ids in collect grows in the loop at line 5; preallocate with make([]int, 0, len(items))\n"
+        ),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.slice_appends.len(), 1);
+    assert_eq!(result.findings()[0].rule_id, "slice-append");
+}
+
+#[test]
+fn group_by_file_lists_findings_under_each_file() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\n// TODO: read from flags\nfunc main() {}\n\nfunc dead() {}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("util.go"),
+        "package main\n\n// FIXME: handle errors\nfunc helper() {}\n",
+    )
+    .unwrap();
+    let options = code_analyze::AnalyzeOptions {
+        find_todos: true,
+        find_unused: true,
+        group_by: code_analyze::GroupBy::File,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+
+    assert!(
+        result.output.contains(
+            "\nFINDINGS BY FILE:\n  main.go:\n    3 todo-comment: TODO: read from flags\n    6 unused-function: dead is never referenced\n  util.go:\n    3 todo-comment: FIXME: handle errors\n    4 unused-function: helper is never referenced\n"
+        ),
+        "{}",
+        result.output
+    );
+    assert!(!result.output.contains("\nTODO:\n"), "{}", result.output);
+    assert!(!result.output.contains("\nUNUSED:\n"), "{}", result.output);
+}
+
+#[test]
+fn stats_count_the_analyzed_files() {
+    let dir = tempfile::tempdir().unwrap();
+    let main = "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() {}\n";
+    let util = "package main\n\nfunc helper() int {\n\treturn 1\n}\n";
+    std::fs::write(dir.path().join("main.go"), main).unwrap();
+    std::fs::write(dir.path().join("util.go"), util).unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+
+    let result = code_analyze::analyze_with_options(&path, &Default::default(), &cwd());
+    assert!(result.stats.is_none());
+
+    let options = code_analyze::AnalyzeOptions {
+        stats: true,
+        format: code_analyze::OutputFormat::Json,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let stats = result.stats.unwrap();
+    assert_eq!(
+        (stats.files, stats.bytes, stats.functions),
+        (2, (main.len() + util.len()) as u64, 3)
+    );
+    assert!(stats.to_string().starts_with("Analyzed 2 files ("));
+    // The output itself is unchanged
+    assert!(result.output.trim_start().starts_with('{'));
+}
+
+#[test]
+fn stats_count_the_files_of_text_and_focused_runs() {
+    let dir = tempfile::tempdir().unwrap();
+    let main = "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() {}\n";
+    let util = "package main\n\nfunc helper() int {\n\treturn 1\n}\n";
+    std::fs::write(dir.path().join("main.go"), main).unwrap();
+    std::fs::write(dir.path().join("util.go"), util).unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+
+    for focus in [None, Some("run".to_string())] {
+        let options = code_analyze::AnalyzeOptions {
+            stats: true,
+            focus: focus.clone(),
+            ..Default::default()
+        };
+        let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+        let stats = result.stats.unwrap();
+        assert_eq!((stats.files, stats.functions), (2, 3), "{:?}", focus);
+        assert!(
+            !result.output.starts_with("Analysis error"),
+            "{}",
+            result.output
+        );
+    }
+}
+
+#[test]
+fn json_lines_write_one_object_per_file() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::create_dir(dir.path().join("pkg")).unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc main() {}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("pkg/util.go"),
+        "package pkg\n\nfunc Helper() int {\n\treturn 1\n}\n",
+    )
+    .unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::JsonLines,
+        jobs: Some(2),
+        ..Default::default()
+    };
+
+    let mut streamed = Vec::new();
+    let summary = code_analyze::write_json_lines(&path, &options, &cwd(), &mut streamed).unwrap();
+    assert_eq!(summary.lines, 2);
+    assert!(summary.passed());
+    assert!(summary.stats.is_none());
+    let mut lines: Vec<String> = String::from_utf8(streamed)
+        .unwrap()
+        .lines()
+        .map(str::to_string)
+        .collect();
+    lines.sort();
+    let paths: Vec<String> = lines
+        .iter()
+        .map(|line| {
+            serde_json::from_str::<code_analyze::JsonFile>(line)
+                .unwrap()
+                .path
+        })
+        .collect();
+    assert_eq!(paths, vec!["main.go", "pkg/util.go"]);
+
+    // Buffered output has the same lines, in path order
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert_eq!(result.output.lines().collect::<Vec<_>>(), lines);
+}
+
+#[test]
+fn json_lines_evaluate_limits_and_stats_file_by_file() {
+    let dir = tempfile::tempdir().unwrap();
+    let source = "package main\n\nfunc branchy(a, b bool) int {\n\tif a {\n\t\treturn 1\n\t}\n\tif b {\n\t\treturn 2\n\t}\n\treturn 0\n}\n\nfunc flat() {}\n";
+    std::fs::write(dir.path().join("main.go"), source).unwrap();
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::JsonLines,
+        max_complexity: Some(2),
+        stats: true,
+        ..Default::default()
+    };
+
+    let mut streamed = Vec::new();
+    let summary = code_analyze::write_json_lines(
+        &dir.path().to_string_lossy(),
+        &options,
+        &cwd(),
+        &mut streamed,
+    )
+    .unwrap();
+    assert_eq!(summary.lines, 1);
+    assert!(!summary.passed());
+    let violated: Vec<(&str, usize)> = summary
+        .violations
+        .iter()
+        .map(|v| (v.rule_id, v.start_line))
+        .collect();
+    assert_eq!(violated, vec![("cyclomatic-complexity", 3)]);
+    let stats = summary.stats.unwrap();
+    assert_eq!(
+        (stats.files, stats.bytes, stats.functions),
+        (1, source.len() as u64, 2)
+    );
+}
+
+#[test]
+fn json_lines_reject_options_needing_every_file() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(dir.path().join("main.go"), "package main\n").unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+    let error = |options: code_analyze::AnalyzeOptions| {
+        let mut streamed = Vec::new();
+        let written = code_analyze::write_json_lines(&path, &options, &cwd(), &mut streamed);
+        assert!(streamed.is_empty());
+        written.unwrap_err()
+    };
+
+    assert_eq!(
+        error(code_analyze::AnalyzeOptions {
+            fail_on_unused: true,
+            ..Default::default()
+        }),
+        "JSONL output does not support failing on unused functions, which needs every file at once"
+    );
+    assert!(
+        error(code_analyze::AnalyzeOptions {
+            find_todos: true,
+            max_complexity: Some(5),
+            ..Default::default()
+        })
+        .contains("does not support checks")
+    );
+    assert!(
+        error(code_analyze::AnalyzeOptions {
+            changed_lines: Some(code_analyze::ChangedLines::default()),
+            ..Default::default()
+        })
+        .contains("does not support diff filtering")
+    );
+}
+
+#[test]
+fn json_lines_exit_non_zero_over_a_limit() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc branchy(a bool) int {\n\tif a {\n\t\treturn 1\n\t}\n\treturn 0\n}\n",
+    )
+    .unwrap();
+    let run = |limit: &str| {
+        std::process::Command::new(env!("CARGO_BIN_EXE_analyze"))
+            .args(["--format", "jsonl", "--max-complexity", limit])
+            .arg(dir.path())
+            .output()
+            .unwrap()
+    };
+
+    let failed = run("1");
+    assert_eq!(failed.status.code(), Some(1));
+    assert_eq!(String::from_utf8_lossy(&failed.stdout).lines().count(), 1);
+    assert!(
+        String::from_utf8_lossy(&failed.stderr).contains("branchy"),
+        "stderr:\n{}",
+        String::from_utf8_lossy(&failed.stderr)
+    );
+    assert!(run("5").status.success());
+}
+
+#[test]
+fn analyze_source_reads_unsaved_buffers() {
+    let result = code_analyze::analyze_source(
+        "/nonexistent/main.go",
+        "package main\n\nfunc helper() int {\n\treturn 1\n}\n\nfunc main() {\n\thelper()\n}\n",
+    )
+    .unwrap();
+    let functions: Vec<(&str, usize)> = result
+        .functions
+        .iter()
+        .map(|f| (f.name.as_str(), f.line))
+        .collect();
+    assert_eq!(functions, vec![("helper", 3), ("main", 7)]);
+    assert_eq!(result.line_count, 9);
+}
+
+#[test]
+fn analyze_packages_resolves_import_paths() {
+    let dir = tempfile::tempdir().unwrap();
+    for (file, content) in [
+        ("go.mod", "module example.com/app\n\ngo 1.22\n"),
+        ("main.go", "package main\n\nfunc main() {}\n"),
+        ("store/store.go", "package store\n\nfunc Get() {}\n"),
+        (
+            "store/store_test.go",
+            "package store\n\nfunc TestGet(t *testing.T) {}\n",
+        ),
+        ("store/_gen.go", "package store\n\nfunc gen() {}\n"),
+        (
+            "store/store_windows.go",
+            "package store\n\nfunc path() string { return `C:` }\n",
+        ),
+        (
+            "winonly/winonly.go",
+            "//go:build windows\n\npackage winonly\n",
+        ),
+    ] {
+        let path = dir.path().join(file);
+        std::fs::create_dir_all(path.parent().unwrap()).unwrap();
+        std::fs::write(path, content).unwrap();
+    }
+
+    let options = code_analyze::AnalyzeOptions {
+        build_context: Some(code_analyze::BuildContext {
+            goos: "linux".into(),
+            goarch: "amd64".into(),
+            tags: vec![],
+        }),
+        ..Default::default()
+    };
+    let cwd = dir.path().to_string_lossy().to_string();
+    let reports =
+        code_analyze::analyze_packages(&["./...", "github.com/lib/pq"], &options, &cwd).unwrap();
+
+    let loaded: Vec<(&str, usize, Option<&str>)> = reports
+        .iter()
+        .map(|r| (r.import_path.as_str(), r.files.len(), r.error.as_deref()))
+        .collect();
+    let winonly_error = format!(
+        "build constraints exclude all Go files in {}",
+        dir.path().join("winonly").display()
+    );
+    assert_eq!(
+        loaded,
+        vec![
+            ("example.com/app", 1, None),
+            ("example.com/app/store", 1, None),
+            ("example.com/app/winonly", 0, Some(winonly_error.as_str())),
+            (
+                "github.com/lib/pq",
+                0,
+                Some("package github.com/lib/pq is not in main module example.com/app")
+            ),
+        ]
+    );
+    assert_eq!(reports[1].skipped_files.len(), 1);
+
+    let outside = tempfile::tempdir().unwrap();
+    let error =
+        code_analyze::analyze_packages(&["./..."], &options, &outside.path().to_string_lossy())
+            .unwrap_err();
+    assert!(error.contains("No go.mod found"), "{}", error);
+}
+
+#[test]
+fn compare_reports_functions_changed_since_baseline() {
+    let dir = tempfile::tempdir().unwrap();
+    let file = dir.path().join("main.go");
+    std::fs::write(
+        &file,
+        "package main\n\nfunc helper(x int) int {\n\treturn x * 2\n}\n\nfunc legacy() {}\n\nfunc main() {\n\thelper(1)\n}\n",
+    )
+    .unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+    let mut options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        ..Default::default()
+    };
+    let baseline = code_analyze::analyze_with_options(&path, &options, &cwd()).output;
+
+    // `main` moves above `helper`, which gains a branch; `legacy` is gone
+    std::fs::write(
+        &file,
+        "package main\n\nfunc main() {\n\thelper(1)\n}\n\nfunc helper(x int) int {\n\tif x > 0 {\n\t\treturn x * 2\n\t}\n\treturn 0\n}\n\nfunc fresh() {}\n",
+    )
+    .unwrap();
+    options.format = code_analyze::OutputFormat::Text;
+    options.baseline = Some(code_analyze::JsonReport::parse(&baseline).unwrap());
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let diff = result.metrics_diff.as_ref().unwrap();
+    let names = |functions: &[code_analyze::FunctionMetrics]| -> Vec<String> {
+        functions.iter().map(|f| f.name.clone()).collect()
+    };
+    assert_eq!(
+        names(&diff.added),
+        vec!["fresh"],
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(
+        names(&diff.removed),
+        vec!["legacy"],
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(diff.changed.len(), 1, "output:\n{}", result.output);
+    assert_eq!(diff.changed[0].name, "helper");
+    assert_eq!(diff.changed[0].complexity_delta(), 1);
+    assert!(
+        result
+            .output
+            .contains("  changed main.go:7 helper complexity 1 -> 2 (+1), LOC 1 -> 4 (+3)\n"),
+        "output:\n{}",
+        result.output
+    );
+
+    options.format = code_analyze::OutputFormat::Markdown;
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("| removed | `legacy` | main.go:7 | 1 | 0 |\n"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+/// Reports `fmt.Println` calls, found in the syntax tree
+struct NoPrintln;
+
+impl code_analyze::Check for NoPrintln {
+    fn name(&self) -> &'static str {
+        "no-println"
+    }
+
+    fn description(&self) -> &'static str {
+        "Use the logger instead of fmt.Println"
+    }
+
+    fn run(&self, ctx: &mut code_analyze::CheckContext) {
+        for (path, _) in ctx.files() {
+            let Some(file) = ctx.parsed(path) else {
+                continue;
+            };
+            let mut stack = vec![file.tree.root_node()];
+            while let Some(node) = stack.pop() {
+                if node.kind() == "call_expression"
+                    && let Some(function) = node.child_by_field_name("function")
+                    && &file.source[function.byte_range()] == "fmt.Println"
+                {
+                    let line = node.start_position().row + 1;
+                    ctx.report(path, line, line, "fmt.Println call");
+                }
+                stack.extend((0..node.child_count() as u32).filter_map(|i| node.child(i)));
+            }
+        }
+    }
+}
+
+#[test]
+fn registered_checks_report_through_every_format() {
+    let mut options = code_analyze::AnalyzeOptions::default();
+    options.register_check(NoPrintln).unwrap();
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    let lines: Vec<(&str, usize)> = result
+        .check_findings
+        .iter()
+        .map(|f| (f.rule_id, f.start_line))
+        .collect();
+    assert_eq!(lines, vec![("no-println", 20), ("no-println", 22)]);
+    assert!(
+        result
+            .output
+            .contains("CHECKS:\n  sample.go:20 no-println: fmt.Println call\n"),
+        "output:\n{}",
+        result.output
+    );
+
+    options.format = code_analyze::OutputFormat::Sarif;
+    let sarif = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd()).output;
+    assert!(sarif.contains("\"ruleId\": \"no-println\""), "{sarif}");
+    assert!(
+        sarif.contains("Use the logger instead of fmt.Println"),
+        "{sarif}"
+    );
+}
+
+/// Goes by the rule ID of the built-in unused function check
+struct TakenName;
+
+impl code_analyze::Check for TakenName {
+    fn name(&self) -> &'static str {
+        "unused-function"
+    }
+
+    fn description(&self) -> &'static str {
+        "Clashes with a built-in check"
+    }
+
+    fn run(&self, _: &mut code_analyze::CheckContext) {}
+}
+
+#[test]
+fn check_names_taken_by_other_checks_are_rejected() {
+    let mut options = code_analyze::AnalyzeOptions::default();
+    assert_eq!(
+        options.register_check(TakenName),
+        Err("Check name 'unused-function' is taken by a built-in check".to_string())
+    );
+    options.register_check(NoPrintln).unwrap();
+    assert_eq!(
+        options.register_check(NoPrintln),
+        Err("Check name 'no-println' is registered more than once".to_string())
+    );
+    assert_eq!(options.checks.len(), 1);
+
+    options.checks.push(std::sync::Arc::new(NoPrintln));
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    assert_eq!(
+        result.output,
+        "Analysis error: Check name 'no-println' is registered more than once"
+    );
+}
+
+#[test]
+fn check_source_runs_checks_on_unsaved_buffers() {
+    let mut options = code_analyze::AnalyzeOptions {
+        find_todos: true,
+        ..Default::default()
+    };
+    options.register_check(NoPrintln).unwrap();
+    let source = "package main\n\nimport \"fmt\"\n\n// TODO: use the logger\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n";
+    let findings = code_analyze::check_source("/nonexistent/main.go", source, &options).unwrap();
+    let lines: Vec<(&str, usize)> = findings.iter().map(|f| (f.rule_id, f.start_line)).collect();
+    assert_eq!(lines, vec![("todo-comment", 5), ("no-println", 7)]);
+}
+
+#[test]
+fn diff_limits_report_to_changed_functions() {
+    let diff = "--- a/tests/fixtures/sample.go\n+++ b/tests/fixtures/sample.go\n@@ -10,3 +10,3 @@\n-\treturn \"hi\"\n+\treturn fmt.Sprintf(\"Hello, %s!\", g.Name)\n }\n-\n+\n";
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        find_unused: true,
+        changed_lines: Some(code_analyze::ChangedLines::from_unified_diff(diff).unwrap()),
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&fixtures_dir().to_string_lossy(), &options, &cwd());
+    let json: serde_json::Value = serde_json::from_str(&result.output).unwrap();
+    let files = json["files"].as_array().unwrap();
+    assert_eq!(files.len(), 1, "output:\n{}", result.output);
+    assert_eq!(files[0]["path"], "sample.go");
+    let names: Vec<&str> = files[0]["functions"]
+        .as_array()
+        .unwrap()
+        .iter()
+        .map(|f| f["name"].as_str().unwrap())
+        .collect();
+    assert_eq!(names, vec!["Greet"], "output:\n{}", result.output);
+    assert!(result.unused_functions.is_empty());
+}
+
+#[test]
+fn diff_narrows_every_check_to_the_changed_lines() {
+    let source = r#"package main
+
+import "fmt"
+
+type Config struct {
+	Name  string `json:"name"`
+	Alias string `json:"name"`
+}
+
+func changed(items []int, cfg interface{}) (total int) {
+	// TODO: cache this
+	var out []string
+	for _, item := range items {
+		out = append(out, fmt.Sprint(item))
+		total += item * 42
+		if item > 7 {
+			panic("too big")
+		}
+	}
+	fmt.Println(out)
+	return
+}
+
+func untouched(items []int, cfg interface{}) (total int) {
+	// TODO: cache this
+	var out []string
+	for _, item := range items {
+		out = append(out, fmt.Sprint(item))
+		total += item * 42
+		if item > 7 {
+			panic("too big")
+		}
+	}
+	fmt.Println(out)
+	return
+}
+"#;
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(dir.path().join("main.go"), source).unwrap();
+    let path = dir.path().to_string_lossy().to_string();
+    let mut options = code_analyze::AnalyzeOptions {
+        max_complexity: Some(1),
+        max_function_loc: Some(1),
+        max_params: Some(1),
+        max_nesting: Some(1),
+        find_unused: true,
+        find_unused_receivers: true,
+        find_duplicate_tags: true,
+        find_shadowed: true,
+        find_naked_returns: true,
+        naked_return_max_loc: 1,
+        find_todos: true,
+        find_clones: true,
+        clone_min_statements: 2,
+        find_ignored_errors: true,
+        find_magic_numbers: true,
+        find_panics: true,
+        find_mixed_receivers: true,
+        find_unused_fields: true,
+        find_string_concats: true,
+        find_unwrapped_errors: true,
+        find_empty_interfaces: true,
+        find_missing_docs: true,
+        find_unreachable: true,
+        find_slice_appends: true,
+        ..Default::default()
+    };
+    let everything = code_analyze::analyze_with_options(&path, &options, &path).findings();
+
+    // The loop body of `changed`, leaving its TODO and signature out
+    let mut changes = code_analyze::ChangedLines::new();
+    changes.add("main.go", 14, 18);
+    options.changed_lines = Some(changes);
+    let result = code_analyze::analyze_with_options(&path, &options, &path);
+    let on_change = |start: usize, end: usize| start <= 18 && 14 <= end;
+
+    let expected: Vec<code_analyze::Finding> = everything
+        .iter()
+        .filter(|f| on_change(f.start_line, f.end_line))
+        .cloned()
+        .collect();
+    assert!(
+        expected.len() < everything.len(),
+        "findings:\n{everything:#?}"
+    );
+    assert!(!expected.is_empty(), "findings:\n{everything:#?}");
+    assert_eq!(result.findings(), expected);
+
+    assert!(result.duplicate_tags.is_empty());
+    assert!(result.todos.is_empty());
+    assert!(result.empty_interfaces.is_empty());
+    assert!(
+        result
+            .magic_numbers
+            .iter()
+            .all(|m| on_change(m.literal.line, m.literal.line))
+    );
+    assert!(result.panics.iter().all(|p| on_change(p.line, p.line)));
+    assert!(
+        result
+            .slice_appends
+            .iter()
+            .all(|a| on_change(a.append.line, a.append.line))
+    );
+    assert!(
+        result
+            .complexity_violations
+            .iter()
+            .all(|v| v.function.name == "changed")
+    );
+}
+
+#[test]
+fn max_params_counts_grouped_and_variadic_parameters() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("draw.go"),
+        "package main\n\nfunc helper(x int) int {\n\treturn x\n}\n\nfunc rect(x, y, w, h int, fill bool, opts ...string) {\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        max_params: Some(5),
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    assert!(!result.passed());
+    assert_eq!(
+        code_analyze::format_violations(dir.path(), &result.violations),
+        "draw.go:7: rect has 6 parameters (max 5)\n"
+    );
+}
+
+#[test]
+fn sarif_reports_findings_with_locations() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc main() {}\n\nfunc dead() {\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Sarif,
+        find_unused: true,
+        ..Default::default()
+    };
+    let result =
+        code_analyze::analyze_with_options(&dir.path().to_string_lossy(), &options, &cwd());
+    let sarif: serde_json::Value = serde_json::from_str(&result.output).expect("valid SARIF");
+    assert_eq!(sarif["version"], "2.1.0");
+    assert_eq!(sarif["runs"][0]["tool"]["driver"]["name"], "code-analyze");
+
+    let findings = sarif["runs"][0]["results"].as_array().unwrap();
+    assert_eq!(findings.len(), 1, "output:\n{}", result.output);
+    assert_eq!(findings[0]["ruleId"], "unused-function");
+    let location = &findings[0]["locations"][0]["physicalLocation"];
+    assert_eq!(location["artifactLocation"]["uri"], "main.go");
+    assert_eq!(location["region"]["startLine"], 5);
+    assert_eq!(location["region"]["endLine"], 6);
+}
+
+#[test]
+fn html_report_embeds_sample_sources() {
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Html,
+        ..Default::default()
+    };
+    let out = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd()).output;
+    assert!(out.starts_with("<!DOCTYPE html>\n"), "output:\n{out}");
+    assert!(
+        out.contains("<td>sample.go</td><td><code>Greet</code></td><td><code>*Greeter</code></td>"),
+        "output:\n{out}"
+    );
+    assert!(
+        out.contains("<span class=\"line hl\"><span class=\"ln\">10</span>\treturn fmt.Sprintf(&quot;Hello, %s!&quot;, g.Name)</span>"),
+        "output:\n{out}"
+    );
+}
+
+#[test]
+fn csv_lists_sample_functions_one_per_row() {
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Csv,
+        ..Default::default()
+    };
+    let out = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd()).output;
+    let rows: Vec<&str> = out.lines().collect();
+    assert_eq!(
+        rows[0],
+        "file,function,receiver,line,complexity,cognitive_complexity,lines_of_code,param_count,max_nesting_depth"
+    );
+    assert_eq!(
+        rows[1], "sample.go,Greet,*Greeter,9,1,0,1,0,0",
+        "output:\n{out}"
+    );
+    assert_eq!(rows[2], "sample.go,helper,,13,1,0,1,1,0", "output:\n{out}");
+    assert_eq!(rows.len(), 4, "output:\n{out}");
+}
+
+#[test]
+fn markdown_lists_sample_functions_in_a_table() {
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Markdown,
+        find_unused: true,
+        ..Default::default()
+    };
+    let out = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd()).output;
+    assert!(
+        out.starts_with("# Code analysis: sample.go\n"),
+        "output:\n{out}"
+    );
+    assert!(
+        out.contains("| File | Function | Receiver | Line | Complexity | Cognitive | LOC |"),
+        "output:\n{out}"
+    );
+    for function in [
+        "`Greet` | `*Greeter` | 9 |",
+        "`helper` |  | 13 |",
+        "`main` |  | 17 |",
+    ] {
+        assert!(out.contains(function), "missing {function}:\n{out}");
+    }
+    assert!(
+        out.contains("## Unused functions\n\nNone found.\n"),
+        "output:\n{out}"
+    );
+}
+
+#[test]
+fn parallel_directory_output_is_deterministic() {
+    let path = fixtures_dir().to_string_lossy().to_string();
+    let run = |jobs| {
+        let options = code_analyze::AnalyzeOptions {
+            format: code_analyze::OutputFormat::Json,
+            jobs: Some(jobs),
+            ..Default::default()
+        };
+        code_analyze::analyze_with_options(&path, &options, &cwd()).output
+    };
+
+    let sequential = run(1);
+    for _ in 0..3 {
+        assert_eq!(run(4), sequential);
+    }
+}
+
+#[test]
+fn directory_walk_skips_vendor_and_testdata() {
+    let dir = tempfile::tempdir().unwrap();
+    for sub in ["vendor", "testdata", ".cache"] {
+        std::fs::create_dir(dir.path().join(sub)).unwrap();
+        std::fs::write(dir.path().join(sub).join("skipped.go"), "package x\n").unwrap();
+    }
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc main() {}\n",
+    )
+    .unwrap();
+    std::fs::write(dir.path().join("notes.txt"), "not code\n").unwrap();
+
+    let path = dir.path().to_string_lossy().to_string();
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let value: serde_json::Value = serde_json::from_str(&result.output).unwrap();
+    assert_eq!(value["files"].as_array().unwrap().len(), 1);
+    assert_eq!(value["files"][0]["path"], "main.go");
+
+    let options = code_analyze::AnalyzeOptions {
+        include_skipped_dirs: true,
+        ..options
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let value: serde_json::Value = serde_json::from_str(&result.output).unwrap();
+    assert_eq!(value["files"].as_array().unwrap().len(), 4);
+}
+
+#[test]
+fn cache_dir_reuses_results_across_runs() {
+    let cache = tempfile::tempdir().unwrap();
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        cache_dir: Some(cache.path().to_path_buf()),
+        ..Default::default()
+    };
+    let first = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    let entries: Vec<_> = std::fs::read_dir(cache.path())
+        .unwrap()
+        .flat_map(|dir| std::fs::read_dir(dir.unwrap().path()).unwrap())
+        .collect();
+    assert_eq!(entries.len(), 1, "expected one cache entry");
+
+    let second = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    assert_eq!(first.output, second.output);
+}
+
+#[test]
+fn sort_cognitive_lists_nested_functions_first() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("main.go"),
+        "package main\n\nfunc flat(a, b bool) {\n\tif a {\n\t}\n\tif b {\n\t}\n}\n\nfunc nested(a, b bool) {\n\tif a {\n\t\tif b {\n\t\t}\n\t}\n}\n",
+    )
+    .unwrap();
+
+    let path = dir.path().join("main.go").to_string_lossy().to_string();
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        sort: code_analyze::SortOrder::Cognitive,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let value: serde_json::Value = serde_json::from_str(&result.output).unwrap();
+    let functions = &value["files"][0]["functions"];
+    assert_eq!(functions[0]["name"], "nested");
+    assert_eq!(functions[0]["complexity"], functions[1]["complexity"]);
+    assert_eq!(functions[0]["cognitive_complexity"], 3);
+    assert_eq!(functions[1]["cognitive_complexity"], 2);
+
+    let options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Text,
+        ..options
+    };
+    let out = code_analyze::analyze_with_options(&path, &options, &cwd()).output;
+    assert!(out.contains("F: nested:10 flat:3"), "output:\n{out}");
+}
+
+#[test]
+fn unused_finds_nothing_in_sample_go() {
+    let options = code_analyze::AnalyzeOptions {
+        find_unused: true,
+        ..Default::default()
+    };
+    let result = code_analyze::analyze_with_options(&fixture("sample.go"), &options, &cwd());
+    assert!(
+        result.unused_functions.is_empty(),
+        "output:\n{}",
+        result.output
+    );
+}
+
 // ── Directory analysis ─────────────────────────────────────────────────
 
 #[test]
@@ -258,3 +2013,181 @@ fn analyze_relative_path() {
     );
     assert!(out.contains("main"), "expected 'main' function:\n{out}");
 }
+
+#[test]
+fn build_constraints_select_platform_files() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(dir.path().join("fs.go"), "package fs\n\nfunc Open() {}\n").unwrap();
+    std::fs::write(
+        dir.path().join("fs_linux.go"),
+        "package fs\n\nfunc openLinux() {}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("fs_windows.go"),
+        "package fs\n\nfunc openWindows() {}\n",
+    )
+    .unwrap();
+    std::fs::write(
+        dir.path().join("debug.go"),
+        "//go:build debug\n\npackage fs\n\nfunc trace() {}\n",
+    )
+    .unwrap();
+
+    let mut options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        build_context: Some(code_analyze::BuildContext {
+            goos: "linux".into(),
+            goarch: "amd64".into(),
+            tags: vec![],
+        }),
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let report = code_analyze::JsonReport::parse(&result.output).unwrap();
+    let files: Vec<&str> = report.files.iter().map(|f| f.path.as_str()).collect();
+    assert_eq!(
+        files,
+        vec!["fs.go", "fs_linux.go"],
+        "output:\n{}",
+        result.output
+    );
+    let skipped: Vec<(&str, &str)> = report
+        .skipped_files
+        .iter()
+        .map(|s| (s.path.as_str(), s.reason.as_str()))
+        .collect();
+    assert_eq!(
+        skipped,
+        vec![
+            ("debug.go", "//go:build debug"),
+            ("fs_windows.go", "file name requires windows"),
+        ]
+    );
+
+    options.format = code_analyze::OutputFormat::Text;
+    options.build_context = Some(code_analyze::BuildContext {
+        goos: "windows".into(),
+        goarch: "amd64".into(),
+        tags: vec!["debug".into()],
+    });
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .contains("SKIPPED (build constraints):\n  fs_linux.go (file name requires linux)\n"),
+        "output:\n{}",
+        result.output
+    );
+    assert_eq!(result.skipped_files.len(), 1);
+}
+
+#[test]
+fn include_and_exclude_globs_select_files() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::create_dir_all(dir.path().join("pkg/store")).unwrap();
+    std::fs::create_dir_all(dir.path().join("gen")).unwrap();
+    for file in [
+        "main.go",
+        "tool.py",
+        "pkg/store/cache.go",
+        "pkg/store/cache_test.go",
+        "gen/parser.go",
+    ] {
+        std::fs::write(dir.path().join(file), "package x\n").unwrap();
+    }
+
+    let mut options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        include: vec!["**/*.go".into()],
+        exclude: vec!["**/*_test.go".into(), "gen/**".into()],
+        max_depth: 0,
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    let report = code_analyze::JsonReport::parse(&result.output).unwrap();
+    let files: Vec<&str> = report.files.iter().map(|f| f.path.as_str()).collect();
+    assert_eq!(
+        files,
+        vec!["main.go", "pkg/store/cache.go"],
+        "output:\n{}",
+        result.output
+    );
+
+    options.include = vec!["pkg/[".into()];
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(
+        result
+            .output
+            .starts_with("Analysis error: Invalid glob 'pkg/['"),
+        "output:\n{}",
+        result.output
+    );
+}
+
+#[test]
+fn max_nesting_fails_on_deeply_nested_functions() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(
+        dir.path().join("grid.go"),
+        "package grid\n\nfunc scan(rows [][]int) int {\n\tn := 0\n\tfor _, row := range rows {\n\t\tfor _, v := range row {\n\t\t\tif v > 0 {\n\t\t\t\tn++\n\t\t\t}\n\t\t}\n\t}\n\treturn n\n}\n\nfunc classify(v int) string {\n\tif v < 0 {\n\t\treturn \"negative\"\n\t} else if v == 0 {\n\t\treturn \"zero\"\n\t} else if v < 10 {\n\t\treturn \"small\"\n\t}\n\treturn \"large\"\n}\n",
+    )
+    .unwrap();
+
+    let options = code_analyze::AnalyzeOptions {
+        max_nesting: Some(2),
+        ..Default::default()
+    };
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(!result.passed());
+    let nesting: Vec<(&str, usize)> = result
+        .nesting_violations
+        .iter()
+        .map(|v| (v.function.name.as_str(), v.function.max_nesting_depth))
+        .collect();
+    assert_eq!(nesting, vec![("scan", 3)], "output:\n{}", result.output);
+    assert_eq!(
+        result.violations[0].message,
+        "scan has nesting depth 3 (max 2)"
+    );
+}
+
+#[test]
+fn cancelled_run_reports_an_error_or_partial_results() {
+    let token = code_analyze::CancelToken::new();
+    token.cancel();
+    let mut options = code_analyze::AnalyzeOptions {
+        format: code_analyze::OutputFormat::Json,
+        cancel: Some(token),
+        ..Default::default()
+    };
+    let dir = tempfile::tempdir().unwrap();
+    for name in ["a.go", "b.go"] {
+        std::fs::write(dir.path().join(name), "package main\n").unwrap();
+    }
+    let path = dir.path().to_string_lossy().to_string();
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(result.cancelled);
+    assert!(
+        result
+            .output
+            .starts_with("Analysis error: Cancelled after analyzing 0 of 2 files"),
+        "output:\n{}",
+        result.output
+    );
+
+    options.keep_partial_results = true;
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(result.cancelled);
+    let report = code_analyze::JsonReport::parse(&result.output).unwrap();
+    assert!(report.files.is_empty());
+
+    options.cancel = Some(code_analyze::CancelToken::with_timeout(
+        std::time::Duration::from_secs(3600),
+    ));
+    let result = code_analyze::analyze_with_options(&path, &options, &cwd());
+    assert!(!result.cancelled);
+}